@@ -1,13 +1,15 @@
 package cmd
 
 import (
-	"log"
+	"log/slog"
+	"os"
 
 	"github.com/Roongkun/software-eng-ii/internal/cli/migrate"
 )
 
 func main() {
 	if err := migrate.MigrateCmd.Execute(); err != nil {
-		log.Fatalln(err.Error())
+		slog.Error(err.Error())
+		os.Exit(1)
 	}
 }
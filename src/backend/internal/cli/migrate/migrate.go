@@ -3,10 +3,14 @@ package migrate
 import (
 	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
 
 	"github.com/Roongkun/software-eng-ii/internal/config"
 	"github.com/Roongkun/software-eng-ii/internal/migrations"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
 	"github.com/spf13/cobra"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -22,6 +26,7 @@ func init() {
 	MigrateCmd.AddCommand(migrateRollbackCmd)
 	MigrateCmd.AddCommand(migrateCreateSQLCmd)
 	MigrateCmd.AddCommand(migrateStatusCmd)
+	MigrateCmd.AddCommand(migrateReencryptCmd)
 }
 
 var MigrateCmd = &cobra.Command{
@@ -49,13 +54,17 @@ func getMigrateCmdFlags(cmd *cobra.Command) migrateCmdFlags {
 	}
 }
 
+func getMigrateCmdDB(appCfg *config.App) *bun.DB {
+	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(appCfg.Database.Postgres.DSN)))
+	return bun.NewDB(sqldb, pgdialect.New())
+}
+
 func getMigrateCmdMigrator(cmd *cobra.Command) *migrate.Migrator {
 	flags := getMigrateCmdFlags(cmd)
 	config := config.MustReadMultipleAppConfigFiles(flags.configFiles)
-	sqldb := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(config.Database.Postgres.DSN)))
-	db := bun.NewDB(sqldb, pgdialect.New())
+	telemetry.InitLogger(config.Log)
 
-	return migrate.NewMigrator(db, migrations.Migrations)
+	return migrate.NewMigrator(getMigrateCmdDB(config), migrations.Migrations)
 }
 
 var migrateUpCmd = &cobra.Command{
@@ -68,25 +77,24 @@ var migrateUpCmd = &cobra.Command{
 			return err
 		}
 
-		log.Print("Migration tables were initialized.\n")
+		slog.Info("migration tables were initialized")
 
-		log.Print("Applying migrations...\n")
+		slog.Info("applying migrations")
 		group, err := migrator.Migrate(context.Background())
 		if err != nil {
 			return err
 		}
 
 		if group.IsZero() {
-			log.Print("No migrations to apply.\n")
+			slog.Info("no migrations to apply")
 			return nil
 		}
 
-		log.Printf("Migrations applied: %s.\n", group.Migrations)
 		for _, m := range group.Migrations {
-			log.Printf("%s was applied.\n", m.String())
+			slog.Info("migration was applied", "migration", m.String())
 		}
 
-		log.Printf("Migration completed.\n")
+		slog.Info("migration completed", "group", group.String())
 
 		return nil
 	},
@@ -104,10 +112,10 @@ var migrateRollbackCmd = &cobra.Command{
 		}
 
 		if group.IsZero() {
-			log.Print("No migrations were rollbacked.\n")
+			slog.Info("no migrations were rollbacked")
 		}
 
-		log.Printf("Rollbacked migrations: %s\n", group.Migrations)
+		slog.Info("rollbacked migrations", "migrations", group.Migrations.String())
 		return nil
 	},
 }
@@ -126,7 +134,7 @@ var migrateCreateSQLCmd = &cobra.Command{
 		}
 
 		for _, file := range files {
-			log.Printf("migration file created: %s\n", file.Path)
+			slog.Info("migration file created", "path", file.Path)
 		}
 
 		return nil
@@ -144,9 +152,45 @@ var migrateStatusCmd = &cobra.Command{
 			return err
 		}
 
-		log.Printf("migrations: %s\n", ms)
-		log.Printf("unapplied migrations: %s\n", ms.Unapplied())
-		log.Printf("last migration group: %s\n", ms.LastGroup())
+		slog.Info("migration status",
+			"migrations", ms.String(),
+			"unapplied", ms.Unapplied().String(),
+			"last_group", ms.LastGroup().String(),
+		)
+		return nil
+	},
+}
+
+var migrateReencryptCmd = &cobra.Command{
+	Use:   "reencrypt",
+	Short: "Encrypts the sensitive columns with the current key, run it after enabling encryption or rotating the key.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flags := getMigrateCmdFlags(cmd)
+		appCfg := config.MustReadMultipleAppConfigFiles(flags.configFiles)
+		telemetry.InitLogger(appCfg.Log)
+
+		if appCfg.Encryption.KeyFile == "" {
+			return errors.New("encryption.key_file is not configured")
+		}
+		if err := encryption.InitFromKeyFile(appCfg.Encryption.KeyFile); err != nil {
+			return err
+		}
+
+		db := getMigrateCmdDB(appCfg)
+		defer db.Close()
+
+		users, err := postgres.NewUserDB(db).Reencrypt(cmd.Context())
+		if err != nil {
+			return err
+		}
+		slog.Info("users were re-encrypted", "count", users)
+
+		tickets, err := postgres.NewVerificationInfoDB(db).Reencrypt(cmd.Context())
+		if err != nil {
+			return err
+		}
+		slog.Info("verification tickets were re-encrypted", "count", tickets)
+
 		return nil
 	},
 }
@@ -0,0 +1,63 @@
+package rates
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	RatesCmd.AddCommand(ratesImportCmd)
+}
+
+var RatesCmd = &cobra.Command{
+	Use:   "rates",
+	Short: "Manage the exchange rates",
+}
+
+var ratesImportCmd = &cobra.Command{
+	Use:   "import file",
+	Short: "Imports the exchange rates of a JSON file.",
+	Long:  `Imports the exchange rates of a JSON file shaped as {"rates": {"USD": 0.0274}}, the amount of every currency that one THB buys. The rates of the currencies left out are kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pathCfgFiles, err := cmd.Flags().GetStringSlice("config-file")
+		if err != nil {
+			return err
+		}
+		appCfg := config.MustReadMultipleAppConfigFiles(pathCfgFiles)
+		telemetry.InitLogger(appCfg.Log)
+
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+
+		input := model.ExchangeRateInput{}
+		if err := json.Unmarshal(content, &input); err != nil {
+			return err
+		}
+
+		db := databases.ConnectSQLDB(appCfg.Database.Postgres.DSN)
+		defer db.Close()
+
+		rates, err := usecase.NewCurrencyUseCase(db).UpdateRates(cmd.Context(), input, model.ExchangeRateFileSource)
+		if err != nil {
+			return err
+		}
+
+		for _, rate := range rates {
+			slog.Info("exchange rate imported", "base", model.BaseCurrency, "currency", rate.Currency, "rate", rate.Rate)
+		}
+		slog.Info("exchange rates were imported", "count", len(rates))
+
+		return nil
+	},
+}
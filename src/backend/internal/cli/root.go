@@ -2,15 +2,19 @@ package cli
 
 import (
 	"github.com/Roongkun/software-eng-ii/internal/cli/migrate"
+	"github.com/Roongkun/software-eng-ii/internal/cli/rates"
 	"github.com/Roongkun/software-eng-ii/internal/cli/serve"
+	"github.com/Roongkun/software-eng-ii/internal/cli/worker"
 	"github.com/spf13/cobra"
 )
 
 func init() {
 	RootCmd.PersistentFlags().BoolP("debug", "d", false, "Run in debug mode")
 	RootCmd.PersistentFlags().StringSliceP("config-file", "c", []string{}, "Path to configuration file")
+	serve.ServeCmd.AddCommand(worker.WorkerCmd)
 	RootCmd.AddCommand(serve.ServeCmd)
 	RootCmd.AddCommand(migrate.MigrateCmd)
+	RootCmd.AddCommand(rates.RatesCmd)
 }
 
 var RootCmd = &cobra.Command{
@@ -2,21 +2,10 @@ package serve
 
 import (
 	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
 )
 
-func getOAuth2GoogleConf(clientId, clientSecret, redirectURL string) oauth2.Config {
-	return oauth2.Config{
-		ClientID:     clientId,
-		ClientSecret: clientSecret,
-		RedirectURL:  redirectURL,
-		Scopes:       []string{"email", "profile"},
-		Endpoint:     google.Endpoint,
-	}
-}
-
 func retrieveSecretConf(appCfg *config.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Set("secretKey", appCfg.SecretKey)
@@ -31,13 +20,16 @@ func retrieveAdminSecretConf(appCfg *config.App) gin.HandlerFunc {
 	}
 }
 
-func setOAuth2GoogleConf(appCfg *config.App) gin.HandlerFunc {
+func setOAuth2Registry(registry *oauth2.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("OAuth2Registry", registry)
+		c.Next()
+	}
+}
+
+func setLoginLockoutConf(appCfg *config.App) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Set("OAuth2GoogleConf", getOAuth2GoogleConf(
-			appCfg.OAuth2Google.ClientId,
-			appCfg.OAuth2Google.ClientSecret,
-			appCfg.OAuth2Google.RedirectURL,
-		))
+		c.Set("loginLockoutConf", appCfg.RateLimit.LoginLockout)
 		c.Next()
 	}
 }
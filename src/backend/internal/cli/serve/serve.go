@@ -1,7 +1,9 @@
 package serve
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 
 	"github.com/Roongkun/software-eng-ii/internal/config"
 	"github.com/Roongkun/software-eng-ii/internal/controller"
@@ -9,10 +11,15 @@ import (
 	"github.com/Roongkun/software-eng-ii/internal/controller/middleware"
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 
 	_ "github.com/Roongkun/software-eng-ii/docs"
 	swaggerFiles "github.com/swaggo/files"
@@ -43,45 +50,78 @@ var ServeCmd = &cobra.Command{
 		}
 
 		appCfg := config.MustReadMultipleAppConfigFiles(pathCfgFiles)
+		telemetry.InitLogger(appCfg.Log)
 		if debug {
 			printAppConfig(appCfg)
 		}
 
+		shutdownTracer, err := telemetry.InitTracer(cmd.Context(), appCfg.Tracing, "api")
+		if err != nil {
+			return err
+		}
+		defer shutdownTracer(context.Background())
+
+		if appCfg.Encryption.KeyFile != "" {
+			if err := encryption.InitFromKeyFile(appCfg.Encryption.KeyFile); err != nil {
+				return fmt.Errorf("failed to load the encryption keys: %w", err)
+			}
+		} else {
+			slog.Warn("no encryption key file is configured, sensitive columns are stored in plaintext")
+		}
+
 		db := databases.ConnectSQLDB(appCfg.Database.Postgres.DSN)
 		handler := controller.NewHandler(db)
 		redisClient := databases.ConnectRedis(appCfg.Database.Redis.DSN)
 		util.InitNgrokEndpoint(appCfg.NgrokEndpoint)
+		oauth2Registry := oauth2.NewRegistry(appCfg)
 
-		autoUpdateBookingStatus(handler)
+		if appCfg.Server.MetricsAddress != "" {
+			go metrics.Serve(appCfg.Server.MetricsAddress)
+		}
 
-		r := gin.Default()
+		r := gin.New()
+		// the context of the request carries its ID and its span down to the usecases and the repositories
+		r.ContextWithFallback = true
+		r.Use(gin.Recovery())
+		// the path requested is replaced by its route on the spans, see telemetry.InitTracer
+		r.Use(otelgin.Middleware(telemetry.ServiceName(appCfg.Tracing, "api")))
+		r.Use(middleware.RequestId)
+		r.Use(middleware.Logger)
+		r.Use(middleware.Metrics)
 		r.Use(retrieveSecretConf(appCfg))
 
 		r.Use(cors.New(cors.Config{
 			AllowOrigins:     []string{"http://localhost:3000"},
 			AllowMethods:     []string{"GET", "POST", "DELETE", "PUT", "PATCH", "OPTIONS"},
-			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-			ExposeHeaders:    []string{"Content-Length"},
+			AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middleware.RequestIdHeader},
+			ExposeHeaders:    []string{"Content-Length", "Retry-After", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", middleware.RequestIdHeader},
 			AllowCredentials: true,
 		}))
 
 		if err := s3utils.InitializeS3(); err != nil {
-			log.Fatalf("Failed to initialize S3: %v", err)
+			return fmt.Errorf("failed to initialize S3: %w", err)
 		}
 
-		authen := r.Group("/authen")
+		authen := r.Group("/authen", middleware.RateLimit("authen", appCfg.RateLimit.Auth))
 		{
 			authen := authen.Group("/v1")
 			authen.POST("/register", handler.User.Register)
-			authen.POST("/login", handler.User.Login)
+			authen.POST("/login", setLoginLockoutConf(appCfg), handler.User.Login)
 			authen.GET("/refresh", handler.User.RefreshToken)
 
 			google := authen.Group("/google")
 			{
-				google.Use(setOAuth2GoogleConf(appCfg))
+				google.Use(setOAuth2Registry(oauth2Registry))
 				google.POST("/login", handler.User.GoogleLogin)
 				google.GET("/callback", handler.User.GoogleCallback)
 			}
+
+			providers := authen.Group("/oauth2/:provider")
+			{
+				providers.Use(setOAuth2Registry(oauth2Registry))
+				providers.POST("/login", handler.User.OAuth2Login)
+				providers.GET("/callback", handler.User.OAuth2Callback)
+			}
 		}
 
 		chatEntity := chat.NewChat(db, redisClient, &handler.Chat)
@@ -90,17 +130,21 @@ var ServeCmd = &cobra.Command{
 		chats := r.Group("/chat")
 		{
 			chats := chats.Group("/v1")
-			chats.GET("/ws/:session-token", chatEntity.ServeWS)
+			chats.GET("/ws/:session-token", middleware.RateLimit("chat", appCfg.RateLimit.Chat), chatEntity.ServeWS)
 		}
 
 		customerGalleries := r.Group("/customers/galleries/v1")
 		{
-			customerGalleries.GET("/search", handler.User.SearchGalleries)
-			customerGalleries.GET("/:id", handler.User.GetPhotoUrlsInGallery)
+			customerGalleries.GET("/search", middleware.RateLimit("search", appCfg.RateLimit.Search), handler.User.SearchGalleries)
+			customerGalleries.GET("/:id", middleware.OptionalUserAuthorizationMiddleware, handler.User.GetOptionalUserInstance, handler.User.GetPhotoUrlsInGallery)
 			// List all reviews in the gallery (Guest can also view the reviews)
 			customerGalleries.GET("/:id/reviews", handler.User.ListReviewsByGalleryId)
+			customerGalleries.GET("/:id/pricing", handler.User.GetGalleryPricing)
 		}
 
+		// the calendar apps subscribe without a session, the secret token in the path identifies the user
+		r.GET("/calendar/v1/:feed", middleware.RateLimit("calendar", appCfg.RateLimit.Calendar), handler.User.ServeCalendarFeed)
+
 		usersNonValidated := r.Group("/users/v1")
 		{
 			usersNonValidated.GET("/get-user/:id", handler.User.GetUserInfo)
@@ -108,7 +152,7 @@ var ServeCmd = &cobra.Command{
 
 		phtgGalleriesNonValidated := r.Group("photographers/galleries/v1")
 		{
-			phtgGalleriesNonValidated.GET("/:id", handler.Photographer.GetOneGallery)
+			phtgGalleriesNonValidated.GET("/:id", middleware.OptionalUserAuthorizationMiddleware, handler.User.GetOptionalUserInstance, handler.Photographer.GetOneGallery)
 		}
 
 		validated := r.Group("/", middleware.UserAuthorizationMiddleware)
@@ -122,22 +166,70 @@ var ServeCmd = &cobra.Command{
 			users.GET("/get-my-user-info", handler.User.GetMyUserInfo)
 			users.PUT("/", handler.User.UpdateUserProfile)
 			users.POST("/req-verify", handler.User.RequestVerification)
+			users.GET("/verification-tickets", handler.User.ListMyVerificationTickets)
 			users.GET("/self-status", handler.User.GetSelfStatus)
 			users.POST("/report-issue", handler.User.ReportIssue)
+			users.GET("/issues", handler.User.ListMyIssues)
+			users.GET("/issues/:id", handler.User.GetMyIssue)
+			users.POST("/issues/:id/messages", handler.User.ReplyToMyIssue)
+			users.GET("/identities", handler.User.ListIdentities)
+			users.POST("/identities/:provider", setOAuth2Registry(oauth2Registry), handler.User.LinkIdentity)
+			users.DELETE("/identities/:provider", handler.User.UnlinkIdentity)
+			users.DELETE("/", handler.User.DeleteAccount)
+			users.POST("/data-exports", handler.User.RequestDataExport)
+			users.GET("/data-exports", handler.User.ListDataExports)
+			users.GET("/data-exports/:id/download", handler.User.DownloadDataExport)
+			users.GET("/notifications", handler.User.ListNotifications)
+			users.PUT("/notifications/read", handler.User.MarkNotificationsRead)
+			users.PUT("/notifications/read-all", handler.User.MarkAllNotificationsRead)
+			users.GET("/calendar-feed", handler.User.GetCalendarFeed)
+			users.POST("/calendar-feed", handler.User.CreateCalendarFeed)
+			users.DELETE("/calendar-feed", handler.User.DeleteCalendarFeed)
 		}
 
-		admin := validated.Group("/admin")
+		admin := validated.Group("/admin", middleware.Audit(handler.Admin.AuditUsecase))
 		{
 			admin := admin.Group("/v1")
 			admin.GET("/pending-photographers", handler.Admin.ListPendingPhotographers)
 			admin.PUT("/verify/:id", handler.Admin.Verify)
 			admin.PUT("/reject/:id", handler.Admin.Reject)
+			admin.GET("/verifications/tickets", handler.Admin.ListVerificationTickets)
+			admin.GET("/verifications/tickets/:id", handler.Admin.GetVerificationTicket)
+			admin.PUT("/verifications/tickets/:id/approve", handler.Admin.ApproveVerificationTicket)
+			admin.PUT("/verifications/tickets/:id/reject", handler.Admin.RejectVerificationTicket)
+			admin.PUT("/verifications/tickets/:id/request-info", handler.Admin.RequestVerificationInfo)
 			admin.PUT("/issues/:id", handler.Admin.CloseIssue)
 			admin.GET("/pending-refund-bookings", handler.Admin.ListPendingRefundBookings)
 			admin.PUT("/bookings/reject/:id", handler.Admin.RejectRefundBooking)
 			admin.PUT("/bookings/refund/:id", handler.Admin.ApproveRefundBooking)
 			admin.GET("/issues", handler.Admin.GetIssuesWithOption)
 			admin.GET("/issue-header", handler.Admin.GetIssueHeaderMetadata)
+			admin.GET("/issues/:id", handler.Admin.GetIssue)
+			admin.POST("/issues/:id/messages", handler.Admin.ReplyToIssue)
+			admin.PUT("/issues/:id/assignee", handler.Admin.AssignIssue)
+			admin.PUT("/issues/:id/triage", handler.Admin.TriageIssue)
+			admin.GET("/issue-sla", handler.Admin.ListIssueSLATargets)
+			admin.PUT("/issue-sla", handler.Admin.UpdateIssueSLATargets)
+			admin.GET("/disputes", handler.Admin.ListDisputes)
+			admin.GET("/disputes/:id", handler.Admin.GetDispute)
+			admin.POST("/disputes/:id/evidence", handler.Admin.AddDisputeEvidence)
+			admin.PUT("/disputes/:id/mediator", handler.Admin.MediateDispute)
+			admin.PUT("/disputes/:id/resolve", handler.Admin.ResolveDispute)
+			admin.GET("/webhooks/subscriptions", handler.Admin.ListWebhookSubscriptions)
+			admin.POST("/webhooks/subscriptions", handler.Admin.CreateWebhookSubscription)
+			admin.PUT("/webhooks/subscriptions/:id", handler.Admin.UpdateWebhookSubscription)
+			admin.DELETE("/webhooks/subscriptions/:id", handler.Admin.DeleteWebhookSubscription)
+			admin.GET("/webhooks/deliveries", handler.Admin.ListWebhookDeliveries)
+			admin.POST("/webhooks/deliveries/:id/replay", handler.Admin.ReplayWebhookDelivery)
+			admin.GET("/coupons", handler.Admin.ListCoupons)
+			admin.POST("/coupons", handler.Admin.CreateCoupon)
+			admin.PUT("/coupons/:id", handler.Admin.UpdateCoupon)
+			admin.GET("/coupons/:id/redemptions", handler.Admin.ListCouponRedemptions)
+			admin.GET("/exchange-rates", handler.Admin.ListExchangeRates)
+			admin.PUT("/exchange-rates", handler.Admin.UpdateExchangeRates)
+			admin.GET("/analytics", handler.Admin.GetAnalytics)
+			admin.GET("/audit", handler.Admin.SearchAuditLog)
+			admin.GET("/audit/verify", handler.Admin.VerifyAuditLog)
 		}
 
 		photographers := validated.Group("/photographers", handler.User.CheckVerificationStatus)
@@ -149,9 +241,15 @@ var ServeCmd = &cobra.Command{
 			phtgGalleries.PUT("/:id", handler.Photographer.UpdateGallery)
 			phtgGalleries.DELETE("/:id/:photoId", handler.Photographer.DeletePhoto)
 			phtgGalleries.DELETE("/:id", handler.Photographer.DeleteGallery)
+			phtgGalleries.POST("/:id/add-ons", handler.Photographer.CreateGalleryAddOn)
+			phtgGalleries.PUT("/:id/add-ons/:addOnId", handler.Photographer.UpdateGalleryAddOn)
+			phtgGalleries.DELETE("/:id/add-ons/:addOnId", handler.Photographer.DeleteGalleryAddOn)
+			phtgGalleries.POST("/:id/pricing-rules", handler.Photographer.CreatePricingRule)
+			phtgGalleries.DELETE("/:id/pricing-rules/:ruleId", handler.Photographer.DeletePricingRule)
 
 			phtgBookings := photographers.Group("/bookings/v1")
 			phtgBookings.POST("/", handler.Photographer.CreateBooking)
+			phtgBookings.POST("/quote", handler.Photographer.QuoteBooking)
 			phtgBookings.GET("/pending-cancellations", handler.Photographer.ListPendingCancellationBookings)
 			phtgBookings.GET("/upcoming", handler.Photographer.ListUpcomingBookings)
 			phtgBookings.GET("/past", handler.Photographer.ListPastBookings)
@@ -159,9 +257,39 @@ var ServeCmd = &cobra.Command{
 			phtgBookings.GET("/my-bookings", handler.Photographer.MyBookings)
 			phtgBookings.PUT("/cancel/:id", handler.Photographer.CancelBooking)
 			phtgBookings.PUT("/approve-cancel/:id", handler.Photographer.ApproveCancelReq)
+			phtgBookings.PUT("/:id/delivered", handler.Photographer.MarkBookingDelivered)
+			phtgBookings.POST("/:id/dispute", handler.Photographer.OpenDispute)
+			phtgBookings.GET("/:id/dispute", handler.Photographer.GetDispute)
+			phtgBookings.POST("/:id/dispute/evidence", handler.Photographer.AddDisputeEvidence)
+			phtgBookings.PUT("/:id/dispute/withdraw", handler.Photographer.WithdrawDispute)
+
+			phtgBookingRequests := photographers.Group("/booking-requests/v1")
+			phtgBookingRequests.GET("/", handler.Photographer.ListBookingRequests)
+			phtgBookingRequests.GET("/:id", handler.Photographer.GetOneBookingRequest)
+			phtgBookingRequests.POST("/:id/quotes", handler.Photographer.QuoteBookingRequest)
+			phtgBookingRequests.PUT("/:id/accept", handler.Photographer.AcceptBookingQuote)
+			phtgBookingRequests.PUT("/:id/decline", handler.Photographer.DeclineBookingRequest)
+
+			phtgCoupons := photographers.Group("/coupons/v1")
+			phtgCoupons.GET("/", handler.Photographer.ListCoupons)
+			phtgCoupons.POST("/", handler.Photographer.CreateCoupon)
+			phtgCoupons.PUT("/:id", handler.Photographer.UpdateCoupon)
+			phtgCoupons.GET("/:id/redemptions", handler.Photographer.ListCouponRedemptions)
+
+			phtgDocuments := photographers.Group("/documents/v1")
+			phtgDocuments.GET("/", handler.Photographer.ListDocuments)
+			phtgDocuments.GET("/:id", handler.Photographer.DownloadDocument)
+
+			phtgBusyBlocks := photographers.Group("/busy-blocks/v1")
+			phtgBusyBlocks.GET("/", handler.Photographer.ListBusyBlocks)
+			phtgBusyBlocks.POST("/", handler.Photographer.ImportBusyBlocks)
+			phtgBusyBlocks.DELETE("/", handler.Photographer.ClearBusyBlocks)
 
 			phtgReviews := photographers.Group("/reviews/v1")
 			phtgReviews.GET("/list", handler.Photographer.ListReceivedReviews)
+
+			phtgInsights := photographers.Group("/insights/v1")
+			phtgInsights.GET("/", handler.Photographer.GetInsights)
 		}
 
 		customerBookings := validated.Group("/customers/bookings/v1")
@@ -172,11 +300,28 @@ var ServeCmd = &cobra.Command{
 			customerBookings.GET("/past", handler.User.ListPastBookings)
 			customerBookings.GET("/my-bookings", handler.User.MyBookings)
 			customerBookings.GET("/:id", handler.User.GetOneBooking)
+			customerBookings.POST("/:id/coupon", handler.User.ApplyCoupon)
+			customerBookings.GET("/:id/documents", handler.User.ListBookingDocuments)
+			customerBookings.GET("/:id/documents/:documentId", handler.User.DownloadBookingDocument)
 			customerBookings.PUT("/cancel/:id", handler.User.CancelBooking)
 			customerBookings.PUT("/req-refund/:id", handler.User.RequestRefundBooking)
+			customerBookings.POST("/:id/dispute", handler.User.OpenDispute)
+			customerBookings.GET("/:id/dispute", handler.User.GetDispute)
+			customerBookings.POST("/:id/dispute/evidence", handler.User.AddDisputeEvidence)
+			customerBookings.PUT("/:id/dispute/withdraw", handler.User.WithdrawDispute)
 			customerBookings.PUT("/approve-cancel/:id", handler.User.ApproveCancelReq)
 		}
 
+		customerBookingRequests := validated.Group("/customers/booking-requests/v1")
+		{
+			customerBookingRequests.POST("/", handler.User.CreateBookingRequest)
+			customerBookingRequests.GET("/", handler.User.ListBookingRequests)
+			customerBookingRequests.GET("/:id", handler.User.GetOneBookingRequest)
+			customerBookingRequests.POST("/:id/quotes", handler.User.CounterBookingQuote)
+			customerBookingRequests.PUT("/:id/accept", handler.User.AcceptBookingQuote)
+			customerBookingRequests.PUT("/:id/withdraw", handler.User.WithdrawBookingRequest)
+		}
+
 		customerReviews := validated.Group("/customers/reviews/v1")
 		{
 			customerReviews.POST("/", handler.User.CreateReview)
@@ -199,8 +344,6 @@ var ServeCmd = &cobra.Command{
 		r.GET("/payment/:bookingId", handler.User.MakeBookingPayment)
 
 		r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-		r.Run()
-
-		return nil
+		return r.Run()
 	},
 }
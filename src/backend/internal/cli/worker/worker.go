@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/Roongkun/software-eng-ii/internal/worker"
+	"github.com/spf13/cobra"
+)
+
+var WorkerCmd = &cobra.Command{
+	Use:   "worker [FLAGS]...",
+	Short: "Run the background jobs queued by the application",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pathCfgFiles, err := cmd.Flags().GetStringSlice("config-file")
+		if err != nil {
+			return err
+		}
+
+		appCfg := config.MustReadMultipleAppConfigFiles(pathCfgFiles)
+		telemetry.InitLogger(appCfg.Log)
+
+		shutdownTracer, err := telemetry.InitTracer(cmd.Context(), appCfg.Tracing, "worker")
+		if err != nil {
+			return err
+		}
+		defer shutdownTracer(context.Background())
+
+		if appCfg.Encryption.KeyFile != "" {
+			if err := encryption.InitFromKeyFile(appCfg.Encryption.KeyFile); err != nil {
+				return fmt.Errorf("failed to load the encryption keys: %w", err)
+			}
+		}
+
+		db := databases.ConnectSQLDB(appCfg.Database.Postgres.DSN)
+		databases.ConnectRedis(appCfg.Database.Redis.DSN)
+		if err := s3utils.InitializeS3(); err != nil {
+			return fmt.Errorf("failed to initialize S3: %w", err)
+		}
+
+		runner := worker.NewRunner(
+			usecase.NewJobUseCase(db),
+			appCfg.Worker.Concurrency,
+			time.Duration(appCfg.Worker.PollIntervalMillis)*time.Millisecond,
+		)
+		worker.RegisterJobs(runner, db, appCfg.Worker.BookingLifecycle)
+
+		if appCfg.Worker.MetricsAddress != "" {
+			go metrics.Serve(appCfg.Worker.MetricsAddress)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return runner.Run(ctx)
+	},
+}
@@ -1,11 +1,18 @@
 package config
 
 type App struct {
-	Database               Database     `mapstructure:"database"`
-	SecretKey              string       `mapstructure:"secretKey"`
-	AdministratorSecretKey string       `mapstructure:"administrator_secretKey"`
-	NgrokEndpoint          string       `mapstructure:"ngrok_endpoint"`
-	OAuth2Google           OAuth2Google `mapstructure:"oauth2_google"`
+	Database               Database                  `mapstructure:"database"`
+	SecretKey              string                    `mapstructure:"secretKey"`
+	AdministratorSecretKey string                    `mapstructure:"administrator_secretKey"`
+	NgrokEndpoint          string                    `mapstructure:"ngrok_endpoint"`
+	OAuth2Google           OAuth2Google              `mapstructure:"oauth2_google"`
+	OAuth2Providers        map[string]OAuth2Provider `mapstructure:"oauth2_providers"`
+	RateLimit              RateLimit                 `mapstructure:"rate_limit"`
+	Encryption             Encryption                `mapstructure:"encryption"`
+	Server                 Server                    `mapstructure:"server"`
+	Worker                 Worker                    `mapstructure:"worker"`
+	Log                    Log                       `mapstructure:"log"`
+	Tracing                Tracing                   `mapstructure:"tracing"`
 }
 
 type Database struct {
@@ -23,3 +30,80 @@ type OAuth2Google struct {
 	ClientSecret string `mapstructure:"client_secret"`
 	RedirectURL  string `mapstructure:"redirect_url"`
 }
+
+// an empty issuer requires the auth, token and userinfo URLs to be given explicitly,
+// otherwise they are resolved through OIDC discovery
+type OAuth2Provider struct {
+	ClientId     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Issuer       string   `mapstructure:"issuer"`
+	AuthURL      string   `mapstructure:"auth_url"`
+	TokenURL     string   `mapstructure:"token_url"`
+	UserInfoURL  string   `mapstructure:"userinfo_url"`
+	Scopes       []string `mapstructure:"scopes"`
+}
+
+type RateLimit struct {
+	Auth         RateLimitRule `mapstructure:"auth"`
+	Search       RateLimitRule `mapstructure:"search"`
+	Chat         RateLimitRule `mapstructure:"chat"`
+	Calendar     RateLimitRule `mapstructure:"calendar"`
+	LoginLockout LoginLockout  `mapstructure:"login_lockout"`
+}
+
+// a zero value for PerIP or PerAccount disables that budget
+type RateLimitRule struct {
+	PerIP         int `mapstructure:"per_ip"`
+	PerAccount    int `mapstructure:"per_account"`
+	WindowSeconds int `mapstructure:"window_seconds"`
+}
+
+type LoginLockout struct {
+	MaxFailures        int `mapstructure:"max_failures"`
+	BaseLockoutSeconds int `mapstructure:"base_lockout_seconds"`
+	MaxLockoutSeconds  int `mapstructure:"max_lockout_seconds"`
+}
+
+// without a key file the sensitive columns are stored in plaintext
+type Encryption struct {
+	KeyFile string `mapstructure:"key_file"`
+}
+
+// an empty metrics address does not serve the metrics
+type Server struct {
+	MetricsAddress string `mapstructure:"metrics_address"`
+}
+
+// zero values fall back to 4 goroutines polling every second, an empty metrics address does not serve the metrics
+type Worker struct {
+	Concurrency        int              `mapstructure:"concurrency"`
+	PollIntervalMillis int              `mapstructure:"poll_interval_millis"`
+	MetricsAddress     string           `mapstructure:"metrics_address"`
+	BookingLifecycle   BookingLifecycle `mapstructure:"booking_lifecycle"`
+}
+
+// a zero lead, delay or expiry turns off its job, the jobs run every 5 minutes unless PeriodSeconds is given
+type BookingLifecycle struct {
+	PeriodSeconds           int  `mapstructure:"period_seconds"`
+	ReminderLeadHours       int  `mapstructure:"reminder_lead_hours"`
+	ReviewRequestDelayHours int  `mapstructure:"review_request_delay_hours"`
+	DraftExpiryHours        int  `mapstructure:"draft_expiry_hours"`
+	DeliveryAlerts          bool `mapstructure:"delivery_alerts"`
+}
+
+// the level is one of debug, info, warn and error and the format is json or text, info and json by default
+type Log struct {
+	Level  string `mapstructure:"level"`
+	Format string `mapstructure:"format"`
+}
+
+// an empty exporter turns the tracing off, otlp sends the spans to the collector at the endpoint and stdout prints
+// them, a zero sample ratio keeps every trace
+type Tracing struct {
+	Exporter    string  `mapstructure:"exporter"`
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
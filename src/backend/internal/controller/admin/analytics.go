@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Get the business analytics
+// @Description  The bookings and the gross volume over time, the conversion, cancellation and refund rates, the top photographers and locations and the growth of the users. The amounts are in THB at the current exchange rates and the dashboard is refreshed every few minutes.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param from query string false "The first day of the range, 30 days before the end by default" format(date)
+// @Param to query string false "The day after the range, tomorrow by default" format(date)
+// @Param granularity query string false "The length of the periods" Enums(day, week, month)
+// @Param limit query int false "The number of photographers and locations ranked, 10 by default"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.AnalyticsDashboard} "The dashboard"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid range or granularity"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/analytics [get]
+func (r *Resolver) GetAnalytics(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.AnalyticsFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	dashboard, err := r.AnalyticsUsecase.Dashboard(c, filter)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAnalyticsRange),
+		errors.Is(err, usecase.ErrAnalyticsRangeTooLong):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dashboard,
+	})
+}
@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Search the audit log
+// @Description  The actions of the administrators with what they changed, who did them, from where and in which request. The log cannot be changed or deleted.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param actor_id query string false "Only the actions of this administrator"
+// @Param action query string false "Only this action" Enums(request, verification_ticket.review, issue.close, issue.assign, issue.triage, issue_sla_target.update, refund.approve, refund.reject, dispute.mediate, dispute.resolve)
+// @Param target_type query string false "Only the actions on this kind of target" Enums(verification_ticket, issue, issue_sla_target, booking, dispute)
+// @Param target_id query string false "Only the actions on this target"
+// @Param request_id query string false "Only the actions of this request"
+// @Param from query string false "The first day of the range" format(date)
+// @Param to query string false "The day after the range" format(date)
+// @Param before_seq query int false "Only the entries before this one, to get the next page"
+// @Param limit query int false "The number of entries, 50 by default and 200 at most"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.AuditEntry} "The entries, the latest first"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/audit [get]
+func (r *Resolver) SearchAuditLog(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.AuditFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	entries, err := r.AuditUsecase.Search(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   entries,
+	})
+}
+
+// @Summary      Verify the audit log
+// @Description  Check that every entry matches its hash and links to the one before it, the first entry that does not tells where the log was tampered with
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.AuditVerification} "The result of the verification"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/audit/verify [get]
+func (r *Resolver) VerifyAuditLog(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	verification, err := r.AuditUsecase.Verify(c)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   verification,
+	})
+}
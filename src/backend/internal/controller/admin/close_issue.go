@@ -3,7 +3,7 @@ package admin
 import (
 	"net/http"
 
-	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -16,6 +16,7 @@ import (
 // @Accept       json
 // @Produce      json
 // @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "Successfully closed the issue"
+// @Failure      409 {object} model.JSONErrorResult{status=string,error=nil} "The issue is already closed"
 // @Failure      500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error OR session token cannot be verified"
 // @Failure      500 {object} model.JSONErrorResult{status=string,error=nil} "Issues with finding the issue in the database"
 // @Router       /admin/v1/issues/close/:id [patch]
@@ -41,13 +42,8 @@ func (r *Resolver) CloseIssue(c *gin.Context) {
 		return
 	}
 
-	issue.Status = model.IssueClosedStatus // Make sure you have a constant defined for the closed status
-	if err = r.IssueUsecase.IssueRepo.UpdateOne(c, issue); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "failed",
-			"error":  err.Error(),
-		})
-		c.Abort()
+	if err = r.IssueUsecase.Close(c, issue); err != nil {
+		util.RaiseIssueError(c, err)
 		return
 	}
 
@@ -0,0 +1,191 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the coupons
+// @Description  The platform-wide coupons and the ones of the photographers, latest first
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param photographer_id query string false "Only the coupons of this photographer"
+// @Param is_active query bool false "Only the active or the inactive coupons"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.Coupon} "The coupons"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/coupons [get]
+func (r *Resolver) ListCoupons(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.CouponFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	coupons, err := r.CouponUsecase.CouponRepo.FindWithFilter(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   coupons,
+	})
+}
+
+// @Summary      Create a platform-wide coupon
+// @Description  The coupon applies to the bookings of every photographer, optionally only the chosen galleries or locations
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param coupon body model.CouponInput true "The code, the kind, the value and the restrictions of the coupon"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.Coupon} "The coupon"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid coupon or an unknown gallery"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The code is already taken"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/coupons [post]
+func (r *Resolver) CreateCoupon(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	input := model.CouponInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	coupon, err := r.CouponUsecase.Create(c, adminObj.Id, nil, input)
+	if err != nil {
+		util.RaiseCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   coupon,
+	})
+}
+
+// @Summary      Update a coupon
+// @Description  Change the terms of any coupon, including the ones of the photographers, or pause it with is_active
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the coupon"
+// @Param coupon body model.CouponInput true "The fields to change"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Coupon} "The updated coupon"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid coupon or an unknown gallery"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The coupon does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The code is already taken"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/coupons/{id} [put]
+func (r *Resolver) UpdateCoupon(c *gin.Context) {
+	coupon, ok := r.getCoupon(c)
+	if !ok {
+		return
+	}
+
+	input := model.CouponInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	if err := r.CouponUsecase.Update(c, coupon, input); err != nil {
+		util.RaiseCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   coupon,
+	})
+}
+
+// @Summary      List the redemptions of a coupon
+// @Description  Every booking the coupon has been applied to, with the price before it and the amount taken off
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the coupon"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.CouponRedemption} "The redemptions, latest first"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The coupon does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/coupons/{id}/redemptions [get]
+func (r *Resolver) ListCouponRedemptions(c *gin.Context) {
+	coupon, ok := r.getCoupon(c)
+	if !ok {
+		return
+	}
+
+	redemptions, err := r.CouponUsecase.CouponRedemptionRepo.FindByCouponId(c, coupon.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   redemptions,
+	})
+}
+
+func (r *Resolver) getCoupon(c *gin.Context) (*model.Coupon, bool) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return nil, false
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return nil, false
+	}
+
+	couponId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid coupon id")
+		return nil, false
+	}
+
+	coupon, err := r.CouponUsecase.CouponRepo.FindOneById(c, couponId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  "the coupon does not exist",
+		})
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return coupon, true
+}
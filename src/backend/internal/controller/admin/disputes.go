@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the disputes
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param status query string false "Only the disputes in this status" Enums(OPEN, RESOLVED, WITHDRAWN)
+// @Param mediator_id query string false "Only the disputes mediated by this administrator"
+// @Param unassigned query bool false "Only the disputes without a mediator, or only the ones with one"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.Dispute} "The disputes, the oldest first"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/disputes [get]
+func (r *Resolver) ListDisputes(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.DisputeFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	disputes, err := r.DisputeUsecase.FindWithFilter(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   disputes,
+	})
+}
+
+// @Summary      Get a dispute
+// @Description  The dispute with its booking and the evidence of both parties and of the mediator, the links to the photos expire after a few minutes
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the dispute"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Dispute} "The dispute and its evidence"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The dispute does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/disputes/{id} [get]
+func (r *Resolver) GetDispute(c *gin.Context) {
+	_, dispute, ok := r.getDispute(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dispute,
+	})
+}
+
+// @Summary      Add evidence to a dispute as its mediator
+// @Description  A text, up to 5 photos of up to 5 MB each and an excerpt of the chat of the booking quoting up to 50 of its messages, at least one of them is needed. Both parties are notified.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the dispute"
+// @Param text formData string false "The statement"
+// @Param photos formData file false "The photos"
+// @Param conversation_ids formData []string false "The IDs of the messages of the chat quoted" collectionFormat(multi)
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.DisputeEvidence} "The evidence added"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Empty evidence, invalid photo or invalid excerpt"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The dispute does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The dispute is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/disputes/{id}/evidence [post]
+func (r *Resolver) AddDisputeEvidence(c *gin.Context) {
+	adminObj, dispute, ok := r.getDispute(c)
+	if !ok {
+		return
+	}
+
+	text, photos, conversationIds, ok := util.ReadDisputeEvidence(c)
+	if !ok {
+		return
+	}
+
+	evidence, err := r.DisputeUsecase.AddEvidence(c, dispute, dispute.Booking, adminObj.Id, model.DisputeMediatorParty, text, photos, conversationIds)
+	if err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   evidence,
+	})
+}
+
+// @Summary      Mediate a dispute
+// @Description  Become the mediator of the dispute, the mediator is notified of the evidence added by the parties
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the dispute"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Dispute} "The dispute"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The dispute does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The dispute is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/disputes/{id}/mediator [put]
+func (r *Resolver) MediateDispute(c *gin.Context) {
+	adminObj, dispute, ok := r.getDispute(c)
+	if !ok {
+		return
+	}
+
+	if err := r.DisputeUsecase.Mediate(c, dispute, adminObj.Id); err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dispute,
+	})
+}
+
+// @Summary      Resolve a dispute
+// @Description  Split the disputed amount between the parties, the customer's share is credited by a credit note and the photographer's share is paid out. The booking is cancelled when the customer gets everything back and completed otherwise. Both parties are notified.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the dispute"
+// @Param resolution body model.DisputeResolutionInput true "The amount credited to the customer, in the currency of the dispute, and the decision"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Dispute} "The resolved dispute"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The customer cannot be credited more than the disputed amount"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The dispute does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The dispute is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/disputes/{id}/resolve [put]
+func (r *Resolver) ResolveDispute(c *gin.Context) {
+	adminObj, dispute, ok := r.getDispute(c)
+	if !ok {
+		return
+	}
+
+	input := model.DisputeResolutionInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	if err := r.DisputeUsecase.Resolve(c, dispute, dispute.Booking, adminObj.Id, input, r.BookingUsecase); err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dispute,
+	})
+}
+
+// the booking of the dispute is populated with its room, the credit note of a resolution is numbered in the
+// sequence of its photographer
+func (r *Resolver) getDispute(c *gin.Context) (*model.User, *model.Dispute, bool) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return nil, nil, false
+	}
+
+	disputeId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid dispute id")
+		return nil, nil, false
+	}
+
+	dispute, err := r.DisputeUsecase.FindOneWithEvidence(c, disputeId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  "the dispute does not exist",
+		})
+		c.Abort()
+		return nil, nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	booking, err := r.BookingUsecase.BookingRepo.FindOneById(c, dispute.BookingId)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+	dispute.Booking = booking
+
+	return adminObj, dispute, true
+}
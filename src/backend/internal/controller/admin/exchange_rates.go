@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List the exchange rates
+// @Description  The amount of every currency that one THB buys, THB itself is not listed
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.ExchangeRate} "The rates"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/exchange-rates [get]
+func (r *Resolver) ListExchangeRates(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	rates, err := r.CurrencyUsecase.ExchangeRateRepo.FindAll(c)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rates,
+	})
+}
+
+// @Summary      Update the exchange rates
+// @Description  Replace the rates of the currencies given, the rates of the other currencies are kept. The bookings already paid keep the rate they were paid at.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param rates body model.ExchangeRateInput true "The amount of every currency that one THB buys"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.ExchangeRate} "The updated rates"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid currency or rate"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/exchange-rates [put]
+func (r *Resolver) UpdateExchangeRates(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	input := model.ExchangeRateInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	rates, err := r.CurrencyUsecase.UpdateRates(c, input, model.ExchangeRateAdminSource)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidExchangeRate):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   rates,
+	})
+}
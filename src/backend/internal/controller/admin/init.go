@@ -12,6 +12,12 @@ type Resolver struct {
 	VerificationTicketUsecase usecase.VerificationTicketUseCase
 	RoomUsecase               usecase.RoomUseCase
 	GalleryUsecase            usecase.GalleryUseCase
+	WebhookUsecase            usecase.WebhookUseCase
+	CouponUsecase             usecase.CouponUseCase
+	CurrencyUsecase           usecase.CurrencyUseCase
+	AnalyticsUsecase          usecase.AnalyticsUseCase
+	DisputeUsecase            usecase.DisputeUseCase
+	AuditUsecase              usecase.AuditUseCase
 }
 
 func NewResolver(db *bun.DB) *Resolver {
@@ -22,5 +28,11 @@ func NewResolver(db *bun.DB) *Resolver {
 		VerificationTicketUsecase: *usecase.NewVerificationTicketUseCase(db),
 		RoomUsecase:               *usecase.NewRoomUseCase(db),
 		GalleryUsecase:            *usecase.NewGalleryUseCase(db),
+		WebhookUsecase:            *usecase.NewWebhookUseCase(db),
+		CouponUsecase:             *usecase.NewCouponUseCase(db),
+		CurrencyUsecase:           *usecase.NewCurrencyUseCase(db),
+		AnalyticsUsecase:          *usecase.NewAnalyticsUseCase(db),
+		DisputeUsecase:            *usecase.NewDisputeUseCase(db),
+		AuditUsecase:              *usecase.NewAuditUseCase(db),
 	}
 }
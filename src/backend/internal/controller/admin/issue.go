@@ -88,7 +88,7 @@ func (r *Resolver) GetIssueHeaderMetadata(c *gin.Context) {
 		return
 	}
 
-	issueHeaderMetadata, err := r.IssueUsecase.GetIssueHeaderMetadata(c)
+	issueHeaderMetadata, err := r.IssueUsecase.GetIssueHeaderMetadata(c, admin.Id)
 	if err != nil {
 		util.Raise500Error(c, err)
 		return
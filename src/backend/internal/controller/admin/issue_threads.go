@@ -0,0 +1,266 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Get an issue
+// @Description  The issue with its reporter, the replies posted on it and their attachments, the links to the attachments expire after a few minutes
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Issue} "The issue and its thread"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issues/{id} [get]
+func (r *Resolver) GetIssue(c *gin.Context) {
+	_, issue, ok := r.getIssue(c)
+	if !ok {
+		return
+	}
+
+	reporter, err := r.UserUsecase.UserRepo.FindOneById(c, issue.ReporterId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		util.Raise500Error(c, err)
+		return
+	}
+	if reporter != nil {
+		issue.Reporter = *reporter
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   issue,
+	})
+}
+
+// @Summary      Reply to an issue
+// @Description  Post a message on the issue as an agent, with up to 5 images, PDF or text files of up to 5 MB each. The first reply of an agent is the first response of the SLA and the reporter is notified.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Param body formData string true "The message"
+// @Param attachments formData file false "The files attached to the message"
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.IssueMessage} "The message posted"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Missing message or invalid attachment"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The issue is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issues/{id}/messages [post]
+func (r *Resolver) ReplyToIssue(c *gin.Context) {
+	adminObj, issue, ok := r.getIssue(c)
+	if !ok {
+		return
+	}
+
+	body, uploads, ok := util.ReadIssueMessage(c)
+	if !ok {
+		return
+	}
+
+	message, err := r.IssueUsecase.Reply(c, issue, adminObj.Id, true, body, uploads)
+	if err != nil {
+		util.RaiseIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   message,
+	})
+}
+
+// @Summary      Assign an issue
+// @Description  Give the issue to an administrator, who is notified. Without an assignee the issue is unassigned.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Param assignee body model.IssueAssigneeInput true "The administrator the issue is assigned to"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Issue} "The assigned issue"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The assignee is not an administrator"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issues/{id}/assignee [put]
+func (r *Resolver) AssignIssue(c *gin.Context) {
+	_, issue, ok := r.getIssue(c)
+	if !ok {
+		return
+	}
+
+	input := model.IssueAssigneeInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	if err := r.IssueUsecase.Assign(c, issue, input.AssigneeId); err != nil {
+		util.RaiseIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   issue,
+	})
+}
+
+// @Summary      Triage an issue
+// @Description  Change the subject or the priority of the issue, a new priority moves its due dates to the SLA target of the priority. The subject of a refund cannot be changed.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Param triage body model.IssueTriageInput true "The new subject and priority"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Issue} "The triaged issue"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid subject or priority"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issues/{id}/triage [put]
+func (r *Resolver) TriageIssue(c *gin.Context) {
+	_, issue, ok := r.getIssue(c)
+	if !ok {
+		return
+	}
+
+	input := model.IssueTriageInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	if err := r.IssueUsecase.Triage(c, issue, input); err != nil {
+		util.RaiseIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   issue,
+	})
+}
+
+// @Summary      List the SLA targets
+// @Description  The time within which the issues of every priority should get a first response from an agent and be resolved
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.IssueSLATarget} "The targets, the most urgent first"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issue-sla [get]
+func (r *Resolver) ListIssueSLATargets(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	targets, err := r.IssueUsecase.FindSLATargets(c)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   targets,
+	})
+}
+
+// @Summary      Update the SLA targets
+// @Description  Replace the targets of the priorities given, in minutes. They apply to the issues reported or re-prioritised afterwards.
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param targets body model.IssueSLAInput true "The targets of the priorities"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.IssueSLATarget} "The updated targets"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid priority or target"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/issue-sla [put]
+func (r *Resolver) UpdateIssueSLATargets(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	input := model.IssueSLAInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	targets, err := r.IssueUsecase.UpdateSLATargets(c, input)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidSLATarget):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   targets,
+	})
+}
+
+func (r *Resolver) getIssue(c *gin.Context) (*model.User, *model.Issue, bool) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return nil, nil, false
+	}
+
+	issueId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid issue id")
+		return nil, nil, false
+	}
+
+	issue, err := r.IssueUsecase.FindOneWithThread(c, issueId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  "the issue does not exist",
+		})
+		c.Abort()
+		return nil, nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	return adminObj, issue, true
+}
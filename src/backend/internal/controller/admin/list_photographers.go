@@ -1,12 +1,10 @@
 package admin
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
-	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -56,13 +54,22 @@ func (r *Resolver) ListPendingPhotographers(c *gin.Context) {
 		return
 	}
 
+	pendingTickets := []*model.VerificationTicket{}
 	for _, vrfTicket := range verificationTickets {
+		if vrfTicket.Status != model.VerificationTicketPendingStatus {
+			continue
+		}
 		vrfTicket.User = *phtgIdsToPhtgEntities[vrfTicket.UserId]
-		vrfTicket.IdCardPictureURL = fmt.Sprintf("http://localhost:4566/%s/%s", s3utils.IdCardBucket, vrfTicket.IdCardPictureKey)
+		pendingTickets = append(pendingTickets, vrfTicket)
+	}
+
+	if err := r.VerificationTicketUsecase.PresignIdCardURLs(c, pendingTickets...); err != nil {
+		util.Raise500Error(c, err)
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   verificationTickets,
+		"data":   pendingTickets,
 	})
 }
@@ -1,10 +1,12 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -49,15 +51,8 @@ func (r *Resolver) RejectRefundBooking(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingPaidStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
-	issue.Status = model.IssueClosedStatus
-	if err := r.IssueUsecase.IssueRepo.UpdateOne(c, issue); err != nil {
-		util.Raise500Error(c, err)
+	if err := r.BookingUsecase.ResolveRefund(c, booking, issue, false); err != nil {
+		raiseRefundError(c, err)
 		return
 	}
 
@@ -112,20 +107,14 @@ func (r *Resolver) ApproveRefundBooking(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingCancelledStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
-	issue.Status = model.IssueClosedStatus
-	if err := r.IssueUsecase.IssueRepo.UpdateOne(c, issue); err != nil {
+	// the credit note is numbered in the sequence of the photographer of the room
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
-		util.Raise500Error(c, err)
+	if err := r.BookingUsecase.ResolveRefund(c, booking, issue, true); err != nil {
+		raiseRefundError(c, err)
 		return
 	}
 
@@ -134,3 +123,13 @@ func (r *Resolver) ApproveRefundBooking(c *gin.Context) {
 		"data":   booking,
 	})
 }
+
+// raiseRefundError answers with a conflict when the refund has been resolved meanwhile
+func raiseRefundError(c *gin.Context, err error) {
+	if errors.Is(err, usecase.ErrRefundNotRequested) || errors.Is(err, usecase.ErrIssueClosed) {
+		util.Raise409Error(c, err.Error())
+		return
+	}
+
+	util.Raise500Error(c, err)
+}
@@ -1,13 +1,30 @@
 package admin
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
+// @Summary      Reject the specified photographer
+// @Description Reject the latest verification ticket of the specified photographer, a reason is required
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param photographerId path string true "The ID of the to-be-rejected photographer"
+// @Param decision body model.VerificationDecisionInput true "The reason of the rejection"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "Successfully rejected the photographer"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The reason is missing"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The photographer has not submitted any verification ticket"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The latest ticket has already been reviewed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/reject/{id} [put]
 func (r *Resolver) Reject(c *gin.Context) {
 	adminObj, ok := getAdmin(c)
 	if !ok {
@@ -18,30 +35,42 @@ func (r *Resolver) Reject(c *gin.Context) {
 		return
 	}
 
-	photographerId := c.Param("id")
+	ticket, ok := r.getLatestVerificationTicket(c)
+	if !ok {
+		return
+	}
+
+	input := model.VerificationDecisionInput{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			util.Raise400Error(c, "could not bind json")
+			return
+		}
+	}
+
+	r.decideVerificationTicket(c, adminObj, ticket, model.VerificationRejectedAction, input.Reason)
+}
 
-	toBeRejected, err := r.UserUsecase.UserRepo.FindOneById(c, uuid.MustParse(photographerId))
+func (r *Resolver) getLatestVerificationTicket(c *gin.Context) (*model.VerificationTicket, bool) {
+	photographerId, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "failed",
-			"error":  err.Error,
-		})
-		c.Abort()
-		return
+		util.Raise400Error(c, "invalid photographer id")
+		return nil, false
 	}
 
-	toBeRejected.VerificationStatus = model.PhotographerRejectedStatus
-	if err = r.UserUsecase.UserRepo.UpdateOne(c, toBeRejected); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
+	ticket, err := r.VerificationTicketUsecase.VerificationInfoRepo.FindLatestByUserId(c, photographerId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
 			"status": "failed",
-			"error":  err.Error,
+			"error":  "the photographer has not submitted any verification ticket",
 		})
 		c.Abort()
-		return
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   toBeRejected,
-	})
+	return ticket, true
 }
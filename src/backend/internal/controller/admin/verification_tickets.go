@@ -0,0 +1,211 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the verification tickets
+// @Description  The review queue of the verification tickets, ordered by due date, with the submitted ID card and the history of each ticket
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param status query string false "PENDING, APPROVED, REJECTED or INFO_REQUESTED"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.VerificationTicket} "The verification tickets"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/verifications/tickets [get]
+func (r *Resolver) ListVerificationTickets(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.VerificationTicketFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	tickets, err := r.VerificationTicketUsecase.FindWithFilter(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.VerificationTicketUsecase.PopulateTickets(c, tickets...); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   tickets,
+	})
+}
+
+// @Summary      Get a verification ticket
+// @Description  Get a verification ticket with the submitted ID card and its history
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the verification ticket"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "The verification ticket"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Incorrect ticket id"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The verification ticket does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/verifications/tickets/{id} [get]
+func (r *Resolver) GetVerificationTicket(c *gin.Context) {
+	ticket, ok := r.getVerificationTicket(c)
+	if !ok {
+		return
+	}
+
+	if err := r.VerificationTicketUsecase.PopulateTickets(c, ticket); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   ticket,
+	})
+}
+
+// @Summary      Approve a verification ticket
+// @Description  Approve a pending verification ticket, the photographer becomes verified
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the verification ticket"
+// @Param decision body model.VerificationDecisionInput false "An optional note"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "The reviewed ticket"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Incorrect input"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The verification ticket does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The ticket has already been reviewed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/verifications/tickets/{id}/approve [put]
+func (r *Resolver) ApproveVerificationTicket(c *gin.Context) {
+	r.reviewVerificationTicket(c, model.VerificationApprovedAction)
+}
+
+// @Summary      Reject a verification ticket
+// @Description  Reject a pending verification ticket, the reason is shown to the photographer who may submit a new ticket
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the verification ticket"
+// @Param decision body model.VerificationDecisionInput true "The reason of the rejection"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "The reviewed ticket"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The reason is missing"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The verification ticket does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The ticket has already been reviewed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/verifications/tickets/{id}/reject [put]
+func (r *Resolver) RejectVerificationTicket(c *gin.Context) {
+	r.reviewVerificationTicket(c, model.VerificationRejectedAction)
+}
+
+// @Summary      Request more information on a verification ticket
+// @Description  Ask the photographer to re-submit the ticket with the information described in the reason
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the verification ticket"
+// @Param decision body model.VerificationDecisionInput true "The information needed"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "The reviewed ticket"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The reason is missing"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The verification ticket does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The ticket has already been reviewed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/verifications/tickets/{id}/request-info [put]
+func (r *Resolver) RequestVerificationInfo(c *gin.Context) {
+	r.reviewVerificationTicket(c, model.VerificationInfoRequestedAction)
+}
+
+func (r *Resolver) getVerificationTicket(c *gin.Context) (*model.VerificationTicket, bool) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return nil, false
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return nil, false
+	}
+
+	ticketId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid verification ticket id")
+		return nil, false
+	}
+
+	ticket, err := r.VerificationTicketUsecase.VerificationInfoRepo.FindOneById(c, ticketId)
+	if errors.Is(err, sql.ErrNoRows) {
+		util.Raise404Error(c, "the verification ticket does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return ticket, true
+}
+
+func (r *Resolver) reviewVerificationTicket(c *gin.Context, decision string) {
+	ticket, ok := r.getVerificationTicket(c)
+	if !ok {
+		return
+	}
+
+	input := model.VerificationDecisionInput{}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			util.Raise400Error(c, "could not bind json")
+			return
+		}
+	}
+
+	adminObj, _ := getAdmin(c)
+	r.decideVerificationTicket(c, adminObj, ticket, decision, input.Reason)
+}
+
+func (r *Resolver) decideVerificationTicket(c *gin.Context, reviewer *model.User, ticket *model.VerificationTicket, decision string, reason *string) {
+	err := r.VerificationTicketUsecase.Review(c, reviewer, ticket, decision, reason)
+	switch {
+	case errors.Is(err, usecase.ErrDecisionReasonMissing), errors.Is(err, usecase.ErrInvalidDecision):
+		util.Raise400Error(c, err.Error())
+		return
+	case errors.Is(err, usecase.ErrTicketAlreadyReviewed):
+		util.Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.VerificationTicketUsecase.PopulateTickets(c, ticket); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   ticket,
+	})
+}
@@ -1,24 +1,22 @@
 package admin
 
 import (
-	"net/http"
-
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
 // @Summary      Verify the specified photographer
-// @Description Verify the specified photographer
+// @Description Approve the latest verification ticket of the specified photographer
 // @Tags         admin
 // @Param Token header string true "Session token is required"
 // @Param photographerId path string true "The ID of the to-be-verified photographer"
 // @Accept       json
 // @Produce      json
-// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "Successfully verified the photographer"
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "Successfully verified the photographer"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The photographer has not submitted any verification ticket"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The latest ticket has already been reviewed"
 // @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error OR session token cannot be verified"
-// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Issues with finding the photographer in the database"
-// @Router       /admin/v1/verifications/verify/:id [get]
+// @Router       /admin/v1/verify/{id} [put]
 func (r *Resolver) Verify(c *gin.Context) {
 	adminObj, ok := getAdmin(c)
 	if !ok {
@@ -29,30 +27,10 @@ func (r *Resolver) Verify(c *gin.Context) {
 		return
 	}
 
-	photographerId := c.Param("id")
-
-	toBeVrf, err := r.UserUsecase.UserRepo.FindOneById(c, uuid.MustParse(photographerId))
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "failed",
-			"error":  err.Error,
-		})
-		c.Abort()
-		return
-	}
-
-	toBeVrf.VerificationStatus = model.PhotographerVerifiedStatus
-	if err = r.UserUsecase.UserRepo.UpdateOne(c, toBeVrf); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "failed",
-			"error":  err.Error,
-		})
-		c.Abort()
+	ticket, ok := r.getLatestVerificationTicket(c)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   toBeVrf,
-	})
+	r.decideVerificationTicket(c, adminObj, ticket, model.VerificationApprovedAction, nil)
 }
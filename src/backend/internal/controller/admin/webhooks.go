@@ -0,0 +1,288 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the webhook subscriptions
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.WebhookSubscription} "The webhook subscriptions"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/subscriptions [get]
+func (r *Resolver) ListWebhookSubscriptions(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	subscriptions, err := r.WebhookUsecase.WebhookSubscriptionRepo.FindAll(c)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscriptions,
+	})
+}
+
+// @Summary      Register a webhook
+// @Description  Register a URL to receive the chosen events, the signing secret is only returned in this response
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param subscription body model.WebhookSubscriptionInput true "The URL and the event types"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.WebhookSubscription} "The subscription, the secret is in the secret field"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid URL or event type"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/subscriptions [post]
+func (r *Resolver) CreateWebhookSubscription(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	input := model.WebhookSubscriptionInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	subscription, secret, err := r.WebhookUsecase.CreateSubscription(c, adminObj, input)
+	if err != nil {
+		raiseWebhookInputError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   subscription,
+		"secret": secret,
+	})
+}
+
+// @Summary      Update a webhook
+// @Description  Change the URL or the event types of a webhook, or pause it with is_active
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the subscription"
+// @Param subscription body model.WebhookSubscriptionInput true "The fields to change"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.WebhookSubscription} "The updated subscription"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid URL or event type"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The subscription does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/subscriptions/{id} [put]
+func (r *Resolver) UpdateWebhookSubscription(c *gin.Context) {
+	subscription, ok := r.getWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	input := model.WebhookSubscriptionInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	if err := r.WebhookUsecase.UpdateSubscription(c, subscription, input); err != nil {
+		raiseWebhookInputError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   subscription,
+	})
+}
+
+// @Summary      Delete a webhook
+// @Description  Delete a webhook along with its deliveries
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the subscription"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The subscription has been deleted"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The subscription does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/subscriptions/{id} [delete]
+func (r *Resolver) DeleteWebhookSubscription(c *gin.Context) {
+	subscription, ok := r.getWebhookSubscription(c)
+	if !ok {
+		return
+	}
+
+	if _, err := r.WebhookUsecase.WebhookSubscriptionRepo.DeleteOneById(c, subscription.Id); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+// @Summary      List the webhook deliveries
+// @Description  The deliveries of the webhooks, newest first, the DEAD ones make up the dead-letter log
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param subscription_id query string false "Only the deliveries of this subscription"
+// @Param status query string false "PENDING, SUCCEEDED or DEAD"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.WebhookDelivery} "The deliveries"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Only administrators are allowed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/deliveries [get]
+func (r *Resolver) ListWebhookDeliveries(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	filter := model.WebhookDeliveryFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	deliveries, err := r.WebhookUsecase.WebhookDeliveryRepo.FindWithFilter(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   deliveries,
+	})
+}
+
+// @Summary      Replay a webhook delivery
+// @Description  Queue a delivery again from its first attempt, e.g. a dead one once the subscriber is fixed
+// @Tags         admin
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the delivery"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.WebhookDelivery} "The queued delivery"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The delivery does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The delivery is still pending"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /admin/v1/webhooks/deliveries/{id}/replay [post]
+func (r *Resolver) ReplayWebhookDelivery(c *gin.Context) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return
+	}
+
+	deliveryId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid delivery id")
+		return
+	}
+
+	delivery, err := r.WebhookUsecase.WebhookDeliveryRepo.FindOneById(c, deliveryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  "the delivery does not exist",
+		})
+		c.Abort()
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	err = r.WebhookUsecase.Replay(c, delivery)
+	switch {
+	case errors.Is(err, usecase.ErrDeliveryInProgress):
+		util.Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   delivery,
+	})
+}
+
+func (r *Resolver) getWebhookSubscription(c *gin.Context) (*model.WebhookSubscription, bool) {
+	adminObj, ok := getAdmin(c)
+	if !ok {
+		return nil, false
+	}
+
+	if ok := checkIsAdmin(adminObj, c); !ok {
+		return nil, false
+	}
+
+	subscriptionId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid subscription id")
+		return nil, false
+	}
+
+	subscription, err := r.WebhookUsecase.WebhookSubscriptionRepo.FindOneById(c, subscriptionId)
+	if errors.Is(err, sql.ErrNoRows) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  "the subscription does not exist",
+		})
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+func raiseWebhookInputError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrInvalidWebhookURL),
+		errors.Is(err, usecase.ErrInvalidWebhookEventType),
+		errors.Is(err, usecase.ErrWebhookEventTypeMissing):
+		util.Raise400Error(c, err.Error())
+	default:
+		util.Raise500Error(c, err)
+	}
+}
@@ -1,7 +1,8 @@
 package chat
 
 import (
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"time"
 
@@ -10,6 +11,8 @@ import (
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/auth"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
@@ -57,6 +60,12 @@ type Chat struct {
 	// maps roomIds <-> userIds; many-to-many
 	rooms    *TableCache
 	Resolver *Resolver
+
+	// the notifications published by the api servers
+	notifications *redis.PubSub
+
+	// the cards of the booking requests and the quotes published by the worker
+	cards *redis.PubSub
 }
 
 func NewChat(db *bun.DB, client *redis.Client, resolver *Resolver) *Chat {
@@ -69,11 +78,58 @@ func NewChat(db *bun.DB, client *redis.Client, resolver *Resolver) *Chat {
 		Resolver:    resolver,
 	}
 
-	log.Println("starting event loop")
+	metrics.ObserveChat(c.sessions.Len, func() int { return len(c.broadcast) })
 	go c.eventloop()
+
+	c.notifications = client.Subscribe(ctx, usecase.NotificationChannel)
+	go c.listenNotifications()
+
+	c.cards = client.Subscribe(ctx, usecase.ChatCardChannel)
+	go c.listenCards()
 	return &c
 }
 
+// forwards the published notifications to the event loop, which owns the writes to the connections
+func (c *Chat) listenNotifications() {
+	for payload := range c.notifications.Channel() {
+		event := usecase.NotificationEvent{}
+		if err := json.Unmarshal([]byte(payload.Payload), &event); err != nil {
+			slog.Warn("invalid notification", "error", err)
+			continue
+		}
+
+		c.broadcast <- Message{
+			ID:           event.Notification.Id,
+			Type:         MessageTypeNotification,
+			Text:         event.Notification.Title,
+			Timestamp:    event.Notification.CreatedAt,
+			Notification: event.Notification,
+			recipient:    event.UserId,
+		}
+	}
+}
+
+// forwards the published cards to the event loop, a card already posted is sent again when its status changes
+func (c *Chat) listenCards() {
+	for payload := range c.cards.Channel() {
+		card := model.Conversation{}
+		if err := json.Unmarshal([]byte(payload.Payload), &card); err != nil {
+			slog.Warn("invalid card", "error", err)
+			continue
+		}
+
+		c.broadcast <- Message{
+			ID:        card.Id,
+			Type:      MessageTypeCard,
+			Text:      card.Text,
+			Timestamp: card.CreatedAt,
+			Sender:    card.UserId,
+			Receiver:  card.RoomId,
+			Card:      &card,
+		}
+	}
+}
+
 func (c *Chat) newSession(ws *websocket.Conn) *Session {
 	sess := NewSession(ws)
 	c.sessions.Put(sess)
@@ -82,9 +138,7 @@ func (c *Chat) newSession(ws *websocket.Conn) *Session {
 
 // ties userId and sessionId together
 func (c *Chat) Bind(uid UserId, sid SessionId) func() {
-	log.Println("method: bind")
-	log.Printf("user: %s\n", uid.String())
-	log.Printf("session: %s\n", sid.String())
+	slog.Debug("binding chat session", "user", uid.String(), "session", sid.String())
 
 	// if the user has no sessions associated, create a new one
 	if sess := c.Get(uid); len(sess) == 0 {
@@ -106,12 +160,10 @@ func (c *Chat) Bind(uid UserId, sid SessionId) func() {
 
 // add user to an existing room
 func (c *Chat) Join(uid UserId) {
-	log.Println("method: join")
-	log.Printf("user: %s\n", uid.String())
-
 	lookups, err := c.Resolver.LookupUsecase.FindByUserId(ctx, uuid.UUID(uid))
 	if err != nil {
-		log.Panicf("error getting rooms: %s\n", err.Error())
+		slog.Error("unable to find the chat rooms of the user", "user", uid.String(), "error", err)
+		return
 	}
 
 	for _, lookup := range lookups {
@@ -129,23 +181,21 @@ func (c *Chat) Join(uid UserId) {
 
 		// then add the user after broadcast to avoid notifying themselves
 		if err := c.rooms.Add(uuid.UUID(uid), lookup.RoomId); err != nil {
-			log.Panicf("join error: %s\n", err.Error())
+			slog.Error("unable to join the chat room", "user", uid.String(), "room", lookup.RoomId, "error", err)
+			continue
 		}
+		metrics.ChatRoomMembers.Inc()
 
-		log.Println("joined room")
-		log.Printf("room: %s\n", lookup.RoomId.String())
+		slog.Debug("joined chat room", "user", uid.String(), "room", lookup.RoomId)
 	}
 }
 
 // clear the user's session from the room
 func (c *Chat) Leave(uid UserId) {
-	log.Println("method: leave")
-	log.Printf("user: %s\n", uid.String())
-
 	// delete user for each room
 	onDelete := func(roomId uuid.UUID) {
-		log.Println("delete user from room")
-		log.Printf("room: %s\n", roomId.String())
+		slog.Debug("left chat room", "user", uid.String(), "room", roomId)
+		metrics.ChatRoomMembers.Dec()
 
 		sender, receiver := uuid.UUID(uid), roomId
 		c.broadcast <- Message{
@@ -160,14 +210,12 @@ func (c *Chat) Leave(uid UserId) {
 
 	// delete user -> rooms relationship
 	if err := c.rooms.Delete(uuid.UUID(uid), onDelete); err != nil {
-		log.Panicf("error removing from room: %s\n", err.Error())
+		slog.Error("unable to leave the chat rooms", "user", uid.String(), "error", err)
 	}
-
-	log.Println("left room")
 }
 
 func (c *Chat) eventloop() {
-	log.Println("event loop started")
+	slog.Info("chat event loop started")
 	getStatus := func(userId uuid.UUID) string {
 		sessions := c.Get(UserId(userId))
 		// no sessions existed
@@ -182,19 +230,14 @@ loop:
 	for {
 		select {
 		case <-c.quit:
-			log.Println("quit")
+			slog.Info("chat event loop stopped")
 			break loop
 		case msg, ok := <-c.broadcast:
 			if !ok {
 				break loop
 			}
 
-			log.Println()
-			log.Println("processing message:")
-			log.Printf("type: %s\n", msg.Type)
-			log.Printf("receiver: %s\n", msg.Receiver)
-			log.Printf("sender: %s\n", msg.Sender)
-			log.Printf("text: %s\n", msg.Text)
+			slog.Debug("processing chat message", "type", msg.Type, "sender", msg.Sender, "receiver", msg.Receiver)
 
 			switch msg.Type {
 			case MessageTypeStatus:
@@ -207,6 +250,7 @@ loop:
 				conversation := model.Conversation{
 					Id:        uuid.New(),
 					Text:      msg.Text,
+					Kind:      model.ConversationMessageKind,
 					UserId:    msg.Sender,
 					RoomId:    msg.Receiver,
 					CreatedAt: time.Now(),
@@ -214,26 +258,29 @@ loop:
 					DeletedAt: nil,
 				}
 				if err := c.Resolver.ConversationUsecase.ConversationRepo.AddOne(ctx, &conversation); err != nil {
-					log.Panicf("error creating reply: %s\n", err.Error())
+					slog.Error("unable to save chat message", "sender", msg.Sender, "room", msg.Receiver, "error", err)
 					continue
 				}
 				msg.ID = conversation.Id
 				msg.Timestamp = conversation.CreatedAt
+			case MessageTypeNotification:
+				if err := c.SendToUser(msg.recipient, msg); err != nil {
+					slog.Warn("unable to send notification", "user", msg.recipient, "error", err)
+				}
+				continue
 			default:
 			}
 			if err := c.Broadcast(msg); err != nil {
-				log.Println("error occured")
-				log.Println(err.Error())
-				log.Println()
+				slog.Warn("unable to broadcast chat message", "type", msg.Type, "room", msg.Receiver, "error", err)
 			}
 		}
 	}
 }
 
-// sends message to a room
+// sends message to a room, like SendToUser a session that cannot be written to is cleared and the other members are
+// still sent the message
 func (c *Chat) Broadcast(msg Message) error {
-	log.Println("method: broadcast")
-
+	var errs []error
 	// get all users in the room
 	users := c.rooms.GetUsers(msg.Receiver)
 	for _, user := range users {
@@ -241,19 +288,36 @@ func (c *Chat) Broadcast(msg Message) error {
 		for _, sid := range sessionIds {
 			sess := c.sessions.Get(sid)
 			if sess == nil {
-				log.Println("session does not exist")
+				slog.Debug("chat session does not exist", "session", sid)
 				continue
 			}
 			sess.Conn().SetWriteDeadline(time.Now().Add(writeWait))
 			err := sess.Conn().WriteJSON(msg)
 			if err != nil {
+				metrics.ChatDroppedWrites.Inc()
 				c.Clear(sess)
-				return err
+				errs = append(errs, err)
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// sends message to every session of the user, it is fine for the user to be offline. A session that cannot be
+// written to is cleared and the other sessions are still sent the message.
+func (c *Chat) SendToUser(userId uuid.UUID, msg Message) error {
+	var errs []error
+	for _, sess := range c.Get(UserId(userId)) {
+		sess.Conn().SetWriteDeadline(time.Now().Add(writeWait))
+		if err := sess.Conn().WriteJSON(msg); err != nil {
+			metrics.ChatDroppedWrites.Inc()
+			c.Clear(sess)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (c *Chat) Clear(sess *Session) {
@@ -267,8 +331,7 @@ func (c *Chat) Clear(sess *Session) {
 	c.sessions.Delete(sessionId)
 	c.lookupTable.Delete(SessionId(sessionId))
 
-	log.Println("cleared session")
-	log.Println("method: clear")
+	slog.Debug("cleared chat session", "session", sessionId)
 }
 
 // get sessions by userId or SessionId
@@ -298,9 +361,11 @@ func (c *Chat) Get(key interface{}) []*Session {
 
 // terminates goroutines peacefully
 func (c *Chat) Close() {
+	c.notifications.Close()
+	c.cards.Close()
 	c.quit <- struct{}{}
 	close(c.quit)
-	log.Println("closing")
+	slog.Info("closing chat")
 }
 
 func ping(ws *websocket.Conn) {
@@ -385,11 +450,16 @@ func (c *Chat) ServeWS(gc *gin.Context) {
 		var msg Message
 		if err := ws.ReadJSON(&msg); err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway) {
-				log.Printf("error: %v, user-agent: %v", err, gc.Request.Header.Get("User-Agent"))
+				slog.Warn("chat connection closed unexpectedly", "error", err, "user_agent", gc.Request.Header.Get("User-Agent"))
 			}
 			break
 		}
 
+		// notifications and cards only come from the server
+		if msg.Type == MessageTypeNotification || msg.Type == MessageTypeCard {
+			continue
+		}
+
 		msg.Sender = user.Id
 		c.broadcast <- msg
 	}
@@ -0,0 +1,68 @@
+package chat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dialTestSessions opens websocket connections to a test server and returns both ends of each
+func dialTestSessions(t *testing.T, n int) ([]*websocket.Conn, []*websocket.Conn) {
+	accepted := make(chan *websocket.Conn, n)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		accepted <- ws
+	}))
+	t.Cleanup(server.Close)
+
+	clients, servers := []*websocket.Conn{}, []*websocket.Conn{}
+	for i := 0; i < n; i++ {
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { client.Close() })
+
+		clients = append(clients, client)
+		servers = append(servers, <-accepted)
+	}
+
+	return clients, servers
+}
+
+func TestSendToUserReachesEverySession(t *testing.T) {
+	c := &Chat{sessions: NewSessions(), lookupTable: NewTableInMemory()}
+	userId := uuid.New()
+
+	clients, servers := dialTestSessions(t, 3)
+	sessions := []*Session{}
+	for _, ws := range servers {
+		sess := c.newSession(ws)
+		c.lookupTable.Add(userId, sess.SessionID())
+		sessions = append(sessions, sess)
+	}
+
+	// the connection of the second session has gone away
+	sessions[1].Conn().Close()
+
+	msg := Message{ID: uuid.New(), Type: MessageTypeNotification, Text: "your booking is paid"}
+	assert.Error(t, c.SendToUser(userId, msg))
+
+	for _, idx := range []int{0, 2} {
+		received := Message{}
+		clients[idx].SetReadDeadline(time.Now().Add(time.Second))
+		require.NoError(t, clients[idx].ReadJSON(&received))
+		assert.Equal(t, msg.ID, received.ID)
+	}
+
+	// the session gone away is cleared and the others are kept
+	assert.Nil(t, c.sessions.Get(sessions[1].SessionID()))
+	assert.Len(t, c.Get(UserId(userId)), 2)
+	assert.NoError(t, c.SendToUser(userId, msg))
+}
@@ -3,6 +3,7 @@ package chat
 import (
 	"time"
 
+	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/google/uuid"
 )
 
@@ -12,6 +13,12 @@ const (
 	MessageTypeAuth     = "auth"
 	MessageTypeMessage  = "message"
 
+	// notifications are only sent by the server, the room is not used
+	MessageTypeNotification = "notification"
+
+	// the cards of the booking requests and the quotes are only sent by the server
+	MessageTypeCard = "card"
+
 	MessageTypeOffline = "0"
 	MessageTypeOnline  = "1"
 )
@@ -25,4 +32,10 @@ type Message struct {
 
 	// this, however, will be the roomId instead of userId as a room may contain more than 2 people.
 	Receiver uuid.UUID `json:"room"`
+
+	Notification *model.Notification `json:"notification,omitempty"`
+	Card         *model.Conversation `json:"card,omitempty"`
+
+	// the user a notification is delivered to
+	recipient uuid.UUID
 }
@@ -61,3 +61,9 @@ func (ss *Sessions) Delete(id uuid.UUID) {
 	delete(ss.sessions, id)
 	ss.mu.Unlock()
 }
+
+func (ss *Sessions) Len() int {
+	ss.mu.RLock()
+	defer ss.mu.RUnlock()
+	return len(ss.sessions)
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Audit records the requests that change something, the usecases record what they changed and the requests that
+// none of them recorded or that failed are recorded as they are
+func Audit(auditUsecase usecase.AuditUseCase) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		trail := &usecase.AuditTrail{
+			IP:        c.ClientIP(),
+			RequestId: c.GetString(RequestIdKey),
+		}
+		if user, ok := c.Value("user").(model.User); ok {
+			trail.ActorId = &user.Id
+		}
+		c.Set(usecase.AuditTrailKey, trail)
+
+		c.Next()
+
+		if trail.Recorded() && c.Writer.Status() < http.StatusBadRequest {
+			return
+		}
+
+		var targetId *uuid.UUID
+		if id, err := uuid.Parse(c.Param("id")); err == nil {
+			targetId = &id
+		}
+
+		if err := auditUsecase.RecordRequest(c, trail, c.Request.Method, c.FullPath(), targetId, c.Writer.Status()); err != nil {
+			slog.ErrorContext(c, "unable to audit the request", "method", c.Request.Method, "route", c.FullPath(), "error", err)
+		}
+	}
+}
@@ -46,3 +46,24 @@ func UserAuthorizationMiddleware(c *gin.Context) {
 	c.Set("email", claims.Email)
 	c.Next()
 }
+
+// OptionalUserAuthorizationMiddleware lets the guests through, the email of a user who sent a valid session token is
+// set like UserAuthorizationMiddleware does
+func OptionalUserAuthorizationMiddleware(c *gin.Context) {
+	token, ok := util.ExtractToken(c)
+	secretKey, exist := c.Get("secretKey")
+	if !ok || !exist {
+		c.Next()
+		return
+	}
+
+	jwtWrapper := auth.JwtWrapper{
+		SecretKey: secretKey.(string),
+		Issuer:    "AuthProvider",
+	}
+
+	if claims, err := jwtWrapper.ValidateToken(c, token, false); err == nil {
+		c.Set("email", claims.Email)
+	}
+	c.Next()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// the requests that match no route are counted together so that scanners cannot blow up the number of series
+const unmatchedRoute = "unmatched"
+
+// Metrics counts and times the requests by route
+func Metrics(c *gin.Context) {
+	start := time.Now()
+	metrics.HTTPRequestsInFlight.Inc()
+	defer metrics.HTTPRequestsInFlight.Dec()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+
+	metrics.HTTPRequests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+	metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit applies the per-IP and per-account budgets of the rule to the route group named scope
+func RateLimit(scope string, rule config.RateLimitRule) gin.HandlerFunc {
+	window := time.Duration(rule.WindowSeconds) * time.Second
+
+	return func(c *gin.Context) {
+		if rule.PerIP > 0 {
+			key := fmt.Sprintf("%s: ip: %s", scope, c.ClientIP())
+			if ok := applyBudget(c, key, rule.PerIP, window); !ok {
+				return
+			}
+		}
+
+		if rule.PerAccount > 0 {
+			if account := extractAccount(c); account != "" {
+				key := fmt.Sprintf("%s: account: %s", scope, account)
+				if ok := applyBudget(c, key, rule.PerAccount, window); !ok {
+					return
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
+
+func applyBudget(c *gin.Context, key string, limit int, window time.Duration) bool {
+	result, err := ratelimit.Allow(c, key, limit, window)
+	if err != nil {
+		// do not lock everyone out when redis is unavailable
+		slog.WarnContext(c, "unable to apply the rate limit", "key", key, "error", err)
+		return true
+	}
+
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(int(result.ResetIn.Seconds())))
+
+	if !result.Allowed {
+		util.Raise429Error(c, result.ResetIn, "too many requests, please try again later")
+		return false
+	}
+
+	return true
+}
+
+// the account is the authenticated email, or the email given in the request body of the authen endpoints
+func extractAccount(c *gin.Context) string {
+	if email := c.GetString("email"); email != "" {
+		return email
+	}
+
+	if c.Request.Body == nil || !strings.HasPrefix(c.ContentType(), "application/json") {
+		return ""
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return ""
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	cred := struct {
+		Email string `json:"email"`
+	}{}
+	if err := json.Unmarshal(body, &cred); err != nil {
+		return ""
+	}
+
+	return ratelimit.NormaliseAccount(cred.Email)
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// RequestIdHeader carries the ID of the request in and out of the server
+	RequestIdHeader = "X-Request-Id"
+	// RequestIdKey is the key of the ID of the request in the gin context
+	RequestIdKey       = "requestId"
+	maxRequestIdLength = 128
+)
+
+// RequestId keeps the ID sent by the client or the proxy, or gives the request a new one, and sends it back. The ID
+// is carried by the context of the request down to the repositories and tags the span of the request.
+func RequestId(c *gin.Context) {
+	requestId := c.GetHeader(RequestIdHeader)
+	if requestId == "" || len(requestId) > maxRequestIdLength {
+		requestId = uuid.NewString()
+	}
+
+	c.Set(RequestIdKey, requestId)
+	c.Header(RequestIdHeader, requestId)
+
+	ctx := telemetry.WithRequestId(c.Request.Context(), requestId)
+	trace.SpanFromContext(ctx).SetAttributes(attribute.String("http.request_id", requestId))
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+}
@@ -51,8 +51,7 @@ func (r *Resolver) ApproveCancelReq(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingCancelledStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.BookingUsecase.ChangeStatus(c, booking, model.BookingCancelledStatus, booking.CustomerId, model.NotificationBookingCancelledType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "failed",
 			"error":  err.Error(),
@@ -0,0 +1,83 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List my invoices, receipts and credit notes
+// @Description  Every document is numbered in its own sequence per kind, for the tax filings
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param kind query string false "Only the documents of this kind" Enums(INVOICE, RECEIPT, CREDIT_NOTE)
+// @Param booking_id query string false "Only the documents of this booking"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BookingDocument} "The documents, latest first"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/documents/v1 [get]
+func (r *Resolver) ListDocuments(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	filter := model.BookingDocumentFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	documents, err := r.DocumentUsecase.DocumentRepo.FindByPhotographerId(c, photographer.Id, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   documents,
+	})
+}
+
+// @Summary      Download one of my documents
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the document"
+// @Produce      application/pdf
+// @Success      200 {file} file "The pdf of the document"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid document id"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The document does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The document is not ready yet"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/documents/v1/{id} [get]
+func (r *Resolver) DownloadDocument(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	documentId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid document id")
+		return
+	}
+
+	document, err := r.DocumentUsecase.DocumentRepo.FindOneById(c, documentId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && document.PhotographerId != photographer.Id) {
+		raiseNotFound(c, "the document does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	util.ServeDocument(c, r.DocumentUsecase, document)
+}
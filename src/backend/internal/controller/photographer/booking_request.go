@@ -0,0 +1,202 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the booking requests of my galleries
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param status query string false "OPEN, ACCEPTED, DECLINED or WITHDRAWN"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BookingRequest} "The requests with their quotes, latest first"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/booking-requests/v1 [get]
+func (r *Resolver) ListBookingRequests(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	filter := model.BookingRequestFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	requests, err := r.BookingRequestUsecase.FindByPhotographerId(c, photographer.Id, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   requests,
+	})
+}
+
+// @Summary      Get a booking request of one of my galleries
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.BookingRequest} "The request with its quotes"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/booking-requests/v1/{id} [get]
+func (r *Resolver) GetOneBookingRequest(c *gin.Context) {
+	request, _, ok := r.getBookingRequestOfOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	if err := r.BookingRequestUsecase.PopulateQuotes(c, request); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   request,
+	})
+}
+
+// @Summary      Quote a booking request
+// @Description  Answer the request, or counter the counter-offer of the customer, with a priced quote that expires
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Param quote body model.BookingQuoteInput true "The quote, the sessions and the add-ons left out are kept"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.BookingQuote} "The quote"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid sessions, add-ons or price"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The request is closed or your quote is still pending"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/booking-requests/v1/{id}/quotes [post]
+func (r *Resolver) QuoteBookingRequest(c *gin.Context) {
+	request, gallery, ok := r.getBookingRequestOfOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	input := model.BookingQuoteInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	quote, err := r.BookingRequestUsecase.Offer(c, request, gallery, gallery.PhotographerId, input, r.PricingUsecase)
+	if err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   quote,
+	})
+}
+
+// @Summary      Accept the counter-offer of the customer
+// @Description  The pending counter-offer becomes a DRAFT booking to be paid by the customer
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Booking} "The booking"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The pending quote is your own"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "No counter-offer is pending or it has expired"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/booking-requests/v1/{id}/accept [put]
+func (r *Resolver) AcceptBookingQuote(c *gin.Context) {
+	request, gallery, ok := r.getBookingRequestOfOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	booking, err := r.BookingRequestUsecase.Accept(c, request, gallery, gallery.PhotographerId, r.BookingUsecase)
+	if err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   booking,
+	})
+}
+
+// @Summary      Decline a booking request
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.BookingRequest} "The declined request"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The request is already closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/booking-requests/v1/{id}/decline [put]
+func (r *Resolver) DeclineBookingRequest(c *gin.Context) {
+	request, gallery, ok := r.getBookingRequestOfOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	if err := r.BookingRequestUsecase.Close(c, request, gallery, gallery.PhotographerId, model.BookingRequestDeclinedStatus); err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   request,
+	})
+}
+
+// the request about the gallery of another photographer is reported as missing
+func (r *Resolver) getBookingRequestOfOwnGallery(c *gin.Context) (*model.BookingRequest, *model.Gallery, bool) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	requestId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid request id")
+		return nil, nil, false
+	}
+
+	request, err := r.BookingRequestUsecase.BookingRequestRepo.FindOneById(c, requestId)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, "the booking request does not exist")
+		return nil, nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	gallery, err := r.GalleryUsecase.GalleryRepo.FindOneById(c, request.GalleryId)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	if gallery.PhotographerId != photographer.Id {
+		raiseNotFound(c, "the booking request does not exist")
+		return nil, nil, false
+	}
+
+	return request, gallery, true
+}
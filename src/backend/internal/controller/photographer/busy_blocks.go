@@ -0,0 +1,108 @@
+package photographer
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/ical"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+const maxCalendarFileSize = 2 << 20
+
+// @Summary      List my busy blocks
+// @Description  The periods imported from my other calendars that are not over yet, no session can be booked during them
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BusyBlock} "The busy blocks, earliest first"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/busy-blocks/v1 [get]
+func (r *Resolver) ListBusyBlocks(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	blocks, err := r.CalendarUsecase.FindUpcomingBusyBlocks(c, photographer.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   blocks,
+	})
+}
+
+// @Summary      Import my busy blocks
+// @Description  Replaces my busy blocks with the events of an iCalendar file, the free and the cancelled events are left out and the recurring ones are repeated over the coming year
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param file formData file true "The .ics file exported from the other calendar"
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BusyBlock} "The imported busy blocks"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The file is missing, too large or not an iCalendar file"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/busy-blocks/v1 [post]
+func (r *Resolver) ImportBusyBlocks(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		util.Raise400Error(c, "could not retrieve the file")
+		return
+	}
+	defer file.Close()
+
+	if header.Size > maxCalendarFileSize {
+		util.Raise400Error(c, "the calendar file cannot be larger than 2 MB")
+		return
+	}
+
+	blocks, err := r.CalendarUsecase.ImportBusyBlocks(c, photographer.Id, file)
+	switch {
+	case errors.Is(err, ical.ErrMalformedCalendar),
+		errors.Is(err, usecase.ErrTooManyBusyBlocks):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   blocks,
+	})
+}
+
+// @Summary      Clear my busy blocks
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The busy blocks have been removed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/busy-blocks/v1 [delete]
+func (r *Resolver) ClearBusyBlocks(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	if err := r.CalendarUsecase.BusyBlockRepo.DeleteByPhotographerId(c, photographer.Id); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
@@ -28,7 +28,7 @@ func (r *Resolver) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if booking.Status != model.BookingPaidStatus {
+	if booking.Status != model.BookingPaidStatus && booking.Status != model.BookingPartiallyPaidStatus {
 		c.JSON(http.StatusForbidden, gin.H{
 			"status": "failed",
 			"error":  "this booking cannot be cancelled anymore",
@@ -51,8 +51,7 @@ func (r *Resolver) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingPhotographerReqCancelStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.BookingUsecase.ChangeStatus(c, booking, model.BookingPhotographerReqCancelStatus, booking.CustomerId, model.NotificationBookingCancelRequestedType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "failed",
 			"error":  err.Error(),
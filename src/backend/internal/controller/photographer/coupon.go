@@ -0,0 +1,173 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List my coupons
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param is_active query bool false "Only the active or the inactive coupons"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.Coupon} "The coupons, latest first"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/coupons/v1 [get]
+func (r *Resolver) ListCoupons(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	filter := model.CouponFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	photographerId := photographer.Id.String()
+	filter.PhotographerId = &photographerId
+
+	coupons, err := r.CouponUsecase.CouponRepo.FindWithFilter(c, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   coupons,
+	})
+}
+
+// @Summary      Create a coupon for my galleries
+// @Description  The coupon applies to the bookings of my galleries, optionally only the chosen galleries or locations
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param coupon body model.CouponInput true "The code, the kind, the value and the restrictions of the coupon"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.Coupon} "The coupon"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid coupon or a gallery that is not yours"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The code is already taken"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/coupons/v1 [post]
+func (r *Resolver) CreateCoupon(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	input := model.CouponInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	coupon, err := r.CouponUsecase.Create(c, photographer.Id, &photographer.Id, input)
+	if err != nil {
+		util.RaiseCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   coupon,
+	})
+}
+
+// @Summary      Update one of my coupons
+// @Description  Change the terms of a coupon or pause it with is_active, the bookings it has been applied to keep their discount
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the coupon"
+// @Param coupon body model.CouponInput true "The fields to change"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Coupon} "The updated coupon"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid coupon or a gallery that is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The coupon does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The code is already taken"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/coupons/v1/{id} [put]
+func (r *Resolver) UpdateCoupon(c *gin.Context) {
+	coupon, ok := r.getOwnCoupon(c)
+	if !ok {
+		return
+	}
+
+	input := model.CouponInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	if err := r.CouponUsecase.Update(c, coupon, input); err != nil {
+		util.RaiseCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   coupon,
+	})
+}
+
+// @Summary      List the redemptions of one of my coupons
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the coupon"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.CouponRedemption} "The redemptions, latest first"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The coupon does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/coupons/v1/{id}/redemptions [get]
+func (r *Resolver) ListCouponRedemptions(c *gin.Context) {
+	coupon, ok := r.getOwnCoupon(c)
+	if !ok {
+		return
+	}
+
+	redemptions, err := r.CouponUsecase.CouponRedemptionRepo.FindByCouponId(c, coupon.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   redemptions,
+	})
+}
+
+// the coupons of other photographers and the platform-wide ones are reported as missing
+func (r *Resolver) getOwnCoupon(c *gin.Context) (*model.Coupon, bool) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return nil, false
+	}
+
+	couponId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid coupon id")
+		return nil, false
+	}
+
+	coupon, err := r.CouponUsecase.CouponRepo.FindOneById(c, couponId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (coupon.PhotographerId == nil || *coupon.PhotographerId != photographer.Id)) {
+		raiseNotFound(c, "the coupon does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return coupon, true
+}
@@ -1,21 +1,69 @@
 package photographer
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
 func (r *Resolver) CreateBooking(c *gin.Context) {
-	photographer, ok := getPhotographer(c)
+	newBooking, ok := r.proposeBooking(c)
 	if !ok {
 		return
 	}
 
+	if err := r.BookingUsecase.Create(c, newBooking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   newBooking,
+	})
+}
+
+// @Summary      Quote a booking
+// @Description  Price a booking proposal with the add-ons, surcharges and discounts of the gallery without creating it
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param proposal body model.BookingProposal true "The same proposal as for creating the booking"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Quote} "The line items and the total"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid sessions or add-ons"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "A session overlaps one of your busy blocks"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/quote [post]
+func (r *Resolver) QuoteBooking(c *gin.Context) {
+	proposedBooking, ok := r.proposeBooking(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data": model.Quote{
+			LineItems: proposedBooking.LineItems,
+			Total:     proposedBooking.ResultedPrice,
+		},
+	})
+}
+
+// proposeBooking plans the sessions of the proposal in the body and prices them
+func (r *Resolver) proposeBooking(c *gin.Context) (*model.Booking, bool) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return nil, false
+	}
+
 	bookingProposal := model.BookingProposal{}
 	if err := c.BindJSON(&bookingProposal); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -24,15 +72,13 @@ func (r *Resolver) CreateBooking(c *gin.Context) {
 			"message": "unable to bind request body with json model, please recheck",
 		})
 		c.Abort()
-		return
+		return nil, false
 	}
 
 	newBooking := &model.Booking{
 		Id:         uuid.New(),
 		CustomerId: bookingProposal.CustomerId,
 		RoomId:     bookingProposal.RoomId,
-		StartTime:  bookingProposal.StartTime,
-		EndTime:    bookingProposal.EndTime,
 		Status:     model.BookingDraftStatus,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
@@ -40,28 +86,44 @@ func (r *Resolver) CreateBooking(c *gin.Context) {
 
 	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, newBooking); err != nil {
 		util.Raise500Error(c, err)
-		return
+		return nil, false
 	}
 
 	if newBooking.Room.Gallery.PhotographerId != photographer.Id {
 		util.Raise401Error(c, "the gallery specified is not your gallery")
-		return
+		return nil, false
 	}
 
-	resultedPrice := newBooking.Room.Gallery.Price
-	if bookingProposal.NegotiatedPrice != nil {
-		resultedPrice = *bookingProposal.NegotiatedPrice
+	if err := r.BookingUsecase.PlanSessions(newBooking, bookingProposal); err != nil {
+		util.Raise400Error(c, err.Error())
+		return nil, false
 	}
 
-	newBooking.ResultedPrice = resultedPrice
+	err := r.BookingUsecase.CheckAvailability(c, photographer.Id, newBooking)
+	switch {
+	case errors.Is(err, usecase.ErrPhotographerBusy):
+		util.Raise409Error(c, err.Error())
+		return nil, false
+	case err != nil:
+		util.Raise500Error(c, err)
+		return nil, false
+	}
 
-	if err := r.BookingUsecase.BookingRepo.AddOne(c, newBooking); err != nil {
+	quote, err := r.PricingUsecase.Quote(c, &newBooking.Room.Gallery, newBooking.Sessions, bookingProposal)
+	switch {
+	case errors.Is(err, usecase.ErrUnknownAddOn),
+		errors.Is(err, usecase.ErrInvalidAddOnQuantity),
+		errors.Is(err, usecase.ErrInvalidNegotiatedPrice):
+		util.Raise400Error(c, err.Error())
+		return nil, false
+	case err != nil:
 		util.Raise500Error(c, err)
-		return
+		return nil, false
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   newBooking,
-	})
+	newBooking.ResultedPrice = quote.Total
+	newBooking.Currency = quote.Currency
+	newBooking.LineItems = quote.LineItems
+	r.BookingUsecase.PlanInstalments(newBooking, &newBooking.Room.Gallery)
+	return newBooking, true
 }
@@ -41,6 +41,10 @@ func (r *Resolver) CreateGallery(c *gin.Context) {
 		return
 	}
 
+	if ok := r.checkGalleryCurrency(c, &galleryInput); !ok {
+		return
+	}
+
 	newGallery := model.Gallery{
 		Id:             uuid.New(),
 		Location:       *galleryInput.Location,
@@ -51,6 +55,16 @@ func (r *Resolver) CreateGallery(c *gin.Context) {
 		Description:    galleryInput.Description,
 		DeliveryTime:   *galleryInput.DeliveryTime,
 		Included:       galleryInput.Included,
+		Currency:       model.BaseCurrency,
+	}
+	if galleryInput.DepositPercent != nil {
+		newGallery.DepositPercent = *galleryInput.DepositPercent
+	}
+	if galleryInput.BalanceDueDays != nil {
+		newGallery.BalanceDueDays = *galleryInput.BalanceDueDays
+	}
+	if galleryInput.Currency != nil {
+		newGallery.Currency = *galleryInput.Currency
 	}
 
 	if err := r.GalleryUsecase.GalleryRepo.AddOne(c, &newGallery); err != nil {
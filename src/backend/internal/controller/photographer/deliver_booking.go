@@ -0,0 +1,72 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Mark the photos of a booking as delivered
+// @Description  Stops the overdue delivery alert of the booking and lets the customer know the photos are ready
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Booking} "The delivered booking"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid booking id"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The booking is not completed yet"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/{id}/delivered [put]
+func (r *Resolver) MarkBookingDelivered(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	bookingId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid booking id")
+		return
+	}
+
+	booking, err := r.BookingUsecase.BookingRepo.FindOneById(c, bookingId)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, "the booking does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if booking.Room.Gallery.PhotographerId != photographer.Id {
+		raiseNotFound(c, "the booking does not exist")
+		return
+	}
+
+	err = r.BookingUsecase.MarkDelivered(c, booking)
+	switch {
+	case errors.Is(err, usecase.ErrBookingNotDeliverable):
+		util.Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   booking,
+	})
+}
@@ -0,0 +1,191 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Dispute one of my bookings
+// @Description  Open a dispute on a paid or completed booking, the booking is not paid out until the dispute is withdrawn or resolved by an administrator. The customer is notified.
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Param dispute body model.DisputeInput true "The reason of the dispute"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.Dispute} "The dispute opened"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid reason or description"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The booking cannot be disputed or is already disputed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/{id}/dispute [post]
+func (r *Resolver) OpenDispute(c *gin.Context) {
+	photographer, booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	input := model.DisputeInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	dispute, err := r.DisputeUsecase.Open(c, booking, photographer.Id, input, r.BookingUsecase)
+	if err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   dispute,
+	})
+}
+
+// @Summary      Get the dispute of one of my bookings
+// @Description  The last dispute opened on the booking with the evidence of both parties and of the mediator, the links to the photos expire after a few minutes
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Dispute} "The dispute and its evidence"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist or has not been disputed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/{id}/dispute [get]
+func (r *Resolver) GetDispute(c *gin.Context) {
+	_, booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	dispute, ok := util.FindBookingDispute(c, r.DisputeUsecase, booking)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   dispute,
+	})
+}
+
+// @Summary      Add evidence to the dispute of one of my bookings
+// @Description  A text, up to 5 photos of up to 5 MB each and an excerpt of the chat of the booking quoting up to 50 of its messages, at least one of them is needed. The customer and the mediator are notified.
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Param text formData string false "The statement"
+// @Param photos formData file false "The photos"
+// @Param conversation_ids formData []string false "The IDs of the messages of the chat quoted" collectionFormat(multi)
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.DisputeEvidence} "The evidence added"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Empty evidence, invalid photo or invalid excerpt"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist or has not been disputed"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The dispute is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/{id}/dispute/evidence [post]
+func (r *Resolver) AddDisputeEvidence(c *gin.Context) {
+	photographer, booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	dispute, ok := util.FindBookingDispute(c, r.DisputeUsecase, booking)
+	if !ok {
+		return
+	}
+
+	text, photos, conversationIds, ok := util.ReadDisputeEvidence(c)
+	if !ok {
+		return
+	}
+
+	evidence, err := r.DisputeUsecase.AddEvidence(c, dispute, booking, photographer.Id, model.DisputePhotographerParty, text, photos, conversationIds)
+	if err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   evidence,
+	})
+}
+
+// @Summary      Withdraw the dispute of one of my bookings
+// @Description  Only the party who opened the dispute can withdraw it, the booking goes back to the status it had before
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Booking} "The booking"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The dispute was opened by the customer"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist or has not been disputed"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The dispute is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/bookings/v1/{id}/dispute/withdraw [put]
+func (r *Resolver) WithdrawDispute(c *gin.Context) {
+	photographer, booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	dispute, ok := util.FindBookingDispute(c, r.DisputeUsecase, booking)
+	if !ok {
+		return
+	}
+
+	if err := r.DisputeUsecase.Withdraw(c, dispute, booking, photographer.Id, r.BookingUsecase); err != nil {
+		util.RaiseDisputeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   booking,
+	})
+}
+
+// the bookings of the galleries of other photographers are reported as missing, the room of the booking is populated
+func (r *Resolver) getOwnBooking(c *gin.Context) (*model.User, *model.Booking, bool) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	bookingId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid booking id")
+		return nil, nil, false
+	}
+
+	booking, err := r.BookingUsecase.BookingRepo.FindOneById(c, bookingId)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, "the booking does not exist")
+		return nil, nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	if booking.Room.Gallery.PhotographerId != photographer.Id {
+		raiseNotFound(c, "the booking does not exist")
+		return nil, nil, false
+	}
+
+	return photographer, booking, true
+}
@@ -1,8 +1,11 @@
 package photographer
 
 import (
+	"errors"
+
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,3 +19,25 @@ func getPhotographer(c *gin.Context) (*model.User, bool) {
 
 	return &phtgObj, true
 }
+
+// checkGalleryCurrency normalises the currency of the gallery input, which must have an exchange rate
+func (r *Resolver) checkGalleryCurrency(c *gin.Context, input *model.GalleryInput) bool {
+	if input.Currency == nil {
+		return true
+	}
+
+	currency := usecase.NormaliseCurrency(*input.Currency)
+	input.Currency = &currency
+
+	err := r.CurrencyUsecase.CheckSupported(c, currency)
+	if errors.Is(err, usecase.ErrUnsupportedCurrency) {
+		util.Raise400Error(c, err.Error())
+		return false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return false
+	}
+
+	return true
+}
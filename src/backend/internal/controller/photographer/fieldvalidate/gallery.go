@@ -35,17 +35,34 @@ func CreateGallery(input model.GalleryInput) []error {
 		))
 	}
 
-	return fieldErrs
+	return append(fieldErrs, paymentSchedule(input)...)
 }
 
 func UpdateGallery(input model.GalleryInput) []error {
 	fieldErrs := []error{}
 
-	if input.Name == nil && input.Price == nil && input.Location == nil {
+	if input.Name == nil && input.Price == nil && input.Location == nil && input.DepositPercent == nil && input.BalanceDueDays == nil && input.Currency == nil {
 		fieldErrs = append(fieldErrs, errors.New(
 			"one of the gallery fields must be changed",
 		))
 	}
 
+	return append(fieldErrs, paymentSchedule(input)...)
+}
+
+func paymentSchedule(input model.GalleryInput) []error {
+	fieldErrs := []error{}
+
+	if input.DepositPercent != nil && (*input.DepositPercent < 0 || *input.DepositPercent > 100) {
+		fieldErrs = append(fieldErrs, errors.New(
+			"the deposit must be between 0 and 100 percent of the price",
+		))
+	}
+	if input.BalanceDueDays != nil && *input.BalanceDueDays < 0 {
+		fieldErrs = append(fieldErrs, errors.New(
+			"the balance cannot be due after the first session",
+		))
+	}
+
 	return fieldErrs
 }
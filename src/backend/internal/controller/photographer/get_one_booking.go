@@ -28,6 +28,11 @@ func (r *Resolver) GetOneBooking(c *gin.Context) {
 		return
 	}
 
+	if err := r.BookingUsecase.PopulateDetails(c, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
 	if booking.Room.Gallery.PhotographerId != photographer.Id {
 		util.Raise403Error(c, "this booking is not yours")
 		return
@@ -1,8 +1,11 @@
 package photographer
 
 import (
+	"log/slog"
 	"net/http"
 
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -20,6 +23,16 @@ func (r *Resolver) GetOneGallery(c *gin.Context) {
 		return
 	}
 
+	if gallery.Hidden {
+		raiseNotFound(c, "the gallery does not exist")
+		return
+	}
+
+	// a view not counted only leaves the insights of the photographer short
+	if err := r.InsightsUsecase.TrackView(c, galleryId, util.ViewerId(c), model.GalleryViewDetailsKind); err != nil {
+		slog.WarnContext(c, "unable to count a view of the gallery", "gallery", galleryId, "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   gallery,
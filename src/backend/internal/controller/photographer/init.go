@@ -6,21 +6,37 @@ import (
 )
 
 type Resolver struct {
-	GalleryUsecase usecase.GalleryUseCase
-	PhotoUsecase   usecase.PhotoUseCase
-	BookingUsecase usecase.BookingUseCase
-	ReviewUsecase  usecase.ReviewUseCase
-	UserUsecase    usecase.UserUseCase
-	RoomUsecase    usecase.RoomUseCase
+	GalleryUsecase        usecase.GalleryUseCase
+	PhotoUsecase          usecase.PhotoUseCase
+	BookingUsecase        usecase.BookingUseCase
+	ReviewUsecase         usecase.ReviewUseCase
+	UserUsecase           usecase.UserUseCase
+	RoomUsecase           usecase.RoomUseCase
+	PricingUsecase        usecase.PricingUseCase
+	BookingRequestUsecase usecase.BookingRequestUseCase
+	CouponUsecase         usecase.CouponUseCase
+	CurrencyUsecase       usecase.CurrencyUseCase
+	DocumentUsecase       usecase.DocumentUseCase
+	CalendarUsecase       usecase.CalendarUseCase
+	InsightsUsecase       usecase.InsightsUseCase
+	DisputeUsecase        usecase.DisputeUseCase
 }
 
 func NewResolver(db *bun.DB) *Resolver {
 	return &Resolver{
-		GalleryUsecase: *usecase.NewGalleryUseCase(db),
-		PhotoUsecase:   *usecase.NewPhotoUseCase(db),
-		BookingUsecase: *usecase.NewBookingUseCase(db),
-		ReviewUsecase:  *usecase.NewReviewUseCase(db),
-		UserUsecase:    *usecase.NewUserUseCase(db),
-		RoomUsecase:    *usecase.NewRoomUseCase(db),
+		GalleryUsecase:        *usecase.NewGalleryUseCase(db),
+		PhotoUsecase:          *usecase.NewPhotoUseCase(db),
+		BookingUsecase:        *usecase.NewBookingUseCase(db),
+		ReviewUsecase:         *usecase.NewReviewUseCase(db),
+		UserUsecase:           *usecase.NewUserUseCase(db),
+		RoomUsecase:           *usecase.NewRoomUseCase(db),
+		PricingUsecase:        *usecase.NewPricingUseCase(db),
+		BookingRequestUsecase: *usecase.NewBookingRequestUseCase(db),
+		CouponUsecase:         *usecase.NewCouponUseCase(db),
+		CurrencyUsecase:       *usecase.NewCurrencyUseCase(db),
+		DocumentUsecase:       *usecase.NewDocumentUseCase(db),
+		CalendarUsecase:       *usecase.NewCalendarUseCase(db),
+		InsightsUsecase:       *usecase.NewInsightsUseCase(db),
+		DisputeUsecase:        *usecase.NewDisputeUseCase(db),
 	}
 }
@@ -0,0 +1,43 @@
+package photographer
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Get my business insights
+// @Description  The monthly earnings and rating of my galleries, their views, chats and bookings with the conversions, how long my customers wait for an answer in the chats and my sessions of the coming weeks. The amounts are in THB at the current exchange rates and the insights are refreshed every few minutes.
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param months query int false "The number of months covered including the current one, 12 by default"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.PhotographerInsights} "The insights"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid number of months"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/insights/v1 [get]
+func (r *Resolver) GetInsights(c *gin.Context) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return
+	}
+
+	filter := model.InsightsFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	insights, err := r.InsightsUsecase.Insights(c, photographer.Id, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   insights,
+	})
+}
@@ -14,7 +14,7 @@ func (r *Resolver) ListUpcomingBookings(c *gin.Context) {
 		return
 	}
 
-	bookings, err := r.BookingUsecase.FindByPhotographerIdWithStatus(c, photographer.Id, r.GalleryUsecase, r.RoomUsecase, model.BookingPaidStatus)
+	bookings, err := r.BookingUsecase.FindByPhotographerIdWithStatus(c, photographer.Id, r.GalleryUsecase, r.RoomUsecase, model.BookingPaidStatus, model.BookingPartiallyPaidStatus)
 	if err != nil {
 		util.Raise500Error(c, err)
 		return
@@ -0,0 +1,275 @@
+package photographer
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Add a priced add-on to a gallery
+// @Description  An add-on is charged once per booking, once per session or per hour, e.g. a printed album or a second shooter
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the gallery"
+// @Param addOn body model.GalleryAddOnInput true "The name, price and unit of the add-on"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.GalleryAddOn} "The add-on"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid add-on"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The gallery does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/galleries/v1/{id}/add-ons [post]
+func (r *Resolver) CreateGalleryAddOn(c *gin.Context) {
+	gallery, ok := r.getOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	input := model.GalleryAddOnInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	addOn, err := r.PricingUsecase.CreateAddOn(c, gallery.Id, input)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAddOn):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   addOn,
+	})
+}
+
+// @Summary      Update an add-on of a gallery
+// @Description  The bookings already made keep the price they were quoted
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the gallery"
+// @Param addOnId path string true "The ID of the add-on"
+// @Param addOn body model.GalleryAddOnInput true "The fields to change"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.GalleryAddOn} "The updated add-on"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid add-on"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The add-on does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/galleries/v1/{id}/add-ons/{addOnId} [put]
+func (r *Resolver) UpdateGalleryAddOn(c *gin.Context) {
+	addOn, ok := r.getOwnAddOn(c)
+	if !ok {
+		return
+	}
+
+	input := model.GalleryAddOnInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	err := r.PricingUsecase.UpdateAddOn(c, addOn, input)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidAddOn):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   addOn,
+	})
+}
+
+// @Summary      Remove an add-on from a gallery
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the gallery"
+// @Param addOnId path string true "The ID of the add-on"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The add-on has been removed"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The add-on does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/galleries/v1/{id}/add-ons/{addOnId} [delete]
+func (r *Resolver) DeleteGalleryAddOn(c *gin.Context) {
+	addOn, ok := r.getOwnAddOn(c)
+	if !ok {
+		return
+	}
+
+	if _, err := r.PricingUsecase.GalleryAddOnRepo.DeleteOneById(c, addOn.Id); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+// @Summary      Add a pricing rule to a gallery
+// @Description  A weekend or holiday surcharge, or a seasonal discount, in percent of the price of a session
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the gallery"
+// @Param rule body model.PricingRuleInput true "The kind, the percentage and the dates of the rule"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.PricingRule} "The rule"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid rule"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The gallery does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/galleries/v1/{id}/pricing-rules [post]
+func (r *Resolver) CreatePricingRule(c *gin.Context) {
+	gallery, ok := r.getOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	input := model.PricingRuleInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	rule, err := r.PricingUsecase.CreateRule(c, gallery.Id, input)
+	switch {
+	case errors.Is(err, usecase.ErrInvalidPricingRule):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   rule,
+	})
+}
+
+// @Summary      Remove a pricing rule from a gallery
+// @Tags         photographer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the gallery"
+// @Param ruleId path string true "The ID of the rule"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The rule has been removed"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The gallery is not yours"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The rule does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /photographers/galleries/v1/{id}/pricing-rules/{ruleId} [delete]
+func (r *Resolver) DeletePricingRule(c *gin.Context) {
+	gallery, ok := r.getOwnGallery(c)
+	if !ok {
+		return
+	}
+
+	ruleId, err := uuid.Parse(c.Param("ruleId"))
+	if err != nil {
+		util.Raise400Error(c, "invalid rule id")
+		return
+	}
+
+	rule, err := r.PricingUsecase.PricingRuleRepo.FindOneById(c, ruleId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && rule.GalleryId != gallery.Id) {
+		raiseNotFound(c, "the rule does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if _, err := r.PricingUsecase.PricingRuleRepo.DeleteOneById(c, rule.Id); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
+
+func (r *Resolver) getOwnGallery(c *gin.Context) (*model.Gallery, bool) {
+	photographer, ok := getPhotographer(c)
+	if !ok {
+		return nil, false
+	}
+
+	galleryId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid gallery id")
+		return nil, false
+	}
+
+	gallery, err := r.GalleryUsecase.GalleryRepo.FindOneById(c, galleryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, "the gallery does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	if gallery.PhotographerId != photographer.Id {
+		util.Raise403Error(c, "You have no permission to edit this gallery")
+		return nil, false
+	}
+
+	return gallery, true
+}
+
+func (r *Resolver) getOwnAddOn(c *gin.Context) (*model.GalleryAddOn, bool) {
+	gallery, ok := r.getOwnGallery(c)
+	if !ok {
+		return nil, false
+	}
+
+	addOnId, err := uuid.Parse(c.Param("addOnId"))
+	if err != nil {
+		util.Raise400Error(c, "invalid add-on id")
+		return nil, false
+	}
+
+	addOn, err := r.PricingUsecase.GalleryAddOnRepo.FindOneById(c, addOnId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && addOn.GalleryId != gallery.Id) {
+		raiseNotFound(c, "the add-on does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return addOn, true
+}
+
+func raiseNotFound(c *gin.Context, message string) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"status": "failed",
+		"error":  message,
+	})
+	c.Abort()
+}
@@ -31,6 +31,10 @@ func (r *Resolver) UpdateGallery(c *gin.Context) {
 		return
 	}
 
+	if ok := r.checkGalleryCurrency(c, &updatingGalleryInput); !ok {
+		return
+	}
+
 	paramId := c.Param("id")
 	galleryId := uuid.MustParse(paramId)
 
@@ -82,4 +86,13 @@ func updateGallery(gallery *model.Gallery, input model.GalleryInput) {
 	if input.Included != nil {
 		gallery.Included = input.Included
 	}
+	if input.DepositPercent != nil {
+		gallery.DepositPercent = *input.DepositPercent
+	}
+	if input.BalanceDueDays != nil {
+		gallery.BalanceDueDays = *input.BalanceDueDays
+	}
+	if input.Currency != nil {
+		gallery.Currency = *input.Currency
+	}
 }
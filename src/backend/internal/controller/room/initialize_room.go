@@ -38,28 +38,7 @@ func (r *Resolver) InitializeRoom(c *gin.Context) {
 		DeletedAt: nil,
 	}
 
-	if err := r.RoomUsecase.RoomRepo.AddOne(c, newRoom); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"status": "failed",
-			"error":  err.Error(),
-		})
-		c.Abort()
-		return
-	}
-
-	lookups := []*model.UserRoomLookup{}
-	for _, memberId := range input.MemberIds {
-		lookups = append(lookups, &model.UserRoomLookup{
-			Id:        uuid.New(),
-			UserId:    memberId,
-			RoomId:    roomId,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			DeletedAt: nil,
-		})
-	}
-
-	if err := r.LookupUsecase.LookupRepo.AddBatch(c, lookups); err != nil {
+	if err := r.RoomUsecase.Initialize(c, newRoom, input.MemberIds); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "failed",
 			"error":  err.Error(),
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Apply a coupon code to one of my bookings
+// @Description  The discount is added to the price breakdown and taken off the instalments left to pay, a booking takes a single coupon before its first payment
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Param coupon body model.CouponRedemptionInput true "The coupon code"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Booking} "The booking at its discounted price, the redemption is in the redemption field"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The coupon is not valid at this time, does not apply to the gallery or the price is below its minimum spend"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking or the coupon code does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The booking has been paid or already has a coupon, or the coupon has been used up"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/bookings/v1/{id}/coupon [post]
+func (r *Resolver) ApplyCoupon(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	bookingId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid booking id")
+		return
+	}
+
+	input := model.CouponRedemptionInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	booking, err := r.BookingUsecase.BookingRepo.FindOneById(c, bookingId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && booking.CustomerId != user.Id) {
+		raiseNotFound(c, "the booking does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	redemption, err := r.CouponUsecase.Redeem(c, booking, input.Code, r.BookingUsecase)
+	if err != nil {
+		util.RaiseCouponError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":     "success",
+		"data":       booking,
+		"redemption": redemption,
+	})
+}
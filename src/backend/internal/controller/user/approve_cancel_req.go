@@ -48,8 +48,12 @@ func (r *Resolver) ApproveCancelReq(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingCancelledStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.BookingUsecase.ChangeStatus(c, booking, model.BookingCancelledStatus, booking.Room.Gallery.PhotographerId, model.NotificationBookingCancelledType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "failed",
 			"error":  err.Error(),
@@ -58,11 +62,6 @@ func (r *Resolver) ApproveCancelReq(c *gin.Context) {
 		return
 	}
 
-	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   booking,
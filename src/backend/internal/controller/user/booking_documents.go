@@ -0,0 +1,104 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the documents of one of my bookings
+// @Description  The receipts of the payments, the invoice of a booking paid in full and the credit note of an approved refund
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BookingDocument} "The documents, oldest first"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid booking id"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/bookings/v1/{id}/documents [get]
+func (r *Resolver) ListBookingDocuments(c *gin.Context) {
+	booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	documents, err := r.DocumentUsecase.DocumentRepo.FindByBookingId(c, booking.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   documents,
+	})
+}
+
+// @Summary      Download a document of one of my bookings
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the booking"
+// @Param documentId path string true "The ID of the document"
+// @Produce      application/pdf
+// @Success      200 {file} file "The pdf of the document"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid booking or document id"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The booking or the document does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The document is not ready yet"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/bookings/v1/{id}/documents/{documentId} [get]
+func (r *Resolver) DownloadBookingDocument(c *gin.Context) {
+	booking, ok := r.getOwnBooking(c)
+	if !ok {
+		return
+	}
+
+	documentId, err := uuid.Parse(c.Param("documentId"))
+	if err != nil {
+		util.Raise400Error(c, "invalid document id")
+		return
+	}
+
+	document, err := r.DocumentUsecase.DocumentRepo.FindOneById(c, documentId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && document.BookingId != booking.Id) {
+		raiseNotFound(c, "the document does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	util.ServeDocument(c, r.DocumentUsecase, document)
+}
+
+// the bookings of other customers are reported as missing
+func (r *Resolver) getOwnBooking(c *gin.Context) (*model.Booking, bool) {
+	user, ok := GetUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	bookingId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid booking id")
+		return nil, false
+	}
+
+	booking, err := r.BookingUsecase.BookingRepo.FindOneById(c, bookingId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && booking.CustomerId != user.Id) {
+		raiseNotFound(c, "the booking does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return booking, true
+}
@@ -0,0 +1,257 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Request a booking of a gallery
+// @Description  The request is posted in the chat with the photographer, who answers it with a quote
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param request body model.BookingRequestInput true "The gallery, the desired sessions, the add-ons and the notes"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.BookingRequest} "The request"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid sessions or add-ons"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The gallery does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1 [post]
+func (r *Resolver) CreateBookingRequest(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	input := model.BookingRequestInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	gallery, err := r.GalleryUsecase.GalleryRepo.FindOneById(c, input.GalleryId)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, "the gallery does not exist")
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if gallery.PhotographerId == user.Id {
+		util.Raise400Error(c, "you cannot request a booking of your own gallery")
+		return
+	}
+
+	request, err := r.BookingRequestUsecase.Open(c, user.Id, gallery, input, r.PricingUsecase)
+	if err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   request,
+	})
+}
+
+// @Summary      List my booking requests
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param status query string false "OPEN, ACCEPTED, DECLINED or WITHDRAWN"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.BookingRequest} "The requests with their quotes, latest first"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1 [get]
+func (r *Resolver) ListBookingRequests(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	filter := model.BookingRequestFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	requests, err := r.BookingRequestUsecase.FindByCustomerId(c, user.Id, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   requests,
+	})
+}
+
+// @Summary      Get one of my booking requests
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.BookingRequest} "The request with its quotes"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1/{id} [get]
+func (r *Resolver) GetOneBookingRequest(c *gin.Context) {
+	request, _, ok := r.getOwnBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := r.BookingRequestUsecase.PopulateQuotes(c, request); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   request,
+	})
+}
+
+// @Summary      Counter the quote of the photographer
+// @Description  The pending quote of the photographer is countered, the sessions and the add-ons left out are kept
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Param quote body model.BookingQuoteInput true "The counter-offer"
+// @Accept       json
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.BookingQuote} "The counter-offer"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid sessions, add-ons or price"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The photographer has not quoted the request yet"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The request is closed or your counter-offer is still pending"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1/{id}/quotes [post]
+func (r *Resolver) CounterBookingQuote(c *gin.Context) {
+	request, gallery, ok := r.getOwnBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	input := model.BookingQuoteInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	quote, err := r.BookingRequestUsecase.Offer(c, request, gallery, request.CustomerId, input, r.PricingUsecase)
+	if err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   quote,
+	})
+}
+
+// @Summary      Accept the quote of the photographer
+// @Description  The pending quote becomes a DRAFT booking to be paid
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Booking} "The booking"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The pending quote is your own"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "No quote is pending or it has expired"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1/{id}/accept [put]
+func (r *Resolver) AcceptBookingQuote(c *gin.Context) {
+	request, gallery, ok := r.getOwnBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	booking, err := r.BookingRequestUsecase.Accept(c, request, gallery, request.CustomerId, r.BookingUsecase)
+	if err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   booking,
+	})
+}
+
+// @Summary      Withdraw a booking request
+// @Tags         customer
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the request"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.BookingRequest} "The withdrawn request"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The request does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The request is already closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/booking-requests/v1/{id}/withdraw [put]
+func (r *Resolver) WithdrawBookingRequest(c *gin.Context) {
+	request, gallery, ok := r.getOwnBookingRequest(c)
+	if !ok {
+		return
+	}
+
+	if err := r.BookingRequestUsecase.Close(c, request, gallery, request.CustomerId, model.BookingRequestWithdrawnStatus); err != nil {
+		util.RaiseBookingRequestError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   request,
+	})
+}
+
+// the request of another customer is reported as missing
+func (r *Resolver) getOwnBookingRequest(c *gin.Context) (*model.BookingRequest, *model.Gallery, bool) {
+	user, ok := GetUser(c)
+	if !ok {
+		return nil, nil, false
+	}
+
+	requestId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid request id")
+		return nil, nil, false
+	}
+
+	request, err := r.BookingRequestUsecase.BookingRequestRepo.FindOneById(c, requestId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && request.CustomerId != user.Id) {
+		raiseNotFound(c, "the booking request does not exist")
+		return nil, nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	gallery, err := r.GalleryUsecase.GalleryRepo.FindOneById(c, request.GalleryId)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, nil, false
+	}
+
+	return request, gallery, true
+}
+
+func raiseNotFound(c *gin.Context, message string) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"status": "failed",
+		"error":  message,
+	})
+	c.Abort()
+}
@@ -0,0 +1,133 @@
+package user
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Get my calendar feed
+// @Description  The feed itself is not shown again, a lost feed url has to be replaced with a new one
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.CalendarFeed} "The feed"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The user has no calendar feed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/calendar-feed [get]
+func (r *Resolver) GetCalendarFeed(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	feed, err := r.CalendarUsecase.CalendarFeedRepo.FindOneByUserId(c, user.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		raiseNotFound(c, usecase.ErrCalendarFeedNotFound.Error())
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   feed,
+	})
+}
+
+// @Summary      Create my calendar feed
+// @Description  Returns the secret url of an iCalendar feed of the sessions of my bookings, any previous url of the user stops working
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=nil} "The feed along with its url, which is only shown once"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/calendar-feed [post]
+func (r *Resolver) CreateCalendarFeed(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	feed, token, err := r.CalendarUsecase.CreateFeed(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data": gin.H{
+			"feed": feed,
+			"url":  fmt.Sprintf("%s://%s/calendar/v1/%s.ics", scheme, c.Request.Host, token),
+		},
+	})
+}
+
+// @Summary      Revoke my calendar feed
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The feed url no longer works"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/calendar-feed [delete]
+func (r *Resolver) DeleteCalendarFeed(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	if err := r.CalendarUsecase.CalendarFeedRepo.DeleteByUserId(c, user.Id); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+	})
+}
+
+// @Summary      Subscribe to a calendar feed
+// @Description  The sessions of the recent and upcoming bookings of the owner of the feed, the cancelled ones are kept as cancelled events
+// @Tags         users
+// @Param feed path string true "The secret token of the feed, optionally followed by .ics"
+// @Produce      text/calendar
+// @Success      200 {file} file "The iCalendar file"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The feed does not exist"
+// @Failure 429 {object} model.JSONErrorResult{status=string,error=nil} "Too many requests"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /calendar/v1/{feed} [get]
+func (r *Resolver) ServeCalendarFeed(c *gin.Context) {
+	feed, err := r.CalendarUsecase.FindFeed(c, strings.TrimSuffix(c.Param("feed"), ".ics"))
+	if errors.Is(err, usecase.ErrCalendarFeedNotFound) {
+		raiseNotFound(c, err.Error())
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	calendar := &bytes.Buffer{}
+	if err := r.CalendarUsecase.WriteFeed(c, calendar, feed.UserId, r.BookingUsecase, r.RoomUsecase, r.GalleryUsecase); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", calendar.Bytes())
+}
@@ -28,7 +28,7 @@ func (r *Resolver) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if booking.Status != model.BookingPaidStatus {
+	if booking.Status != model.BookingPaidStatus && booking.Status != model.BookingPartiallyPaidStatus {
 		c.JSON(http.StatusForbidden, gin.H{
 			"status": "failed",
 			"error":  "this booking cannot be cancelled anymore",
@@ -46,8 +46,12 @@ func (r *Resolver) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingCustomerReqCancelStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if err := r.BookingUsecase.ChangeStatus(c, booking, model.BookingCustomerReqCancelStatus, booking.Room.Gallery.PhotographerId, model.NotificationBookingCancelRequestedType); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"status": "failed",
 			"error":  err.Error(),
@@ -56,11 +60,6 @@ func (r *Resolver) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   booking,
@@ -1,11 +1,13 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/user/fieldvalidate"
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -57,17 +59,22 @@ func (r *Resolver) CreateReview(c *gin.Context) {
 		return
 	}
 
-	if err := r.ReviewUsecase.ReviewRepo.AddOne(c, newReview); err != nil {
+	err := r.BookingUsecase.CheckReviewable(c, &newReview.Booking)
+	switch {
+	case errors.Is(err, usecase.ErrBookingNotReviewable):
+		util.Raise403Error(c, err.Error())
+		return
+	case err != nil:
 		util.Raise500Error(c, err)
 		return
 	}
 
-	if err := r.UserUsecase.PopulateCustomerInReviews(c, newReview); err != nil {
+	if err := r.ReviewUsecase.Create(c, newReview); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	if err := UpdateGalleryRating(c, r.GalleryUsecase, r.ReviewUsecase, &newReview.Booking.Room.Gallery); err != nil {
+	if err := r.UserUsecase.PopulateCustomerInReviews(c, newReview); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      Request a copy of the personal data
+// @Description  Starts building an archive of the profile, bookings, reviews, conversations and uploaded files of the user
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      202 {object} model.JSONSuccessResult{status=string,data=model.DataExport} "The export has been queued"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/data-exports [post]
+func (r *Resolver) RequestDataExport(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	export, err := r.PersonalDataUsecase.RequestExport(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"status": "success",
+		"data":   export,
+	})
+}
+
+// @Summary      List the data exports
+// @Description  List the data exports requested by the user with their status
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.DataExport} "The data exports"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/data-exports [get]
+func (r *Resolver) ListDataExports(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	exports, err := r.PersonalDataUsecase.FindExportsByUserId(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   exports,
+	})
+}
+
+// @Summary      Download a data export
+// @Description  Download the zip archive of a completed data export
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The id of the data export"
+// @Produce      application/zip
+// @Success      200 {file} file "The archive"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Incorrect id"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "The export belongs to someone else"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The export is not ready yet"
+// @Failure 410 {object} model.JSONErrorResult{status=string,error=nil} "The export has expired"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/data-exports/{id}/download [get]
+func (r *Resolver) DownloadDataExport(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	exportId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid data export id")
+		return
+	}
+
+	export, err := r.PersonalDataUsecase.DataExportRepo.FindOneById(c, exportId)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if export.UserId != user.Id {
+		util.Raise403Error(c, "you have no permission to download this export")
+		return
+	}
+
+	archive, size, err := r.PersonalDataUsecase.OpenExport(c, export)
+	switch {
+	case errors.Is(err, usecase.ErrExportNotReady):
+		util.Raise409Error(c, err.Error())
+		return
+	case errors.Is(err, usecase.ErrExportExpired):
+		c.JSON(http.StatusGone, gin.H{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		c.Abort()
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+	defer archive.Close()
+
+	c.DataFromReader(http.StatusOK, size, "application/zip", archive, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="pic-keeper-%s.zip"`, export.Id),
+	})
+}
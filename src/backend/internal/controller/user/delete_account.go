@@ -0,0 +1,58 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      Delete the account
+// @Description  Erases the personal data of the account, bookings are kept anonymised for the financial records. The email of the account has to be given as the confirmation
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param confirmation body model.AccountErasureInput true "The email of the account"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The account has been deleted"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The confirmation does not match"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The account still has ongoing bookings"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/ [delete]
+func (r *Resolver) DeleteAccount(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	input := model.AccountErasureInput{}
+	if err := c.ShouldBindJSON(&input); err != nil {
+		util.Raise400Error(c, "could not bind json")
+		return
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(input.Confirmation), user.Email) {
+		util.Raise400Error(c, "please confirm the deletion with the email of your account")
+		return
+	}
+
+	err := r.PersonalDataUsecase.Erase(c, user)
+	switch {
+	case errors.Is(err, usecase.ErrActiveBookings):
+		util.Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.SetCookie("session_token", "", -1, "/", "localhost", false, true)
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
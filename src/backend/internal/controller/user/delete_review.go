@@ -33,25 +33,19 @@ func (r *Resolver) DeleteReview(c *gin.Context) {
 		return
 	}
 
-	// deleting
-	deletedId, err := r.ReviewUsecase.ReviewRepo.DeleteOneById(c, existingReview.Id)
-	if err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
 	if err := r.BookingUsecase.PopulateBookingInReviews(c, r.GalleryUsecase, r.RoomUsecase, existingReview); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	if err := UpdateGalleryRating(c, r.GalleryUsecase, r.ReviewUsecase, &existingReview.Booking.Room.Gallery); err != nil {
+	// deleting
+	if err := r.ReviewUsecase.Delete(c, existingReview); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
-		"data":   deletedId,
+		"data":   existingReview.Id,
 	})
 }
@@ -80,7 +80,7 @@ func Register(newUser model.UserInput) []error {
 func UpdateUser(input model.UserUpdateInput) []error {
 	fieldErrs := []error{}
 
-	if input.About == nil && input.Email == nil && input.Firstname == nil && input.Gender == nil && input.Lastname == nil && input.Address == nil && input.Password == nil && input.PhoneNumber == nil && input.Username == nil {
+	if input.About == nil && input.Email == nil && input.Firstname == nil && input.Gender == nil && input.Lastname == nil && input.Address == nil && input.Password == nil && input.PhoneNumber == nil && input.Username == nil && input.TaxId == nil {
 		fieldErrs = append(fieldErrs, errors.New(
 			"one of the update fields must be changed",
 		))
@@ -92,6 +92,13 @@ func UpdateUser(input model.UserUpdateInput) []error {
 		}
 	}
 
+	// the tax IDs differ between countries, only their characters are checked
+	if input.TaxId != nil {
+		if !regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9 -]{0,31}$`).MatchString(*input.TaxId) {
+			fieldErrs = append(fieldErrs, errors.New("tax id must be up to 32 letters, digits, spaces or dashes"))
+		}
+	}
+
 	// Validate Email (simple regex example, consider a more comprehensive solution for production)
 	if input.Email != nil {
 		if !regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`).MatchString(*input.Email) {
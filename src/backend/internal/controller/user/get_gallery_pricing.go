@@ -0,0 +1,36 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List the add-ons and the pricing rules of a gallery
+// @Tags         customer
+// @Param id path string true "The ID of the gallery"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.GalleryPricing} "The add-ons and the rules"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid gallery id"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /customers/galleries/v1/{id}/pricing [get]
+func (r *Resolver) GetGalleryPricing(c *gin.Context) {
+	galleryId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid gallery id")
+		return
+	}
+
+	pricing, err := r.PricingUsecase.FindByGalleryId(c, galleryId)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   pricing,
+	})
+}
@@ -41,6 +41,22 @@ func (r *Resolver) GetUserInstance(c *gin.Context) {
 	c.Next()
 }
 
+// GetOptionalUserInstance sets the user of the session for the routes open to the guests, nothing is set for a guest
+// or a user who has logged out
+func (r *Resolver) GetOptionalUserInstance(c *gin.Context) {
+	email, exist := c.Get("email")
+	if !exist {
+		c.Next()
+		return
+	}
+
+	user, err := r.UserUsecase.UserRepo.FindOneByEmail(c, email.(string))
+	if err == nil && !user.LoggedOut {
+		c.Set("user", getUserInstance(user))
+	}
+	c.Next()
+}
+
 func getUserInstance(user *model.User) model.User {
 	return *user
 }
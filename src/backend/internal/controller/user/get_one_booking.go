@@ -45,6 +45,11 @@ func (r *Resolver) GetOneBooking(c *gin.Context) {
 		return
 	}
 
+	if err := r.BookingUsecase.PopulateDetails(c, booking); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   booking,
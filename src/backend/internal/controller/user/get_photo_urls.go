@@ -1,8 +1,11 @@
 package user
 
 import (
+	"log/slog"
 	"net/http"
 
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -21,6 +24,11 @@ func (r *Resolver) GetPhotoUrlsInGallery(c *gin.Context) {
 		return
 	}
 
+	// the photos are served even if the view could not be queued
+	if err := r.InsightsUsecase.TrackView(c, galleryId, util.ViewerId(c), model.GalleryViewPhotosKind); err != nil {
+		slog.WarnContext(c, "unable to count a view of the gallery", "gallery", galleryId, "error", err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   urls,
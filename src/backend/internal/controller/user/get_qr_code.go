@@ -2,12 +2,14 @@ package user
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/skip2/go-qrcode"
@@ -35,12 +37,32 @@ func (r *Resolver) GetQRCode(c *gin.Context) {
 		return
 	}
 
-	if booking.Status != model.BookingDraftStatus {
-		util.Raise403Error(c, "the booking is not in the draft status")
+	// the QR code pays the deposit of a draft booking, then its balance
+	instalment, err := r.BookingUsecase.OutstandingInstalment(c, booking)
+	if errors.Is(err, usecase.ErrBookingNotPayable) {
+		util.Raise403Error(c, err.Error())
+		return
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	// a tourist may pay in their own currency, until the first payment locks the rate
+	amount, err := r.BookingUsecase.PaymentAmount(c, booking, instalment, usecase.NormaliseCurrency(c.Query("currency")))
+	switch {
+	case errors.Is(err, usecase.ErrPaymentCurrencyMismatch):
+		util.Raise409Error(c, err.Error())
+		return
+	case errors.Is(err, usecase.ErrUnsupportedCurrency):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
 		return
 	}
 
-	png, err := qrcode.Encode(fmt.Sprintf("%s/%s", util.NgrokEndpoint, paramId), qrcode.Medium, 256)
+	png, err := qrcode.Encode(fmt.Sprintf("%s/%s?instalment=%s&currency=%s", util.NgrokEndpoint, paramId, instalment.Id, amount.Currency), qrcode.Medium, 256)
 	if err != nil {
 		util.Raise500Error(c, err)
 		return
@@ -52,15 +74,18 @@ func (r *Resolver) GetQRCode(c *gin.Context) {
 		return
 	}
 
+	qrKey := usecase.PaymentQRKey(instalment)
 	contentType := http.DetectContentType(png)
 	buffer := bytes.NewBuffer(png)
-	if err := s3basics.UploadFile(c, s3utils.QRPaymentBucket, paramId, buffer, contentType); err != nil {
+	if err := s3basics.UploadFile(c, s3utils.QRPaymentBucket, qrKey, buffer, contentType); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"status": "success",
-		"data":   util.GetPaymentQRCodeUrl(&paramId),
+		"status":     "success",
+		"data":       util.GetPaymentQRCodeUrl(&qrKey),
+		"instalment": instalment,
+		"amount":     amount,
 	})
 }
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List the providers linked to the account
+// @Description  List the OAuth2/OIDC identities linked to the account
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.UserIdentity} "The linked identities"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/identities [get]
+func (r *Resolver) ListIdentities(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	identities, err := r.UserIdentityUsecase.FindByUserId(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   identities,
+	})
+}
+
+// @Summary      Link another provider to the account
+// @Description  Starts the authorization flow of the provider, the callback links the identity instead of signing in
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param provider path string true "The name of the provider, e.g. google or line"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The URL to the provider will be returned in the url field"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The provider is not supported"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/identities/{provider} [post]
+func (r *Resolver) LinkIdentity(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	provider, ok := util.GetOAuth2Provider(c, c.Param("provider"))
+	if !ok {
+		return
+	}
+
+	state, err := oauth2.SaveState(c, oauth2.State{
+		Provider:   provider.Name,
+		LinkUserId: &user.Id,
+	})
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	url, err := provider.AuthCodeURL(c, state)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"url":    url,
+	})
+}
+
+// @Summary      Unlink a provider from the account
+// @Description  Unlink a provider, the last sign-in method of an account without a password cannot be unlinked
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param provider path string true "The name of the provider, e.g. google or line"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "Successfully unlinked"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The provider is not linked"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The provider is the only sign-in method"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/identities/{provider} [delete]
+func (r *Resolver) UnlinkIdentity(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	// the identities are stored under the lowercase names of the providers
+	err := r.UserIdentityUsecase.Unlink(c, user, strings.ToLower(c.Param("provider")))
+	switch {
+	case errors.Is(err, usecase.ErrIdentityNotLinked):
+		c.JSON(http.StatusNotFound, gin.H{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		c.Abort()
+		return
+	case errors.Is(err, usecase.ErrLastSignInMethod):
+		util.Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   nil,
+	})
+}
@@ -14,6 +14,17 @@ type Resolver struct {
 	VerificationTicketUsecase usecase.VerificationTicketUseCase
 	ReviewUsecase             usecase.ReviewUseCase
 	RoomUsecase               usecase.RoomUseCase
+	UserIdentityUsecase       usecase.UserIdentityUseCase
+	PersonalDataUsecase       usecase.PersonalDataUseCase
+	NotificationUsecase       usecase.NotificationUseCase
+	PricingUsecase            usecase.PricingUseCase
+	BookingRequestUsecase     usecase.BookingRequestUseCase
+	CouponUsecase             usecase.CouponUseCase
+	CurrencyUsecase           usecase.CurrencyUseCase
+	DocumentUsecase           usecase.DocumentUseCase
+	CalendarUsecase           usecase.CalendarUseCase
+	InsightsUsecase           usecase.InsightsUseCase
+	DisputeUsecase            usecase.DisputeUseCase
 }
 
 func NewResolver(db *bun.DB) *Resolver {
@@ -26,5 +37,16 @@ func NewResolver(db *bun.DB) *Resolver {
 		VerificationTicketUsecase: *usecase.NewVerificationTicketUseCase(db),
 		ReviewUsecase:             *usecase.NewReviewUseCase(db),
 		RoomUsecase:               *usecase.NewRoomUseCase(db),
+		UserIdentityUsecase:       *usecase.NewUserIdentityUseCase(db),
+		PersonalDataUsecase:       *usecase.NewPersonalDataUseCase(db),
+		NotificationUsecase:       *usecase.NewNotificationUseCase(db),
+		PricingUsecase:            *usecase.NewPricingUseCase(db),
+		BookingRequestUsecase:     *usecase.NewBookingRequestUseCase(db),
+		CouponUsecase:             *usecase.NewCouponUseCase(db),
+		CurrencyUsecase:           *usecase.NewCurrencyUseCase(db),
+		DocumentUsecase:           *usecase.NewDocumentUseCase(db),
+		CalendarUsecase:           *usecase.NewCalendarUseCase(db),
+		InsightsUsecase:           *usecase.NewInsightsUseCase(db),
+		DisputeUsecase:            *usecase.NewDisputeUseCase(db),
 	}
 }
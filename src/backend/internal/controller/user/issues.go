@@ -0,0 +1,125 @@
+package user
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      List my issues
+// @Description  The issues I reported, including the refund requests, with their SLA targets
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.Issue} "The issues, the earliest due first"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /users/v1/issues [get]
+func (r *Resolver) ListMyIssues(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	reporterId := user.Id.String()
+	issues, err := r.IssueUsecase.FindIssuesWithFilter(c, model.IssueFilter{ReporterId: &reporterId})
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   issues,
+	})
+}
+
+// @Summary      Get one of my issues
+// @Description  The issue with the replies posted on it and their attachments, the links to the attachments expire after a few minutes
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.Issue} "The issue and its thread"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /users/v1/issues/{id} [get]
+func (r *Resolver) GetMyIssue(c *gin.Context) {
+	issue, ok := r.getOwnIssue(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   issue,
+	})
+}
+
+// @Summary      Reply to one of my issues
+// @Description  Post a message on the issue, with up to 5 images, PDF or text files of up to 5 MB each. The agent assigned to the issue is notified.
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param id path string true "The ID of the issue"
+// @Param body formData string true "The message"
+// @Param attachments formData file false "The files attached to the message"
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      201 {object} model.JSONSuccessResult{status=string,data=model.IssueMessage} "The message posted"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Missing message or invalid attachment"
+// @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "The issue does not exist"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The issue is closed"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router       /users/v1/issues/{id}/messages [post]
+func (r *Resolver) ReplyToMyIssue(c *gin.Context) {
+	issue, ok := r.getOwnIssue(c)
+	if !ok {
+		return
+	}
+
+	body, uploads, ok := util.ReadIssueMessage(c)
+	if !ok {
+		return
+	}
+
+	message, err := r.IssueUsecase.Reply(c, issue, issue.ReporterId, false, body, uploads)
+	if err != nil {
+		util.RaiseIssueError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"status": "success",
+		"data":   message,
+	})
+}
+
+// the issues of other users are reported as missing
+func (r *Resolver) getOwnIssue(c *gin.Context) (*model.Issue, bool) {
+	user, ok := GetUser(c)
+	if !ok {
+		return nil, false
+	}
+
+	issueId, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		util.Raise400Error(c, "invalid issue id")
+		return nil, false
+	}
+
+	issue, err := r.IssueUsecase.FindOneWithThread(c, issueId)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && issue.ReporterId != user.Id) {
+		raiseNotFound(c, "the issue does not exist")
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return issue, true
+}
@@ -2,12 +2,16 @@ package user
 
 import (
 	"fmt"
+	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/Roongkun/software-eng-ii/internal/config"
 	"github.com/Roongkun/software-eng-ii/internal/controller/user/fieldvalidate"
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/auth"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/ratelimit"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -21,6 +25,7 @@ import (
 // @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The token and user data will be returned inside the data field"
 // @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Incorrect input"
 // @Failure 404 {object} model.JSONErrorResult{status=string,error=nil} "User does not exist"
+// @Failure 429 {object} model.JSONErrorResult{status=string,error=nil} "Too many requests or too many failed attempts, retry after the Retry-After header"
 // @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
 //
 // @Router       /authen/v1/login [post]
@@ -46,6 +51,18 @@ func (r *Resolver) Login(c *gin.Context) {
 		return
 	}
 
+	// the lockout fails open like the rate limits, the password is still checked without redis
+	lockoutConf := c.MustGet("loginLockoutConf").(config.LoginLockout)
+	lockedFor, err := ratelimit.CheckLoginLockout(c, cred.Email)
+	if err != nil {
+		slog.WarnContext(c, "unable to check the login lockout", "error", err)
+	}
+
+	if lockedFor > 0 {
+		util.Raise429Error(c, lockedFor, "too many failed login attempts, the account is temporarily locked")
+		return
+	}
+
 	existedUser, err := r.UserUsecase.FindOneByEmail(c, cred.Email)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{
@@ -58,6 +75,16 @@ func (r *Resolver) Login(c *gin.Context) {
 
 	if existedUser.Provider == nil {
 		if err := bcrypt.CompareHashAndPassword([]byte(*existedUser.Password), []byte(cred.Password)); err != nil {
+			if _, lockErr := ratelimit.RegisterLoginFailure(
+				c,
+				cred.Email,
+				lockoutConf.MaxFailures,
+				time.Duration(lockoutConf.BaseLockoutSeconds)*time.Second,
+				time.Duration(lockoutConf.MaxLockoutSeconds)*time.Second,
+			); lockErr != nil {
+				slog.WarnContext(c, "unable to register the failed login", "error", lockErr)
+			}
+
 			c.JSON(http.StatusConflict, gin.H{
 				"status":  "failed",
 				"error":   err.Error(),
@@ -66,6 +93,10 @@ func (r *Resolver) Login(c *gin.Context) {
 			c.Abort()
 			return
 		}
+
+		if err := ratelimit.ResetLoginFailures(c, cred.Email); err != nil {
+			slog.WarnContext(c, "unable to reset the failed logins", "error", err)
+		}
 	} else {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"status": "failed",
@@ -1,11 +1,11 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
-	"github.com/Roongkun/software-eng-ii/internal/model"
-	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -20,19 +20,36 @@ func (r *Resolver) MakeBookingPayment(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingPaidStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	s3basics, err := s3utils.GetInstance()
-	if err != nil {
-		util.Raise500Error(c, err)
-		return
+	// the QR codes of the instalments name the instalment they pay
+	var instalmentId *uuid.UUID
+	if query := c.Query("instalment"); query != "" {
+		parsed, err := uuid.Parse(query)
+		if err != nil {
+			util.Raise400Error(c, "invalid instalment id")
+			return
+		}
+		instalmentId = &parsed
 	}
 
-	if err := s3basics.DeleteFile(c, s3utils.QRPaymentBucket, paramId); err != nil {
+	// and the currency they are paid in
+	currency := usecase.NormaliseCurrency(c.Query("currency"))
+
+	err = r.BookingUsecase.PayInstalment(c, booking, instalmentId, currency)
+	switch {
+	case errors.Is(err, usecase.ErrBookingNotPayable),
+		errors.Is(err, usecase.ErrInstalmentNotOutstanding),
+		errors.Is(err, usecase.ErrPaymentCurrencyMismatch):
+		util.Raise409Error(c, err.Error())
+		return
+	case errors.Is(err, usecase.ErrUnsupportedCurrency):
+		util.Raise400Error(c, err.Error())
+		return
+	case err != nil:
 		util.Raise500Error(c, err)
 		return
 	}
@@ -0,0 +1,116 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List my notifications
+// @Description  The notifications of the user, newest first, along with the number of unread ones
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param unread query bool false "Only return the unread notifications"
+// @Param limit query int false "The maximum number of notifications, 50 by default"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.Notification} "The notifications"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Invalid query parameters"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/notifications [get]
+func (r *Resolver) ListNotifications(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	filter := model.NotificationFilter{}
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		util.Raise400Error(c, err.Error())
+		return
+	}
+
+	notifications, err := r.NotificationUsecase.FindWithFilter(c, user.Id, filter)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	unreadCount, err := r.NotificationUsecase.CountUnreadByUserId(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":       "success",
+		"data":         notifications,
+		"unread_count": unreadCount,
+	})
+}
+
+// @Summary      Mark notifications as read
+// @Description  Mark the specified notifications of the user as read, the ids of other users are ignored
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Param request body model.NotificationReadInput true "The ids of the notifications"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The number of notifications marked"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "No notification specified"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/notifications/read [put]
+func (r *Resolver) MarkNotificationsRead(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	input := model.NotificationReadInput{}
+	if err := c.BindJSON(&input); err != nil {
+		util.Raise400Error(c, "unable to bind request body with json model, please recheck")
+		return
+	}
+
+	if len(input.Ids) == 0 {
+		util.Raise400Error(c, "the ids of the notifications must be specified")
+		return
+	}
+
+	marked, err := r.NotificationUsecase.NotificationRepo.MarkRead(c, user.Id, input.Ids...)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   gin.H{"marked": marked},
+	})
+}
+
+// @Summary      Mark all notifications as read
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The number of notifications marked"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/notifications/read-all [put]
+func (r *Resolver) MarkAllNotificationsRead(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	marked, err := r.NotificationUsecase.NotificationRepo.MarkAllRead(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   gin.H{"marked": marked},
+	})
+}
@@ -0,0 +1,217 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/auth"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// @Summary      This will be automatically called when the OAuth2 login process of a provider is completed
+// @Description  Signs the user in, creating the account on the first login, or links the provider when the flow was started from the link endpoint
+// @Tags         oauth2
+// @Param provider path string true "The name of the provider, e.g. google or line"
+// @Accept       json
+// @Produce      json
+// @Success      302 "Redirects back to the front-end with the session token set in the cookie"
+// @Failure 401 {object} model.JSONErrorResult{status=string,error=nil} "Missing authorization code or invalid state"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The identity is already linked to another user"
+// @Failure 502 {object} model.JSONErrorResult{status=string,error=nil} "The provider rejected the authorization code"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+//
+// @Router       /authen/v1/oauth2/{provider}/callback [get]
+func (r *Resolver) OAuth2Callback(c *gin.Context) {
+	r.oauth2Callback(c, c.Param("provider"))
+}
+
+// @Summary      This will be automatically called when the Google OAuth2 login process is completed
+// @Description  This will be automatically called when the Google OAuth2 login process is completed
+// @Tags         google
+// @Accept       json
+// @Produce      json
+// @Success      302 "Redirects back to the front-end with the session token set in the cookie"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+//
+// @Router       /authen/v1/google/callback [get]
+func (r *Resolver) GoogleCallback(c *gin.Context) {
+	r.oauth2Callback(c, "google")
+}
+
+func (r *Resolver) oauth2Callback(c *gin.Context, providerName string) {
+	provider, ok := util.GetOAuth2Provider(c, providerName)
+	if !ok {
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		util.Raise401Error(c, "Authorization code not provided")
+		return
+	}
+
+	state, err := oauth2.ConsumeState(c, c.Query("state"))
+	if err != nil || state.Provider != provider.Name {
+		util.Raise401Error(c, "the login request is invalid or has expired, please try again")
+		return
+	}
+
+	info, err := provider.Authenticate(c, code)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	if state.LinkUserId != nil {
+		if _, ok := r.linkOAuth2Identity(c, *state.LinkUserId, provider.Name, info); !ok {
+			return
+		}
+
+		location := url.URL{Path: "http://localhost:3000/settings/account-management"}
+		c.Redirect(http.StatusFound, location.RequestURI())
+		return
+	}
+
+	user, ok := r.signInOAuth2User(c, provider.Name, info)
+	if !ok {
+		return
+	}
+
+	jwtWrapper := auth.JwtWrapper{
+		SecretKey:         c.GetString("secretKey"),
+		Issuer:            "AuthProvider",
+		ExpirationMinutes: 5,
+		ExpirationHours:   12,
+	}
+
+	token, err := jwtWrapper.GenerateToken(c, user.Email, false)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.SetCookie("session_token", token, 3600, "/", "localhost", false, true)
+	location := url.URL{Path: "http://localhost:3000/auth/handle-login"}
+	c.Redirect(http.StatusFound, location.RequestURI())
+}
+
+func (r *Resolver) linkOAuth2Identity(c *gin.Context, userId uuid.UUID, provider string, info *oauth2.UserInfo) (*model.UserIdentity, bool) {
+	identity, err := r.UserIdentityUsecase.Link(c, userId, provider, info.Subject, optionalEmail(info))
+	if errors.Is(err, usecase.ErrIdentityLinkedToOtherUser) {
+		util.Raise409Error(c, err.Error())
+		return nil, false
+	}
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return identity, true
+}
+
+// signInOAuth2User resolves the user by the linked identity first, then by a verified email, and creates the user otherwise
+func (r *Resolver) signInOAuth2User(c *gin.Context, provider string, info *oauth2.UserInfo) (*model.User, bool) {
+	userId, err := r.UserIdentityUsecase.FindUserIdByIdentity(c, provider, info.Subject)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	var user *model.User
+	switch {
+	case userId != uuid.Nil:
+		user, err = r.UserUsecase.UserRepo.FindOneById(c, userId)
+		if err != nil {
+			util.Raise500Error(c, err)
+			return nil, false
+		}
+	case info.Email == "":
+		util.Raise400Error(c, "the provider did not share an email address, please allow the email scope")
+		return nil, false
+	default:
+		exist, err := r.UserUsecase.CheckExistenceByEmail(c, info.Email)
+		if err != nil {
+			util.Raise500Error(c, err)
+			return nil, false
+		}
+
+		if exist {
+			// an unverified email could be used to take over the existing account
+			if !info.VerifiedEmail {
+				util.Raise409Error(c, "the email is already registered, please sign in and link the provider from your account instead")
+				return nil, false
+			}
+
+			user, err = r.UserUsecase.UserRepo.FindOneByEmail(c, info.Email)
+			if err != nil {
+				util.Raise500Error(c, err)
+				return nil, false
+			}
+		} else {
+			firstname, lastname := splitOAuth2Name(info)
+			newUser := model.User{
+				Id:                 uuid.New(),
+				Username:           uuid.New().String(),
+				Email:              info.Email,
+				Provider:           &provider,
+				Password:           nil,
+				LoggedOut:          false,
+				Firstname:          firstname,
+				Lastname:           lastname,
+				VerificationStatus: model.PhotographerNotVerifiedStatus,
+			}
+
+			if err := r.UserUsecase.UserRepo.AddOne(c, &newUser); err != nil {
+				util.Raise500Error(c, err)
+				return nil, false
+			}
+			user = &newUser
+		}
+
+		if _, ok := r.linkOAuth2Identity(c, user.Id, provider, info); !ok {
+			return nil, false
+		}
+	}
+
+	user.LoggedOut = false
+	if err := r.UserUsecase.UserRepo.UpdateOne(c, user); err != nil {
+		util.Raise500Error(c, err)
+		return nil, false
+	}
+
+	return user, true
+}
+
+func splitOAuth2Name(info *oauth2.UserInfo) (string, string) {
+	if info.GivenName != "" {
+		return info.GivenName, info.FamilyName
+	}
+
+	// split the full name into firstname and lastname
+	combinedName := strings.SplitN(info.Name, " ", 2)
+	firstname := combinedName[0]
+	lastname := ""
+	if len(combinedName) > 1 {
+		lastname = combinedName[1]
+	}
+
+	return firstname, lastname
+}
+
+func optionalEmail(info *oauth2.UserInfo) *string {
+	if info.Email == "" {
+		return nil
+	}
+	return &info.Email
+}
@@ -0,0 +1,66 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      User login via an OAuth2/OIDC provider
+// @Description  User login via any provider configured under `oauth2_providers`
+// @Tags         oauth2
+// @Param provider path string true "The name of the provider, e.g. google or line"
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The URL to the provider will be returned in the url field"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "The provider is not supported"
+// @Failure 502 {object} model.JSONErrorResult{status=string,error=nil} "The provider could not be reached"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+//
+// @Router       /authen/v1/oauth2/{provider}/login [post]
+func (r *Resolver) OAuth2Login(c *gin.Context) {
+	r.oauth2Login(c, c.Param("provider"))
+}
+
+// @Summary      User login via Google OAuth2
+// @Description  User login via Google OAuth2, kept for the existing clients of the Google-only flow
+// @Tags         google
+// @Accept       json
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=nil} "The URL to the Google OAuth2 will be returned in the url field"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+//
+// @Router       /authen/v1/google/login [post]
+func (r *Resolver) GoogleLogin(c *gin.Context) {
+	r.oauth2Login(c, "google")
+}
+
+func (r *Resolver) oauth2Login(c *gin.Context, providerName string) {
+	provider, ok := util.GetOAuth2Provider(c, providerName)
+	if !ok {
+		return
+	}
+
+	state, err := oauth2.SaveState(c, oauth2.State{Provider: provider.Name})
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	url, err := provider.AuthCodeURL(c, state)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"status": "failed",
+			"error":  err.Error(),
+		})
+		c.Abort()
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"url":    url,
+	})
+}
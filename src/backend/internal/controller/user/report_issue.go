@@ -17,8 +17,8 @@ func (r *Resolver) ReportIssue(c *gin.Context) {
 	}
 
 	issueInput := model.IssueInput{}
-	if err := c.BindJSON(&issueInput); err != nil {
-		util.Raise500Error(c, err)
+	if err := c.ShouldBindJSON(&issueInput); err != nil {
+		util.Raise400Error(c, err.Error())
 		return
 	}
 
@@ -28,17 +28,23 @@ func (r *Resolver) ReportIssue(c *gin.Context) {
 		return
 	}
 
+	subject := model.IssueTechnicalSubject
+	if issueInput.Subject != nil {
+		subject = *issueInput.Subject
+	}
+
 	issue := &model.Issue{
 		Id:          uuid.New(),
 		Description: *issueInput.Description,
 		ReporterId:  user.Id,
 		Status:      model.IssueOpenStatus,
-		Subject:     model.IssueTechnicalSubject,
+		Subject:     subject,
+		Priority:    model.IssueNormalPriority,
 		CreatedAt:   time.Now(),
-		DueDate:     time.Now().Add(3 * 24 * time.Hour),
+		UpdatedAt:   time.Now(),
 	}
 
-	if err := r.IssueUsecase.IssueRepo.AddOne(c, issue); err != nil {
+	if err := r.IssueUsecase.Report(c, issue); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
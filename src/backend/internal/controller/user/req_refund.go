@@ -1,11 +1,13 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -34,8 +36,7 @@ func (r *Resolver) RequestRefundBooking(c *gin.Context) {
 		return
 	}
 
-	booking.Status = model.BookingRefundReqStatus
-	if err := r.BookingUsecase.BookingRepo.UpdateOne(c, booking); err != nil {
+	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
@@ -43,20 +44,21 @@ func (r *Resolver) RequestRefundBooking(c *gin.Context) {
 	newIssue := &model.Issue{
 		Id:          uuid.New(),
 		Subject:     model.IssueRefundSubject,
+		Priority:    model.IssueHighPriority,
 		CreatedAt:   time.Now(),
-		DueDate:     booking.EndTime.Add(3 * 24 * time.Hour),
+		UpdatedAt:   time.Now(),
 		Status:      model.IssueOpenStatus,
 		ReporterId:  user.Id,
 		BookingId:   &bookingId,
 		Description: bookingId.String(),
 	}
 
-	if err := r.IssueUsecase.IssueRepo.AddOne(c, newIssue); err != nil {
-		util.Raise500Error(c, err)
+	err = r.BookingUsecase.RequestRefund(c, booking, newIssue)
+	if errors.Is(err, usecase.ErrBookingNotRefundable) {
+		util.Raise409Error(c, err.Error())
 		return
 	}
-
-	if err := r.RoomUsecase.PopulateRoomsInBookings(c, r.GalleryUsecase, booking); err != nil {
+	if err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
@@ -1,31 +1,50 @@
 package user
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
 
-func hashIdCardNumber(idCardNumber string) string {
-	hasher := sha256.New()
-	hasher.Write([]byte(idCardNumber))
-	return fmt.Sprintf("%x", hasher.Sum(nil))
+// the object of the ID card is named after its keyed blind index, a plain hash of a 13-digit number is easily
+// reversed, it is only named randomly when there is no key
+func idCardObjectKey(idCardNumber string) string {
+	index := encryption.Default().BlindIndex(idCardNumber)
+	if index == "" {
+		return uuid.New().String()
+	}
+
+	return fmt.Sprintf("%s-%s", index, uuid.New().String())
 }
 
+// @Summary      Request verification as a photographer
+// @Description  Submit the ID card for verification, a photographer who was rejected or asked for more information may submit again
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Accept       multipart/form-data
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=model.VerificationTicket} "The submitted ticket"
+// @Failure 400 {object} model.JSONErrorResult{status=string,error=nil} "Incorrect input"
+// @Failure 403 {object} model.JSONErrorResult{status=string,error=nil} "Verification cannot be requested with the current status"
+// @Failure 409 {object} model.JSONErrorResult{status=string,error=nil} "The ID card has already been used by another account"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/req-verify [post]
 func (r *Resolver) RequestVerification(c *gin.Context) {
 	user, ok := GetUser(c)
 	if !ok {
 		return
 	}
 
-	if user.VerificationStatus != model.PhotographerNotVerifiedStatus && user.VerificationStatus != model.PhotographerRejectedStatus {
+	if user.VerificationStatus != model.PhotographerNotVerifiedStatus &&
+		user.VerificationStatus != model.PhotographerRejectedStatus &&
+		user.VerificationStatus != model.PhotographerInfoRequestedStatus {
 		c.JSON(http.StatusForbidden, gin.H{
 			"status": "failed",
 			"error":  "cannot request further verification with your current status",
@@ -45,6 +64,17 @@ func (r *Resolver) RequestVerification(c *gin.Context) {
 		return
 	}
 
+	usedByOthers, err := r.VerificationTicketUsecase.VerificationInfoRepo.CheckIdCardNumberUsedByOthers(c, verificationTicketInput.IdCardNumber, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	if usedByOthers {
+		util.Raise409Error(c, "this ID card has already been used to verify another account")
+		return
+	}
+
 	idCardPictureFile, err := verificationTicketInput.IdCardPicture.Open()
 	if err != nil {
 		util.Raise500Error(c, err)
@@ -59,7 +89,7 @@ func (r *Resolver) RequestVerification(c *gin.Context) {
 		return
 	}
 
-	objectKey := fmt.Sprintf("%s-%s", hashIdCardNumber(verificationTicketInput.IdCardNumber), uuid.New().String())
+	objectKey := idCardObjectKey(verificationTicketInput.IdCardNumber)
 
 	bucket, err := s3utils.GetInstance()
 	if err != nil {
@@ -82,20 +112,16 @@ func (r *Resolver) RequestVerification(c *gin.Context) {
 		DueDate:               time.Now().Add(3 * 24 * time.Hour),
 	}
 
-	if err := r.VerificationTicketUsecase.VerificationInfoRepo.AddOne(c, newVerificationInfo); err != nil {
+	if err := r.VerificationTicketUsecase.Submit(c, user, newVerificationInfo); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	user.VerificationStatus = model.PhotographerPendingStatus
-	if err := r.UserUsecase.UserRepo.UpdateOne(c, user); err != nil {
+	if err := r.VerificationTicketUsecase.PopulateTickets(c, newVerificationInfo); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
 
-	newVerificationInfo.User = *user
-	newVerificationInfo.IdCardPictureURL = fmt.Sprintf("http://localhost:4566/%s/%s", s3utils.IdCardBucket, objectKey)
-
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   newVerificationInfo,
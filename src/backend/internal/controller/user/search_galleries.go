@@ -1,10 +1,12 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -27,6 +29,27 @@ func (r *Resolver) SearchGalleries(c *gin.Context) {
 		return
 	}
 
+	// galleries are priced in different currencies, so price bounds without a currency are in the base currency
+	if searchFilter.Currency == nil && (searchFilter.MinPrice != nil || searchFilter.MaxPrice != nil) {
+		baseCurrency := model.BaseCurrency
+		searchFilter.Currency = &baseCurrency
+	}
+
+	if searchFilter.Currency != nil {
+		currency := usecase.NormaliseCurrency(*searchFilter.Currency)
+		searchFilter.Currency = &currency
+
+		err := r.CurrencyUsecase.CheckSupported(c, currency)
+		if errors.Is(err, usecase.ErrUnsupportedCurrency) {
+			util.Raise400Error(c, err.Error())
+			return
+		}
+		if err != nil {
+			util.Raise500Error(c, err)
+			return
+		}
+	}
+
 	if searchFilter.PhotographerName != nil {
 		photographerIds := []uuid.UUID{}
 		matchedPhotographerIds, err := r.UserUsecase.FindPhotograperIdsNameAlike(c, *searchFilter.PhotographerName)
@@ -48,6 +71,14 @@ func (r *Resolver) SearchGalleries(c *gin.Context) {
 		return
 	}
 
+	if searchFilter.Currency != nil {
+		targetGalleries, err = r.CurrencyUsecase.EstimatePrices(c, targetGalleries, &searchFilter)
+		if err != nil {
+			util.Raise500Error(c, err)
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"status": "success",
 		"data":   targetGalleries,
@@ -64,12 +64,7 @@ func (r *Resolver) UpdateReview(c *gin.Context) {
 	// editing
 	updateReview(existingReview, updatingReviewInput)
 
-	if err := r.ReviewUsecase.ReviewRepo.UpdateOne(c, existingReview); err != nil {
-		util.Raise500Error(c, err)
-		return
-	}
-
-	if err := UpdateGalleryRating(c, r.GalleryUsecase, r.ReviewUsecase, &existingReview.Booking.Room.Gallery); err != nil {
+	if err := r.ReviewUsecase.Update(c, existingReview); err != nil {
 		util.Raise500Error(c, err)
 		return
 	}
@@ -47,6 +47,9 @@ func updateUserFieldsFromInput(userObj model.User, updatingUserInput model.UserU
 	if updatingUserInput.Username != nil {
 		updatedUser.Username = *updatingUserInput.Username
 	}
+	if updatingUserInput.TaxId != nil {
+		updatedUser.TaxId = updatingUserInput.TaxId
+	}
 
 	return updatedUser, nil // Return the updated user object
 }
@@ -0,0 +1,43 @@
+package user
+
+import (
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/controller/util"
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary      List my verification tickets
+// @Description  The verification tickets of the user, newest first, with the decision and reason of the reviewer and the full history
+// @Tags         users
+// @Param Token header string true "Session token is required"
+// @Produce      json
+// @Success      200 {object} model.JSONSuccessResult{status=string,data=[]model.VerificationTicket} "The verification tickets"
+// @Failure 500 {object} model.JSONErrorResult{status=string,error=nil} "Unhandled internal server error"
+// @Router /users/v1/verification-tickets [get]
+func (r *Resolver) ListMyVerificationTickets(c *gin.Context) {
+	user, ok := GetUser(c)
+	if !ok {
+		return
+	}
+
+	tickets, err := r.VerificationTicketUsecase.FindHistoryByUserId(c, user.Id)
+	if err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	for _, ticket := range tickets {
+		ticket.User = *user
+	}
+
+	if err := r.VerificationTicketUsecase.PresignIdCardURLs(c, tickets...); err != nil {
+		util.Raise500Error(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status": "success",
+		"data":   tickets,
+	})
+}
@@ -0,0 +1,37 @@
+package util
+
+import (
+	"errors"
+
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// RaiseBookingRequestError answers with the status matching an error of the negotiation of a booking request,
+// it is shared with the photographer's side of the negotiation
+func RaiseBookingRequestError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrBookingRequestClosed),
+		errors.Is(err, usecase.ErrQuoteAwaitingAnswer),
+		errors.Is(err, usecase.ErrNoPendingQuote),
+		errors.Is(err, usecase.ErrQuoteExpired),
+		errors.Is(err, usecase.ErrPhotographerBusy):
+		Raise409Error(c, err.Error())
+	case errors.Is(err, usecase.ErrOwnQuote),
+		errors.Is(err, usecase.ErrFirstQuoteByPhotographer):
+		Raise403Error(c, err.Error())
+	case errors.Is(err, usecase.ErrBookingRequestInPast),
+		errors.Is(err, usecase.ErrBookingSessionMissing),
+		errors.Is(err, usecase.ErrInvalidBookingSession),
+		errors.Is(err, usecase.ErrBookingSessionsOverlap),
+		errors.Is(err, usecase.ErrTooManyBookingSessions),
+		errors.Is(err, usecase.ErrInvalidRecurrence),
+		errors.Is(err, usecase.ErrRecurrenceWithSessions),
+		errors.Is(err, usecase.ErrUnknownAddOn),
+		errors.Is(err, usecase.ErrInvalidAddOnQuantity),
+		errors.Is(err, usecase.ErrInvalidNegotiatedPrice):
+		Raise400Error(c, err.Error())
+	default:
+		Raise500Error(c, err)
+	}
+}
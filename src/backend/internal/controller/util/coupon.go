@@ -0,0 +1,34 @@
+package util
+
+import (
+	"errors"
+
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// RaiseCouponError answers with the status matching an error of the management or the redemption of a coupon,
+// it is shared with the coupon endpoints of the photographers and the administrators
+func RaiseCouponError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrCouponNotFound):
+		Raise404Error(c, err.Error())
+	case errors.Is(err, usecase.ErrCouponCodeTaken),
+		errors.Is(err, usecase.ErrCouponExhausted),
+		errors.Is(err, usecase.ErrCouponUserLimitReached),
+		errors.Is(err, usecase.ErrCouponAlreadyApplied),
+		errors.Is(err, usecase.ErrCouponBookingNotDraft):
+		Raise409Error(c, err.Error())
+	case errors.Is(err, usecase.ErrInvalidCoupon),
+		errors.Is(err, usecase.ErrInvalidCouponWindow),
+		errors.Is(err, usecase.ErrInvalidCouponLimit),
+		errors.Is(err, usecase.ErrCouponGalleryNotOwned),
+		errors.Is(err, usecase.ErrCouponNotActive),
+		errors.Is(err, usecase.ErrCouponNotApplicable),
+		errors.Is(err, usecase.ErrCouponMinimumSpend),
+		errors.Is(err, usecase.ErrUnsupportedCurrency):
+		Raise400Error(c, err.Error())
+	default:
+		Raise500Error(c, err)
+	}
+}
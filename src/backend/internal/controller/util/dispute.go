@@ -0,0 +1,89 @@
+package util
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// FindBookingDispute returns the last dispute of the booking with its evidence
+func FindBookingDispute(c *gin.Context, disputeUsecase usecase.DisputeUseCase, booking *model.Booking) (*model.Dispute, bool) {
+	dispute, err := disputeUsecase.FindLatestWithEvidence(c, booking.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		Raise404Error(c, "the booking has not been disputed")
+		return nil, false
+	}
+	if err != nil {
+		Raise500Error(c, err)
+		return nil, false
+	}
+
+	return dispute, true
+}
+
+// ReadDisputeEvidence reads the text, the photos and the messages quoted of the evidence of a dispute
+func ReadDisputeEvidence(c *gin.Context) (string, [][]byte, []uuid.UUID, bool) {
+	input := model.DisputeEvidenceInput{}
+	if err := c.ShouldBind(&input); err != nil {
+		Raise400Error(c, err.Error())
+		return "", nil, nil, false
+	}
+
+	if len(input.Photos) > usecase.MaxDisputePhotos {
+		Raise400Error(c, usecase.ErrTooManyDisputePhotos.Error())
+		return "", nil, nil, false
+	}
+
+	photos := [][]byte{}
+	for _, header := range input.Photos {
+		if header.Size > usecase.MaxDisputePhotoSize {
+			Raise400Error(c, usecase.ErrInvalidDisputePhoto.Error())
+			return "", nil, nil, false
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			Raise500Error(c, err)
+			return "", nil, nil, false
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			Raise500Error(c, err)
+			return "", nil, nil, false
+		}
+
+		photos = append(photos, data)
+	}
+
+	conversationIds := []uuid.UUID{}
+	for _, id := range input.ConversationIds {
+		conversationIds = append(conversationIds, uuid.MustParse(id))
+	}
+
+	return input.Text, photos, conversationIds, true
+}
+
+func RaiseDisputeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrBookingNotDisputable),
+		errors.Is(err, usecase.ErrBookingAlreadyDisputed),
+		errors.Is(err, usecase.ErrDisputeClosed):
+		Raise409Error(c, err.Error())
+	case errors.Is(err, usecase.ErrNotDisputeOpener):
+		Raise403Error(c, err.Error())
+	case errors.Is(err, usecase.ErrEmptyEvidence),
+		errors.Is(err, usecase.ErrInvalidDisputePhoto),
+		errors.Is(err, usecase.ErrTooManyDisputePhotos),
+		errors.Is(err, usecase.ErrInvalidExcerpt),
+		errors.Is(err, usecase.ErrInvalidDisputeSplit):
+		Raise400Error(c, err.Error())
+	default:
+		Raise500Error(c, err)
+	}
+}
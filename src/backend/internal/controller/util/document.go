@@ -0,0 +1,29 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// ServeDocument answers with the pdf of an issued document, it is shared with the document endpoints of the photographers
+func ServeDocument(c *gin.Context, documentUsecase usecase.DocumentUseCase, document *model.BookingDocument) {
+	file, size, err := documentUsecase.Open(c, document)
+	switch {
+	case errors.Is(err, usecase.ErrDocumentNotReady):
+		Raise409Error(c, err.Error())
+		return
+	case err != nil:
+		Raise500Error(c, err)
+		return
+	}
+	defer file.Close()
+
+	c.DataFromReader(http.StatusOK, size, "application/pdf", file, map[string]string{
+		"Content-Disposition": fmt.Sprintf(`attachment; filename="%s.pdf"`, document.Number),
+	})
+}
@@ -1,12 +1,17 @@
 package util
 
 import (
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func Raise500Error(c *gin.Context, err error) {
+	// kept for the request log and the span of the request
+	c.Error(err)
 	c.JSON(http.StatusInternalServerError, gin.H{
 		"status": "failed",
 		"error":  err.Error(),
@@ -38,6 +43,14 @@ func Raise403Error(c *gin.Context, message string) {
 	c.Abort()
 }
 
+func Raise404Error(c *gin.Context, message string) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"status": "failed",
+		"error":  message,
+	})
+	c.Abort()
+}
+
 func Raise405Error(c *gin.Context, message string) {
 	c.JSON(http.StatusMethodNotAllowed, gin.H{
 		"status": "failed",
@@ -53,3 +66,12 @@ func Raise409Error(c *gin.Context, message string) {
 	})
 	c.Abort()
 }
+
+func Raise429Error(c *gin.Context, retryAfter time.Duration, message string) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"status": "failed",
+		"error":  message,
+	})
+	c.Abort()
+}
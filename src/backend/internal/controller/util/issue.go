@@ -0,0 +1,62 @@
+package util
+
+import (
+	"errors"
+	"io"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/gin-gonic/gin"
+)
+
+// ReadIssueMessage reads the body and the attachments of a message posted on an issue
+func ReadIssueMessage(c *gin.Context) (string, []usecase.IssueUpload, bool) {
+	input := model.IssueMessageInput{}
+	if err := c.ShouldBind(&input); err != nil {
+		Raise400Error(c, err.Error())
+		return "", nil, false
+	}
+
+	if len(input.Attachments) > usecase.MaxIssueAttachments {
+		Raise400Error(c, usecase.ErrTooManyAttachments.Error())
+		return "", nil, false
+	}
+
+	uploads := []usecase.IssueUpload{}
+	for _, header := range input.Attachments {
+		if header.Size > usecase.MaxIssueAttachmentSize {
+			Raise400Error(c, usecase.ErrInvalidAttachment.Error())
+			return "", nil, false
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			Raise500Error(c, err)
+			return "", nil, false
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			Raise500Error(c, err)
+			return "", nil, false
+		}
+
+		uploads = append(uploads, usecase.IssueUpload{Filename: header.Filename, Data: data})
+	}
+
+	return input.Body, uploads, true
+}
+
+func RaiseIssueError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, usecase.ErrIssueClosed):
+		Raise409Error(c, err.Error())
+	case errors.Is(err, usecase.ErrInvalidAttachment),
+		errors.Is(err, usecase.ErrTooManyAttachments),
+		errors.Is(err, usecase.ErrAssigneeNotAdmin),
+		errors.Is(err, usecase.ErrRefundSubjectLocked):
+		Raise400Error(c, err.Error())
+	default:
+		Raise500Error(c, err)
+	}
+}
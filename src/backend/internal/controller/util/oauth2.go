@@ -0,0 +1,24 @@
+package util
+
+import (
+	"fmt"
+
+	"github.com/Roongkun/software-eng-ii/internal/third-party/oauth2"
+	"github.com/gin-gonic/gin"
+)
+
+func GetOAuth2Provider(c *gin.Context, name string) (*oauth2.Provider, bool) {
+	registry, exist := c.Get("OAuth2Registry")
+	if !exist {
+		Raise500Error(c, fmt.Errorf("the oauth2 registry is not found, please check the `setOAuth2Registry` middleware"))
+		return nil, false
+	}
+
+	provider, ok := registry.(*oauth2.Registry).Get(name)
+	if !ok {
+		Raise400Error(c, fmt.Sprintf("the oauth2 provider %s is not supported", name))
+		return nil, false
+	}
+
+	return provider, true
+}
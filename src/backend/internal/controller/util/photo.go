@@ -9,7 +9,9 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"time"
 
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
 	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/nfnt/resize"
 )
@@ -29,6 +31,8 @@ func validateImage(file io.ReadSeeker) (string, error) {
 }
 
 func decodeImage(contentType string, file io.Reader) (image.Image, error) {
+	defer metrics.ObserveImageProcessing("decode", time.Now())
+
 	var img image.Image
 	var err error
 	switch contentType {
@@ -48,6 +52,8 @@ func decodeImage(contentType string, file io.Reader) (image.Image, error) {
 
 // processImage resizes and compresses the image.
 func processImage(img image.Image, contentType string) (*bytes.Buffer, error) {
+	defer metrics.ObserveImageProcessing("resize", time.Now())
+
 	maxWidth, maxHeight := uint(800), uint(600)
 	img = resize.Thumbnail(maxWidth, maxHeight, img, resize.Lanczos3)
 
@@ -111,15 +117,12 @@ func FormatImage(file multipart.File) (*bytes.Buffer, string, error) {
 	return buf, contentType, nil
 }
 
-const baseUrl = "http://localhost:4566"
-
 func GetProfilePictureUrl(profilePictureKey *string) string {
 	if profilePictureKey == nil {
 		return ""
 	}
-	// TODO: Add aws endpoint to config
-	url := fmt.Sprintf("%s/%s/%s", baseUrl, s3utils.ProfilePicBucket, *profilePictureKey)
-	return url
+
+	return s3utils.ObjectUrl(s3utils.ProfilePicBucket, *profilePictureKey)
 }
 
 func GetPaymentQRCodeUrl(qrPaymentKey *string) string {
@@ -127,7 +130,7 @@ func GetPaymentQRCodeUrl(qrPaymentKey *string) string {
 		return ""
 	}
 
-	return fmt.Sprintf("%s/%s/%s", baseUrl, s3utils.QRPaymentBucket, *qrPaymentKey)
+	return s3utils.ObjectUrl(s3utils.QRPaymentBucket, *qrPaymentKey)
 }
 
 func GetGalleryPictureUrl(galleryPictureKey *string) string {
@@ -135,6 +138,5 @@ func GetGalleryPictureUrl(galleryPictureKey *string) string {
 		return ""
 	}
 
-	url := fmt.Sprintf("%s/%s/%s", baseUrl, s3utils.GalleryPhotoBucket, *galleryPictureKey)
-	return url
+	return s3utils.ObjectUrl(s3utils.GalleryPhotoBucket, *galleryPictureKey)
 }
@@ -0,0 +1,22 @@
+package util
+
+import (
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ViewerId is the ID of the user of the session on the routes open to the guests, it is nil for a guest
+func ViewerId(c *gin.Context) *uuid.UUID {
+	user, ok := c.Get("user")
+	if !ok {
+		return nil
+	}
+
+	viewer, ok := user.(model.User)
+	if !ok {
+		return nil
+	}
+
+	return &viewer.Id
+}
@@ -0,0 +1,13 @@
+CREATE TABLE user_identities (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  provider varchar(255) NOT NULL,
+  subject varchar(2000) NOT NULL,
+  email varchar(2000),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  CONSTRAINT uq_provider_subject UNIQUE (provider, subject),
+  CONSTRAINT uq_user_id_provider UNIQUE (user_id, provider)
+);
+
+
+CREATE INDEX user_identity_user_idx ON user_identities (user_id);
@@ -0,0 +1,36 @@
+ALTER TABLE users
+ADD COLUMN deleted_at timestamptz;
+
+
+-- erased accounts are anonymised rather than deleted, a hard delete must not wipe the financial records
+ALTER TABLE bookings
+DROP CONSTRAINT bookings_customer_id_fkey,
+ADD CONSTRAINT bookings_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+
+ALTER TABLE reviews
+DROP CONSTRAINT reviews_customer_id_fkey,
+ADD CONSTRAINT reviews_customer_id_fkey FOREIGN KEY (customer_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+
+ALTER TABLE galleries
+DROP CONSTRAINT galleries_photographer_id_fkey,
+ADD CONSTRAINT galleries_photographer_id_fkey FOREIGN KEY (photographer_id) REFERENCES users (id) ON DELETE RESTRICT;
+
+
+CREATE TYPE data_export_status AS enum('PENDING', 'COMPLETED', 'FAILED');
+
+
+CREATE TABLE data_exports (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  status data_export_status NOT NULL DEFAULT 'PENDING',
+  object_key varchar(2000),
+  error varchar(2000),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  completed_at timestamptz,
+  expires_at timestamptz
+);
+
+
+CREATE INDEX data_export_user_idx ON data_exports (user_id);
@@ -0,0 +1,86 @@
+ALTER TYPE photographer_status
+ADD VALUE 'INFO_REQUESTED';
+
+
+CREATE TYPE verification_ticket_status AS enum('PENDING', 'APPROVED', 'REJECTED', 'INFO_REQUESTED');
+
+
+ALTER TABLE verification_ticket
+ADD COLUMN status verification_ticket_status NOT NULL DEFAULT 'PENDING',
+ADD COLUMN previous_ticket_id UUID REFERENCES verification_ticket (id) ON DELETE SET NULL,
+ADD COLUMN reviewer_id UUID REFERENCES users (id) ON DELETE SET NULL,
+ADD COLUMN decision_reason varchar(2000),
+ADD COLUMN reviewed_at timestamptz;
+
+
+-- tickets decided before the review workflow only changed the status of the user
+UPDATE verification_ticket
+SET
+  status = 'APPROVED'
+FROM
+  users
+WHERE
+  users.id = verification_ticket.user_id
+  AND users.verification_status = 'VERIFIED';
+
+
+UPDATE verification_ticket
+SET
+  status = 'REJECTED'
+FROM
+  users
+WHERE
+  users.id = verification_ticket.user_id
+  AND users.verification_status = 'REJECTED';
+
+
+CREATE INDEX verification_ticket_status_idx ON verification_ticket (status, due_date);
+
+
+CREATE TYPE verification_event_action AS enum(
+  'SUBMITTED',
+  'RESUBMITTED',
+  'APPROVED',
+  'REJECTED',
+  'INFO_REQUESTED'
+);
+
+
+-- ticket_id has no foreign key, the history outlives the documents of an erased account
+CREATE TABLE verification_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  ticket_id UUID NOT NULL,
+  actor_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  action verification_event_action NOT NULL,
+  reason varchar(2000),
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX verification_event_user_idx ON verification_events (user_id, created_at);
+
+
+CREATE FUNCTION reject_verification_event_change () RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'verification history is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+
+CREATE TRIGGER verification_events_append_only BEFORE
+UPDATE
+OR DELETE ON verification_events FOR EACH ROW
+EXECUTE FUNCTION reject_verification_event_change ();
+
+
+INSERT INTO
+  verification_events (user_id, ticket_id, actor_id, action, created_at)
+SELECT
+  user_id,
+  id,
+  user_id,
+  'SUBMITTED',
+  created_at
+FROM
+  verification_ticket;
@@ -0,0 +1,12 @@
+-- the columns hold ciphertext once `migrate reencrypt` has run, which is longer than the plaintext
+ALTER TABLE verification_ticket
+ALTER COLUMN id_card_number TYPE varchar(2000),
+ADD COLUMN id_card_number_index varchar(64);
+
+
+CREATE INDEX verification_ticket_id_card_idx ON verification_ticket (id_card_number_index);
+
+
+ALTER TABLE users
+ALTER COLUMN address TYPE varchar(2000),
+ALTER COLUMN phone_number TYPE varchar(2000);
@@ -0,0 +1,18 @@
+CREATE TABLE notifications (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  type varchar(255) NOT NULL,
+  title varchar(255) NOT NULL,
+  body varchar(2000),
+  resource_id UUID,
+  read_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX notification_user_created_at_idx ON notifications (user_id, created_at DESC);
+
+
+CREATE INDEX notification_unread_idx ON notifications (user_id)
+WHERE
+  read_at IS NULL;
@@ -0,0 +1,37 @@
+CREATE TABLE webhook_subscriptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  url varchar(2000) NOT NULL,
+  event_types varchar(255) [] NOT NULL,
+  secret varchar(2000) NOT NULL,
+  is_active boolean NOT NULL DEFAULT TRUE,
+  created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE TYPE webhook_delivery_status AS enum('PENDING', 'SUCCEEDED', 'DEAD');
+
+
+CREATE TABLE webhook_deliveries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  subscription_id UUID NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+  event_id UUID NOT NULL,
+  event_type varchar(255) NOT NULL,
+  payload jsonb NOT NULL,
+  status webhook_delivery_status NOT NULL DEFAULT 'PENDING',
+  attempts int NOT NULL DEFAULT 0,
+  next_attempt_at timestamptz NOT NULL DEFAULT now(),
+  last_status_code int,
+  last_error varchar(2000),
+  created_at timestamptz NOT NULL DEFAULT now(),
+  delivered_at timestamptz
+);
+
+
+CREATE INDEX webhook_delivery_due_idx ON webhook_deliveries (next_attempt_at)
+WHERE
+  status = 'PENDING';
+
+
+CREATE INDEX webhook_delivery_subscription_idx ON webhook_deliveries (subscription_id, created_at DESC);
@@ -0,0 +1,29 @@
+CREATE TYPE job_status AS enum('PENDING', 'RUNNING', 'SUCCEEDED', 'FAILED');
+
+
+-- the outbox, jobs are inserted in the same transaction as the change that causes them
+CREATE TABLE jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  kind varchar(255) NOT NULL,
+  payload jsonb NOT NULL DEFAULT '{}',
+  status job_status NOT NULL DEFAULT 'PENDING',
+  attempts int NOT NULL DEFAULT 0,
+  max_attempts int NOT NULL DEFAULT 5,
+  run_at timestamptz NOT NULL DEFAULT now(),
+  locked_by varchar(255),
+  locked_until timestamptz,
+  last_error varchar(2000),
+  dedup_key varchar(255) UNIQUE,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  completed_at timestamptz
+);
+
+
+CREATE INDEX job_due_idx ON jobs (run_at)
+WHERE
+  status = 'PENDING';
+
+
+CREATE INDEX job_lease_idx ON jobs (locked_until)
+WHERE
+  status = 'RUNNING';
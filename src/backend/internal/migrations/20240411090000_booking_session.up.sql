@@ -0,0 +1,34 @@
+CREATE TABLE booking_sessions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+  title varchar(255),
+  start_time timestamptz NOT NULL,
+  end_time timestamptz NOT NULL,
+  status varchar(255) NOT NULL DEFAULT 'SCHEDULED',
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX booking_session_booking_id_idx ON booking_sessions (booking_id, start_time);
+
+
+CREATE INDEX booking_session_scheduled_idx ON booking_sessions (end_time)
+WHERE
+  status = 'SCHEDULED';
+
+
+-- every existing booking becomes a package of a single session
+INSERT INTO
+  booking_sessions (booking_id, start_time, end_time, status)
+SELECT
+  id,
+  start_time,
+  end_time,
+  CASE
+    WHEN status IN ('COMPLETED', 'PAID_OUT', 'REQ_REFUND') THEN 'COMPLETED'
+    WHEN status = 'CANCELLED' THEN 'CANCELLED'
+    ELSE 'SCHEDULED'
+  END
+FROM
+  bookings;
@@ -0,0 +1,44 @@
+CREATE TABLE gallery_add_ons (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  gallery_id UUID NOT NULL REFERENCES galleries (id) ON DELETE CASCADE,
+  name varchar(255) NOT NULL,
+  description varchar(2000),
+  price integer NOT NULL,
+  unit varchar(255) NOT NULL DEFAULT 'PER_BOOKING',
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX gallery_add_on_gallery_id_idx ON gallery_add_ons (gallery_id);
+
+
+CREATE TABLE pricing_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  gallery_id UUID NOT NULL REFERENCES galleries (id) ON DELETE CASCADE,
+  kind varchar(255) NOT NULL,
+  name varchar(255) NOT NULL,
+  percent integer NOT NULL,
+  start_date date,
+  end_date date,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX pricing_rule_gallery_id_idx ON pricing_rules (gallery_id);
+
+
+-- the breakdown is a snapshot, it does not change when the add-ons or the rules of the gallery do
+CREATE TABLE booking_line_items (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+  position integer NOT NULL,
+  kind varchar(255) NOT NULL,
+  description varchar(255) NOT NULL,
+  quantity integer NOT NULL,
+  unit_price integer NOT NULL,
+  amount integer NOT NULL
+);
+
+
+CREATE INDEX booking_line_item_booking_id_idx ON booking_line_items (booking_id, position);
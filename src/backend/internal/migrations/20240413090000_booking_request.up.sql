@@ -0,0 +1,60 @@
+CREATE TABLE booking_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  customer_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  gallery_id UUID NOT NULL REFERENCES galleries (id) ON DELETE CASCADE,
+  room_id UUID NOT NULL REFERENCES rooms (id) ON DELETE CASCADE,
+  status varchar(255) NOT NULL DEFAULT 'OPEN',
+  notes varchar(2000),
+  sessions jsonb NOT NULL DEFAULT '[]',
+  add_ons jsonb NOT NULL DEFAULT '[]',
+  booking_id UUID REFERENCES bookings (id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX booking_request_customer_id_idx ON booking_requests (customer_id, created_at);
+
+
+CREATE INDEX booking_request_gallery_id_idx ON booking_requests (gallery_id, created_at);
+
+
+CREATE TABLE booking_quotes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  request_id UUID NOT NULL REFERENCES booking_requests (id) ON DELETE CASCADE,
+  author_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  round integer NOT NULL,
+  status varchar(255) NOT NULL DEFAULT 'PENDING',
+  sessions jsonb NOT NULL DEFAULT '[]',
+  add_ons jsonb NOT NULL DEFAULT '[]',
+  negotiated_price integer,
+  line_items jsonb NOT NULL DEFAULT '[]',
+  total integer NOT NULL,
+  message varchar(2000),
+  expires_at timestamptz NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (request_id, round)
+);
+
+
+-- a request has at most one quote waiting for an answer
+CREATE UNIQUE INDEX booking_quote_pending_idx ON booking_quotes (request_id)
+WHERE
+  status = 'PENDING';
+
+
+CREATE INDEX booking_quote_expires_at_idx ON booking_quotes (expires_at)
+WHERE
+  status = 'PENDING';
+
+
+-- the cards of the requests and the quotes are posted in the chat of the room
+ALTER TABLE conversations
+ADD COLUMN kind varchar(255) NOT NULL DEFAULT 'MESSAGE',
+ADD COLUMN resource_id UUID;
+
+
+CREATE INDEX conversation_resource_id_idx ON conversations (resource_id)
+WHERE
+  resource_id IS NOT NULL;
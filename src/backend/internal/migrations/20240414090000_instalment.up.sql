@@ -0,0 +1,51 @@
+ALTER TYPE booking_status
+ADD VALUE 'PARTIALLY_PAID';
+
+
+-- a deposit of 0 takes the whole price in a single payment
+ALTER TABLE galleries
+ADD COLUMN deposit_percent integer NOT NULL DEFAULT 0,
+ADD COLUMN balance_due_days integer NOT NULL DEFAULT 0;
+
+
+CREATE TABLE booking_instalments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+  position integer NOT NULL,
+  kind varchar(255) NOT NULL,
+  amount integer NOT NULL,
+  due_at timestamptz NOT NULL,
+  status varchar(255) NOT NULL DEFAULT 'PENDING',
+  paid_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX booking_instalment_booking_id_idx ON booking_instalments (booking_id, position);
+
+
+CREATE INDEX booking_instalment_pending_idx ON booking_instalments (due_at)
+WHERE
+  status = 'PENDING';
+
+
+-- every existing booking is paid in full in a single instalment
+INSERT INTO
+  booking_instalments (booking_id, position, kind, amount, due_at, status, paid_at)
+SELECT
+  id,
+  0,
+  'FULL',
+  resulted_price,
+  start_time,
+  CASE
+    WHEN status = 'DRAFT' THEN 'PENDING'
+    WHEN status = 'CANCELLED' THEN 'CANCELLED'
+    ELSE 'PAID'
+  END,
+  CASE
+    WHEN status NOT IN ('DRAFT', 'CANCELLED') THEN updated_at
+  END
+FROM
+  bookings;
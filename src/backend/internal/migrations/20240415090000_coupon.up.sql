@@ -0,0 +1,38 @@
+-- the codes are stored upper-cased, so that they are unique regardless of their case
+CREATE TABLE coupons (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  code varchar(32) NOT NULL UNIQUE,
+  photographer_id UUID REFERENCES users (id) ON DELETE CASCADE,
+  kind varchar(255) NOT NULL,
+  value integer NOT NULL,
+  min_spend integer NOT NULL DEFAULT 0,
+  starts_at timestamptz,
+  ends_at timestamptz,
+  max_redemptions integer,
+  max_redemptions_per_user integer,
+  gallery_ids uuid[] NOT NULL DEFAULT '{}',
+  locations varchar(255)[] NOT NULL DEFAULT '{}',
+  is_active boolean NOT NULL DEFAULT TRUE,
+  created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX coupon_photographer_id_idx ON coupons (photographer_id);
+
+
+-- the redemptions are financial records, a booking takes a single coupon
+CREATE TABLE coupon_redemptions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  coupon_id UUID NOT NULL REFERENCES coupons (id) ON DELETE RESTRICT,
+  booking_id UUID NOT NULL UNIQUE REFERENCES bookings (id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  code varchar(32) NOT NULL,
+  subtotal integer NOT NULL,
+  amount integer NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX coupon_redemption_coupon_id_idx ON coupon_redemptions (coupon_id, user_id);
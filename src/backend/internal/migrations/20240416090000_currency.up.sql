@@ -0,0 +1,55 @@
+-- the rates are quoted against THB, the base currency, which is not stored
+CREATE TABLE exchange_rates (
+  currency varchar(3) PRIMARY KEY,
+  rate numeric(20, 10) NOT NULL CHECK (rate > 0),
+  source varchar(255) NOT NULL,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+-- every price so far has been in THB
+ALTER TABLE galleries
+ADD COLUMN currency varchar(3) NOT NULL DEFAULT 'THB';
+
+
+ALTER TABLE booking_quotes
+ADD COLUMN currency varchar(3) NOT NULL DEFAULT 'THB';
+
+
+ALTER TABLE coupons
+ADD COLUMN currency varchar(3) NOT NULL DEFAULT 'THB';
+
+
+ALTER TABLE bookings
+ADD COLUMN currency varchar(3) NOT NULL DEFAULT 'THB',
+ADD COLUMN payment_currency varchar(3),
+ADD COLUMN exchange_rate numeric(20, 10),
+ADD COLUMN rate_locked_at timestamptz;
+
+
+-- the amount paid is in the payment currency of the booking
+ALTER TABLE booking_instalments
+ADD COLUMN paid_amount integer;
+
+
+UPDATE booking_instalments
+SET
+  paid_amount = amount
+WHERE
+  status = 'PAID';
+
+
+UPDATE bookings
+SET
+  payment_currency = 'THB',
+  exchange_rate = 1,
+  rate_locked_at = updated_at
+WHERE
+  id IN (
+    SELECT
+      booking_id
+    FROM
+      booking_instalments
+    WHERE
+      status = 'PAID'
+  );
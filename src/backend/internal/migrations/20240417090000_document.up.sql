@@ -0,0 +1,37 @@
+-- sealed like the address and the phone number
+ALTER TABLE users
+ADD COLUMN tax_id varchar;
+
+
+-- the last number given to the documents of each kind of a photographer, the numbers are taken in the transaction
+-- of the payment or the refund so that a rolled back one leaves no gap
+CREATE TABLE document_sequences (
+  photographer_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  kind varchar(255) NOT NULL,
+  last_sequence integer NOT NULL,
+  PRIMARY KEY (photographer_id, kind)
+);
+
+
+-- the documents are financial records, they outlive the accounts of both parties
+CREATE TABLE booking_documents (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE RESTRICT,
+  instalment_id UUID REFERENCES booking_instalments (id) ON DELETE RESTRICT,
+  photographer_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  customer_id UUID NOT NULL REFERENCES users (id) ON DELETE RESTRICT,
+  kind varchar(255) NOT NULL,
+  sequence integer NOT NULL,
+  number varchar(255) NOT NULL,
+  amount integer NOT NULL,
+  currency varchar(3) NOT NULL,
+  status varchar(255) NOT NULL,
+  object_key varchar(255),
+  error varchar,
+  issued_at timestamptz NOT NULL DEFAULT now(),
+  rendered_at timestamptz,
+  UNIQUE (photographer_id, kind, sequence)
+);
+
+
+CREATE INDEX booking_document_booking_id_idx ON booking_documents (booking_id);
@@ -0,0 +1,22 @@
+-- a user has at most one feed, a new one replaces it
+CREATE TABLE calendar_feeds (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  user_id UUID NOT NULL UNIQUE REFERENCES users (id) ON DELETE CASCADE,
+  token_hash varchar(64) NOT NULL UNIQUE,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+-- replaced as a whole by every import
+CREATE TABLE busy_blocks (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  photographer_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  uid varchar NOT NULL,
+  summary varchar,
+  start_time timestamptz NOT NULL,
+  end_time timestamptz NOT NULL CHECK (end_time > start_time),
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX busy_block_photographer_id_idx ON busy_blocks (photographer_id, start_time);
@@ -0,0 +1,14 @@
+-- set by the photographer once the photos of a completed booking have been handed over
+ALTER TABLE bookings
+ADD COLUMN delivered_at timestamptz;
+
+
+-- the reminders and follow-ups already sent, a worker only sends those it manages to record
+CREATE TABLE booking_lifecycle_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+  subject_id UUID NOT NULL,
+  kind varchar(255) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  UNIQUE (kind, subject_id)
+);
@@ -0,0 +1,64 @@
+-- the registration of the users who signed up before this migration is not recorded, it is taken from their first
+-- activity and left empty for those without any, who are not counted in the growth
+ALTER TABLE users
+ADD COLUMN created_at timestamptz;
+
+
+UPDATE users
+SET
+  created_at = LEAST(
+    (
+      SELECT
+        min(created_at)
+      FROM
+        user_identities
+      WHERE
+        user_identities.user_id = users.id
+    ),
+    (
+      SELECT
+        min(created_at)
+      FROM
+        bookings
+      WHERE
+        bookings.customer_id = users.id
+    ),
+    (
+      SELECT
+        min(created_at)
+      FROM
+        conversations
+      WHERE
+        conversations.user_id = users.id
+    ),
+    (
+      SELECT
+        min(created_at)
+      FROM
+        issues
+      WHERE
+        issues.reporter_id = users.id
+    ),
+    (
+      SELECT
+        min(created_at)
+      FROM
+        verification_events
+      WHERE
+        verification_events.user_id = users.id
+    )
+  );
+
+
+ALTER TABLE users
+ALTER COLUMN created_at
+SET DEFAULT now();
+
+
+CREATE INDEX user_created_at_idx ON users (created_at);
+
+
+CREATE INDEX booking_created_at_idx ON bookings (created_at);
+
+
+CREATE INDEX verification_event_action_created_at_idx ON verification_events (action, created_at);
@@ -0,0 +1,28 @@
+-- the views are counted per gallery and day, the details and the photos of a gallery are counted apart
+CREATE TABLE gallery_views (
+  gallery_id UUID NOT NULL REFERENCES galleries (id) ON DELETE CASCADE,
+  day date NOT NULL,
+  kind varchar(255) NOT NULL,
+  views INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (gallery_id, day, kind)
+);
+
+
+-- the reviews written before this migration are dated at the end of their booking
+ALTER TABLE reviews
+ADD COLUMN created_at timestamptz NOT NULL DEFAULT now();
+
+
+UPDATE reviews
+SET
+  created_at = bookings.end_time
+FROM
+  bookings
+WHERE
+  bookings.id = reviews.booking_id;
+
+
+CREATE INDEX booking_session_start_time_idx ON booking_sessions (start_time);
+
+
+CREATE INDEX conversation_room_id_created_at_idx ON conversations (room_id, created_at);
@@ -0,0 +1,87 @@
+-- the subjects and the statuses are kept as text so that new ones do not need a migration
+ALTER TABLE issues
+ALTER COLUMN status DROP DEFAULT,
+ALTER COLUMN status TYPE varchar(255) USING status::text,
+ALTER COLUMN status SET DEFAULT 'OPEN',
+ALTER COLUMN subject DROP DEFAULT,
+ALTER COLUMN subject TYPE varchar(255) USING subject::text,
+ALTER COLUMN subject SET DEFAULT 'TECHNICAL';
+
+
+DROP TYPE issue_status;
+
+
+DROP TYPE issue_subject;
+
+
+-- the due date is the resolution target, the issues reported before this migration keep theirs
+ALTER TABLE issues
+ADD COLUMN priority varchar(255) NOT NULL DEFAULT 'NORMAL',
+ADD COLUMN assignee_id UUID REFERENCES users (id) ON DELETE SET NULL,
+ADD COLUMN first_response_due_at timestamptz,
+ADD COLUMN first_responded_at timestamptz,
+ADD COLUMN closed_at timestamptz,
+ADD COLUMN updated_at timestamptz NOT NULL DEFAULT now();
+
+
+UPDATE issues
+SET
+  first_response_due_at = LEAST(created_at + interval '8 hours', due_date);
+
+
+ALTER TABLE issues
+ALTER COLUMN first_response_due_at SET NOT NULL,
+ALTER COLUMN first_response_due_at SET DEFAULT now();
+
+
+CREATE INDEX issue_assignee_id_idx ON issues (assignee_id, status);
+
+
+CREATE INDEX issue_status_due_date_idx ON issues (status, due_date);
+
+
+CREATE TABLE issue_sla_targets (
+  priority varchar(255) PRIMARY KEY,
+  first_response_minutes INTEGER NOT NULL,
+  resolution_minutes INTEGER NOT NULL,
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+INSERT INTO
+  issue_sla_targets (priority, first_response_minutes, resolution_minutes)
+VALUES
+  ('URGENT', 60, 8 * 60),
+  ('HIGH', 4 * 60, 24 * 60),
+  ('NORMAL', 8 * 60, 3 * 24 * 60),
+  ('LOW', 24 * 60, 7 * 24 * 60);
+
+
+CREATE TABLE issue_messages (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  issue_id UUID NOT NULL REFERENCES issues (id) ON DELETE CASCADE,
+  author_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  from_agent BOOLEAN NOT NULL DEFAULT FALSE,
+  body varchar(4000) NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX issue_message_issue_id_idx ON issue_messages (issue_id, created_at);
+
+
+CREATE INDEX issue_message_author_id_idx ON issue_messages (author_id);
+
+
+CREATE TABLE issue_attachments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  message_id UUID NOT NULL REFERENCES issue_messages (id) ON DELETE CASCADE,
+  object_key varchar(2000) NOT NULL,
+  filename varchar(255) NOT NULL,
+  content_type varchar(255) NOT NULL,
+  size INTEGER NOT NULL,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX issue_attachment_message_id_idx ON issue_attachments (message_id);
@@ -0,0 +1,3 @@
+-- NO ACTION
+SELECT
+  1
@@ -0,0 +1,51 @@
+ALTER TYPE booking_status
+ADD VALUE 'DISPUTED';
+
+
+-- the booking is DISPUTED while its dispute is open so that it is not paid out, booking_status is the status it
+-- goes back to if the dispute is withdrawn and the amounts are in the currency the booking has been paid in
+CREATE TABLE disputes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  booking_id UUID NOT NULL REFERENCES bookings (id) ON DELETE CASCADE,
+  opened_by_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  reason varchar(255) NOT NULL,
+  description varchar(4000) NOT NULL,
+  status varchar(255) NOT NULL DEFAULT 'OPEN',
+  booking_status varchar(255) NOT NULL,
+  mediator_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  disputed_amount INTEGER NOT NULL,
+  currency varchar(255) NOT NULL,
+  customer_amount INTEGER,
+  photographer_amount INTEGER,
+  resolution varchar(4000),
+  resolved_at timestamptz,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE UNIQUE INDEX dispute_booking_id_open_idx ON disputes (booking_id)
+WHERE
+  status = 'OPEN';
+
+
+CREATE INDEX dispute_status_idx ON disputes (status, created_at);
+
+
+-- the chat excerpts are copied when they are submitted so that the evidence does not change afterwards
+CREATE TABLE dispute_evidence (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  dispute_id UUID NOT NULL REFERENCES disputes (id) ON DELETE CASCADE,
+  submitted_by_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  party varchar(255) NOT NULL,
+  text varchar(4000),
+  photo_keys varchar(2000) [] NOT NULL DEFAULT '{}',
+  excerpt jsonb,
+  created_at timestamptz NOT NULL DEFAULT now()
+);
+
+
+CREATE INDEX dispute_evidence_dispute_id_idx ON dispute_evidence (dispute_id, created_at);
+
+
+CREATE INDEX dispute_evidence_submitted_by_id_idx ON dispute_evidence (submitted_by_id);
@@ -0,0 +1,50 @@
+-- every entry chains the hash of the one before it, seq has no gaps so that a removed entry is noticed too
+CREATE TABLE audit_entries (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+  seq BIGINT NOT NULL UNIQUE,
+  actor_id UUID,
+  action varchar(255) NOT NULL,
+  target_type varchar(255),
+  target_id UUID,
+  changes jsonb,
+  ip varchar(255) NOT NULL DEFAULT '',
+  request_id varchar(255) NOT NULL DEFAULT '',
+  method varchar(16),
+  path varchar(2000),
+  status_code INTEGER,
+  created_at timestamptz NOT NULL DEFAULT now(),
+  prev_hash varchar(64) NOT NULL,
+  hash varchar(64) NOT NULL
+);
+
+
+CREATE INDEX audit_entry_actor_id_idx ON audit_entries (actor_id, seq);
+
+
+CREATE INDEX audit_entry_target_idx ON audit_entries (target_type, target_id, seq);
+
+
+CREATE INDEX audit_entry_action_idx ON audit_entries (action, seq);
+
+
+CREATE INDEX audit_entry_request_id_idx ON audit_entries (request_id);
+
+
+CREATE INDEX audit_entry_created_at_idx ON audit_entries (created_at);
+
+
+CREATE FUNCTION reject_audit_entry_change () RETURNS TRIGGER AS $$
+BEGIN
+  RAISE EXCEPTION 'the audit log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+
+CREATE TRIGGER audit_entries_append_only BEFORE
+UPDATE
+OR DELETE ON audit_entries FOR EACH ROW
+EXECUTE FUNCTION reject_audit_entry_change ();
+
+
+CREATE TRIGGER audit_entries_no_truncate BEFORE TRUNCATE ON audit_entries FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_entry_change ();
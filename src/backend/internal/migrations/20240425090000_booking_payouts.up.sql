@@ -0,0 +1,30 @@
+-- the amount paid out to the photographer in the currency the booking has been paid in, the share of the
+-- photographer when a dispute of the booking has been resolved and everything paid otherwise
+ALTER TABLE bookings
+ADD COLUMN payout_amount INTEGER;
+
+
+UPDATE bookings
+SET
+  payout_amount = COALESCE(
+    (
+      SELECT
+        disputes.photographer_amount
+      FROM
+        disputes
+      WHERE
+        disputes.booking_id = bookings.id
+        AND disputes.status = 'RESOLVED'
+    ),
+    (
+      SELECT
+        COALESCE(sum(booking_instalments.paid_amount), 0)
+      FROM
+        booking_instalments
+      WHERE
+        booking_instalments.booking_id = bookings.id
+        AND booking_instalments.status = 'PAID'
+    )
+  )
+WHERE
+  status = 'PAID_OUT';
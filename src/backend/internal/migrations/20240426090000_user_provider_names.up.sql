@@ -0,0 +1,6 @@
+-- the users signed up with Google before the providers were named in lowercase
+UPDATE users
+SET
+  provider = lower(provider)
+WHERE
+  provider <> lower(provider);
@@ -0,0 +1,17 @@
+-- the galleries of erased photographers are kept for their bookings but no longer shown
+ALTER TABLE galleries
+ADD COLUMN hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+
+UPDATE galleries
+SET
+  hidden = TRUE
+WHERE
+  photographer_id IN (
+    SELECT
+      id
+    FROM
+      users
+    WHERE
+      deleted_at IS NOT NULL
+  );
@@ -2,7 +2,8 @@ package migrations
 
 import (
 	"embed"
-	"log"
+	"log/slog"
+	"os"
 
 	"github.com/uptrace/bun/migrate"
 )
@@ -14,6 +15,7 @@ var migrationFiles embed.FS
 
 func init() {
 	if err := Migrations.Discover(migrationFiles); err != nil {
-		log.Fatalln(err.Error())
+		slog.Error("could not discover the migrations", "error", err)
+		os.Exit(1)
 	}
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"mime/multipart"
 	"time"
 
@@ -17,10 +18,11 @@ type (
 )
 
 const (
-	PhotographerNotVerifiedStatus = "NOT_VERIFIED"
-	PhotographerPendingStatus     = "PENDING"
-	PhotographerVerifiedStatus    = "VERIFIED"
-	PhotographerRejectedStatus    = "REJECTED"
+	PhotographerNotVerifiedStatus   = "NOT_VERIFIED"
+	PhotographerPendingStatus       = "PENDING"
+	PhotographerVerifiedStatus      = "VERIFIED"
+	PhotographerRejectedStatus      = "REJECTED"
+	PhotographerInfoRequestedStatus = "INFO_REQUESTED"
 )
 
 const (
@@ -31,21 +33,56 @@ const (
 
 type User struct {
 	bun.BaseModel      `bun:"table:users,alias:u"`
-	Id                 uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	Username           string    `bun:"username,type:varchar" json:"username"`
-	Email              string    `bun:"email,type:varchar" json:"email"`
-	Provider           *string   `bun:"provider,type:varchar" json:"provider"`
-	Password           *string   `bun:"password,type:varchar" json:"-"`
-	LoggedOut          bool      `bun:"logged_out,type:boolean" json:"logged_out"`
-	ProfilePictureKey  *string   `bun:"profile_picture_key,type:varchar" json:"profile_picture_key"`
-	Firstname          string    `bun:"firstname,type:varchar" json:"firstname"`
-	Lastname           string    `bun:"lastname,type:varchar" json:"lastname"`
-	VerificationStatus string    `bun:"verification_status,type:varchar" json:"verification_status"`
-	IsAdmin            bool      `bun:"is_admin,type:boolean" json:"is_admin"`
-	About              *string   `bun:"about,type:varchar" json:"about"`
-	Address            *string   `bun:"address,type:varchar" json:"address"`
-	PhoneNumber        *string   `bun:"phone_number,type:varchar" json:"phone_number"`
-	Gender             *string   `bun:"gender,type:varchar" json:"gender"`
+	Id                 uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Username           string     `bun:"username,type:varchar" json:"username"`
+	Email              string     `bun:"email,type:varchar" json:"email"`
+	Provider           *string    `bun:"provider,type:varchar" json:"provider"`
+	Password           *string    `bun:"password,type:varchar" json:"-"`
+	LoggedOut          bool       `bun:"logged_out,type:boolean" json:"logged_out"`
+	ProfilePictureKey  *string    `bun:"profile_picture_key,type:varchar" json:"profile_picture_key"`
+	Firstname          string     `bun:"firstname,type:varchar" json:"firstname"`
+	Lastname           string     `bun:"lastname,type:varchar" json:"lastname"`
+	VerificationStatus string     `bun:"verification_status,type:varchar" json:"verification_status"`
+	IsAdmin            bool       `bun:"is_admin,type:boolean" json:"is_admin"`
+	About              *string    `bun:"about,type:varchar" json:"about"`
+	Address            *string    `bun:"address,type:varchar" json:"address"`
+	PhoneNumber        *string    `bun:"phone_number,type:varchar" json:"phone_number"`
+	Gender             *string    `bun:"gender,type:varchar" json:"gender"`
+	TaxId              *string    `bun:"tax_id,type:varchar" json:"tax_id"`
+	CreatedAt          *time.Time `bun:"created_at,nullzero,type:timestamptz,default:now()" json:"created_at"`
+	DeletedAt          *time.Time `bun:"deleted_at,nullzero,type:timestamptz" json:"-"`
+}
+
+type UserIdentity struct {
+	bun.BaseModel `bun:"table:user_identities,alias:identities"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId        uuid.UUID `bun:"user_id,type:uuid" json:"-"`
+	Provider      string    `bun:"provider,type:varchar" json:"provider"`
+	Subject       string    `bun:"subject,type:varchar" json:"-"`
+	Email         *string   `bun:"email,type:varchar" json:"email"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+const (
+	DataExportPendingStatus   = "PENDING"
+	DataExportCompletedStatus = "COMPLETED"
+	DataExportFailedStatus    = "FAILED"
+)
+
+type DataExport struct {
+	bun.BaseModel `bun:"table:data_exports,alias:exports"`
+	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId        uuid.UUID  `bun:"user_id,type:uuid" json:"-"`
+	Status        string     `bun:"status,type:varchar" json:"status"`
+	ObjectKey     *string    `bun:"object_key,type:varchar" json:"-"`
+	Error         *string    `bun:"error,type:varchar" json:"error"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	CompletedAt   *time.Time `bun:"completed_at,nullzero,type:timestamptz" json:"completed_at"`
+	ExpiresAt     *time.Time `bun:"expires_at,nullzero,type:timestamptz" json:"expires_at"`
+}
+
+type AccountErasureInput struct {
+	Confirmation string `json:"confirmation" example:"test@mail.com"`
 }
 
 type UserInput struct {
@@ -65,19 +102,62 @@ type UserUpdateInput struct {
 	About       *string `json:"about" example:"Hello"`
 	Username    *string `json:"username" example:"test"`
 	Address     *string `json:"address" example:"Bangkok"`
+	TaxId       *string `json:"tax_id" example:"1234567890123"`
 }
 
+const (
+	VerificationTicketPendingStatus       = "PENDING"
+	VerificationTicketApprovedStatus      = "APPROVED"
+	VerificationTicketRejectedStatus      = "REJECTED"
+	VerificationTicketInfoRequestedStatus = "INFO_REQUESTED"
+)
+
 type VerificationTicket struct {
 	bun.BaseModel         `bun:"table:verification_ticket,alias:vrf_ticket"`
-	Id                    uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	UserId                uuid.UUID `bun:"user_id,type:uuid" json:"-"`
-	User                  User      `bun:"-" json:"user"`
-	IdCardNumber          string    `bun:"id_card_number,type:varchar" json:"id_card_number"`
-	IdCardPictureKey      string    `bun:"id_card_picture_key,type:varchar" json:"-"`
-	IdCardPictureURL      string    `bun:"-" json:"id_card_picture_url"`
-	AdditionalDescription *string   `bun:"additional_desc,type:varchar" json:"additional_desc"`
-	CreatedAt             time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
-	DueDate               time.Time `bun:"due_date,type:timestamptz,default:now()" json:"due_date"`
+	Id                    uuid.UUID            `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId                uuid.UUID            `bun:"user_id,type:uuid" json:"-"`
+	User                  User                 `bun:"-" json:"user"`
+	IdCardNumber          string               `bun:"id_card_number,type:varchar" json:"id_card_number"`
+	IdCardNumberIndex     string               `bun:"id_card_number_index,type:varchar,nullzero" json:"-"`
+	IdCardPictureKey      string               `bun:"id_card_picture_key,type:varchar" json:"-"`
+	IdCardPictureURL      string               `bun:"-" json:"id_card_picture_url"`
+	AdditionalDescription *string              `bun:"additional_desc,type:varchar" json:"additional_desc"`
+	CreatedAt             time.Time            `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	DueDate               time.Time            `bun:"due_date,type:timestamptz,default:now()" json:"due_date"`
+	Status                string               `bun:"status,type:varchar" json:"status"`
+	PreviousTicketId      *uuid.UUID           `bun:"previous_ticket_id,type:uuid" json:"previous_ticket_id"`
+	ReviewerId            *uuid.UUID           `bun:"reviewer_id,type:uuid" json:"reviewer_id"`
+	DecisionReason        *string              `bun:"decision_reason,type:varchar" json:"decision_reason"`
+	ReviewedAt            *time.Time           `bun:"reviewed_at,nullzero,type:timestamptz" json:"reviewed_at"`
+	Events                []*VerificationEvent `bun:"-" json:"events,omitempty"`
+}
+
+const (
+	VerificationSubmittedAction     = "SUBMITTED"
+	VerificationResubmittedAction   = "RESUBMITTED"
+	VerificationApprovedAction      = "APPROVED"
+	VerificationRejectedAction      = "REJECTED"
+	VerificationInfoRequestedAction = "INFO_REQUESTED"
+)
+
+// VerificationEvent is an append-only entry in the verification history of a photographer
+type VerificationEvent struct {
+	bun.BaseModel `bun:"table:verification_events,alias:vrf_events"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId        uuid.UUID `bun:"user_id,type:uuid" json:"user_id"`
+	TicketId      uuid.UUID `bun:"ticket_id,type:uuid" json:"ticket_id"`
+	ActorId       uuid.UUID `bun:"actor_id,type:uuid" json:"actor_id"`
+	Action        string    `bun:"action,type:varchar" json:"action"`
+	Reason        *string   `bun:"reason,type:varchar" json:"reason"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type VerificationDecisionInput struct {
+	Reason *string `json:"reason" example:"The picture of the ID card is blurry"`
+}
+
+type VerificationTicketFilter struct {
+	Status *string `form:"status" binding:"omitempty,oneof=PENDING APPROVED REJECTED INFO_REQUESTED"`
 }
 
 type VerificationTicketInput struct {
@@ -98,16 +178,191 @@ type Gallery struct {
 	Description    *string   `bun:"description,type:varchar" json:"description"`
 	DeliveryTime   int       `bun:"delivery_time,type:integer" json:"delivery_time"`
 	Included       []string  `bun:",array" json:"included"`
+	DepositPercent int       `bun:"deposit_percent,type:integer" json:"deposit_percent"`
+	BalanceDueDays int       `bun:"balance_due_days,type:integer" json:"balance_due_days"`
+	Currency       string    `bun:"currency,type:varchar" json:"currency"`
+	Hidden         bool      `bun:"hidden,type:boolean" json:"-"`
+	EstimatedPrice *Price    `bun:"-" json:"estimated_price,omitempty"`
 }
 
+// the deposit is the share of the price paid up front, the balance is due BalanceDueDays before the first session
 type GalleryInput struct {
-	Name         *string  `bun:"name,type:varchar" json:"name"`
-	Location     *string  `bun:"name,type:varchar" json:"location"`
-	Price        *int     `bun:"price,type:integer" json:"price"`
-	Hours        *int     `bun:"hours,type:integer" json:"hours"`
-	Description  *string  `bun:"description,type:varchar" json:"description"`
-	DeliveryTime *int     `bun:"delivery_time,type:integer" json:"delivery_time"`
-	Included     []string `bun:",array" json:"included"`
+	Name           *string  `bun:"name,type:varchar" json:"name"`
+	Location       *string  `bun:"name,type:varchar" json:"location"`
+	Price          *int     `bun:"price,type:integer" json:"price"`
+	Hours          *int     `bun:"hours,type:integer" json:"hours"`
+	Description    *string  `bun:"description,type:varchar" json:"description"`
+	DeliveryTime   *int     `bun:"delivery_time,type:integer" json:"delivery_time"`
+	Included       []string `bun:",array" json:"included"`
+	DepositPercent *int     `bun:"deposit_percent,type:integer" json:"deposit_percent" example:"30"`
+	BalanceDueDays *int     `bun:"balance_due_days,type:integer" json:"balance_due_days" example:"7"`
+	Currency       *string  `bun:"currency,type:varchar" json:"currency" example:"THB"`
+}
+
+const (
+	AddOnPerBookingUnit = "PER_BOOKING"
+	AddOnPerSessionUnit = "PER_SESSION"
+	AddOnPerHourUnit    = "PER_HOUR"
+)
+
+type GalleryAddOn struct {
+	bun.BaseModel `bun:"table:gallery_add_ons,alias:gallery_add_ons"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	GalleryId     uuid.UUID `bun:"gallery_id,type:uuid" json:"-"`
+	Name          string    `bun:"name,type:varchar" json:"name"`
+	Description   *string   `bun:"description,type:varchar" json:"description"`
+	Price         int       `bun:"price,type:integer" json:"price"`
+	Unit          string    `bun:"unit,type:varchar" json:"unit"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt     time.Time `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+type GalleryAddOnInput struct {
+	Name        *string `json:"name" example:"Second shooter"`
+	Description *string `json:"description"`
+	Price       *int    `json:"price" example:"3000"`
+	Unit        *string `json:"unit" example:"PER_SESSION"`
+}
+
+const (
+	PricingWeekendSurchargeKind = "WEEKEND_SURCHARGE"
+	PricingHolidaySurchargeKind = "HOLIDAY_SURCHARGE"
+	PricingSeasonalDiscountKind = "SEASONAL_DISCOUNT"
+)
+
+// a rule changes the price of the sessions it applies to by Percent, a weekend surcharge applies to every
+// Saturday and Sunday within the optional dates while the other rules need both dates
+type PricingRule struct {
+	bun.BaseModel `bun:"table:pricing_rules,alias:pricing_rules"`
+	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	GalleryId     uuid.UUID  `bun:"gallery_id,type:uuid" json:"-"`
+	Kind          string     `bun:"kind,type:varchar" json:"kind"`
+	Name          string     `bun:"name,type:varchar" json:"name"`
+	Percent       int        `bun:"percent,type:integer" json:"percent"`
+	StartDate     *time.Time `bun:"start_date,type:date" json:"start_date"`
+	EndDate       *time.Time `bun:"end_date,type:date" json:"end_date"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type PricingRuleInput struct {
+	Kind      string     `json:"kind" binding:"required" example:"HOLIDAY_SURCHARGE"`
+	Name      string     `json:"name" binding:"required" example:"New Year"`
+	Percent   int        `json:"percent" binding:"required,min=1,max=100" example:"25"`
+	StartDate *time.Time `json:"start_date" example:"2024-12-31T00:00:00Z"`
+	EndDate   *time.Time `json:"end_date" example:"2025-01-01T00:00:00Z"`
+}
+
+type GalleryPricing struct {
+	AddOns []*GalleryAddOn `json:"add_ons"`
+	Rules  []*PricingRule  `json:"rules"`
+}
+
+const (
+	LineItemBaseKind       = "BASE"
+	LineItemAddOnKind      = "ADD_ON"
+	LineItemSurchargeKind  = "SURCHARGE"
+	LineItemDiscountKind   = "DISCOUNT"
+	LineItemAdjustmentKind = "ADJUSTMENT"
+	LineItemCouponKind     = "COUPON"
+)
+
+type BookingLineItem struct {
+	bun.BaseModel `bun:"table:booking_line_items,alias:booking_line_items"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	BookingId     uuid.UUID `bun:"booking_id,type:uuid" json:"-"`
+	Position      int       `bun:"position,type:integer" json:"-"`
+	Kind          string    `bun:"kind,type:varchar" json:"kind"`
+	Description   string    `bun:"description,type:varchar" json:"description"`
+	Quantity      int       `bun:"quantity,type:integer" json:"quantity"`
+	UnitPrice     int       `bun:"unit_price,type:integer" json:"unit_price"`
+	Amount        int       `bun:"amount,type:integer" json:"amount"`
+}
+
+type Quote struct {
+	LineItems []*BookingLineItem `json:"line_items"`
+	Total     int                `json:"total"`
+	Currency  string             `json:"currency"`
+}
+
+type BookingAddOnInput struct {
+	AddOnId  uuid.UUID `json:"add_on_id"`
+	Quantity int       `json:"quantity" example:"1"`
+}
+
+const (
+	BookingRequestOpenStatus      = "OPEN"
+	BookingRequestAcceptedStatus  = "ACCEPTED"
+	BookingRequestDeclinedStatus  = "DECLINED"
+	BookingRequestWithdrawnStatus = "WITHDRAWN"
+)
+
+// a customer asks for a booking of a gallery, the photographer answers with a quote
+type BookingRequest struct {
+	bun.BaseModel `bun:"table:booking_requests,alias:booking_requests"`
+	Id            uuid.UUID             `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	CustomerId    uuid.UUID             `bun:"customer_id,type:uuid" json:"customer_id"`
+	GalleryId     uuid.UUID             `bun:"gallery_id,type:uuid" json:"gallery_id"`
+	RoomId        uuid.UUID             `bun:"room_id,type:uuid" json:"room_id"`
+	Status        string                `bun:"status,type:varchar" json:"status"`
+	Notes         *string               `bun:"notes,type:varchar" json:"notes"`
+	Sessions      []BookingSessionInput `bun:"sessions,type:jsonb" json:"sessions"`
+	AddOns        []BookingAddOnInput   `bun:"add_ons,type:jsonb" json:"add_ons"`
+	BookingId     *uuid.UUID            `bun:"booking_id,type:uuid" json:"booking_id"`
+	CreatedAt     time.Time             `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt     time.Time             `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+	Quotes        []*BookingQuote       `bun:"-" json:"quotes,omitempty"`
+}
+
+// the desired sessions are either listed or repeated like the sessions of a booking proposal
+type BookingRequestInput struct {
+	GalleryId  uuid.UUID             `binding:"required" json:"gallery_id"`
+	Notes      *string               `json:"notes" example:"Outdoor shoot, golden hour if possible"`
+	StartTime  time.Time             `json:"start_time"`
+	EndTime    time.Time             `json:"end_time"`
+	Sessions   []BookingSessionInput `json:"sessions"`
+	Recurrence *BookingRecurrence    `json:"recurrence"`
+	AddOns     []BookingAddOnInput   `json:"add_ons"`
+}
+
+type BookingRequestFilter struct {
+	Status *string `form:"status"`
+}
+
+const (
+	BookingQuotePendingStatus   = "PENDING"
+	BookingQuoteCounteredStatus = "COUNTERED"
+	BookingQuoteAcceptedStatus  = "ACCEPTED"
+	BookingQuoteDeclinedStatus  = "DECLINED"
+	BookingQuoteExpiredStatus   = "EXPIRED"
+)
+
+// every counter-offer is a new quote of the next round, only the latest one of a request can be pending
+type BookingQuote struct {
+	bun.BaseModel   `bun:"table:booking_quotes,alias:booking_quotes"`
+	Id              uuid.UUID             `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	RequestId       uuid.UUID             `bun:"request_id,type:uuid" json:"request_id"`
+	AuthorId        uuid.UUID             `bun:"author_id,type:uuid" json:"author_id"`
+	Round           int                   `bun:"round,type:integer" json:"round"`
+	Status          string                `bun:"status,type:varchar" json:"status"`
+	Sessions        []BookingSessionInput `bun:"sessions,type:jsonb" json:"sessions"`
+	AddOns          []BookingAddOnInput   `bun:"add_ons,type:jsonb" json:"add_ons"`
+	NegotiatedPrice *int                  `bun:"negotiated_price,type:integer" json:"negotiated_price"`
+	LineItems       []*BookingLineItem    `bun:"line_items,type:jsonb" json:"line_items"`
+	Total           int                   `bun:"total,type:integer" json:"total"`
+	Currency        string                `bun:"currency,type:varchar" json:"currency"`
+	Message         *string               `bun:"message,type:varchar" json:"message"`
+	ExpiresAt       time.Time             `bun:"expires_at,type:timestamptz" json:"expires_at"`
+	CreatedAt       time.Time             `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt       time.Time             `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+// the sessions and the add-ons left out are carried over from the previous round
+type BookingQuoteInput struct {
+	Sessions        []BookingSessionInput `json:"sessions"`
+	AddOns          []BookingAddOnInput   `json:"add_ons"`
+	NegotiatedPrice *int                  `json:"negotiated_price" example:"25000"`
+	Message         *string               `json:"message" example:"Includes travel to the venue"`
+	ExpiresInHours  int                   `binding:"omitempty,min=1,max=720" json:"expires_in_hours" example:"72"`
 }
 
 const (
@@ -119,23 +374,233 @@ const (
 	BookingCompletedStatus             = "COMPLETED"
 	BookingPaidOutStatus               = "PAID_OUT"
 	BookingRefundReqStatus             = "REQ_REFUND"
+	BookingPartiallyPaidStatus         = "PARTIALLY_PAID"
+	BookingDisputedStatus              = "DISPUTED"
 )
 
+const (
+	InstalmentDepositKind = "DEPOSIT"
+	InstalmentBalanceKind = "BALANCE"
+	InstalmentFullKind    = "FULL"
+)
+
+const (
+	InstalmentPendingStatus   = "PENDING"
+	InstalmentPaidStatus      = "PAID"
+	InstalmentCancelledStatus = "CANCELLED"
+)
+
+// the instalments of a booking are paid in the order of their position
+type BookingInstalment struct {
+	bun.BaseModel `bun:"table:booking_instalments,alias:booking_instalments"`
+	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	BookingId     uuid.UUID  `bun:"booking_id,type:uuid" json:"-"`
+	Position      int        `bun:"position,type:integer" json:"-"`
+	Kind          string     `bun:"kind,type:varchar" json:"kind"`
+	Amount        int        `bun:"amount,type:integer" json:"amount"`
+	DueAt         time.Time  `bun:"due_at,type:timestamptz" json:"due_at"`
+	Status        string     `bun:"status,type:varchar" json:"status"`
+	PaidAt        *time.Time `bun:"paid_at,nullzero,type:timestamptz" json:"paid_at"`
+	PaidAmount    *int       `bun:"paid_amount,type:integer" json:"paid_amount"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt     time.Time  `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+// BaseCurrency is the currency the exchange rates are quoted against
+const BaseCurrency = "THB"
+
+const (
+	ExchangeRateAdminSource = "ADMIN"
+	ExchangeRateFileSource  = "FILE"
+)
+
+// the rate is the amount of the currency that one unit of the base currency buys
+type ExchangeRate struct {
+	bun.BaseModel `bun:"table:exchange_rates,alias:exchange_rates"`
+	Currency      string    `bun:"currency,pk,type:varchar" json:"currency"`
+	Rate          float64   `bun:"rate,type:numeric" json:"rate"`
+	Source        string    `bun:"source,type:varchar" json:"source"`
+	UpdatedAt     time.Time `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+// the rates are keyed by the ISO 4217 code of their currency, the file imported by the rates command has the same shape
+type ExchangeRateInput struct {
+	Rates map[string]float64 `binding:"required" json:"rates" example:"USD:0.0274"`
+}
+
+type Price struct {
+	Amount   int    `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+const (
+	CouponPercentageKind = "PERCENTAGE"
+	CouponFixedKind      = "FIXED"
+)
+
+// a coupon without a photographer is platform-wide, the empty restrictions leave every gallery and location eligible
+type Coupon struct {
+	bun.BaseModel         `bun:"table:coupons,alias:coupons"`
+	Id                    uuid.UUID   `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Code                  string      `bun:"code,type:varchar" json:"code"`
+	PhotographerId        *uuid.UUID  `bun:"photographer_id,type:uuid" json:"photographer_id"`
+	Kind                  string      `bun:"kind,type:varchar" json:"kind"`
+	Value                 int         `bun:"value,type:integer" json:"value"`
+	Currency              string      `bun:"currency,type:varchar" json:"currency"`
+	MinSpend              int         `bun:"min_spend,type:integer" json:"min_spend"`
+	StartsAt              *time.Time  `bun:"starts_at,nullzero,type:timestamptz" json:"starts_at"`
+	EndsAt                *time.Time  `bun:"ends_at,nullzero,type:timestamptz" json:"ends_at"`
+	MaxRedemptions        *int        `bun:"max_redemptions,type:integer" json:"max_redemptions"`
+	MaxRedemptionsPerUser *int        `bun:"max_redemptions_per_user,type:integer" json:"max_redemptions_per_user"`
+	GalleryIds            []uuid.UUID `bun:"gallery_ids,type:uuid[],array" json:"gallery_ids"`
+	Locations             []string    `bun:"locations,array" json:"locations"`
+	IsActive              bool        `bun:"is_active,type:boolean" json:"is_active"`
+	CreatedBy             *uuid.UUID  `bun:"created_by,type:uuid" json:"created_by"`
+	CreatedAt             time.Time   `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt             time.Time   `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+// the value is a percentage of the price or an amount off it, the code is matched case-insensitively. The amount
+// off and the minimum spend are in the currency of the coupon and converted to the one of the booking.
+type CouponInput struct {
+	Code                  *string     `json:"code" example:"SUMMER24"`
+	Kind                  *string     `json:"kind" example:"PERCENTAGE"`
+	Value                 *int        `json:"value" example:"10"`
+	Currency              *string     `json:"currency" example:"THB"`
+	MinSpend              *int        `json:"min_spend" example:"5000"`
+	StartsAt              *time.Time  `json:"starts_at" example:"2024-06-01T00:00:00Z"`
+	EndsAt                *time.Time  `json:"ends_at" example:"2024-08-31T23:59:59Z"`
+	MaxRedemptions        *int        `json:"max_redemptions" example:"100"`
+	MaxRedemptionsPerUser *int        `json:"max_redemptions_per_user" example:"1"`
+	GalleryIds            []uuid.UUID `json:"gallery_ids"`
+	Locations             []string    `json:"locations" example:"Bangkok"`
+	IsActive              *bool       `json:"is_active"`
+}
+
+type CouponFilter struct {
+	PhotographerId *string `binding:"omitempty,uuid" form:"photographer_id"`
+	IsActive       *bool   `form:"is_active"`
+}
+
+// the record of a coupon taking Amount off the price of a booking, the price before it is kept as Subtotal
+type CouponRedemption struct {
+	bun.BaseModel `bun:"table:coupon_redemptions,alias:coupon_redemptions"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	CouponId      uuid.UUID `bun:"coupon_id,type:uuid" json:"coupon_id"`
+	BookingId     uuid.UUID `bun:"booking_id,type:uuid" json:"booking_id"`
+	UserId        uuid.UUID `bun:"user_id,type:uuid" json:"user_id"`
+	Code          string    `bun:"code,type:varchar" json:"code"`
+	Subtotal      int       `bun:"subtotal,type:integer" json:"subtotal"`
+	Amount        int       `bun:"amount,type:integer" json:"amount"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type CouponRedemptionInput struct {
+	Code string `binding:"required" json:"code" example:"SUMMER24"`
+}
+
+const (
+	DocumentInvoiceKind    = "INVOICE"
+	DocumentReceiptKind    = "RECEIPT"
+	DocumentCreditNoteKind = "CREDIT_NOTE"
+)
+
+const (
+	DocumentPendingStatus = "PENDING"
+	DocumentIssuedStatus  = "ISSUED"
+	DocumentFailedStatus  = "FAILED"
+)
+
+// the number of a document is taken from the sequence of its photographer and its kind when the document is queued,
+// its pdf is rendered afterwards by the worker. The amount is in the currency the booking has been paid in, but for
+// the invoices which are in the currency of the booking.
+type BookingDocument struct {
+	bun.BaseModel  `bun:"table:booking_documents,alias:booking_documents"`
+	Id             uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	BookingId      uuid.UUID  `bun:"booking_id,type:uuid" json:"booking_id"`
+	InstalmentId   *uuid.UUID `bun:"instalment_id,type:uuid" json:"instalment_id"`
+	PhotographerId uuid.UUID  `bun:"photographer_id,type:uuid" json:"photographer_id"`
+	CustomerId     uuid.UUID  `bun:"customer_id,type:uuid" json:"customer_id"`
+	Kind           string     `bun:"kind,type:varchar" json:"kind"`
+	Sequence       int        `bun:"sequence,type:integer" json:"-"`
+	Number         string     `bun:"number,type:varchar" json:"number"`
+	Amount         int        `bun:"amount,type:integer" json:"amount"`
+	Currency       string     `bun:"currency,type:varchar" json:"currency"`
+	Status         string     `bun:"status,type:varchar" json:"status"`
+	ObjectKey      *string    `bun:"object_key,type:varchar" json:"-"`
+	Error          *string    `bun:"error,type:varchar" json:"error"`
+	IssuedAt       time.Time  `bun:"issued_at,type:timestamptz,default:now()" json:"issued_at"`
+	RenderedAt     *time.Time `bun:"rendered_at,nullzero,type:timestamptz" json:"rendered_at"`
+}
+
+type BookingDocumentFilter struct {
+	Kind      *string `form:"kind" binding:"omitempty,oneof=INVOICE RECEIPT CREDIT_NOTE"`
+	BookingId *string `form:"booking_id" binding:"omitempty,uuid"`
+}
+
+// the token of a feed is shown once, when the feed is created, only its hash is kept
+type CalendarFeed struct {
+	bun.BaseModel `bun:"table:calendar_feeds,alias:calendar_feeds"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId        uuid.UUID `bun:"user_id,type:uuid" json:"-"`
+	TokenHash     string    `bun:"token_hash,type:varchar" json:"-"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+// a busy block is an event imported from the calendar of a photographer, the sessions cannot be booked over it
+type BusyBlock struct {
+	bun.BaseModel  `bun:"table:busy_blocks,alias:busy_blocks"`
+	Id             uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	PhotographerId uuid.UUID `bun:"photographer_id,type:uuid" json:"-"`
+	Uid            string    `bun:"uid,type:varchar" json:"uid"`
+	Summary        *string   `bun:"summary,type:varchar" json:"summary"`
+	StartTime      time.Time `bun:"start_time,type:timestamptz" json:"start_time"`
+	EndTime        time.Time `bun:"end_time,type:timestamptz" json:"end_time"`
+	CreatedAt      time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+const (
+	BookingSessionScheduledStatus = "SCHEDULED"
+	BookingSessionCompletedStatus = "COMPLETED"
+	BookingSessionCancelledStatus = "CANCELLED"
+)
+
+const (
+	RecurrenceWeeklyFrequency  = "WEEKLY"
+	RecurrenceMonthlyFrequency = "MONTHLY"
+)
+
+// a proposal either lists its sessions, or gives the first one with StartTime and EndTime and
+// optionally a recurrence to repeat it
 type BookingProposal struct {
-	CustomerId      uuid.UUID `json:"customer_id"`
-	RoomId          uuid.UUID `bun:"room_id,type:uuid" json:"room_id"`
-	NegotiatedPrice *int      `json:"negotiated_price"`
-	StartTime       time.Time `bun:"start_time,type:timestamptz" json:"start_time"`
-	EndTime         time.Time `bun:"end_time,type:timestamptz" json:"end_time"`
+	CustomerId      uuid.UUID             `json:"customer_id"`
+	RoomId          uuid.UUID             `bun:"room_id,type:uuid" json:"room_id"`
+	NegotiatedPrice *int                  `json:"negotiated_price"`
+	StartTime       time.Time             `bun:"start_time,type:timestamptz" json:"start_time"`
+	EndTime         time.Time             `bun:"end_time,type:timestamptz" json:"end_time"`
+	Sessions        []BookingSessionInput `json:"sessions"`
+	Recurrence      *BookingRecurrence    `json:"recurrence"`
+	AddOns          []BookingAddOnInput   `json:"add_ons"`
 }
 
-type Booking struct {
-	bun.BaseModel `bun:"table:bookings,alias:bookings"`
+type BookingSessionInput struct {
+	Title     *string   `json:"title" example:"Engagement shoot"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// the first session is repeated every Interval weeks or months until there are Count sessions
+type BookingRecurrence struct {
+	Frequency string `json:"frequency" example:"MONTHLY"`
+	Interval  int    `json:"interval" example:"1"`
+	Count     int    `json:"count" example:"6"`
+}
+
+type BookingSession struct {
+	bun.BaseModel `bun:"table:booking_sessions,alias:booking_sessions"`
 	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	CustomerId    uuid.UUID `bun:"customer_id,type:uuid" json:"customer_id"`
-	RoomId        uuid.UUID `bun:"room_id,type:uuid" json:"-"`
-	Room          Room      `bun:"-" json:"room"`
-	ResultedPrice int       `bun:"resulted_price,type:integer" json:"resulted_price"`
+	BookingId     uuid.UUID `bun:"booking_id,type:uuid" json:"-"`
+	Title         *string   `bun:"title,type:varchar" json:"title"`
 	StartTime     time.Time `bun:"start_time,type:timestamptz" json:"start_time"`
 	EndTime       time.Time `bun:"end_time,type:timestamptz" json:"end_time"`
 	Status        string    `bun:"status,type:varchar" json:"status"`
@@ -143,6 +608,50 @@ type Booking struct {
 	UpdatedAt     time.Time `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
 }
 
+const (
+	LifecycleSessionReminderKind = "SESSION_REMINDER"
+	LifecycleReviewRequestKind   = "REVIEW_REQUEST"
+	LifecycleDeliveryOverdueKind = "DELIVERY_OVERDUE"
+)
+
+// a lifecycle event is recorded along with its notifications, so that it is only sent once whichever worker
+// gets to it first, the subject is the session of a reminder and the booking otherwise
+type BookingLifecycleEvent struct {
+	bun.BaseModel `bun:"table:booking_lifecycle_events,alias:booking_lifecycle_events"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()"`
+	BookingId     uuid.UUID `bun:"booking_id,type:uuid"`
+	SubjectId     uuid.UUID `bun:"subject_id,type:uuid"`
+	Kind          string    `bun:"kind,type:varchar"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()"`
+}
+
+// StartTime and EndTime of a booking span its sessions
+// the price is in the currency of the gallery, the rate to the currency the booking is paid in is locked by its
+// first payment
+type Booking struct {
+	bun.BaseModel   `bun:"table:bookings,alias:bookings"`
+	Id              uuid.UUID            `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	CustomerId      uuid.UUID            `bun:"customer_id,type:uuid" json:"customer_id"`
+	RoomId          uuid.UUID            `bun:"room_id,type:uuid" json:"-"`
+	Room            Room                 `bun:"-" json:"room"`
+	ResultedPrice   int                  `bun:"resulted_price,type:integer" json:"resulted_price"`
+	Currency        string               `bun:"currency,type:varchar" json:"currency"`
+	PaymentCurrency *string              `bun:"payment_currency,type:varchar" json:"payment_currency"`
+	ExchangeRate    *float64             `bun:"exchange_rate,type:numeric" json:"exchange_rate"`
+	RateLockedAt    *time.Time           `bun:"rate_locked_at,nullzero,type:timestamptz" json:"rate_locked_at"`
+	DeliveredAt     *time.Time           `bun:"delivered_at,nullzero,type:timestamptz" json:"delivered_at"`
+	PayoutAmount    *int                 `bun:"payout_amount,type:integer" json:"payout_amount"`
+	StartTime       time.Time            `bun:"start_time,type:timestamptz" json:"start_time"`
+	EndTime         time.Time            `bun:"end_time,type:timestamptz" json:"end_time"`
+	Status          string               `bun:"status,type:varchar" json:"status"`
+	CreatedAt       time.Time            `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt       time.Time            `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+	Sessions        []*BookingSession    `bun:"-" json:"sessions"`
+	LineItems       []*BookingLineItem   `bun:"-" json:"line_items"`
+	Instalments     []*BookingInstalment `bun:"-" json:"instalments"`
+}
+
+// with a currency the prices of the galleries are estimated in it, and the price bounds are in it too
 type SearchFilter struct {
 	PhotographerId                  *string `binding:"omitempty,uuid" form:"photographer_id"`
 	MatchedConditionPhotographerIds []uuid.UUID
@@ -151,6 +660,7 @@ type SearchFilter struct {
 	Location                        *string `form:"location"`
 	MinPrice                        *int    `form:"min_price"`
 	MaxPrice                        *int    `form:"max_price"`
+	Currency                        *string `form:"currency"`
 }
 
 type Room struct {
@@ -174,15 +684,26 @@ type UserRoomLookup struct {
 	DeletedAt     *time.Time `bun:"deleted_at,soft_delete,nullzero,type:timestamptz" json:"deleted_at"`
 }
 
+const (
+	ConversationMessageKind        = "MESSAGE"
+	ConversationBookingRequestKind = "BOOKING_REQUEST"
+	ConversationBookingQuoteKind   = "BOOKING_QUOTE"
+)
+
+// a card is a conversation posted by the server about a booking request or a quote, the text is its summary
 type Conversation struct {
-	bun.BaseModel `bun:"table:conversations,alias:convs"`
-	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	Text          string     `bun:"text,type:varchar" json:"text"`
-	UserId        uuid.UUID  `bun:"user_id,type:uuid" json:"user_id"`
-	RoomId        uuid.UUID  `bun:"room_id,type:uuid" json:"room_id"`
-	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
-	UpdatedAt     time.Time  `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
-	DeletedAt     *time.Time `bun:"deleted_at,soft_delete,nullzero,type:timestamptz" json:"deleted_at"`
+	bun.BaseModel  `bun:"table:conversations,alias:convs"`
+	Id             uuid.UUID       `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Text           string          `bun:"text,type:varchar" json:"text"`
+	Kind           string          `bun:"kind,type:varchar" json:"kind"`
+	ResourceId     *uuid.UUID      `bun:"resource_id,type:uuid" json:"resource_id"`
+	UserId         uuid.UUID       `bun:"user_id,type:uuid" json:"user_id"`
+	RoomId         uuid.UUID       `bun:"room_id,type:uuid" json:"room_id"`
+	CreatedAt      time.Time       `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt      time.Time       `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+	DeletedAt      *time.Time      `bun:"deleted_at,soft_delete,nullzero,type:timestamptz" json:"deleted_at"`
+	BookingRequest *BookingRequest `bun:"-" json:"booking_request,omitempty"`
+	BookingQuote   *BookingQuote   `bun:"-" json:"booking_quote,omitempty"`
 }
 
 type RoomMemberInput struct {
@@ -212,6 +733,7 @@ type Review struct {
 	Booking       Booking   `bun:"-" json:"booking"`
 	Rating        int       `bun:"rating,type:integer" json:"rating"`
 	ReviewText    *string   `bun:"review_text,type:varchar" json:"review_text"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
 }
 
 const (
@@ -222,37 +744,630 @@ const (
 const (
 	IssueRefundSubject    = "REFUND"
 	IssueTechnicalSubject = "TECHNICAL"
+	IssueBookingSubject   = "BOOKING"
+	IssuePaymentSubject   = "PAYMENT"
+	IssueAccountSubject   = "ACCOUNT"
+	IssueAbuseSubject     = "ABUSE"
+	IssueOtherSubject     = "OTHER"
+)
+
+const (
+	IssueLowPriority    = "LOW"
+	IssueNormalPriority = "NORMAL"
+	IssueHighPriority   = "HIGH"
+	IssueUrgentPriority = "URGENT"
 )
 
+// the due date is the target of the resolution and FirstResponseDueAt the one of the first reply of an agent, both
+// are set from the SLA target of the priority. The breach flags are computed when the issue is read.
 type Issue struct {
-	bun.BaseModel `bun:"table:issues,alias:issues"`
-	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
-	ReporterId    uuid.UUID  `bun:"reporter_id,type:uuid" json:"-"`
-	Reporter      User       `bun:"-" json:"reporter"`
-	BookingId     *uuid.UUID `bun:"booking_id,type:uuid" json:"-"`
-	Booking       *Booking   `bun:"-" json:"booking"`
-	Status        string     `bun:"status,type:varchar" json:"status"`
-	Subject       string     `bun:"subject,type:varchar" json:"subject"`
-	DueDate       time.Time  `bun:"due_date,type:timestamptz,default:now()" json:"due_date"`
-	Description   string     `bun:"description,type:varchar" json:"description"`
-	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	bun.BaseModel      `bun:"table:issues,alias:issues"`
+	Id                 uuid.UUID       `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	ReporterId         uuid.UUID       `bun:"reporter_id,type:uuid" json:"-"`
+	Reporter           User            `bun:"-" json:"reporter"`
+	BookingId          *uuid.UUID      `bun:"booking_id,type:uuid" json:"-"`
+	Booking            *Booking        `bun:"-" json:"booking"`
+	AssigneeId         *uuid.UUID      `bun:"assignee_id,type:uuid" json:"assignee_id"`
+	Status             string          `bun:"status,type:varchar" json:"status"`
+	Subject            string          `bun:"subject,type:varchar" json:"subject"`
+	Priority           string          `bun:"priority,type:varchar" json:"priority"`
+	DueDate            time.Time       `bun:"due_date,type:timestamptz,default:now()" json:"due_date"`
+	FirstResponseDueAt time.Time       `bun:"first_response_due_at,type:timestamptz,default:now()" json:"first_response_due_at"`
+	FirstRespondedAt   *time.Time      `bun:"first_responded_at,nullzero,type:timestamptz" json:"first_responded_at"`
+	ClosedAt           *time.Time      `bun:"closed_at,nullzero,type:timestamptz" json:"closed_at"`
+	ResponseBreached   bool            `bun:"-" json:"response_breached"`
+	ResolutionBreached bool            `bun:"-" json:"resolution_breached"`
+	Description        string          `bun:"description,type:varchar" json:"description"`
+	CreatedAt          time.Time       `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt          time.Time       `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+	Messages           []*IssueMessage `bun:"-" json:"messages,omitempty"`
+}
+
+// the replies posted on the issue after its description, the agents are the administrators
+type IssueMessage struct {
+	bun.BaseModel `bun:"table:issue_messages,alias:issue_messages"`
+	Id            uuid.UUID          `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	IssueId       uuid.UUID          `bun:"issue_id,type:uuid" json:"-"`
+	AuthorId      *uuid.UUID         `bun:"author_id,type:uuid" json:"author_id"`
+	FromAgent     bool               `bun:"from_agent,type:boolean" json:"from_agent"`
+	Body          string             `bun:"body,type:varchar" json:"body"`
+	CreatedAt     time.Time          `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	Attachments   []*IssueAttachment `bun:"-" json:"attachments"`
 }
 
+type IssueAttachment struct {
+	bun.BaseModel `bun:"table:issue_attachments,alias:issue_attachments"`
+	Id            uuid.UUID `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	MessageId     uuid.UUID `bun:"message_id,type:uuid" json:"-"`
+	ObjectKey     string    `bun:"object_key,type:varchar" json:"-"`
+	Filename      string    `bun:"filename,type:varchar" json:"filename"`
+	ContentType   string    `bun:"content_type,type:varchar" json:"content_type"`
+	Size          int       `bun:"size,type:integer" json:"size"`
+	URL           string    `bun:"-" json:"url"`
+	CreatedAt     time.Time `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type IssueSLATarget struct {
+	bun.BaseModel        `bun:"table:issue_sla_targets,alias:issue_sla_targets"`
+	Priority             string    `bun:"priority,pk,type:varchar" json:"priority" binding:"required,oneof=LOW NORMAL HIGH URGENT"`
+	FirstResponseMinutes int       `bun:"first_response_minutes,type:integer" json:"first_response_minutes" binding:"required,min=1"`
+	ResolutionMinutes    int       `bun:"resolution_minutes,type:integer" json:"resolution_minutes" binding:"required,min=1"`
+	UpdatedAt            time.Time `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+// the new targets apply to the issues reported or re-prioritised afterwards
+type IssueSLAInput struct {
+	Targets []*IssueSLATarget `json:"targets" binding:"required,min=1,dive"`
+}
+
+// the subject is TECHNICAL by default, the refunds are requested from the booking
 type IssueInput struct {
 	Description *string `json:"description"`
+	Subject     *string `json:"subject" binding:"omitempty,oneof=TECHNICAL BOOKING PAYMENT ACCOUNT ABUSE OTHER"`
+}
+
+type IssueMessageInput struct {
+	Body        string                  `form:"body" binding:"required,max=4000"`
+	Attachments []*multipart.FileHeader `form:"attachments"`
 }
 
+// without an assignee the issue is unassigned
+type IssueAssigneeInput struct {
+	AssigneeId *uuid.UUID `json:"assignee_id"`
+}
+
+type IssueTriageInput struct {
+	Priority *string `json:"priority" binding:"omitempty,oneof=LOW NORMAL HIGH URGENT"`
+	Subject  *string `json:"subject" binding:"omitempty,oneof=TECHNICAL BOOKING PAYMENT ACCOUNT ABUSE OTHER"`
+}
+
+// Breached keeps the issues that missed one of their SLA targets or are past it
 type IssueFilter struct {
 	ReporterId *string    `binding:"omitempty,uuid" form:"reporter_id"`
+	AssigneeId *string    `binding:"omitempty,uuid" form:"assignee_id"`
+	Unassigned *bool      `form:"unassigned"`
 	Status     *string    `form:"status"`
+	Priority   *string    `form:"priority"`
+	Breached   *bool      `form:"breached"`
 	DueDate    *time.Time `form:"due_date" time_format:"2006-01-02" time_utc:"7"`
 	CreatedAt  *time.Time `form:"created_at" time_format:"2006-01-02" time_utc:"7"`
 	Subject    *string    `form:"subject"`
 }
 
+const (
+	DisputeOpenStatus      = "OPEN"
+	DisputeResolvedStatus  = "RESOLVED"
+	DisputeWithdrawnStatus = "WITHDRAWN"
+)
+
+const (
+	DisputeNotDeliveredReason = "NOT_DELIVERED"
+	DisputePoorQualityReason  = "POOR_QUALITY"
+	DisputeNoShowReason       = "NO_SHOW"
+	DisputeOtherReason        = "OTHER"
+)
+
+const (
+	DisputeCustomerParty     = "CUSTOMER"
+	DisputePhotographerParty = "PHOTOGRAPHER"
+	DisputeMediatorParty     = "MEDIATOR"
+)
+
+// the booking is DISPUTED and is not paid out while the dispute is open, BookingStatus is the status it goes back to
+// if the dispute is withdrawn. The amounts are in the currency the booking is paid in and the resolution splits the
+// disputed amount between the customer, who is credited their share, and the photographer.
+type Dispute struct {
+	bun.BaseModel      `bun:"table:disputes,alias:disputes"`
+	Id                 uuid.UUID          `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	BookingId          uuid.UUID          `bun:"booking_id,type:uuid" json:"booking_id"`
+	Booking            *Booking           `bun:"-" json:"booking,omitempty"`
+	OpenedById         *uuid.UUID         `bun:"opened_by_id,type:uuid" json:"opened_by_id"`
+	Reason             string             `bun:"reason,type:varchar" json:"reason"`
+	Description        string             `bun:"description,type:varchar" json:"description"`
+	Status             string             `bun:"status,type:varchar,default:'OPEN'" json:"status"`
+	BookingStatus      string             `bun:"booking_status,type:varchar" json:"-"`
+	MediatorId         *uuid.UUID         `bun:"mediator_id,type:uuid" json:"mediator_id"`
+	DisputedAmount     int                `bun:"disputed_amount,type:integer" json:"disputed_amount"`
+	Currency           string             `bun:"currency,type:varchar" json:"currency"`
+	CustomerAmount     *int               `bun:"customer_amount,type:integer" json:"customer_amount"`
+	PhotographerAmount *int               `bun:"photographer_amount,type:integer" json:"photographer_amount"`
+	Resolution         *string            `bun:"resolution,type:varchar" json:"resolution"`
+	ResolvedAt         *time.Time         `bun:"resolved_at,nullzero,type:timestamptz" json:"resolved_at"`
+	CreatedAt          time.Time          `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt          time.Time          `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+	Evidence           []*DisputeEvidence `bun:"-" json:"evidence,omitempty"`
+}
+
+// the excerpt is a copy of the messages of the chat of the booking taken when the evidence is submitted
+type DisputeEvidence struct {
+	bun.BaseModel `bun:"table:dispute_evidence,alias:dispute_evidence"`
+	Id            uuid.UUID          `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	DisputeId     uuid.UUID          `bun:"dispute_id,type:uuid" json:"-"`
+	SubmittedById *uuid.UUID         `bun:"submitted_by_id,type:uuid" json:"submitted_by_id"`
+	Party         string             `bun:"party,type:varchar" json:"party"`
+	Text          *string            `bun:"text,type:varchar" json:"text"`
+	PhotoKeys     []string           `bun:"photo_keys,array" json:"-"`
+	PhotoURLs     []string           `bun:"-" json:"photo_urls"`
+	Excerpt       []*DisputeChatLine `bun:"excerpt,type:jsonb" json:"excerpt"`
+	CreatedAt     time.Time          `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type DisputeChatLine struct {
+	ConversationId uuid.UUID `json:"conversation_id"`
+	UserId         uuid.UUID `json:"user_id"`
+	Text           string    `json:"text"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DisputeInput struct {
+	Reason      string `json:"reason" binding:"required,oneof=NOT_DELIVERED POOR_QUALITY NO_SHOW OTHER"`
+	Description string `json:"description" binding:"required,max=4000"`
+}
+
+// the conversations are messages of the chat of the booking quoted in the evidence
+type DisputeEvidenceInput struct {
+	Text            string                  `form:"text" binding:"max=4000"`
+	Photos          []*multipart.FileHeader `form:"photos"`
+	ConversationIds []string                `form:"conversation_ids" binding:"dive,uuid"`
+}
+
+// the photographer gets what the customer is not credited
+type DisputeResolutionInput struct {
+	CustomerAmount *int   `json:"customer_amount" binding:"required,min=0" example:"5000"`
+	Resolution     string `json:"resolution" binding:"required,max=4000"`
+}
+
+type DisputeFilter struct {
+	Status     *string `form:"status" binding:"omitempty,oneof=OPEN RESOLVED WITHDRAWN"`
+	MediatorId *string `form:"mediator_id" binding:"omitempty,uuid"`
+	Unassigned *bool   `form:"unassigned"`
+}
+
+const (
+	AuditRequestAction            = "request"
+	AuditVerificationReviewAction = "verification_ticket.review"
+	AuditIssueCloseAction         = "issue.close"
+	AuditIssueAssignAction        = "issue.assign"
+	AuditIssueTriageAction        = "issue.triage"
+	AuditIssueSLAUpdateAction     = "issue_sla_target.update"
+	AuditRefundApproveAction      = "refund.approve"
+	AuditRefundRejectAction       = "refund.reject"
+	AuditDisputeMediateAction     = "dispute.mediate"
+	AuditDisputeResolveAction     = "dispute.resolve"
+)
+
+const (
+	AuditVerificationTicketTarget = "verification_ticket"
+	AuditIssueTarget              = "issue"
+	AuditIssueSLATarget           = "issue_sla_target"
+	AuditBookingTarget            = "booking"
+	AuditDisputeTarget            = "dispute"
+)
+
+// an entry is either an action recorded by a usecase, with the fields it changed, or a request to the admin routes
+// that no usecase recorded or that failed. Hash covers the entry and the hash of the one before it.
+type AuditEntry struct {
+	bun.BaseModel `bun:"table:audit_entries,alias:audit_entries"`
+	Id            uuid.UUID              `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Seq           int64                  `bun:"seq,type:bigint" json:"seq"`
+	ActorId       *uuid.UUID             `bun:"actor_id,type:uuid" json:"actor_id"`
+	Action        string                 `bun:"action,type:varchar" json:"action"`
+	TargetType    *string                `bun:"target_type,type:varchar" json:"target_type"`
+	TargetId      *uuid.UUID             `bun:"target_id,type:uuid" json:"target_id"`
+	Changes       map[string]AuditChange `bun:"changes,type:jsonb" json:"changes"`
+	IP            string                 `bun:"ip,type:varchar" json:"ip"`
+	RequestId     string                 `bun:"request_id,type:varchar" json:"request_id"`
+	Method        *string                `bun:"method,type:varchar" json:"method"`
+	Path          *string                `bun:"path,type:varchar" json:"path"`
+	StatusCode    *int                   `bun:"status_code,type:integer" json:"status_code"`
+	CreatedAt     time.Time              `bun:"created_at,type:timestamptz" json:"created_at"`
+	PrevHash      string                 `bun:"prev_hash,type:varchar" json:"prev_hash"`
+	Hash          string                 `bun:"hash,type:varchar" json:"hash"`
+}
+
+// the values are the JSON of the field before and after the action, null when the target did not exist
+type AuditChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// the entries are listed newest first, BeforeSeq pages through them
+type AuditFilter struct {
+	ActorId    *string    `form:"actor_id" binding:"omitempty,uuid"`
+	Action     *string    `form:"action"`
+	TargetType *string    `form:"target_type"`
+	TargetId   *string    `form:"target_id" binding:"omitempty,uuid"`
+	RequestId  *string    `form:"request_id"`
+	From       *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To         *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	BeforeSeq  *int64     `form:"before_seq" binding:"omitempty,min=1"`
+	Limit      int        `form:"limit" binding:"omitempty,min=1,max=200"`
+}
+
+// BrokenAt is the first entry whose hash or link to the one before it does not match
+type AuditVerification struct {
+	Valid      bool      `json:"valid"`
+	Checked    int64     `json:"checked"`
+	BrokenAt   *int64    `json:"broken_at"`
+	Reason     string    `json:"reason,omitempty"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+const (
+	AnalyticsDayGranularity   = "day"
+	AnalyticsWeekGranularity  = "week"
+	AnalyticsMonthGranularity = "month"
+)
+
+// the dates are days in UTC, To is excluded. Without them the last 30 days up to today are shown, by day.
+type AnalyticsFilter struct {
+	From        *time.Time `form:"from" time_format:"2006-01-02" time_utc:"1"`
+	To          *time.Time `form:"to" time_format:"2006-01-02" time_utc:"1"`
+	Granularity string     `form:"granularity" binding:"omitempty,oneof=day week month"`
+	Limit       int        `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+// the bookings are counted in the period they were created in, the gross volume is the price of the paid ones
+// in the base currency at the current exchange rates
+type BookingAnalytics struct {
+	Period            time.Time `bun:"period" json:"period"`
+	Bookings          int       `bun:"bookings" json:"bookings"`
+	PaidBookings      int       `bun:"paid_bookings" json:"paid_bookings"`
+	CancelledBookings int       `bun:"cancelled_bookings" json:"cancelled_bookings"`
+	RefundedBookings  int       `bun:"refunded_bookings" json:"refunded_bookings"`
+	GrossVolume       int64     `bun:"gross_volume" json:"gross_volume"`
+}
+
+type GrowthAnalytics struct {
+	Period                time.Time `bun:"period" json:"period"`
+	NewUsers              int       `bun:"new_users" json:"new_users"`
+	VerifiedPhotographers int       `bun:"verified_photographers" json:"verified_photographers"`
+}
+
+type PhotographerRanking struct {
+	PhotographerId uuid.UUID `bun:"photographer_id" json:"photographer_id"`
+	Firstname      string    `bun:"firstname" json:"firstname"`
+	Lastname       string    `bun:"lastname" json:"lastname"`
+	PaidBookings   int       `bun:"paid_bookings" json:"paid_bookings"`
+	GrossVolume    int64     `bun:"gross_volume" json:"gross_volume"`
+}
+
+type LocationRanking struct {
+	Location     string `bun:"location" json:"location"`
+	PaidBookings int    `bun:"paid_bookings" json:"paid_bookings"`
+	GrossVolume  int64  `bun:"gross_volume" json:"gross_volume"`
+}
+
+// the conversion is the share of the bookings that were paid, the refund rate the share of the paid ones that
+// were cancelled afterwards
+type AnalyticsSummary struct {
+	Bookings         int     `json:"bookings"`
+	PaidBookings     int     `json:"paid_bookings"`
+	GrossVolume      int64   `json:"gross_volume"`
+	ConversionRate   float64 `json:"conversion_rate"`
+	CancellationRate float64 `json:"cancellation_rate"`
+	RefundRate       float64 `json:"refund_rate"`
+	NewUsers         int     `json:"new_users"`
+}
+
+type AnalyticsDashboard struct {
+	From             time.Time              `json:"from"`
+	To               time.Time              `json:"to"`
+	Granularity      string                 `json:"granularity"`
+	Currency         string                 `json:"currency"`
+	Summary          AnalyticsSummary       `json:"summary"`
+	Bookings         []*BookingAnalytics    `json:"bookings"`
+	Growth           []*GrowthAnalytics     `json:"growth"`
+	TopPhotographers []*PhotographerRanking `json:"top_photographers"`
+	TopLocations     []*LocationRanking     `json:"top_locations"`
+	GeneratedAt      time.Time              `json:"generated_at"`
+}
+
+const (
+	GalleryViewDetailsKind = "DETAILS"
+	GalleryViewPhotosKind  = "PHOTOS"
+)
+
+type GalleryView struct {
+	bun.BaseModel `bun:"table:gallery_views,alias:gallery_views"`
+	GalleryId     uuid.UUID `bun:"gallery_id,pk,type:uuid"`
+	Day           time.Time `bun:"day,pk,type:date"`
+	Kind          string    `bun:"kind,pk,type:varchar"`
+	Views         int       `bun:"views,type:integer"`
+}
+
+// the insights cover the last Months months including the current one, 12 by default
+type InsightsFilter struct {
+	Months int `form:"months" binding:"omitempty,min=1,max=24"`
+}
+
+// the earnings are the instalments paid in the month in the base currency at the current exchange rates, those of
+// the cancelled bookings are left out as they are refunded
+type MonthlyEarnings struct {
+	Month        time.Time `bun:"month" json:"month"`
+	PaidBookings int       `bun:"paid_bookings" json:"paid_bookings"`
+	Earnings     int64     `bun:"earnings" json:"earnings"`
+}
+
+// the conversions are the share of the views of the details and of the chats that led to a paid booking
+type GalleryInsights struct {
+	GalleryId      uuid.UUID `bun:"gallery_id" json:"gallery_id"`
+	Name           string    `bun:"name" json:"name"`
+	DetailViews    int       `bun:"detail_views" json:"detail_views"`
+	PhotoViews     int       `bun:"photo_views" json:"photo_views"`
+	Chats          int       `bun:"chats" json:"chats"`
+	BookedChats    int       `bun:"booked_chats" json:"booked_chats"`
+	Bookings       int       `bun:"bookings" json:"bookings"`
+	PaidBookings   int       `bun:"paid_bookings" json:"paid_bookings"`
+	ViewConversion float64   `bun:"-" json:"view_conversion"`
+	ChatConversion float64   `bun:"-" json:"chat_conversion"`
+}
+
+type RatingTrend struct {
+	Month         time.Time `bun:"month" json:"month"`
+	Reviews       int       `bun:"reviews" json:"reviews"`
+	AverageRating *float64  `bun:"average_rating" json:"average_rating"`
+}
+
+// a customer message is answered by the next message of the photographer in the chat, the messages sent by the
+// customer in a row are waiting for the same answer
+type ResponseTime struct {
+	Answered       int      `bun:"answered" json:"answered"`
+	Unanswered     int      `bun:"unanswered" json:"unanswered"`
+	AverageSeconds *float64 `bun:"average_seconds" json:"average_seconds"`
+}
+
+type WeeklyWorkload struct {
+	Week     time.Time `bun:"week" json:"week"`
+	Sessions int       `bun:"sessions" json:"sessions"`
+	Hours    float64   `bun:"hours" json:"hours"`
+}
+
+type PhotographerInsights struct {
+	From         time.Time          `json:"from"`
+	Currency     string             `json:"currency"`
+	Earnings     []*MonthlyEarnings `json:"earnings"`
+	Galleries    []*GalleryInsights `json:"galleries"`
+	RatingTrend  []*RatingTrend     `json:"rating_trend"`
+	ResponseTime ResponseTime       `json:"response_time"`
+	Workload     []*WeeklyWorkload  `json:"workload"`
+	GeneratedAt  time.Time          `json:"generated_at"`
+}
+
+// the tickets by priority, unassigned, breached and assigned to me are the open ones
 type IssueHeaderMetadata struct {
-	PendingTickets  int `json:"pending_tickets"`
-	TicketsToday    int `json:"tickets_today"`
-	TicketsDueToday int `json:"tickets_due_today"`
-	ClosedTickets   int `json:"closed_tickets"`
+	PendingTickets    int            `json:"pending_tickets"`
+	TicketsToday      int            `json:"tickets_today"`
+	TicketsDueToday   int            `json:"tickets_due_today"`
+	ClosedTickets     int            `json:"closed_tickets"`
+	UnassignedTickets int            `json:"unassigned_tickets"`
+	BreachedTickets   int            `json:"breached_tickets"`
+	MyTickets         int            `json:"my_tickets"`
+	TicketsByPriority map[string]int `json:"tickets_by_priority"`
+}
+
+const (
+	NotificationBookingCreatedType            = "BOOKING_CREATED"
+	NotificationBookingPaidType               = "BOOKING_PAID"
+	NotificationBookingCancelRequestedType    = "BOOKING_CANCEL_REQUESTED"
+	NotificationBookingCancelledType          = "BOOKING_CANCELLED"
+	NotificationRefundRequestedType           = "REFUND_REQUESTED"
+	NotificationRefundApprovedType            = "REFUND_APPROVED"
+	NotificationRefundRejectedType            = "REFUND_REJECTED"
+	NotificationReviewReceivedType            = "REVIEW_RECEIVED"
+	NotificationIssueClosedType               = "ISSUE_CLOSED"
+	NotificationVerificationApprovedType      = "VERIFICATION_APPROVED"
+	NotificationVerificationRejectedType      = "VERIFICATION_REJECTED"
+	NotificationVerificationInfoRequestedType = "VERIFICATION_INFO_REQUESTED"
+	NotificationBookingRequestedType          = "BOOKING_REQUESTED"
+	NotificationBookingRequestClosedType      = "BOOKING_REQUEST_CLOSED"
+	NotificationQuoteReceivedType             = "QUOTE_RECEIVED"
+	NotificationQuoteAcceptedType             = "QUOTE_ACCEPTED"
+	NotificationDepositPaidType               = "DEPOSIT_PAID"
+	NotificationSessionReminderType           = "SESSION_REMINDER"
+	NotificationReviewRequestedType           = "REVIEW_REQUESTED"
+	NotificationBookingExpiredType            = "BOOKING_EXPIRED"
+	NotificationDeliveryOverdueType           = "DELIVERY_OVERDUE"
+	NotificationPhotosDeliveredType           = "PHOTOS_DELIVERED"
+	NotificationIssueRepliedType              = "ISSUE_REPLIED"
+	NotificationIssueReporterRepliedType      = "ISSUE_REPORTER_REPLIED"
+	NotificationIssueAssignedType             = "ISSUE_ASSIGNED"
+	NotificationDisputeOpenedType             = "DISPUTE_OPENED"
+	NotificationDisputeEvidenceAddedType      = "DISPUTE_EVIDENCE_ADDED"
+	NotificationDisputeWithdrawnType          = "DISPUTE_WITHDRAWN"
+	NotificationDisputeResolvedType           = "DISPUTE_RESOLVED"
+)
+
+type Notification struct {
+	bun.BaseModel `bun:"table:notifications,alias:notifications"`
+	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	UserId        uuid.UUID  `bun:"user_id,type:uuid" json:"-"`
+	Type          string     `bun:"type,type:varchar" json:"type"`
+	Title         string     `bun:"title,type:varchar" json:"title"`
+	Body          *string    `bun:"body,type:varchar" json:"body"`
+	ResourceId    *uuid.UUID `bun:"resource_id,type:uuid" json:"resource_id"`
+	ReadAt        *time.Time `bun:"read_at,nullzero,type:timestamptz" json:"read_at"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+}
+
+type NotificationFilter struct {
+	UnreadOnly bool `form:"unread"`
+	Limit      int  `binding:"omitempty,min=1,max=100" form:"limit"`
+}
+
+type NotificationReadInput struct {
+	Ids []uuid.UUID `json:"ids"`
+}
+
+const (
+	WebhookBookingCreatedEvent       = "booking.created"
+	WebhookBookingStatusChangedEvent = "booking.status_changed"
+	WebhookPaymentSucceededEvent     = "payment.succeeded"
+	WebhookRefundApprovedEvent       = "refund.approved"
+	WebhookRefundRejectedEvent       = "refund.rejected"
+	WebhookReviewCreatedEvent        = "review.created"
+	WebhookVerificationDecidedEvent  = "verification.decided"
+)
+
+var WebhookEventTypes = []string{
+	WebhookBookingCreatedEvent,
+	WebhookBookingStatusChangedEvent,
+	WebhookPaymentSucceededEvent,
+	WebhookRefundApprovedEvent,
+	WebhookRefundRejectedEvent,
+	WebhookReviewCreatedEvent,
+	WebhookVerificationDecidedEvent,
+}
+
+type WebhookSubscription struct {
+	bun.BaseModel `bun:"table:webhook_subscriptions,alias:webhooks"`
+	Id            uuid.UUID  `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Url           string     `bun:"url,type:varchar" json:"url"`
+	EventTypes    []string   `bun:"event_types,array" json:"event_types"`
+	Secret        string     `bun:"secret,type:varchar" json:"-"`
+	IsActive      bool       `bun:"is_active,type:boolean" json:"is_active"`
+	CreatedBy     *uuid.UUID `bun:"created_by,type:uuid" json:"created_by"`
+	CreatedAt     time.Time  `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	UpdatedAt     time.Time  `bun:"updated_at,type:timestamptz,default:now()" json:"updated_at"`
+}
+
+type WebhookSubscriptionInput struct {
+	Url        *string  `json:"url" example:"https://crm.example.com/hooks/pic-keeper"`
+	EventTypes []string `json:"event_types" example:"booking.status_changed"`
+	IsActive   *bool    `json:"is_active"`
+}
+
+const (
+	WebhookDeliveryPendingStatus   = "PENDING"
+	WebhookDeliverySucceededStatus = "SUCCEEDED"
+	WebhookDeliveryDeadStatus      = "DEAD"
+)
+
+type WebhookDelivery struct {
+	bun.BaseModel  `bun:"table:webhook_deliveries,alias:deliveries"`
+	Id             uuid.UUID       `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	SubscriptionId uuid.UUID       `bun:"subscription_id,type:uuid" json:"subscription_id"`
+	EventId        uuid.UUID       `bun:"event_id,type:uuid" json:"event_id"`
+	EventType      string          `bun:"event_type,type:varchar" json:"event_type"`
+	Payload        json.RawMessage `bun:"payload,type:jsonb" json:"payload"`
+	Status         string          `bun:"status,type:varchar" json:"status"`
+	Attempts       int             `bun:"attempts,type:integer" json:"attempts"`
+	NextAttemptAt  time.Time       `bun:"next_attempt_at,type:timestamptz" json:"next_attempt_at"`
+	LastStatusCode *int            `bun:"last_status_code,type:integer" json:"last_status_code"`
+	LastError      *string         `bun:"last_error,type:varchar" json:"last_error"`
+	CreatedAt      time.Time       `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	DeliveredAt    *time.Time      `bun:"delivered_at,nullzero,type:timestamptz" json:"delivered_at"`
+}
+
+type WebhookDeliveryFilter struct {
+	SubscriptionId *string `binding:"omitempty,uuid" form:"subscription_id"`
+	Status         *string `form:"status"`
+}
+
+const (
+	JobPendingStatus   = "PENDING"
+	JobRunningStatus   = "RUNNING"
+	JobSucceededStatus = "SUCCEEDED"
+	JobFailedStatus    = "FAILED"
+)
+
+const (
+	JobDeleteS3ObjectKind         = "s3.delete_object"
+	JobSendNotificationKind       = "notification.send"
+	JobRecomputeGalleryRatingKind = "gallery.recompute_rating"
+	JobBuildDataExportKind        = "data_export.build"
+	JobUpdateBookingStatusKind    = "booking.update_status"
+	JobDeliverWebhooksKind        = "webhook.deliver"
+	JobPublishWebhookKind         = "webhook.publish"
+	JobPurgeJobsKind              = "job.purge"
+	JobPublishChatCardKind        = "chat.publish_card"
+	JobExpireBookingQuotesKind    = "booking_quote.expire"
+	JobCancelOverdueDepositsKind  = "booking.cancel_overdue_deposits"
+	JobRenderDocumentKind         = "document.render"
+	JobRemindSessionsKind         = "booking.remind_sessions"
+	JobRequestReviewsKind         = "booking.request_reviews"
+	JobExpireDraftsKind           = "booking.expire_drafts"
+	JobAlertOverdueDeliveryKind   = "booking.alert_overdue_delivery"
+	JobTrackGalleryViewKind       = "gallery.track_view"
+)
+
+type Job struct {
+	bun.BaseModel `bun:"table:jobs,alias:jobs"`
+	Id            uuid.UUID       `bun:"id,pk,type:uuid,default:gen_random_uuid()" json:"id"`
+	Kind          string          `bun:"kind,type:varchar" json:"kind"`
+	Payload       json.RawMessage `bun:"payload,type:jsonb" json:"payload"`
+	Status        string          `bun:"status,type:varchar" json:"status"`
+	Attempts      int             `bun:"attempts,type:integer" json:"attempts"`
+	MaxAttempts   int             `bun:"max_attempts,type:integer" json:"max_attempts"`
+	RunAt         time.Time       `bun:"run_at,type:timestamptz" json:"run_at"`
+	LockedBy      *string         `bun:"locked_by,type:varchar" json:"locked_by"`
+	LockedUntil   *time.Time      `bun:"locked_until,nullzero,type:timestamptz" json:"locked_until"`
+	LastError     *string         `bun:"last_error,type:varchar" json:"last_error"`
+	DedupKey      *string         `bun:"dedup_key,type:varchar" json:"dedup_key"`
+	CreatedAt     time.Time       `bun:"created_at,type:timestamptz,default:now()" json:"created_at"`
+	CompletedAt   *time.Time      `bun:"completed_at,nullzero,type:timestamptz" json:"completed_at"`
+}
+
+type S3ObjectJob struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+}
+
+type NotificationJob struct {
+	Id         uuid.UUID  `json:"id"`
+	UserId     uuid.UUID  `json:"user_id"`
+	Type       string     `json:"type"`
+	ResourceId *uuid.UUID `json:"resource_id"`
+	Body       *string    `json:"body"`
+}
+
+type ChatCardJob struct {
+	ConversationId uuid.UUID `json:"conversation_id"`
+}
+
+type GalleryRatingJob struct {
+	GalleryId uuid.UUID `json:"gallery_id"`
+}
+
+// the day is the one the gallery was viewed on, the viewer is nil for the guests
+type GalleryViewJob struct {
+	GalleryId uuid.UUID  `json:"gallery_id"`
+	ViewerId  *uuid.UUID `json:"viewer_id"`
+	Kind      string     `json:"kind"`
+	Day       time.Time  `json:"day"`
+}
+
+type DataExportJob struct {
+	ExportId uuid.UUID `json:"export_id"`
+}
+
+type DocumentJob struct {
+	DocumentId uuid.UUID `json:"document_id"`
+}
+
+type WebhookEventJob struct {
+	Id        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
 }
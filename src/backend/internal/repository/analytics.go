@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+// Analytics aggregates the bookings and the users created within [from, to), the periods are truncated to the
+// granularity in UTC and the periods without any activity are included
+type Analytics interface {
+	BookingSeries(ctx context.Context, from, to time.Time, granularity string) ([]*model.BookingAnalytics, error)
+	GrowthSeries(ctx context.Context, from, to time.Time, granularity string) ([]*model.GrowthAnalytics, error)
+	TopPhotographers(ctx context.Context, from, to time.Time, limit int) ([]*model.PhotographerRanking, error)
+	TopLocations(ctx context.Context, from, to time.Time, limit int) ([]*model.LocationRanking, error)
+}
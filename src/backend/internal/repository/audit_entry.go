@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+type AuditEntry interface {
+	BaseRepo[model.AuditEntry]
+	LockChain(ctx context.Context) error
+	FindLast(ctx context.Context) (*model.AuditEntry, error)
+	FindWithFilter(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error)
+	FindAfterSeq(ctx context.Context, seq int64, limit int) ([]*model.AuditEntry, error)
+}
@@ -10,9 +10,14 @@ import (
 
 type Booking interface {
 	BaseRepo[model.Booking]
+	LockOneById(ctx context.Context, id uuid.UUID) (*model.Booking, error)
 	FindByUserIdWithStatus(ctx context.Context, userId uuid.UUID, status ...string) ([]*model.Booking, error)
 	FindByPhotographerIdWithStatus(ctx context.Context, phtgId uuid.UUID, status ...string) ([]*model.Booking, error)
-	UpdateStatusRoutine(ctx context.Context, currentTime time.Time) error
+	UpdateStatusRoutine(ctx context.Context, currentTime time.Time) (map[string][]*model.Booking, error)
 	ListPendingRefundBookings(ctx context.Context) ([]*model.Booking, error)
 	FindByRoomId(ctx context.Context, roomId uuid.UUID) (*model.Booking, error)
+	FindForCalendar(ctx context.Context, userId uuid.UUID, endingAfter time.Time) ([]*model.Booking, error)
+	FindAwaitingReview(ctx context.Context, endedAfter, endedBefore time.Time) ([]*model.Booking, error)
+	FindStaleDrafts(ctx context.Context, updatedBefore time.Time) ([]*model.Booking, error)
+	FindOverdueDeliveries(ctx context.Context, currentTime time.Time) ([]*model.Booking, error)
 }
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type BookingDocument interface {
+	BaseRepo[model.BookingDocument]
+	FindByBookingId(ctx context.Context, bookingId uuid.UUID) ([]*model.BookingDocument, error)
+	FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, filter model.BookingDocumentFilter) ([]*model.BookingDocument, error)
+	NextSequence(ctx context.Context, photographerId uuid.UUID, kind string) (int, error)
+}
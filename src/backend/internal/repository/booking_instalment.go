@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type BookingInstalment interface {
+	BaseRepo[model.BookingInstalment]
+	FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingInstalment, error)
+	CancelPending(ctx context.Context, bookingId uuid.UUID) error
+	LockByBookingId(ctx context.Context, bookingId uuid.UUID) ([]*model.BookingInstalment, error)
+	FindOverdue(ctx context.Context, currentTime time.Time) ([]*model.BookingInstalment, error)
+}
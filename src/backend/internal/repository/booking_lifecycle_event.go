@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+type BookingLifecycleEvent interface {
+	BaseRepo[model.BookingLifecycleEvent]
+	AddUnique(ctx context.Context, event *model.BookingLifecycleEvent) (bool, error)
+}
@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type BookingRequest interface {
+	BaseRepo[model.BookingRequest]
+	LockOneById(ctx context.Context, id uuid.UUID) (*model.BookingRequest, error)
+	FindByCustomerId(ctx context.Context, customerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error)
+	FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error)
+}
+
+type BookingQuote interface {
+	BaseRepo[model.BookingQuote]
+	FindByRequestIds(ctx context.Context, requestIds ...uuid.UUID) ([]*model.BookingQuote, error)
+	FindLatestByRequestId(ctx context.Context, requestId uuid.UUID) (*model.BookingQuote, error)
+	ExpirePending(ctx context.Context, currentTime time.Time) ([]*model.BookingQuote, error)
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type BookingSession interface {
+	BaseRepo[model.BookingSession]
+	FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingSession, error)
+	CancelScheduled(ctx context.Context, bookingId uuid.UUID) error
+	CompleteEnded(ctx context.Context, currentTime time.Time) ([]*model.BookingSession, error)
+	FindStartingBetween(ctx context.Context, from, to time.Time) ([]*model.BookingSession, error)
+}
@@ -0,0 +1,22 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type CalendarFeed interface {
+	BaseRepo[model.CalendarFeed]
+	FindOneByUserId(ctx context.Context, userId uuid.UUID) (*model.CalendarFeed, error)
+	FindOneByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error)
+	DeleteByUserId(ctx context.Context, userId uuid.UUID) error
+}
+
+type BusyBlock interface {
+	BaseRepo[model.BusyBlock]
+	FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.BusyBlock, error)
+	DeleteByPhotographerId(ctx context.Context, photographerId uuid.UUID) error
+}
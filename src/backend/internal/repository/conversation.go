@@ -10,4 +10,7 @@ import (
 type Conversation interface {
 	BaseRepo[model.Conversation]
 	ListByRoomId(ctx context.Context, roomId uuid.UUID) ([]*model.Conversation, error)
+	FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.Conversation, error)
+	RedactByUserId(ctx context.Context, userId uuid.UUID, text string) error
+	FindByResourceId(ctx context.Context, resourceId uuid.UUID) (*model.Conversation, error)
 }
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type Coupon interface {
+	BaseRepo[model.Coupon]
+	FindWithFilter(ctx context.Context, filter model.CouponFilter) ([]*model.Coupon, error)
+	FindOneByCode(ctx context.Context, code string) (*model.Coupon, error)
+}
+
+type CouponRedemption interface {
+	BaseRepo[model.CouponRedemption]
+	FindByCouponId(ctx context.Context, couponId uuid.UUID) ([]*model.CouponRedemption, error)
+	FindOneByBookingId(ctx context.Context, bookingId uuid.UUID) (*model.CouponRedemption, error)
+	CountRedeemed(ctx context.Context, couponId uuid.UUID, userId *uuid.UUID) (int, error)
+}
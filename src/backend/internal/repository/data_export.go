@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type DataExport interface {
+	BaseRepo[model.DataExport]
+	FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.DataExport, error)
+}
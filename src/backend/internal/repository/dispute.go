@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type Dispute interface {
+	BaseRepo[model.Dispute]
+	FindLatestByBookingId(ctx context.Context, bookingId uuid.UUID) (*model.Dispute, error)
+	FindWithFilter(ctx context.Context, filter model.DisputeFilter) ([]*model.Dispute, error)
+	FindResolvedByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.Dispute, error)
+}
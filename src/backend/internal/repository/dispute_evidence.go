@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type DisputeEvidence interface {
+	BaseRepo[model.DisputeEvidence]
+	FindByDisputeIds(ctx context.Context, disputeIds ...uuid.UUID) ([]*model.DisputeEvidence, error)
+	FindBySubmitterId(ctx context.Context, submitterId uuid.UUID) ([]*model.DisputeEvidence, error)
+	RedactBySubmitterId(ctx context.Context, submitterId uuid.UUID, text string) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+type ExchangeRate interface {
+	FindAll(ctx context.Context) ([]*model.ExchangeRate, error)
+	Upsert(ctx context.Context, rates []*model.ExchangeRate) error
+}
@@ -11,4 +11,5 @@ type Gallery interface {
 	BaseRepo[model.Gallery]
 	FindByPhotographerId(ctx context.Context, photographerId uuid.UUID) ([]*model.Gallery, error)
 	SearchWithFilter(ctx context.Context, filter *model.SearchFilter) ([]*model.Gallery, error)
+	HideByPhotographerId(ctx context.Context, photographerId uuid.UUID) error
 }
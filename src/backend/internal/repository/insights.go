@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+// Insights aggregates the activity of the galleries of a photographer since from, the months and the weeks are
+// truncated in UTC and those without any activity are included
+type Insights interface {
+	AddView(ctx context.Context, view *model.GalleryView) error
+	MonthlyEarnings(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.MonthlyEarnings, error)
+	GalleryInsights(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.GalleryInsights, error)
+	RatingTrend(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.RatingTrend, error)
+	ResponseTime(ctx context.Context, photographerId uuid.UUID, from, to time.Time) (*model.ResponseTime, error)
+	WeeklyWorkload(ctx context.Context, photographerId uuid.UUID, from time.Time, weeks int) ([]*model.WeeklyWorkload, error)
+}
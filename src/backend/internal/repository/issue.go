@@ -4,10 +4,13 @@ import (
 	"context"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
 )
 
 type Issue interface {
 	BaseRepo[model.Issue]
 	FindIssuesWithFilter(ctx context.Context, filter model.IssueFilter) ([]*model.Issue, error)
-	GetIssueHeaderMetadata(ctx context.Context) (*model.IssueHeaderMetadata, error)
+	GetIssueHeaderMetadata(ctx context.Context, adminId uuid.UUID) (*model.IssueHeaderMetadata, error)
+	RedactByReporterId(ctx context.Context, reporterId uuid.UUID, text string) error
+	LockOneById(ctx context.Context, id uuid.UUID) (*model.Issue, error)
 }
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type IssueAttachment interface {
+	BaseRepo[model.IssueAttachment]
+	FindByMessageIds(ctx context.Context, messageIds ...uuid.UUID) ([]*model.IssueAttachment, error)
+	FindByAuthorId(ctx context.Context, authorId uuid.UUID) ([]*model.IssueAttachment, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type IssueMessage interface {
+	BaseRepo[model.IssueMessage]
+	FindByIssueIds(ctx context.Context, issueIds ...uuid.UUID) ([]*model.IssueMessage, error)
+	RedactByAuthorId(ctx context.Context, authorId uuid.UUID, text string) error
+}
@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+type IssueSLATarget interface {
+	FindAll(ctx context.Context) ([]*model.IssueSLATarget, error)
+	Upsert(ctx context.Context, targets []*model.IssueSLATarget) error
+}
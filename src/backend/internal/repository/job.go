@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+)
+
+type Job interface {
+	BaseRepo[model.Job]
+	AddUnique(ctx context.Context, job *model.Job) (bool, error)
+	ClaimDue(ctx context.Context, workerId string, now, leaseUntil time.Time, limit int) ([]*model.Job, error)
+	UpdateClaimed(ctx context.Context, job *model.Job, workerId string) (bool, error)
+	DeleteSucceededBefore(ctx context.Context, before time.Time) (int, error)
+}
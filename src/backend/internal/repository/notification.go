@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type Notification interface {
+	BaseRepo[model.Notification]
+	FindWithFilter(ctx context.Context, userId uuid.UUID, filter model.NotificationFilter) ([]*model.Notification, error)
+	CountUnreadByUserId(ctx context.Context, userId uuid.UUID) (int, error)
+	MarkRead(ctx context.Context, userId uuid.UUID, ids ...uuid.UUID) (int, error)
+	MarkAllRead(ctx context.Context, userId uuid.UUID) (int, error)
+	DeleteByUserId(ctx context.Context, userId uuid.UUID) error
+}
@@ -0,0 +1,156 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/uptrace/bun"
+)
+
+// the queries take the granularity as ?0 and the range as ?1 and ?2
+
+// the periods of the range, as timestamps in UTC
+const analyticsPeriods = `
+periods AS (
+  SELECT generate_series(
+    date_trunc(?0, ?1::timestamptz AT TIME ZONE 'UTC'),
+    ?2::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+    ('1 ' || ?0)::interval
+  ) AS period
+)`
+
+// the bookings created in the range, a booking is paid once one of its instalments is and its price is converted to
+// the base currency, the rate being the amount of the currency one unit of the base currency buys
+const analyticsFacts = `
+facts AS (
+  SELECT
+    bookings.id,
+    bookings.room_id,
+    bookings.status,
+    bookings.created_at,
+    EXISTS (
+      SELECT 1 FROM booking_instalments
+      WHERE booking_instalments.booking_id = bookings.id AND booking_instalments.status = ?3
+    ) AS paid,
+    bookings.resulted_price / COALESCE(exchange_rates.rate, 1) AS base_price
+  FROM bookings
+  LEFT JOIN exchange_rates ON exchange_rates.currency = bookings.currency
+  WHERE bookings.created_at >= ?1 AND bookings.created_at < ?2
+)`
+
+type AnalyticsDB struct {
+	db *bun.DB
+}
+
+func NewAnalyticsDB(db *bun.DB) *AnalyticsDB {
+	return &AnalyticsDB{db: db}
+}
+
+func (a *AnalyticsDB) conn(ctx context.Context) bun.IDB {
+	return connOf(ctx, a.db)
+}
+
+func (a *AnalyticsDB) BookingSeries(ctx context.Context, from, to time.Time, granularity string) ([]*model.BookingAnalytics, error) {
+	var series []*model.BookingAnalytics
+	query := `WITH ` + analyticsPeriods + `, ` + analyticsFacts + `
+SELECT
+  periods.period,
+  count(facts.id) AS bookings,
+  count(facts.id) FILTER (WHERE facts.paid) AS paid_bookings,
+  count(facts.id) FILTER (WHERE facts.status = ?4) AS cancelled_bookings,
+  count(facts.id) FILTER (WHERE facts.status = ?4 AND facts.paid) AS refunded_bookings,
+  COALESCE(round(sum(facts.base_price) FILTER (WHERE facts.paid)), 0)::bigint AS gross_volume
+FROM periods
+LEFT JOIN facts ON date_trunc(?0, facts.created_at AT TIME ZONE 'UTC') = periods.period
+GROUP BY periods.period
+ORDER BY periods.period`
+
+	if err := a.conn(ctx).NewRaw(query, granularity, from, to, model.InstalmentPaidStatus, model.BookingCancelledStatus).Scan(ctx, &series); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// GrowthSeries counts the users who registered and the photographers whose verification was approved in each period,
+// the users whose registration is unknown are left out
+func (a *AnalyticsDB) GrowthSeries(ctx context.Context, from, to time.Time, granularity string) ([]*model.GrowthAnalytics, error) {
+	var series []*model.GrowthAnalytics
+	query := `WITH ` + analyticsPeriods + `,
+new_users AS (
+  SELECT date_trunc(?0, created_at AT TIME ZONE 'UTC') AS period, count(*) AS count
+  FROM users
+  WHERE created_at >= ?1 AND created_at < ?2
+  GROUP BY 1
+),
+verified AS (
+  SELECT date_trunc(?0, created_at AT TIME ZONE 'UTC') AS period, count(DISTINCT user_id) AS count
+  FROM verification_events
+  WHERE action = ?3 AND created_at >= ?1 AND created_at < ?2
+  GROUP BY 1
+)
+SELECT
+  periods.period,
+  COALESCE(new_users.count, 0) AS new_users,
+  COALESCE(verified.count, 0) AS verified_photographers
+FROM periods
+LEFT JOIN new_users ON new_users.period = periods.period
+LEFT JOIN verified ON verified.period = periods.period
+ORDER BY periods.period`
+
+	if err := a.conn(ctx).NewRaw(query, granularity, from, to, model.VerificationApprovedAction).Scan(ctx, &series); err != nil {
+		return nil, err
+	}
+
+	return series, nil
+}
+
+// TopPhotographers ranks the photographers by the gross volume of their paid bookings
+func (a *AnalyticsDB) TopPhotographers(ctx context.Context, from, to time.Time, limit int) ([]*model.PhotographerRanking, error) {
+	var rankings []*model.PhotographerRanking
+	query := `WITH ` + analyticsFacts + `
+SELECT
+  galleries.photographer_id,
+  users.firstname,
+  users.lastname,
+  count(*) AS paid_bookings,
+  round(sum(facts.base_price))::bigint AS gross_volume
+FROM facts
+JOIN rooms ON rooms.id = facts.room_id
+JOIN galleries ON galleries.id = rooms.gallery_id
+JOIN users ON users.id = galleries.photographer_id
+WHERE facts.paid
+GROUP BY galleries.photographer_id, users.firstname, users.lastname
+ORDER BY gross_volume DESC, paid_bookings DESC
+LIMIT ?4`
+
+	if err := a.conn(ctx).NewRaw(query, nil, from, to, model.InstalmentPaidStatus, limit).Scan(ctx, &rankings); err != nil {
+		return nil, err
+	}
+
+	return rankings, nil
+}
+
+// TopLocations ranks the locations of the galleries by the gross volume of their paid bookings
+func (a *AnalyticsDB) TopLocations(ctx context.Context, from, to time.Time, limit int) ([]*model.LocationRanking, error) {
+	var rankings []*model.LocationRanking
+	query := `WITH ` + analyticsFacts + `
+SELECT
+  galleries.location,
+  count(*) AS paid_bookings,
+  round(sum(facts.base_price))::bigint AS gross_volume
+FROM facts
+JOIN rooms ON rooms.id = facts.room_id
+JOIN galleries ON galleries.id = rooms.gallery_id
+WHERE facts.paid
+GROUP BY galleries.location
+ORDER BY gross_volume DESC, paid_bookings DESC
+LIMIT ?4`
+
+	if err := a.conn(ctx).NewRaw(query, nil, from, to, model.InstalmentPaidStatus, limit).Scan(ctx, &rankings); err != nil {
+		return nil, err
+	}
+
+	return rankings, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// auditChainLock is the key of the advisory lock taken to append to the audit log
+const auditChainLock = 4_810_001
+
+type AuditEntryDB struct {
+	*BaseDB[model.AuditEntry]
+}
+
+func NewAuditEntryDB(db *bun.DB) *AuditEntryDB {
+	type T = model.AuditEntry
+
+	return &AuditEntryDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+// LockChain serialises the appends until the end of the transaction, so that every entry links to the last one
+func (a *AuditEntryDB) LockChain(ctx context.Context) error {
+	_, err := a.conn(ctx).ExecContext(ctx, "SELECT pg_advisory_xact_lock(?)", auditChainLock)
+	return err
+}
+
+func (a *AuditEntryDB) FindLast(ctx context.Context) (*model.AuditEntry, error) {
+	var entry model.AuditEntry
+	if err := a.conn(ctx).NewSelect().Model(&entry).OrderExpr("seq DESC").Limit(1).Scan(ctx, &entry); err != nil {
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+func (a *AuditEntryDB) FindWithFilter(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+	var entries []*model.AuditEntry
+	query := a.conn(ctx).NewSelect().Model(&entries)
+
+	if filter.ActorId != nil {
+		query = query.Where("actor_id = ?", uuid.MustParse(*filter.ActorId))
+	}
+	if filter.Action != nil {
+		query = query.Where("action = ?", *filter.Action)
+	}
+	if filter.TargetType != nil {
+		query = query.Where("target_type = ?", *filter.TargetType)
+	}
+	if filter.TargetId != nil {
+		query = query.Where("target_id = ?", uuid.MustParse(*filter.TargetId))
+	}
+	if filter.RequestId != nil {
+		query = query.Where("request_id = ?", *filter.RequestId)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.BeforeSeq != nil {
+		query = query.Where("seq < ?", *filter.BeforeSeq)
+	}
+
+	if err := query.OrderExpr("seq DESC").Limit(filter.Limit).Scan(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (a *AuditEntryDB) FindAfterSeq(ctx context.Context, seq int64, limit int) ([]*model.AuditEntry, error) {
+	var entries []*model.AuditEntry
+	if err := a.conn(ctx).NewSelect().Model(&entries).Where("seq > ?", seq).OrderExpr("seq ASC").Limit(limit).Scan(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
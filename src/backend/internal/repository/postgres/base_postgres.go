@@ -16,17 +16,17 @@ func NewBaseDB[T any](db *bun.DB) *BaseDB[T] {
 }
 
 func (b *BaseDB[T]) AddOne(ctx context.Context, model *T) error {
-	_, err := b.db.NewInsert().Model(model).Exec(ctx)
+	_, err := b.conn(ctx).NewInsert().Model(model).Exec(ctx)
 	return err
 }
 
 func (b *BaseDB[T]) AddBatch(ctx context.Context, models []*T) error {
-	_, err := b.db.NewInsert().Model(&models).Exec(ctx)
+	_, err := b.conn(ctx).NewInsert().Model(&models).Exec(ctx)
 	return err
 }
 
 func (b *BaseDB[T]) UpdateOne(ctx context.Context, model *T) error {
-	_, err := b.db.NewUpdate().Model(model).WherePK().Exec(ctx)
+	_, err := b.conn(ctx).NewUpdate().Model(model).WherePK().Exec(ctx)
 	return err
 }
 
@@ -34,7 +34,7 @@ func (b *BaseDB[T]) DeleteOneById(ctx context.Context, id uuid.UUID) (uuid.UUID,
 	var models []*T
 	var deletedId uuid.UUID
 
-	if err := b.db.NewDelete().Model(&models).Where("id = ?", id).Returning("id").Scan(ctx, &deletedId); err != nil {
+	if err := b.conn(ctx).NewDelete().Model(&models).Where("id = ?", id).Returning("id").Scan(ctx, &deletedId); err != nil {
 		return uuid.Nil, err
 	}
 	return deletedId, nil
@@ -44,7 +44,7 @@ func (b *BaseDB[T]) DeleteByIds(ctx context.Context, ids ...uuid.UUID) ([]uuid.U
 	var models []*T
 	var deletedIds []uuid.UUID
 
-	if err := b.db.NewDelete().Model(&models).Where("id IN (?)", bun.In(ids)).Returning("id").Scan(ctx, &deletedIds); err != nil {
+	if err := b.conn(ctx).NewDelete().Model(&models).Where("id IN (?)", bun.In(ids)).Returning("id").Scan(ctx, &deletedIds); err != nil {
 		return nil, err
 	}
 	return deletedIds, nil
@@ -52,7 +52,7 @@ func (b *BaseDB[T]) DeleteByIds(ctx context.Context, ids ...uuid.UUID) ([]uuid.U
 
 func (b *BaseDB[T]) FindOneById(ctx context.Context, id uuid.UUID) (*T, error) {
 	var model T
-	if err := b.db.NewSelect().Model(&model).Where("id = ?", id).Scan(ctx, &model); err != nil {
+	if err := b.conn(ctx).NewSelect().Model(&model).Where("id = ?", id).Scan(ctx, &model); err != nil {
 		return nil, err
 	}
 	return &model, nil
@@ -60,7 +60,7 @@ func (b *BaseDB[T]) FindOneById(ctx context.Context, id uuid.UUID) (*T, error) {
 
 func (b *BaseDB[T]) FindByIds(ctx context.Context, ids ...uuid.UUID) ([]*T, error) {
 	var models []*T
-	if err := b.db.NewSelect().Model(&models).Where("id IN (?)", bun.In(ids)).Scan(ctx, &models); err != nil {
+	if err := b.conn(ctx).NewSelect().Model(&models).Where("id IN (?)", bun.In(ids)).Scan(ctx, &models); err != nil {
 		return nil, err
 	}
 	return models, nil
@@ -21,13 +21,27 @@ func NewBookingDB(db *bun.DB) *BookingDB {
 	}
 }
 
+// LockOneById locks the booking until the end of the transaction, so that its status is not changed meanwhile
+func (b *BookingDB) LockOneById(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
+	var booking model.Booking
+	if err := b.conn(ctx).NewSelect().Model(&booking).Where("id = ?", id).For("UPDATE").Scan(ctx, &booking); err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
 func (b *BookingDB) FindByUserIdWithStatus(ctx context.Context, userId uuid.UUID, status ...string) ([]*model.Booking, error) {
 	var bookings []*model.Booking
-	query := b.db.NewSelect().Model(&bookings)
+	query := b.conn(ctx).NewSelect().Model(&bookings)
 
-	for _, acceptedStatus := range status {
-		query = query.WhereOr("status = ?", acceptedStatus)
-	}
+	// the statuses are grouped, so that the owner applies to all of them
+	query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, acceptedStatus := range status {
+			q = q.WhereOr("status = ?", acceptedStatus)
+		}
+		return q
+	})
 
 	query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
 		return q.Where("customer_id = ?", userId)
@@ -45,15 +59,19 @@ func (b *BookingDB) FindByPhotographerIdWithStatus(ctx context.Context, phtgId u
 	var rooms []*model.Room
 	var pkg model.Gallery
 
-	subq := b.db.NewSelect().Model(&pkg).Where("photographer_id = ?", phtgId).Column("id")
+	subq := b.conn(ctx).NewSelect().Model(&pkg).Where("photographer_id = ?", phtgId).Column("id")
 
-	medq := b.db.NewSelect().Model(&rooms).Where("gallery_id IN (?)", subq).Column("id")
+	medq := b.conn(ctx).NewSelect().Model(&rooms).Where("gallery_id IN (?)", subq).Column("id")
 
-	query := b.db.NewSelect().Model(&bookings)
+	query := b.conn(ctx).NewSelect().Model(&bookings)
 
-	for _, acceptedStatus := range status {
-		query = query.WhereOr("status = ?", acceptedStatus)
-	}
+	// the statuses are grouped, so that the owner applies to all of them
+	query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+		for _, acceptedStatus := range status {
+			q = q.WhereOr("status = ?", acceptedStatus)
+		}
+		return q
+	})
 
 	query = query.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
 		return q.Where("room_id IN (?)", medq)
@@ -66,26 +84,40 @@ func (b *BookingDB) FindByPhotographerIdWithStatus(ctx context.Context, phtgId u
 	return bookings, nil
 }
 
-func (b *BookingDB) UpdateStatusRoutine(ctx context.Context, currentTime time.Time) error {
-	var booking model.Booking
+// UpdateStatusRoutine returns the bookings whose status it changed by the status they had, a paid or partially paid
+// booking is completed once none of its sessions is left to take place, and a completed booking is paid out three days
+// after its end. A booking with a refund requested or a dispute opened is left alone until it is resolved: a rejected
+// refund puts it back to paid and a dispute settles it as completed, after which the routine picks it up again
+func (b *BookingDB) UpdateStatusRoutine(ctx context.Context, currentTime time.Time) (map[string][]*model.Booking, error) {
+	updated := map[string][]*model.Booking{}
 	paidOutTime := currentTime.Add(-time.Hour * 24 * 3)
 
-	_, err := b.db.NewUpdate().Model(&booking).Set("status = ?", model.BookingCompletedStatus).Where("status = ? AND end_time <= ?", model.BookingPaidStatus, currentTime).Exec(ctx)
-	if err != nil {
-		return err
+	scheduledSessions := b.conn(ctx).NewSelect().Model((*model.BookingSession)(nil)).
+		ColumnExpr("1").
+		Where("booking_sessions.booking_id = bookings.id AND booking_sessions.status = ?", model.BookingSessionScheduledStatus)
+
+	for _, status := range []string{model.BookingPaidStatus, model.BookingPartiallyPaidStatus} {
+		var completed []*model.Booking
+		_, err := b.conn(ctx).NewUpdate().Model((*model.Booking)(nil)).Set("status = ?", model.BookingCompletedStatus).Where("status = ? AND end_time <= ?", status, currentTime).Where("NOT EXISTS (?)", scheduledSessions).Returning("*").Exec(ctx, &completed)
+		if err != nil {
+			return nil, err
+		}
+		updated[status] = completed
 	}
 
-	_, err = b.db.NewUpdate().Model(&booking).Set("status = ?", model.BookingPaidOutStatus).Where("status = ? AND end_time <= ?", model.BookingCompletedStatus, paidOutTime).Exec(ctx)
+	var paidOut []*model.Booking
+	_, err := b.conn(ctx).NewUpdate().Model((*model.Booking)(nil)).Set("status = ?", model.BookingPaidOutStatus).Where("status = ? AND end_time <= ?", model.BookingCompletedStatus, paidOutTime).Returning("*").Exec(ctx, &paidOut)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	updated[model.BookingCompletedStatus] = paidOut
 
-	return nil
+	return updated, nil
 }
 
 func (b *BookingDB) ListPendingRefundBookings(ctx context.Context) ([]*model.Booking, error) {
 	var bookings []*model.Booking
-	if err := b.db.NewSelect().Model(&bookings).Where("status = ?", model.BookingRefundReqStatus).Scan(ctx, &bookings); err != nil {
+	if err := b.conn(ctx).NewSelect().Model(&bookings).Where("status = ?", model.BookingRefundReqStatus).Scan(ctx, &bookings); err != nil {
 		return nil, err
 	}
 
@@ -94,9 +126,78 @@ func (b *BookingDB) ListPendingRefundBookings(ctx context.Context) ([]*model.Boo
 
 func (b *BookingDB) FindByRoomId(ctx context.Context, roomId uuid.UUID) (*model.Booking, error) {
 	var booking model.Booking
-	if err := b.db.NewSelect().Model(&booking).Where("room_id = ?", roomId).Scan(ctx, &booking); err != nil {
+	if err := b.conn(ctx).NewSelect().Model(&booking).Where("room_id = ?", roomId).Scan(ctx, &booking); err != nil {
 		return nil, err
 	}
 
 	return &booking, nil
 }
+
+// FindForCalendar returns the bookings the user takes part in, as the customer or as the photographer, which end after
+// the time given
+func (b *BookingDB) FindForCalendar(ctx context.Context, userId uuid.UUID, endingAfter time.Time) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+
+	galleries := b.conn(ctx).NewSelect().Model((*model.Gallery)(nil)).Where("photographer_id = ?", userId).Column("id")
+	rooms := b.conn(ctx).NewSelect().Model((*model.Room)(nil)).Where("gallery_id IN (?)", galleries).Column("id")
+
+	if err := b.conn(ctx).NewSelect().Model(&bookings).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("customer_id = ?", userId).WhereOr("room_id IN (?)", rooms)
+		}).
+		Where("end_time > ?", endingAfter).
+		OrderExpr("start_time ASC").
+		Scan(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// FindAwaitingReview returns the completed bookings which ended within the period and have not been reviewed
+func (b *BookingDB) FindAwaitingReview(ctx context.Context, endedAfter, endedBefore time.Time) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+	reviews := b.conn(ctx).NewSelect().Model((*model.Review)(nil)).ColumnExpr("1").Where("reviews.booking_id = bookings.id")
+
+	if err := b.conn(ctx).NewSelect().Model(&bookings).
+		Where("status IN (?)", bun.In([]string{model.BookingCompletedStatus, model.BookingPaidOutStatus})).
+		Where("end_time > ? AND end_time <= ?", endedAfter, endedBefore).
+		Where("NOT EXISTS (?)", reviews).
+		Scan(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// FindStaleDrafts returns the DRAFT bookings which have not changed since the time given and locks them until the end
+// of the transaction, the ones locked by a payment are skipped
+func (b *BookingDB) FindStaleDrafts(ctx context.Context, updatedBefore time.Time) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+	if err := b.conn(ctx).NewSelect().Model(&bookings).Where("status = ? AND updated_at <= ?", model.BookingDraftStatus, updatedBefore).For("UPDATE SKIP LOCKED").Scan(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// FindOverdueDeliveries returns the completed bookings whose photos have not been delivered within the delivery time
+// of their gallery, in days after the end of the booking
+func (b *BookingDB) FindOverdueDeliveries(ctx context.Context, currentTime time.Time) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+	overdue := b.conn(ctx).NewSelect().Model((*model.Room)(nil)).
+		ColumnExpr("1").
+		Join("JOIN galleries ON galleries.id = rooms.gallery_id").
+		Where("rooms.id = bookings.room_id AND galleries.delivery_time > 0").
+		Where("bookings.end_time + galleries.delivery_time * interval '1 day' <= ?", currentTime)
+
+	if err := b.conn(ctx).NewSelect().Model(&bookings).
+		Where("status IN (?)", bun.In([]string{model.BookingCompletedStatus, model.BookingPaidOutStatus})).
+		Where("delivered_at IS NULL").
+		Where("EXISTS (?)", overdue).
+		Scan(ctx, &bookings); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
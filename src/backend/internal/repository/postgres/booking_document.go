@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BookingDocumentDB struct {
+	*BaseDB[model.BookingDocument]
+}
+
+func NewBookingDocumentDB(db *bun.DB) *BookingDocumentDB {
+	type T = model.BookingDocument
+
+	return &BookingDocumentDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (b *BookingDocumentDB) FindByBookingId(ctx context.Context, bookingId uuid.UUID) ([]*model.BookingDocument, error) {
+	var documents []*model.BookingDocument
+	if err := b.conn(ctx).NewSelect().Model(&documents).Where("booking_id = ?", bookingId).OrderExpr("issued_at ASC, sequence ASC").Scan(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+func (b *BookingDocumentDB) FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, filter model.BookingDocumentFilter) ([]*model.BookingDocument, error) {
+	var documents []*model.BookingDocument
+	query := b.conn(ctx).NewSelect().Model(&documents).Where("photographer_id = ?", photographerId)
+	if filter.Kind != nil {
+		query = query.Where("kind = ?", *filter.Kind)
+	}
+	if filter.BookingId != nil {
+		query = query.Where("booking_id = ?", *filter.BookingId)
+	}
+
+	if err := query.OrderExpr("issued_at DESC, sequence DESC").Scan(ctx, &documents); err != nil {
+		return nil, err
+	}
+
+	return documents, nil
+}
+
+// NextSequence takes the next number of the documents of a kind of the photographer, the row of the sequence stays
+// locked until the transaction ends so that the numbers have no gap
+func (b *BookingDocumentDB) NextSequence(ctx context.Context, photographerId uuid.UUID, kind string) (int, error) {
+	var sequence int
+	err := b.conn(ctx).NewRaw(
+		`INSERT INTO document_sequences (photographer_id, kind, last_sequence) VALUES (?, ?, 1)
+		ON CONFLICT (photographer_id, kind) DO UPDATE SET last_sequence = document_sequences.last_sequence + 1
+		RETURNING last_sequence`,
+		photographerId, kind,
+	).Scan(ctx, &sequence)
+
+	return sequence, err
+}
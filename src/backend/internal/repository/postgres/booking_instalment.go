@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BookingInstalmentDB struct {
+	*BaseDB[model.BookingInstalment]
+}
+
+func NewBookingInstalmentDB(db *bun.DB) *BookingInstalmentDB {
+	type T = model.BookingInstalment
+
+	return &BookingInstalmentDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (b *BookingInstalmentDB) FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingInstalment, error) {
+	var instalments []*model.BookingInstalment
+	if len(bookingIds) == 0 {
+		return instalments, nil
+	}
+
+	if err := b.conn(ctx).NewSelect().Model(&instalments).Where("booking_id IN (?)", bun.In(bookingIds)).OrderExpr("position ASC").Scan(ctx, &instalments); err != nil {
+		return nil, err
+	}
+
+	return instalments, nil
+}
+
+// LockByBookingId returns the instalments of the booking locked until the end of the transaction, so that an
+// instalment is not paid twice
+func (b *BookingInstalmentDB) LockByBookingId(ctx context.Context, bookingId uuid.UUID) ([]*model.BookingInstalment, error) {
+	var instalments []*model.BookingInstalment
+	if err := b.conn(ctx).NewSelect().Model(&instalments).Where("booking_id = ?", bookingId).OrderExpr("position ASC").For("UPDATE").Scan(ctx, &instalments); err != nil {
+		return nil, err
+	}
+
+	return instalments, nil
+}
+
+// CancelPending cancels the instalments of the booking that have not been paid, the paid ones are kept for the refund
+func (b *BookingInstalmentDB) CancelPending(ctx context.Context, bookingId uuid.UUID) error {
+	_, err := b.conn(ctx).NewUpdate().Model((*model.BookingInstalment)(nil)).
+		Set("status = ?", model.InstalmentCancelledStatus).
+		Set("updated_at = ?", time.Now()).
+		Where("booking_id = ? AND status = ?", bookingId, model.InstalmentPendingStatus).
+		Exec(ctx)
+	return err
+}
+
+// FindOverdue finds the instalments past their due date of the bookings still waiting for them, the deposits of the
+// DRAFT bookings and the balances of the PARTIALLY_PAID ones
+func (b *BookingInstalmentDB) FindOverdue(ctx context.Context, currentTime time.Time) ([]*model.BookingInstalment, error) {
+	var instalments []*model.BookingInstalment
+	draftBookings := b.conn(ctx).NewSelect().Model((*model.Booking)(nil)).Column("id").Where("status = ?", model.BookingDraftStatus)
+	partiallyPaidBookings := b.conn(ctx).NewSelect().Model((*model.Booking)(nil)).Column("id").Where("status = ?", model.BookingPartiallyPaidStatus)
+
+	if err := b.conn(ctx).NewSelect().Model(&instalments).
+		Where("status = ? AND due_at <= ?", model.InstalmentPendingStatus, currentTime).
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			return q.Where("kind = ? AND booking_id IN (?)", model.InstalmentDepositKind, draftBookings).
+				WhereOr("kind = ? AND booking_id IN (?)", model.InstalmentBalanceKind, partiallyPaidBookings)
+		}).
+		Scan(ctx, &instalments); err != nil {
+		return nil, err
+	}
+
+	return instalments, nil
+}
@@ -0,0 +1,42 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindOverdue(t *testing.T) {
+	db := connectTestDB(t)
+	instalmentDB := NewBookingInstalmentDB(db)
+	now := time.Now()
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		draft := addTestBooking(ctx, t, db, model.BookingDraftStatus, now)
+		partiallyPaid := addTestBooking(ctx, t, db, model.BookingPartiallyPaidStatus, now)
+
+		deposit := &model.BookingInstalment{Id: uuid.New(), BookingId: draft.Id, Position: 0, Kind: model.InstalmentDepositKind, Amount: 3000, DueAt: now.Add(-time.Hour), Status: model.InstalmentPendingStatus}
+		balance := &model.BookingInstalment{Id: uuid.New(), BookingId: partiallyPaid.Id, Position: 1, Kind: model.InstalmentBalanceKind, Amount: 7000, DueAt: now.Add(-time.Hour), Status: model.InstalmentPendingStatus}
+		// the balance of a DRAFT booking is not due before its deposit has been paid
+		draftBalance := &model.BookingInstalment{Id: uuid.New(), BookingId: draft.Id, Position: 1, Kind: model.InstalmentBalanceKind, Amount: 7000, DueAt: now.Add(-time.Hour), Status: model.InstalmentPendingStatus}
+		assert.NoError(t, instalmentDB.AddBatch(ctx, []*model.BookingInstalment{deposit, balance, draftBalance}))
+
+		overdue, err := instalmentDB.FindOverdue(ctx, now)
+		assert.NoError(t, err)
+
+		ids := map[uuid.UUID]bool{}
+		for _, instalment := range overdue {
+			ids[instalment.Id] = true
+		}
+		assert.True(t, ids[deposit.Id])
+		assert.True(t, ids[balance.Id])
+		assert.False(t, ids[draftBalance.Id])
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
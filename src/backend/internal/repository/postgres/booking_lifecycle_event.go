@@ -0,0 +1,31 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type BookingLifecycleEventDB struct {
+	*BaseDB[model.BookingLifecycleEvent]
+}
+
+func NewBookingLifecycleEventDB(db *bun.DB) *BookingLifecycleEventDB {
+	type T = model.BookingLifecycleEvent
+
+	return &BookingLifecycleEventDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+// AddUnique records the event unless another one of the same kind has already been recorded for its subject
+func (b *BookingLifecycleEventDB) AddUnique(ctx context.Context, event *model.BookingLifecycleEvent) (bool, error) {
+	res, err := b.conn(ctx).NewInsert().Model(event).On("CONFLICT (kind, subject_id) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BookingRequestDB struct {
+	*BaseDB[model.BookingRequest]
+}
+
+func NewBookingRequestDB(db *bun.DB) *BookingRequestDB {
+	type T = model.BookingRequest
+
+	return &BookingRequestDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+// LockOneById locks the request until the end of the transaction, so that it is answered only once
+func (b *BookingRequestDB) LockOneById(ctx context.Context, id uuid.UUID) (*model.BookingRequest, error) {
+	var request model.BookingRequest
+	if err := b.conn(ctx).NewSelect().Model(&request).Where("id = ?", id).For("UPDATE").Scan(ctx, &request); err != nil {
+		return nil, err
+	}
+
+	return &request, nil
+}
+
+func (b *BookingRequestDB) FindByCustomerId(ctx context.Context, customerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error) {
+	var requests []*model.BookingRequest
+	query := b.conn(ctx).NewSelect().Model(&requests).Where("customer_id = ?", customerId)
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+
+	if err := query.OrderExpr("created_at DESC").Scan(ctx, &requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+func (b *BookingRequestDB) FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error) {
+	var requests []*model.BookingRequest
+	galleries := b.conn(ctx).NewSelect().Model((*model.Gallery)(nil)).Column("id").Where("photographer_id = ?", photographerId)
+
+	query := b.conn(ctx).NewSelect().Model(&requests).Where("gallery_id IN (?)", galleries)
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+
+	if err := query.OrderExpr("created_at DESC").Scan(ctx, &requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+type BookingQuoteDB struct {
+	*BaseDB[model.BookingQuote]
+}
+
+func NewBookingQuoteDB(db *bun.DB) *BookingQuoteDB {
+	type T = model.BookingQuote
+
+	return &BookingQuoteDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (b *BookingQuoteDB) FindByRequestIds(ctx context.Context, requestIds ...uuid.UUID) ([]*model.BookingQuote, error) {
+	var quotes []*model.BookingQuote
+	if len(requestIds) == 0 {
+		return quotes, nil
+	}
+
+	if err := b.conn(ctx).NewSelect().Model(&quotes).Where("request_id IN (?)", bun.In(requestIds)).OrderExpr("round ASC").Scan(ctx, &quotes); err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
+}
+
+// FindLatestByRequestId locks the quote of the last round, so that two answers to it cannot both go through
+func (b *BookingQuoteDB) FindLatestByRequestId(ctx context.Context, requestId uuid.UUID) (*model.BookingQuote, error) {
+	var quote model.BookingQuote
+	if err := b.conn(ctx).NewSelect().Model(&quote).Where("request_id = ?", requestId).OrderExpr("round DESC").Limit(1).For("UPDATE").Scan(ctx, &quote); err != nil {
+		return nil, err
+	}
+
+	return &quote, nil
+}
+
+// ExpirePending expires the pending quotes that have not been answered in time
+func (b *BookingQuoteDB) ExpirePending(ctx context.Context, currentTime time.Time) ([]*model.BookingQuote, error) {
+	var quotes []*model.BookingQuote
+	_, err := b.conn(ctx).NewUpdate().Model((*model.BookingQuote)(nil)).
+		Set("status = ?", model.BookingQuoteExpiredStatus).
+		Set("updated_at = ?", currentTime).
+		Where("status = ? AND expires_at <= ?", model.BookingQuotePendingStatus, currentTime).
+		Returning("*").
+		Exec(ctx, &quotes)
+	if err != nil {
+		return nil, err
+	}
+
+	return quotes, nil
+}
@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type BookingSessionDB struct {
+	*BaseDB[model.BookingSession]
+}
+
+func NewBookingSessionDB(db *bun.DB) *BookingSessionDB {
+	type T = model.BookingSession
+
+	return &BookingSessionDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (b *BookingSessionDB) FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingSession, error) {
+	var sessions []*model.BookingSession
+	if len(bookingIds) == 0 {
+		return sessions, nil
+	}
+
+	if err := b.conn(ctx).NewSelect().Model(&sessions).Where("booking_id IN (?)", bun.In(bookingIds)).OrderExpr("start_time ASC").Scan(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// CancelScheduled cancels the sessions of the booking that have not taken place yet
+func (b *BookingSessionDB) CancelScheduled(ctx context.Context, bookingId uuid.UUID) error {
+	_, err := b.conn(ctx).NewUpdate().Model((*model.BookingSession)(nil)).
+		Set("status = ?", model.BookingSessionCancelledStatus).
+		Set("updated_at = ?", time.Now()).
+		Where("booking_id = ? AND status = ?", bookingId, model.BookingSessionScheduledStatus).
+		Exec(ctx)
+	return err
+}
+
+// CompleteEnded completes the sessions of the paid and partially paid bookings that are over
+func (b *BookingSessionDB) CompleteEnded(ctx context.Context, currentTime time.Time) ([]*model.BookingSession, error) {
+	var sessions []*model.BookingSession
+	paidBookings := b.conn(ctx).NewSelect().Model((*model.Booking)(nil)).Column("id").Where("status IN (?)", bun.In([]string{model.BookingPaidStatus, model.BookingPartiallyPaidStatus}))
+
+	_, err := b.conn(ctx).NewUpdate().Model((*model.BookingSession)(nil)).
+		Set("status = ?", model.BookingSessionCompletedStatus).
+		Set("updated_at = ?", currentTime).
+		Where("status = ? AND end_time <= ?", model.BookingSessionScheduledStatus, currentTime).
+		Where("booking_id IN (?)", paidBookings).
+		Returning("*").
+		Exec(ctx, &sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// FindStartingBetween returns the scheduled sessions of the paid bookings, in full or in part, starting within the period
+func (b *BookingSessionDB) FindStartingBetween(ctx context.Context, from, to time.Time) ([]*model.BookingSession, error) {
+	var sessions []*model.BookingSession
+	paidBookings := b.conn(ctx).NewSelect().Model((*model.Booking)(nil)).Column("id").
+		Where("status IN (?)", bun.In([]string{model.BookingPaidStatus, model.BookingPartiallyPaidStatus}))
+
+	if err := b.conn(ctx).NewSelect().Model(&sessions).
+		Where("status = ? AND start_time >= ? AND start_time < ?", model.BookingSessionScheduledStatus, from, to).
+		Where("booking_id IN (?)", paidBookings).
+		OrderExpr("start_time ASC").
+		Scan(ctx, &sessions); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+)
+
+// addTestBooking adds the booking of a new customer in the chat of a new gallery
+func addTestBooking(ctx context.Context, t *testing.T, db *bun.DB, status string, updatedAt time.Time) *model.Booking {
+	customer, photographer := newTestUser(), newTestUser()
+	assert.NoError(t, NewUserDB(db).AddBatch(ctx, []*model.User{customer, photographer}))
+
+	gallery := &model.Gallery{Id: uuid.New(), PhotographerId: photographer.Id, Name: "stale drafts", Currency: "THB", Included: []string{}}
+	assert.NoError(t, NewGalleryDB(db).AddOne(ctx, gallery))
+
+	room := &model.Room{Id: uuid.New(), GalleryId: gallery.Id}
+	assert.NoError(t, NewRoomDB(db).AddOne(ctx, room))
+
+	booking := &model.Booking{
+		Id:         uuid.New(),
+		CustomerId: customer.Id,
+		RoomId:     room.Id,
+		Currency:   "THB",
+		StartTime:  updatedAt.Add(24 * time.Hour),
+		EndTime:    updatedAt.Add(26 * time.Hour),
+		Status:     status,
+		CreatedAt:  updatedAt,
+		UpdatedAt:  updatedAt,
+	}
+	assert.NoError(t, NewBookingDB(db).AddOne(ctx, booking))
+
+	return booking
+}
+
+func TestFindStaleDrafts(t *testing.T) {
+	db := connectTestDB(t)
+	bookingDB := NewBookingDB(db)
+	now := time.Now()
+
+	// nothing is kept, so that the drafts of other runs do not get in the way
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		stale := addTestBooking(ctx, t, db, model.BookingDraftStatus, now.Add(-2*time.Hour))
+		fresh := addTestBooking(ctx, t, db, model.BookingDraftStatus, now)
+		paid := addTestBooking(ctx, t, db, model.BookingPaidStatus, now.Add(-2*time.Hour))
+
+		bookings, err := bookingDB.FindStaleDrafts(ctx, now.Add(-time.Hour))
+		assert.NoError(t, err)
+
+		ids := map[uuid.UUID]bool{}
+		for _, booking := range bookings {
+			ids[booking.Id] = true
+		}
+		assert.True(t, ids[stale.Id])
+		assert.False(t, ids[fresh.Id])
+		assert.False(t, ids[paid.Id])
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
+
+func TestUpdateStatusRoutine(t *testing.T) {
+	db := connectTestDB(t)
+	bookingDB := NewBookingDB(db)
+	now := time.Now()
+
+	// the bookings of addTestBooking end 26 hours after the time given
+	ended, longEnded, upcoming := now.Add(-30*time.Hour), now.Add(-4*24*time.Hour), now
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		paid := addTestBooking(ctx, t, db, model.BookingPaidStatus, ended)
+		partiallyPaid := addTestBooking(ctx, t, db, model.BookingPartiallyPaidStatus, ended)
+		paidUpcoming := addTestBooking(ctx, t, db, model.BookingPaidStatus, upcoming)
+		paidLongAgo := addTestBooking(ctx, t, db, model.BookingPaidStatus, longEnded)
+		completed := addTestBooking(ctx, t, db, model.BookingCompletedStatus, longEnded)
+		completedRecently := addTestBooking(ctx, t, db, model.BookingCompletedStatus, ended)
+		refundRequested := addTestBooking(ctx, t, db, model.BookingRefundReqStatus, longEnded)
+		disputed := addTestBooking(ctx, t, db, model.BookingDisputedStatus, longEnded)
+
+		updated, err := bookingDB.UpdateStatusRoutine(ctx, now)
+		assert.NoError(t, err)
+
+		ids := func(status string) map[uuid.UUID]bool {
+			found := map[uuid.UUID]bool{}
+			for _, booking := range updated[status] {
+				found[booking.Id] = true
+			}
+			return found
+		}
+		fromPaid, fromPartiallyPaid, fromCompleted := ids(model.BookingPaidStatus), ids(model.BookingPartiallyPaidStatus), ids(model.BookingCompletedStatus)
+
+		assert.True(t, fromPaid[paid.Id])
+		assert.True(t, fromPartiallyPaid[partiallyPaid.Id])
+		assert.False(t, fromPaid[paidUpcoming.Id])
+		assert.True(t, fromCompleted[completed.Id])
+		assert.False(t, fromCompleted[completedRecently.Id])
+		// completed and then paid out within the same run
+		assert.True(t, fromPaid[paidLongAgo.Id])
+		assert.True(t, fromCompleted[paidLongAgo.Id])
+
+		for _, booking := range []*model.Booking{refundRequested, disputed} {
+			found, err := bookingDB.FindOneById(ctx, booking.Id)
+			assert.NoError(t, err)
+			assert.Equal(t, booking.Status, found.Status)
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
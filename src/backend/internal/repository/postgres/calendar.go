@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type CalendarFeedDB struct {
+	*BaseDB[model.CalendarFeed]
+}
+
+func NewCalendarFeedDB(db *bun.DB) *CalendarFeedDB {
+	type T = model.CalendarFeed
+
+	return &CalendarFeedDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (c *CalendarFeedDB) FindOneByUserId(ctx context.Context, userId uuid.UUID) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := c.conn(ctx).NewSelect().Model(&feed).Where("user_id = ?", userId).Scan(ctx, &feed); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func (c *CalendarFeedDB) FindOneByTokenHash(ctx context.Context, tokenHash string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+	if err := c.conn(ctx).NewSelect().Model(&feed).Where("token_hash = ?", tokenHash).Scan(ctx, &feed); err != nil {
+		return nil, err
+	}
+
+	return &feed, nil
+}
+
+func (c *CalendarFeedDB) DeleteByUserId(ctx context.Context, userId uuid.UUID) error {
+	var feed model.CalendarFeed
+	_, err := c.conn(ctx).NewDelete().Model(&feed).Where("user_id = ?", userId).Exec(ctx)
+	return err
+}
+
+type BusyBlockDB struct {
+	*BaseDB[model.BusyBlock]
+}
+
+func NewBusyBlockDB(db *bun.DB) *BusyBlockDB {
+	type T = model.BusyBlock
+
+	return &BusyBlockDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+// FindByPhotographerId returns the blocks of the photographer that overlap the period from to, sorted by their start
+func (b *BusyBlockDB) FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.BusyBlock, error) {
+	var blocks []*model.BusyBlock
+	if err := b.conn(ctx).NewSelect().Model(&blocks).
+		Where("photographer_id = ?", photographerId).
+		Where("start_time < ?", to).
+		Where("end_time > ?", from).
+		OrderExpr("start_time ASC").
+		Scan(ctx, &blocks); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+func (b *BusyBlockDB) DeleteByPhotographerId(ctx context.Context, photographerId uuid.UUID) error {
+	var block model.BusyBlock
+	_, err := b.conn(ctx).NewDelete().Model(&block).Where("photographer_id = ?", photographerId).Exec(ctx)
+	return err
+}
@@ -22,9 +22,34 @@ func NewConversationDB(db *bun.DB) *ConversationDB {
 
 func (c *ConversationDB) ListByRoomId(ctx context.Context, roomId uuid.UUID) ([]*model.Conversation, error) {
 	var conversations []*model.Conversation
-	if err := c.db.NewSelect().Model(&conversations).Where("room_id = ?", roomId).OrderExpr("created_at DESC").Scan(ctx, &conversations); err != nil {
+	if err := c.conn(ctx).NewSelect().Model(&conversations).Where("room_id = ?", roomId).OrderExpr("created_at DESC").Scan(ctx, &conversations); err != nil {
 		return nil, err
 	}
 
 	return conversations, nil
 }
+
+func (c *ConversationDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.Conversation, error) {
+	var conversations []*model.Conversation
+	if err := c.conn(ctx).NewSelect().Model(&conversations).Where("user_id = ?", userId).OrderExpr("created_at ASC").Scan(ctx, &conversations); err != nil {
+		return nil, err
+	}
+
+	return conversations, nil
+}
+
+func (c *ConversationDB) RedactByUserId(ctx context.Context, userId uuid.UUID, text string) error {
+	var conversation model.Conversation
+	_, err := c.conn(ctx).NewUpdate().Model(&conversation).Set("text = ?", text).Set("updated_at = now()").Where("user_id = ?", userId).Exec(ctx)
+	return err
+}
+
+// FindByResourceId finds the card posted about a booking request or a quote
+func (c *ConversationDB) FindByResourceId(ctx context.Context, resourceId uuid.UUID) (*model.Conversation, error) {
+	var conversation model.Conversation
+	if err := c.conn(ctx).NewSelect().Model(&conversation).Where("resource_id = ?", resourceId).Limit(1).Scan(ctx, &conversation); err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type CouponDB struct {
+	*BaseDB[model.Coupon]
+}
+
+func NewCouponDB(db *bun.DB) *CouponDB {
+	type T = model.Coupon
+
+	return &CouponDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (c *CouponDB) FindWithFilter(ctx context.Context, filter model.CouponFilter) ([]*model.Coupon, error) {
+	var coupons []*model.Coupon
+	query := c.conn(ctx).NewSelect().Model(&coupons)
+	if filter.PhotographerId != nil {
+		query = query.Where("photographer_id = ?", *filter.PhotographerId)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+
+	if err := query.OrderExpr("created_at DESC").Scan(ctx, &coupons); err != nil {
+		return nil, err
+	}
+
+	return coupons, nil
+}
+
+// FindOneByCode locks the coupon, so that two redemptions cannot both take its last use
+func (c *CouponDB) FindOneByCode(ctx context.Context, code string) (*model.Coupon, error) {
+	var coupon model.Coupon
+	if err := c.conn(ctx).NewSelect().Model(&coupon).Where("code = ?", code).For("UPDATE").Scan(ctx, &coupon); err != nil {
+		return nil, err
+	}
+
+	return &coupon, nil
+}
+
+type CouponRedemptionDB struct {
+	*BaseDB[model.CouponRedemption]
+}
+
+func NewCouponRedemptionDB(db *bun.DB) *CouponRedemptionDB {
+	type T = model.CouponRedemption
+
+	return &CouponRedemptionDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (c *CouponRedemptionDB) FindByCouponId(ctx context.Context, couponId uuid.UUID) ([]*model.CouponRedemption, error) {
+	var redemptions []*model.CouponRedemption
+	if err := c.conn(ctx).NewSelect().Model(&redemptions).Where("coupon_id = ?", couponId).OrderExpr("created_at DESC").Scan(ctx, &redemptions); err != nil {
+		return nil, err
+	}
+
+	return redemptions, nil
+}
+
+func (c *CouponRedemptionDB) FindOneByBookingId(ctx context.Context, bookingId uuid.UUID) (*model.CouponRedemption, error) {
+	var redemption model.CouponRedemption
+	if err := c.conn(ctx).NewSelect().Model(&redemption).Where("booking_id = ?", bookingId).Scan(ctx, &redemption); err != nil {
+		return nil, err
+	}
+
+	return &redemption, nil
+}
+
+// CountRedeemed counts the redemptions of the coupon, of the user only if given, the ones of cancelled bookings
+// give their use back
+func (c *CouponRedemptionDB) CountRedeemed(ctx context.Context, couponId uuid.UUID, userId *uuid.UUID) (int, error) {
+	cancelledBookings := c.conn(ctx).NewSelect().Model((*model.Booking)(nil)).Column("id").Where("status = ?", model.BookingCancelledStatus)
+
+	query := c.conn(ctx).NewSelect().Model((*model.CouponRedemption)(nil)).
+		Where("coupon_id = ?", couponId).
+		Where("booking_id NOT IN (?)", cancelledBookings)
+	if userId != nil {
+		query = query.Where("user_id = ?", *userId)
+	}
+
+	return query.Count(ctx)
+}
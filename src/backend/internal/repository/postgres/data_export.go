@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type DataExportDB struct {
+	*BaseDB[model.DataExport]
+}
+
+func NewDataExportDB(db *bun.DB) *DataExportDB {
+	type T = model.DataExport
+
+	return &DataExportDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (d *DataExportDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.DataExport, error) {
+	var exports []*model.DataExport
+	if err := d.conn(ctx).NewSelect().Model(&exports).Where("user_id = ?", userId).OrderExpr("created_at DESC").Scan(ctx, &exports); err != nil {
+		return nil, err
+	}
+
+	return exports, nil
+}
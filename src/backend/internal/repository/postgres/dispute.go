@@ -0,0 +1,69 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type DisputeDB struct {
+	*BaseDB[model.Dispute]
+}
+
+func NewDisputeDB(db *bun.DB) *DisputeDB {
+	type T = model.Dispute
+
+	return &DisputeDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (d *DisputeDB) FindLatestByBookingId(ctx context.Context, bookingId uuid.UUID) (*model.Dispute, error) {
+	var dispute model.Dispute
+	if err := d.conn(ctx).NewSelect().Model(&dispute).Where("booking_id = ?", bookingId).OrderExpr("created_at DESC").Limit(1).Scan(ctx, &dispute); err != nil {
+		return nil, err
+	}
+
+	return &dispute, nil
+}
+
+// FindResolvedByBookingIds returns the resolved disputes of the bookings, a booking has at most one
+func (d *DisputeDB) FindResolvedByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.Dispute, error) {
+	disputes := []*model.Dispute{}
+	if len(bookingIds) == 0 {
+		return disputes, nil
+	}
+
+	if err := d.conn(ctx).NewSelect().Model(&disputes).Where("booking_id IN (?) AND status = ?", bun.In(bookingIds), model.DisputeResolvedStatus).Scan(ctx, &disputes); err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
+
+func (d *DisputeDB) FindWithFilter(ctx context.Context, filter model.DisputeFilter) ([]*model.Dispute, error) {
+	var disputes []*model.Dispute
+	query := d.conn(ctx).NewSelect().Model(&disputes)
+
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+	if filter.MediatorId != nil {
+		query = query.Where("mediator_id = ?", uuid.MustParse(*filter.MediatorId))
+	}
+	if filter.Unassigned != nil {
+		if *filter.Unassigned {
+			query = query.Where("mediator_id IS NULL")
+		} else {
+			query = query.Where("mediator_id IS NOT NULL")
+		}
+	}
+
+	if err := query.OrderExpr("created_at ASC").Scan(ctx, &disputes); err != nil {
+		return nil, err
+	}
+
+	return disputes, nil
+}
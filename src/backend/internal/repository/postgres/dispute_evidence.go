@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type DisputeEvidenceDB struct {
+	*BaseDB[model.DisputeEvidence]
+}
+
+func NewDisputeEvidenceDB(db *bun.DB) *DisputeEvidenceDB {
+	type T = model.DisputeEvidence
+
+	return &DisputeEvidenceDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (d *DisputeEvidenceDB) FindByDisputeIds(ctx context.Context, disputeIds ...uuid.UUID) ([]*model.DisputeEvidence, error) {
+	var evidence []*model.DisputeEvidence
+	if len(disputeIds) == 0 {
+		return evidence, nil
+	}
+
+	if err := d.conn(ctx).NewSelect().Model(&evidence).Where("dispute_id IN (?)", bun.In(disputeIds)).OrderExpr("created_at ASC").Scan(ctx, &evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+func (d *DisputeEvidenceDB) FindBySubmitterId(ctx context.Context, submitterId uuid.UUID) ([]*model.DisputeEvidence, error) {
+	var evidence []*model.DisputeEvidence
+	if err := d.conn(ctx).NewSelect().Model(&evidence).Where("submitted_by_id = ?", submitterId).OrderExpr("created_at ASC").Scan(ctx, &evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+// the photos are removed from the bucket by the caller, the chat excerpts quote the other party too so they go with them
+func (d *DisputeEvidenceDB) RedactBySubmitterId(ctx context.Context, submitterId uuid.UUID, text string) error {
+	var evidence model.DisputeEvidence
+	_, err := d.conn(ctx).NewUpdate().Model(&evidence).
+		Set("text = CASE WHEN text IS NULL THEN NULL ELSE ? END", text).
+		Set("photo_keys = '{}'").
+		Set("excerpt = NULL").
+		Where("submitted_by_id = ?", submitterId).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type ExchangeRateDB struct {
+	*BaseDB[model.ExchangeRate]
+}
+
+func NewExchangeRateDB(db *bun.DB) *ExchangeRateDB {
+	type T = model.ExchangeRate
+
+	return &ExchangeRateDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (e *ExchangeRateDB) FindAll(ctx context.Context) ([]*model.ExchangeRate, error) {
+	var rates []*model.ExchangeRate
+	if err := e.conn(ctx).NewSelect().Model(&rates).OrderExpr("currency ASC").Scan(ctx, &rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// Upsert replaces the rates of the currencies given, the other rates are kept
+func (e *ExchangeRateDB) Upsert(ctx context.Context, rates []*model.ExchangeRate) error {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	_, err := e.conn(ctx).NewInsert().Model(&rates).
+		On("CONFLICT (currency) DO UPDATE").
+		Set("rate = EXCLUDED.rate").
+		Set("source = EXCLUDED.source").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
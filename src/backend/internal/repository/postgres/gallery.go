@@ -23,7 +23,7 @@ func NewGalleryDB(db *bun.DB) *GalleryDB {
 
 func (p *GalleryDB) FindByPhotographerId(ctx context.Context, photographerId uuid.UUID) ([]*model.Gallery, error) {
 	var galleries []*model.Gallery
-	if err := p.db.NewSelect().Model(&galleries).Where("photographer_id = ?", photographerId).Scan(ctx, &galleries); err != nil {
+	if err := p.conn(ctx).NewSelect().Model(&galleries).Where("photographer_id = ?", photographerId).Scan(ctx, &galleries); err != nil {
 		return nil, err
 	}
 
@@ -32,7 +32,7 @@ func (p *GalleryDB) FindByPhotographerId(ctx context.Context, photographerId uui
 
 func (p *GalleryDB) SearchWithFilter(ctx context.Context, filter *model.SearchFilter) ([]*model.Gallery, error) {
 	var galleries []*model.Gallery
-	query := p.db.NewSelect().Model(&galleries)
+	query := p.conn(ctx).NewSelect().Model(&galleries)
 
 	if filter.PhotographerId != nil {
 		query.Where("photographer_id = ?", uuid.MustParse(*filter.PhotographerId))
@@ -40,17 +40,13 @@ func (p *GalleryDB) SearchWithFilter(ctx context.Context, filter *model.SearchFi
 		query.Where("photographer_id IN (?)", bun.In(filter.MatchedConditionPhotographerIds))
 	}
 
+	// galleries of erased photographers are kept for their bookings but no longer listed
+	query.Where("hidden = ?", false)
+
 	if filter.Location != nil {
 		query.Where("location = ?", *filter.Location)
 	}
 
-	if filter.MinPrice != nil {
-		query.Where("price >= ?", *filter.MinPrice)
-	}
-	if filter.MaxPrice != nil {
-		query.Where("price <= ?", *filter.MaxPrice)
-	}
-
 	if filter.GalleryName != nil {
 		query.Where("name LIKE ?", fmt.Sprintf("%%%s%%", *filter.GalleryName))
 	}
@@ -61,3 +57,9 @@ func (p *GalleryDB) SearchWithFilter(ctx context.Context, filter *model.SearchFi
 
 	return galleries, nil
 }
+
+func (p *GalleryDB) HideByPhotographerId(ctx context.Context, photographerId uuid.UUID) error {
+	var gallery model.Gallery
+	_, err := p.conn(ctx).NewUpdate().Model(&gallery).Set("hidden = ?", true).Where("photographer_id = ?", photographerId).Exec(ctx)
+	return err
+}
@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHideByPhotographerId(t *testing.T) {
+	db := connectTestDB(t)
+	galleryDB := NewGalleryDB(db)
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		erased, other := newTestUser(), newTestUser()
+		assert.NoError(t, NewUserDB(db).AddBatch(ctx, []*model.User{erased, other}))
+
+		for _, photographer := range []*model.User{erased, erased, other} {
+			gallery := &model.Gallery{Id: uuid.New(), PhotographerId: photographer.Id, Name: "hidden galleries", Currency: "THB", Included: []string{}}
+			assert.NoError(t, galleryDB.AddOne(ctx, gallery))
+		}
+
+		assert.NoError(t, galleryDB.HideByPhotographerId(ctx, erased.Id))
+
+		// the galleries are kept for the bookings but no longer listed
+		galleries, err := galleryDB.FindByPhotographerId(ctx, erased.Id)
+		assert.NoError(t, err)
+		assert.Len(t, galleries, 2)
+		for _, gallery := range galleries {
+			assert.True(t, gallery.Hidden)
+		}
+
+		for photographer, listed := range map[uuid.UUID]int{erased.Id: 0, other.Id: 1} {
+			photographerId := photographer.String()
+			galleries, err := galleryDB.SearchWithFilter(ctx, &model.SearchFilter{PhotographerId: &photographerId})
+			assert.NoError(t, err)
+			assert.Len(t, galleries, listed)
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
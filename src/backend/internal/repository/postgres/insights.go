@@ -0,0 +1,243 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// the queries take the photographer as ?0 and the range as ?1 and ?2
+
+// the months of the range, as timestamps in UTC
+const insightsMonths = `
+months AS (
+  SELECT generate_series(
+    date_trunc('month', ?1::timestamptz AT TIME ZONE 'UTC'),
+    ?2::timestamptz AT TIME ZONE 'UTC' - interval '1 microsecond',
+    interval '1 month'
+  ) AS month
+)`
+
+// the bookings made in the chats of the galleries of the photographer
+const insightsBookings = `
+own_bookings AS (
+  SELECT bookings.*, rooms.gallery_id
+  FROM bookings
+  JOIN rooms ON rooms.id = bookings.room_id
+  JOIN galleries ON galleries.id = rooms.gallery_id
+  WHERE galleries.photographer_id = ?0
+)`
+
+type InsightsDB struct {
+	db *bun.DB
+}
+
+func NewInsightsDB(db *bun.DB) *InsightsDB {
+	return &InsightsDB{db: db}
+}
+
+func (i *InsightsDB) conn(ctx context.Context) bun.IDB {
+	return connOf(ctx, i.db)
+}
+
+// AddView adds the views to the counter of the gallery for the day
+func (i *InsightsDB) AddView(ctx context.Context, view *model.GalleryView) error {
+	_, err := i.conn(ctx).NewInsert().
+		Model(view).
+		On("CONFLICT (gallery_id, day, kind) DO UPDATE").
+		Set("views = gallery_views.views + EXCLUDED.views").
+		Exec(ctx)
+	return err
+}
+
+func (i *InsightsDB) MonthlyEarnings(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.MonthlyEarnings, error) {
+	var earnings []*model.MonthlyEarnings
+	query := `WITH ` + insightsMonths + `, ` + insightsBookings + `,
+payments AS (
+  SELECT
+    date_trunc('month', booking_instalments.paid_at AT TIME ZONE 'UTC') AS month,
+    own_bookings.id AS booking_id,
+    -- a resolved dispute leaves the photographer its share of what has been paid
+    booking_instalments.amount / COALESCE(exchange_rates.rate, 1)
+      * COALESCE(disputes.photographer_amount::numeric / NULLIF(disputes.disputed_amount, 0), 1) AS base_amount
+  FROM booking_instalments
+  JOIN own_bookings ON own_bookings.id = booking_instalments.booking_id
+  LEFT JOIN exchange_rates ON exchange_rates.currency = own_bookings.currency
+  LEFT JOIN disputes ON disputes.booking_id = own_bookings.id AND disputes.status = ?5
+  WHERE booking_instalments.status = ?3 AND own_bookings.status <> ?4
+    AND booking_instalments.paid_at >= ?1 AND booking_instalments.paid_at < ?2
+)
+SELECT
+  months.month,
+  count(DISTINCT payments.booking_id) AS paid_bookings,
+  COALESCE(round(sum(payments.base_amount)), 0)::bigint AS earnings
+FROM months
+LEFT JOIN payments ON payments.month = months.month
+GROUP BY months.month
+ORDER BY months.month`
+
+	if err := i.conn(ctx).NewRaw(query, photographerId, from, to, model.InstalmentPaidStatus, model.BookingCancelledStatus, model.DisputeResolvedStatus).Scan(ctx, &earnings); err != nil {
+		return nil, err
+	}
+
+	return earnings, nil
+}
+
+// GalleryInsights counts the views, the chats opened and the bookings made in the range for every gallery
+func (i *InsightsDB) GalleryInsights(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.GalleryInsights, error) {
+	var insights []*model.GalleryInsights
+	query := `WITH ` + insightsBookings + `,
+views AS (
+  SELECT
+    gallery_id,
+    sum(views) FILTER (WHERE kind = ?3) AS detail_views,
+    sum(views) FILTER (WHERE kind = ?4) AS photo_views
+  FROM gallery_views
+  WHERE day >= (?1::timestamptz AT TIME ZONE 'UTC')::date AND day < (?2::timestamptz AT TIME ZONE 'UTC')::date
+  GROUP BY gallery_id
+),
+paid AS (
+  SELECT own_bookings.*, EXISTS (
+    SELECT 1 FROM booking_instalments
+    WHERE booking_instalments.booking_id = own_bookings.id AND booking_instalments.status = ?5
+  ) AS paid
+  FROM own_bookings
+),
+chats AS (
+  SELECT
+    rooms.gallery_id,
+    count(*) AS chats,
+    count(*) FILTER (WHERE EXISTS (SELECT 1 FROM paid WHERE paid.room_id = rooms.id AND paid.paid)) AS booked_chats
+  FROM rooms
+  WHERE rooms.created_at >= ?1 AND rooms.created_at < ?2
+  GROUP BY rooms.gallery_id
+),
+booked AS (
+  SELECT gallery_id, count(*) AS bookings, count(*) FILTER (WHERE paid) AS paid_bookings
+  FROM paid
+  WHERE created_at >= ?1 AND created_at < ?2
+  GROUP BY gallery_id
+)
+SELECT
+  galleries.id AS gallery_id,
+  galleries.name,
+  COALESCE(views.detail_views, 0) AS detail_views,
+  COALESCE(views.photo_views, 0) AS photo_views,
+  COALESCE(chats.chats, 0) AS chats,
+  COALESCE(chats.booked_chats, 0) AS booked_chats,
+  COALESCE(booked.bookings, 0) AS bookings,
+  COALESCE(booked.paid_bookings, 0) AS paid_bookings
+FROM galleries
+LEFT JOIN views ON views.gallery_id = galleries.id
+LEFT JOIN chats ON chats.gallery_id = galleries.id
+LEFT JOIN booked ON booked.gallery_id = galleries.id
+WHERE galleries.photographer_id = ?0
+ORDER BY paid_bookings DESC, detail_views DESC, galleries.name`
+
+	if err := i.conn(ctx).NewRaw(query, photographerId, from, to, model.GalleryViewDetailsKind, model.GalleryViewPhotosKind, model.InstalmentPaidStatus).Scan(ctx, &insights); err != nil {
+		return nil, err
+	}
+
+	return insights, nil
+}
+
+func (i *InsightsDB) RatingTrend(ctx context.Context, photographerId uuid.UUID, from, to time.Time) ([]*model.RatingTrend, error) {
+	var trend []*model.RatingTrend
+	query := `WITH ` + insightsMonths + `, ` + insightsBookings + `,
+own_reviews AS (
+  SELECT date_trunc('month', reviews.created_at AT TIME ZONE 'UTC') AS month, reviews.rating
+  FROM reviews
+  JOIN own_bookings ON own_bookings.id = reviews.booking_id
+  WHERE reviews.created_at >= ?1 AND reviews.created_at < ?2
+)
+SELECT
+  months.month,
+  count(own_reviews.rating) AS reviews,
+  avg(own_reviews.rating)::float8 AS average_rating
+FROM months
+LEFT JOIN own_reviews ON own_reviews.month = months.month
+GROUP BY months.month
+ORDER BY months.month`
+
+	if err := i.conn(ctx).NewRaw(query, photographerId, from, to).Scan(ctx, &trend); err != nil {
+		return nil, err
+	}
+
+	return trend, nil
+}
+
+// ResponseTime measures how long the customers who wrote to the photographer in the range waited for an answer
+func (i *InsightsDB) ResponseTime(ctx context.Context, photographerId uuid.UUID, from, to time.Time) (*model.ResponseTime, error) {
+	responseTime := &model.ResponseTime{}
+	query := `WITH messages AS (
+  SELECT
+    convs.room_id,
+    convs.created_at,
+    convs.user_id = ?0 AS mine,
+    lag(convs.user_id = ?0) OVER (PARTITION BY convs.room_id ORDER BY convs.created_at) AS previous_mine
+  FROM conversations AS convs
+  JOIN rooms ON rooms.id = convs.room_id
+  JOIN galleries ON galleries.id = rooms.gallery_id
+  WHERE galleries.photographer_id = ?0 AND convs.created_at >= ?1
+),
+waits AS (
+  SELECT
+    asked.created_at AS asked_at,
+    (
+      SELECT min(answer.created_at) FROM messages AS answer
+      WHERE answer.room_id = asked.room_id AND answer.mine AND answer.created_at > asked.created_at
+    ) AS answered_at
+  FROM messages AS asked
+  WHERE NOT asked.mine AND asked.previous_mine IS DISTINCT FROM false AND asked.created_at < ?2
+)
+SELECT
+  count(answered_at) AS answered,
+  count(*) - count(answered_at) AS unanswered,
+  avg(extract(epoch FROM answered_at - asked_at))::float8 AS average_seconds
+FROM waits`
+
+	if err := i.conn(ctx).NewRaw(query, photographerId, from, to).Scan(ctx, responseTime); err != nil {
+		return nil, err
+	}
+
+	return responseTime, nil
+}
+
+// WeeklyWorkload counts the scheduled sessions of the bookings still going ahead, week by week from the week of from
+func (i *InsightsDB) WeeklyWorkload(ctx context.Context, photographerId uuid.UUID, from time.Time, weeks int) ([]*model.WeeklyWorkload, error) {
+	var workload []*model.WeeklyWorkload
+	query := `WITH ` + insightsBookings + `,
+weeks AS (
+  SELECT generate_series(
+    date_trunc('week', ?1::timestamptz AT TIME ZONE 'UTC'),
+    date_trunc('week', ?1::timestamptz AT TIME ZONE 'UTC') + (?2 - 1) * interval '1 week',
+    interval '1 week'
+  ) AS week
+),
+sessions AS (
+  SELECT
+    date_trunc('week', booking_sessions.start_time AT TIME ZONE 'UTC') AS week,
+    extract(epoch FROM booking_sessions.end_time - booking_sessions.start_time) / 3600 AS hours
+  FROM booking_sessions
+  JOIN own_bookings ON own_bookings.id = booking_sessions.booking_id
+  WHERE booking_sessions.status = ?3 AND own_bookings.status NOT IN (?4, ?5)
+    AND booking_sessions.start_time >= ?1
+)
+SELECT
+  weeks.week,
+  count(sessions.week) AS sessions,
+  COALESCE(sum(sessions.hours), 0)::float8 AS hours
+FROM weeks
+LEFT JOIN sessions ON sessions.week = weeks.week
+GROUP BY weeks.week
+ORDER BY weeks.week`
+
+	if err := i.conn(ctx).NewRaw(query, photographerId, from, weeks, model.BookingSessionScheduledStatus, model.BookingDraftStatus, model.BookingCancelledStatus).Scan(ctx, &workload); err != nil {
+		return nil, err
+	}
+
+	return workload, nil
+}
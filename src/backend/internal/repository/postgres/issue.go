@@ -9,6 +9,14 @@ import (
 	"github.com/uptrace/bun"
 )
 
+// an open issue breaches a target once it is past it, a closed or answered one if it was answered or closed too late
+const issueBreachedCondition = `(
+  (issues.first_responded_at IS NULL AND issues.status = ? AND issues.first_response_due_at < now())
+  OR issues.first_responded_at > issues.first_response_due_at
+  OR (issues.closed_at IS NULL AND issues.status = ? AND issues.due_date < now())
+  OR issues.closed_at > issues.due_date
+)`
+
 type IssueDB struct {
 	*BaseDB[model.Issue]
 }
@@ -23,7 +31,7 @@ func NewIssueDB(db *bun.DB) *IssueDB {
 
 func (i *IssueDB) FindIssuesWithFilter(ctx context.Context, filter model.IssueFilter) ([]*model.Issue, error) {
 	var issues []*model.Issue
-	query := i.db.NewSelect().Model(&issues)
+	query := i.conn(ctx).NewSelect().Model(&issues)
 
 	if filter.Subject != nil {
 		query = query.Where("subject = ?", *filter.Subject)
@@ -42,42 +50,116 @@ func (i *IssueDB) FindIssuesWithFilter(ctx context.Context, filter model.IssueFi
 	if filter.Status != nil {
 		query = query.Where("status = ?", *filter.Status)
 	}
+	if filter.AssigneeId != nil {
+		query = query.Where("assignee_id = ?", uuid.MustParse(*filter.AssigneeId))
+	}
+	if filter.Unassigned != nil {
+		if *filter.Unassigned {
+			query = query.Where("assignee_id IS NULL")
+		} else {
+			query = query.Where("assignee_id IS NOT NULL")
+		}
+	}
+	if filter.Priority != nil {
+		query = query.Where("priority = ?", *filter.Priority)
+	}
+	if filter.Breached != nil {
+		if *filter.Breached {
+			query = query.Where(issueBreachedCondition, model.IssueOpenStatus, model.IssueOpenStatus)
+		} else {
+			query = query.Where("NOT "+issueBreachedCondition, model.IssueOpenStatus, model.IssueOpenStatus)
+		}
+	}
 
-	if err := query.Scan(ctx, &issues); err != nil {
+	if err := query.OrderExpr("due_date ASC").Scan(ctx, &issues); err != nil {
 		return nil, err
 	}
 
 	return issues, nil
 }
 
-func (i *IssueDB) GetIssueHeaderMetadata(ctx context.Context) (*model.IssueHeaderMetadata, error) {
+func (i *IssueDB) GetIssueHeaderMetadata(ctx context.Context, adminId uuid.UUID) (*model.IssueHeaderMetadata, error) {
 	var issue model.Issue
 	var result model.IssueHeaderMetadata
 
-	count, err := i.db.NewSelect().Model(&issue).Where("status = ?", model.IssueOpenStatus).Count(ctx)
+	count, err := i.conn(ctx).NewSelect().Model(&issue).Where("status = ?", model.IssueOpenStatus).Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 	result.PendingTickets = count
 
 	date := time.Now().Format("2006-01-02")
-	count, err = i.db.NewSelect().Model(&issue).Where("DATE(created_at) = ?", date).Count(ctx)
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("DATE(created_at) = ?", date).Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 	result.TicketsToday = count
 
-	count, err = i.db.NewSelect().Model(&issue).Where("DATE(due_date) = ?", date).Count(ctx)
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("DATE(due_date) = ?", date).Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 	result.TicketsDueToday = count
 
-	count, err = i.db.NewSelect().Model(&issue).Where("status = ?", model.IssueClosedStatus).Count(ctx)
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("status = ?", model.IssueClosedStatus).Count(ctx)
 	if err != nil {
 		return nil, err
 	}
 	result.ClosedTickets = count
 
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("status = ?", model.IssueOpenStatus).Where("assignee_id IS NULL").Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.UnassignedTickets = count
+
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("status = ?", model.IssueOpenStatus).Where(issueBreachedCondition, model.IssueOpenStatus, model.IssueOpenStatus).Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.BreachedTickets = count
+
+	count, err = i.conn(ctx).NewSelect().Model(&issue).Where("status = ?", model.IssueOpenStatus).Where("assignee_id = ?", adminId).Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result.MyTickets = count
+
+	var priorities []struct {
+		Priority string `bun:"priority"`
+		Count    int    `bun:"count"`
+	}
+	if err := i.conn(ctx).NewSelect().Model(&issue).
+		Column("priority").
+		ColumnExpr("count(*) AS count").
+		Where("status = ?", model.IssueOpenStatus).
+		Group("priority").
+		Scan(ctx, &priorities); err != nil {
+		return nil, err
+	}
+	result.TicketsByPriority = map[string]int{}
+	for _, priority := range []string{model.IssueLowPriority, model.IssueNormalPriority, model.IssueHighPriority, model.IssueUrgentPriority} {
+		result.TicketsByPriority[priority] = 0
+	}
+	for _, priority := range priorities {
+		result.TicketsByPriority[priority.Priority] = priority.Count
+	}
+
 	return &result, nil
 }
+
+func (i *IssueDB) RedactByReporterId(ctx context.Context, reporterId uuid.UUID, text string) error {
+	var issue model.Issue
+	_, err := i.conn(ctx).NewUpdate().Model(&issue).Set("description = ?", text).Where("reporter_id = ?", reporterId).Exec(ctx)
+	return err
+}
+
+// LockOneById locks the issue until the end of the transaction, so that it is not closed twice
+func (i *IssueDB) LockOneById(ctx context.Context, id uuid.UUID) (*model.Issue, error) {
+	var issue model.Issue
+	if err := i.conn(ctx).NewSelect().Model(&issue).Where("id = ?", id).For("UPDATE").Scan(ctx, &issue); err != nil {
+		return nil, err
+	}
+
+	return &issue, nil
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type IssueAttachmentDB struct {
+	*BaseDB[model.IssueAttachment]
+}
+
+func NewIssueAttachmentDB(db *bun.DB) *IssueAttachmentDB {
+	type T = model.IssueAttachment
+
+	return &IssueAttachmentDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (i *IssueAttachmentDB) FindByMessageIds(ctx context.Context, messageIds ...uuid.UUID) ([]*model.IssueAttachment, error) {
+	var attachments []*model.IssueAttachment
+	if len(messageIds) == 0 {
+		return attachments, nil
+	}
+
+	if err := i.conn(ctx).NewSelect().Model(&attachments).Where("message_id IN (?)", bun.In(messageIds)).OrderExpr("created_at ASC").Scan(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+// FindByAuthorId finds the attachments of the messages the user posted
+func (i *IssueAttachmentDB) FindByAuthorId(ctx context.Context, authorId uuid.UUID) ([]*model.IssueAttachment, error) {
+	var attachments []*model.IssueAttachment
+	if err := i.conn(ctx).NewSelect().
+		Model(&attachments).
+		Join("JOIN issue_messages ON issue_messages.id = issue_attachments.message_id").
+		Where("issue_messages.author_id = ?", authorId).
+		Scan(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type IssueMessageDB struct {
+	*BaseDB[model.IssueMessage]
+}
+
+func NewIssueMessageDB(db *bun.DB) *IssueMessageDB {
+	type T = model.IssueMessage
+
+	return &IssueMessageDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (i *IssueMessageDB) FindByIssueIds(ctx context.Context, issueIds ...uuid.UUID) ([]*model.IssueMessage, error) {
+	var messages []*model.IssueMessage
+	if len(issueIds) == 0 {
+		return messages, nil
+	}
+
+	if err := i.conn(ctx).NewSelect().Model(&messages).Where("issue_id IN (?)", bun.In(issueIds)).OrderExpr("created_at ASC").Scan(ctx, &messages); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (i *IssueMessageDB) RedactByAuthorId(ctx context.Context, authorId uuid.UUID, text string) error {
+	var message model.IssueMessage
+	_, err := i.conn(ctx).NewUpdate().Model(&message).Set("body = ?", text).Where("author_id = ?", authorId).Exec(ctx)
+	return err
+}
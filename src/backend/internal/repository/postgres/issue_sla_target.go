@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type IssueSLATargetDB struct {
+	*BaseDB[model.IssueSLATarget]
+}
+
+func NewIssueSLATargetDB(db *bun.DB) *IssueSLATargetDB {
+	type T = model.IssueSLATarget
+
+	return &IssueSLATargetDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (i *IssueSLATargetDB) FindAll(ctx context.Context) ([]*model.IssueSLATarget, error) {
+	var targets []*model.IssueSLATarget
+	if err := i.conn(ctx).NewSelect().Model(&targets).OrderExpr("first_response_minutes ASC").Scan(ctx, &targets); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// Upsert replaces the targets of the priorities given, the other targets are kept
+func (i *IssueSLATargetDB) Upsert(ctx context.Context, targets []*model.IssueSLATarget) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	_, err := i.conn(ctx).NewInsert().Model(&targets).
+		On("CONFLICT (priority) DO UPDATE").
+		Set("first_response_minutes = EXCLUDED.first_response_minutes").
+		Set("resolution_minutes = EXCLUDED.resolution_minutes").
+		Set("updated_at = EXCLUDED.updated_at").
+		Exec(ctx)
+	return err
+}
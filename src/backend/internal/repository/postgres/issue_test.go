@@ -0,0 +1,45 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactByReporterId(t *testing.T) {
+	db := connectTestDB(t)
+	issueDB := NewIssueDB(db)
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		erased, other := newTestUser(), newTestUser()
+		assert.NoError(t, NewUserDB(db).AddBatch(ctx, []*model.User{erased, other}))
+
+		issues := map[uuid.UUID]string{}
+		for reporterId, description := range map[uuid.UUID]string{erased.Id: "[deleted]", other.Id: "my booking was not delivered"} {
+			issue := &model.Issue{
+				Id:          uuid.New(),
+				ReporterId:  reporterId,
+				Status:      model.IssueOpenStatus,
+				Subject:     model.IssueRefundSubject,
+				Priority:    model.IssueLowPriority,
+				Description: "my booking was not delivered",
+			}
+			assert.NoError(t, issueDB.AddOne(ctx, issue))
+			issues[issue.Id] = description
+		}
+
+		assert.NoError(t, issueDB.RedactByReporterId(ctx, erased.Id, "[deleted]"))
+
+		for issueId, description := range issues {
+			issue, err := issueDB.FindOneById(ctx, issueId)
+			assert.NoError(t, err)
+			assert.Equal(t, description, issue.Description)
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/uptrace/bun"
+)
+
+type JobDB struct {
+	*BaseDB[model.Job]
+}
+
+func NewJobDB(db *bun.DB) *JobDB {
+	type T = model.Job
+
+	return &JobDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+// AddUnique skips the job if another one with the same dedup key has already been queued
+func (j *JobDB) AddUnique(ctx context.Context, job *model.Job) (bool, error) {
+	res, err := j.conn(ctx).NewInsert().Model(job).On("CONFLICT (dedup_key) DO NOTHING").Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimDue leases the due jobs to the worker, a running job whose lease has expired belonged to a worker
+// that died and is claimed again, the rows locked by other workers are skipped
+func (j *JobDB) ClaimDue(ctx context.Context, workerId string, now, leaseUntil time.Time, limit int) ([]*model.Job, error) {
+	var jobs []*model.Job
+
+	due := j.conn(ctx).NewSelect().Model((*model.Job)(nil)).Column("id").
+		Where("status = ? AND run_at <= ?", model.JobPendingStatus, now).
+		WhereOr("status = ? AND locked_until <= ?", model.JobRunningStatus, now).
+		OrderExpr("run_at ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	if _, err := j.conn(ctx).NewUpdate().Model((*model.Job)(nil)).
+		Set("status = ?", model.JobRunningStatus).
+		Set("locked_by = ?", workerId).
+		Set("locked_until = ?", leaseUntil).
+		Set("attempts = attempts + 1").
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	return jobs, nil
+}
+
+// UpdateClaimed saves the job as long as it is still claimed by the worker, a job claimed again since has had its
+// attempts counted once more so that the worker's own claims are told apart as well
+func (j *JobDB) UpdateClaimed(ctx context.Context, job *model.Job, workerId string) (bool, error) {
+	res, err := j.conn(ctx).NewUpdate().Model(job).WherePK().
+		Where("locked_by = ? AND attempts = ?", workerId, job.Attempts).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (j *JobDB) DeleteSucceededBefore(ctx context.Context, before time.Time) (int, error) {
+	res, err := j.conn(ctx).NewDelete().Model((*model.Job)(nil)).Where("status = ? AND completed_at < ?", model.JobSucceededStatus, before).Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClaimDueTakesOverExpiredLease(t *testing.T) {
+	db := connectTestDB(t)
+	jobDB := NewJobDB(db)
+	now := time.Now()
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		deadWorker := "dead"
+		expired, leased := now.Add(-time.Minute), now.Add(time.Minute)
+		abandoned := &model.Job{Id: uuid.New(), Kind: "test.claim", Payload: []byte("{}"), Status: model.JobRunningStatus, Attempts: 1, MaxAttempts: 5, RunAt: now.Add(-time.Hour), LockedBy: &deadWorker, LockedUntil: &expired}
+		running := &model.Job{Id: uuid.New(), Kind: "test.claim", Payload: []byte("{}"), Status: model.JobRunningStatus, Attempts: 1, MaxAttempts: 5, RunAt: now.Add(-time.Hour), LockedBy: &deadWorker, LockedUntil: &leased}
+		assert.NoError(t, jobDB.AddBatch(ctx, []*model.Job{abandoned, running}))
+
+		jobs, err := jobDB.ClaimDue(ctx, "alive", now, now.Add(5*time.Minute), 1000)
+		assert.NoError(t, err)
+
+		claimed := map[uuid.UUID]*model.Job{}
+		for _, job := range jobs {
+			claimed[job.Id] = job
+		}
+		if assert.Contains(t, claimed, abandoned.Id) {
+			assert.Equal(t, "alive", *claimed[abandoned.Id].LockedBy)
+			assert.Equal(t, 2, claimed[abandoned.Id].Attempts)
+		}
+		assert.NotContains(t, claimed, running.Id)
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
+
+func TestUpdateClaimedRejectsStaleWorker(t *testing.T) {
+	db := connectTestDB(t)
+	jobDB := NewJobDB(db)
+	now := time.Now()
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		firstWorker, expired := "first", now.Add(-time.Minute)
+		stale := &model.Job{Id: uuid.New(), Kind: "test.claim", Payload: []byte("{}"), Status: model.JobRunningStatus, Attempts: 1, MaxAttempts: 5, RunAt: now.Add(-time.Hour), LockedBy: &firstWorker, LockedUntil: &expired}
+		assert.NoError(t, jobDB.AddOne(ctx, stale))
+
+		// the second worker takes the job over once the lease of the first one has passed
+		jobs, err := jobDB.ClaimDue(ctx, "second", now, now.Add(5*time.Minute), 1000)
+		assert.NoError(t, err)
+
+		var claimed *model.Job
+		for _, job := range jobs {
+			if job.Id == stale.Id {
+				claimed = job
+			}
+		}
+		if !assert.NotNil(t, claimed) {
+			return errAbort
+		}
+
+		// the first worker finishing late is not to overwrite the run of the second one
+		stale.Status = model.JobSucceededStatus
+		updated, err := jobDB.UpdateClaimed(ctx, stale, firstWorker)
+		assert.NoError(t, err)
+		assert.False(t, updated)
+
+		found, err := jobDB.FindOneById(ctx, stale.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, model.JobRunningStatus, found.Status)
+		assert.Equal(t, "second", *found.LockedBy)
+
+		claimed.Status = model.JobSucceededStatus
+		updated, err = jobDB.UpdateClaimed(ctx, claimed, "second")
+		assert.NoError(t, err)
+		assert.True(t, updated)
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
@@ -22,7 +22,7 @@ func NewLookupDB(db *bun.DB) *LookupDB {
 
 func (l *LookupDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.UserRoomLookup, error) {
 	var subscriptions []*model.UserRoomLookup
-	if err := l.db.NewSelect().Model(&subscriptions).Where("user_id = ?", userId).Scan(ctx, &subscriptions); err != nil {
+	if err := l.conn(ctx).NewSelect().Model(&subscriptions).Where("user_id = ?", userId).Scan(ctx, &subscriptions); err != nil {
 		return nil, err
 	}
 
@@ -31,7 +31,7 @@ func (l *LookupDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model
 
 func (l *LookupDB) CheckRoomMembership(ctx context.Context, userId, roomId uuid.UUID) (bool, error) {
 	var lookup model.UserRoomLookup
-	exist, err := l.db.NewSelect().Model(&lookup).Where("user_id = ? AND room_id = ?", userId, roomId).Exists(ctx)
+	exist, err := l.conn(ctx).NewSelect().Model(&lookup).Where("user_id = ? AND room_id = ?", userId, roomId).Exists(ctx)
 	if err != nil {
 		return false, err
 	}
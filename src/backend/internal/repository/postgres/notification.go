@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const defaultNotificationLimit = 50
+
+type NotificationDB struct {
+	*BaseDB[model.Notification]
+}
+
+func NewNotificationDB(db *bun.DB) *NotificationDB {
+	type T = model.Notification
+
+	return &NotificationDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (n *NotificationDB) FindWithFilter(ctx context.Context, userId uuid.UUID, filter model.NotificationFilter) ([]*model.Notification, error) {
+	var notifications []*model.Notification
+	query := n.conn(ctx).NewSelect().Model(&notifications).Where("user_id = ?", userId)
+
+	if filter.UnreadOnly {
+		query.Where("read_at IS NULL")
+	}
+
+	limit := defaultNotificationLimit
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+
+	if err := query.OrderExpr("created_at DESC").Limit(limit).Scan(ctx, &notifications); err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (n *NotificationDB) CountUnreadByUserId(ctx context.Context, userId uuid.UUID) (int, error) {
+	var notification model.Notification
+	return n.conn(ctx).NewSelect().Model(&notification).Where("user_id = ? AND read_at IS NULL", userId).Count(ctx)
+}
+
+// MarkRead only touches the unread notifications of the user, it returns how many were marked
+func (n *NotificationDB) MarkRead(ctx context.Context, userId uuid.UUID, ids ...uuid.UUID) (int, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	var notification model.Notification
+	res, err := n.conn(ctx).NewUpdate().Model(&notification).
+		Set("read_at = ?", time.Now()).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Where("id IN (?)", bun.In(ids)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+func (n *NotificationDB) MarkAllRead(ctx context.Context, userId uuid.UUID) (int, error) {
+	var notification model.Notification
+	res, err := n.conn(ctx).NewUpdate().Model(&notification).
+		Set("read_at = ?", time.Now()).
+		Where("user_id = ? AND read_at IS NULL", userId).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	affected, err := res.RowsAffected()
+	return int(affected), err
+}
+
+func (n *NotificationDB) DeleteByUserId(ctx context.Context, userId uuid.UUID) error {
+	var notification model.Notification
+	_, err := n.conn(ctx).NewDelete().Model(&notification).Where("user_id = ?", userId).Exec(ctx)
+	return err
+}
@@ -22,7 +22,7 @@ func NewPhotoDB(db *bun.DB) *PhotoDB {
 
 func (p *PhotoDB) FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.Photo, error) {
 	var photos []*model.Photo
-	if err := p.db.NewSelect().Model(&photos).Where("gallery_id = ?", galleryId).Scan(ctx, &photos); err != nil {
+	if err := p.conn(ctx).NewSelect().Model(&photos).Where("gallery_id = ?", galleryId).Scan(ctx, &photos); err != nil {
 		return nil, err
 	}
 
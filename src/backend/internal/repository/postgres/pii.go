@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
+)
+
+// the sensitive columns are sealed on the way into the database and opened on the way out,
+// so that the usecases and controllers only ever deal with plaintext
+
+func encryptNullable(value *string) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	sealed, err := encryption.Default().Encrypt(*value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sealed, nil
+}
+
+func decryptNullable(value *string) error {
+	if value == nil {
+		return nil
+	}
+
+	plaintext, err := encryption.Default().Decrypt(*value)
+	if err != nil {
+		return err
+	}
+
+	*value = plaintext
+	return nil
+}
+
+func needsReencryption(value *string) bool {
+	return value != nil && encryption.Default().NeedsReencryption(*value)
+}
+
+// sealUser returns a sealed copy, the caller keeps working with the plaintext user
+func sealUser(user *model.User) (*model.User, error) {
+	sealed := *user
+
+	var err error
+	if sealed.PhoneNumber, err = encryptNullable(user.PhoneNumber); err != nil {
+		return nil, err
+	}
+	if sealed.Address, err = encryptNullable(user.Address); err != nil {
+		return nil, err
+	}
+	if sealed.TaxId, err = encryptNullable(user.TaxId); err != nil {
+		return nil, err
+	}
+
+	return &sealed, nil
+}
+
+func openUsers(users ...*model.User) error {
+	for _, user := range users {
+		if err := decryptNullable(user.PhoneNumber); err != nil {
+			return err
+		}
+		if err := decryptNullable(user.Address); err != nil {
+			return err
+		}
+		if err := decryptNullable(user.TaxId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sealVerificationTicket(ticket *model.VerificationTicket) (*model.VerificationTicket, error) {
+	sealed := *ticket
+	sealed.IdCardNumberIndex = encryption.Default().BlindIndex(ticket.IdCardNumber)
+
+	var err error
+	if sealed.IdCardNumber, err = encryption.Default().Encrypt(ticket.IdCardNumber); err != nil {
+		return nil, err
+	}
+
+	return &sealed, nil
+}
+
+func openVerificationTickets(tickets ...*model.VerificationTicket) error {
+	for _, ticket := range tickets {
+		if err := decryptNullable(&ticket.IdCardNumber); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type GalleryAddOnDB struct {
+	*BaseDB[model.GalleryAddOn]
+}
+
+func NewGalleryAddOnDB(db *bun.DB) *GalleryAddOnDB {
+	type T = model.GalleryAddOn
+
+	return &GalleryAddOnDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (g *GalleryAddOnDB) FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.GalleryAddOn, error) {
+	var addOns []*model.GalleryAddOn
+	if err := g.conn(ctx).NewSelect().Model(&addOns).Where("gallery_id = ?", galleryId).OrderExpr("created_at ASC").Scan(ctx, &addOns); err != nil {
+		return nil, err
+	}
+
+	return addOns, nil
+}
+
+type PricingRuleDB struct {
+	*BaseDB[model.PricingRule]
+}
+
+func NewPricingRuleDB(db *bun.DB) *PricingRuleDB {
+	type T = model.PricingRule
+
+	return &PricingRuleDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (p *PricingRuleDB) FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.PricingRule, error) {
+	var rules []*model.PricingRule
+	if err := p.conn(ctx).NewSelect().Model(&rules).Where("gallery_id = ?", galleryId).OrderExpr("created_at ASC").Scan(ctx, &rules); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+type BookingLineItemDB struct {
+	*BaseDB[model.BookingLineItem]
+}
+
+func NewBookingLineItemDB(db *bun.DB) *BookingLineItemDB {
+	type T = model.BookingLineItem
+
+	return &BookingLineItemDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (b *BookingLineItemDB) FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingLineItem, error) {
+	var lineItems []*model.BookingLineItem
+	if len(bookingIds) == 0 {
+		return lineItems, nil
+	}
+
+	if err := b.conn(ctx).NewSelect().Model(&lineItems).Where("booking_id IN (?)", bun.In(bookingIds)).OrderExpr("position ASC").Scan(ctx, &lineItems); err != nil {
+		return nil, err
+	}
+
+	return lineItems, nil
+}
@@ -22,23 +22,29 @@ func NewReviewDB(db *bun.DB) *ReviewDB {
 
 func (p *ReviewDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.Review, error) {
 	var reviews []*model.Review
-	if err := p.db.NewSelect().Model(&reviews).Where("customer_id = ?", userId).Scan(ctx, &reviews); err != nil {
+	if err := p.conn(ctx).NewSelect().Model(&reviews).Where("customer_id = ?", userId).Scan(ctx, &reviews); err != nil {
 		return nil, err
 	}
 
 	return reviews, nil
 }
 
+func (r *ReviewDB) ClearTextByUserId(ctx context.Context, userId uuid.UUID) error {
+	var review model.Review
+	_, err := r.conn(ctx).NewUpdate().Model(&review).Set("review_text = NULL").Where("customer_id = ?", userId).Exec(ctx)
+	return err
+}
+
 func (r *ReviewDB) FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.Review, error) {
 	var reviews []*model.Review
 
 	var room model.Room
-	allRoomIds := r.db.NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
+	allRoomIds := r.conn(ctx).NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
 
 	var booking model.Booking
-	allBookingIds := r.db.NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
+	allBookingIds := r.conn(ctx).NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
 
-	if err := r.db.NewSelect().Model(&reviews).Where("booking_id IN (?)", allBookingIds).Scan(ctx, &reviews); err != nil {
+	if err := r.conn(ctx).NewSelect().Model(&reviews).Where("booking_id IN (?)", allBookingIds).Scan(ctx, &reviews); err != nil {
 		return nil, err
 	}
 
@@ -49,15 +55,15 @@ func (r *ReviewDB) FindByPhotographerId(ctx context.Context, photographerId uuid
 	var gallery model.Gallery
 	var reviews []*model.Review
 
-	allGalleryIds := r.db.NewSelect().Model(&gallery).Where("photographer_id = ?", photographerId).Column("id")
+	allGalleryIds := r.conn(ctx).NewSelect().Model(&gallery).Where("photographer_id = ?", photographerId).Column("id")
 
 	var room model.Room
-	allRoomIds := r.db.NewSelect().Model(&room).Where("gallery_id IN (?)", allGalleryIds).Column("id")
+	allRoomIds := r.conn(ctx).NewSelect().Model(&room).Where("gallery_id IN (?)", allGalleryIds).Column("id")
 
 	var booking model.Booking
-	allBookingIds := r.db.NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
+	allBookingIds := r.conn(ctx).NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
 
-	if err := r.db.NewSelect().Model(&reviews).Where("booking_id IN (?)", allBookingIds).Scan(ctx, &reviews); err != nil {
+	if err := r.conn(ctx).NewSelect().Model(&reviews).Where("booking_id IN (?)", allBookingIds).Scan(ctx, &reviews); err != nil {
 		return nil, err
 	}
 
@@ -66,13 +72,13 @@ func (r *ReviewDB) FindByPhotographerId(ctx context.Context, photographerId uuid
 
 func (r *ReviewDB) CheckExistenceByGalleryId(ctx context.Context, galleryId uuid.UUID) (bool, error) {
 	var room model.Room
-	allRoomIds := r.db.NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
+	allRoomIds := r.conn(ctx).NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
 
 	var booking model.Booking
-	allBookingIds := r.db.NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
+	allBookingIds := r.conn(ctx).NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
 
 	var review model.Review
-	exist, err := r.db.NewSelect().Model(&review).Where("booking_id IN (?)", allBookingIds).Exists(ctx)
+	exist, err := r.conn(ctx).NewSelect().Model(&review).Where("booking_id IN (?)", allBookingIds).Exists(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -82,14 +88,14 @@ func (r *ReviewDB) CheckExistenceByGalleryId(ctx context.Context, galleryId uuid
 
 func (r *ReviewDB) SumAndCountRatingByGalleryId(ctx context.Context, galleryId uuid.UUID) (int, int, error) {
 	var room model.Room
-	allRoomIds := r.db.NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
+	allRoomIds := r.conn(ctx).NewSelect().Model(&room).Where("gallery_id = ?", galleryId).Column("id")
 
 	var booking model.Booking
-	allBookingIds := r.db.NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
+	allBookingIds := r.conn(ctx).NewSelect().Model(&booking).Where("room_id IN (?)", allRoomIds).Column("id")
 
 	var review model.Review
 	var sum int
-	count, err := r.db.NewSelect().Model(&review).Where("booking_id IN (?)", allBookingIds).ColumnExpr("SUM(rating)").ScanAndCount(ctx, &sum)
+	count, err := r.conn(ctx).NewSelect().Model(&review).Where("booking_id IN (?)", allBookingIds).ColumnExpr("SUM(rating)").ScanAndCount(ctx, &sum)
 	if err != nil {
 		return 0, 0, err
 	}
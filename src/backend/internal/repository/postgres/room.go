@@ -22,12 +22,12 @@ func NewRoomDB(db *bun.DB) *RoomDB {
 
 func (r *RoomDB) CheckRoomExistenceOfUserByGalleryId(ctx context.Context, availableRoomIds []uuid.UUID, galleryId uuid.UUID) (bool, error) {
 	var room model.Room
-	return r.db.NewSelect().Model(&room).Where("id IN (?) AND gallery_id = ?", bun.In(availableRoomIds), galleryId).Exists(ctx)
+	return r.conn(ctx).NewSelect().Model(&room).Where("id IN (?) AND gallery_id = ?", bun.In(availableRoomIds), galleryId).Exists(ctx)
 }
 
 func (r *RoomDB) FindRoomOfUserByGalleryId(ctx context.Context, availableRoomIds []uuid.UUID, galleryId uuid.UUID) (*model.Room, error) {
 	var room model.Room
-	if err := r.db.NewSelect().Model(&room).Where("id IN (?) AND gallery_id = ?", bun.In(availableRoomIds), galleryId).Scan(ctx, &room); err != nil {
+	if err := r.conn(ctx).NewSelect().Model(&room).Where("id IN (?) AND gallery_id = ?", bun.In(availableRoomIds), galleryId).Scan(ctx, &room); err != nil {
 		return nil, err
 	}
 
@@ -38,11 +38,11 @@ func (r *RoomDB) FindOtherUsersInRoom(ctx context.Context, selfUserId, roomId uu
 	var lookup model.UserRoomLookup
 	var otherUsers []*model.User
 
-	subq := r.db.NewSelect().Model(&lookup).Where("user_id != ? AND room_id = ?", selfUserId, roomId).Column("user_id")
+	subq := r.conn(ctx).NewSelect().Model(&lookup).Where("user_id != ? AND room_id = ?", selfUserId, roomId).Column("user_id")
 
-	if err := r.db.NewSelect().Model(&otherUsers).Where("id IN (?)", subq).Scan(ctx, &otherUsers); err != nil {
+	if err := r.conn(ctx).NewSelect().Model(&otherUsers).Where("id IN (?)", subq).Scan(ctx, &otherUsers); err != nil {
 		return nil, err
 	}
 
-	return otherUsers, nil
+	return otherUsers, openUsers(otherUsers...)
 }
@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type txKey struct{}
+
+// RunInTx runs fn in a transaction that every repository called with the given context takes part in,
+// a call made inside another transaction joins it instead of opening a new one
+func RunInTx(ctx context.Context, db *bun.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return fn(ctx)
+	}
+
+	return db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
+
+// conn is the transaction of the context if there is one, the pool otherwise
+func (b *BaseDB[T]) conn(ctx context.Context) bun.IDB {
+	return connOf(ctx, b.db)
+}
+
+// connOf is conn for the repositories that are not bound to a model
+func connOf(ctx context.Context, db *bun.DB) bun.IDB {
+	if tx, ok := ctx.Value(txKey{}).(bun.Tx); ok {
+		return tx
+	}
+
+	return db
+}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/uptrace/bun"
+)
+
+type UnitOfWorkDB struct {
+	db *bun.DB
+}
+
+func NewUnitOfWorkDB(db *bun.DB) *UnitOfWorkDB {
+	return &UnitOfWorkDB{db: db}
+}
+
+func (u *UnitOfWorkDB) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return RunInTx(ctx, u.db, fn)
+}
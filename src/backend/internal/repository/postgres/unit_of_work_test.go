@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+var errAbort = errors.New("abort")
+
+func connectTestDB(t *testing.T) *bun.DB {
+	dsn := os.Getenv("dsn")
+	if dsn == "" {
+		t.Skip("dsn is not set")
+	}
+
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn))), pgdialect.New())
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestUser() *model.User {
+	return &model.User{
+		Id:                 uuid.New(),
+		Username:           uuid.New().String(),
+		Email:              uuid.New().String() + "@mail.com",
+		Firstname:          "unit",
+		Lastname:           "of work",
+		VerificationStatus: model.PhotographerNotVerifiedStatus,
+	}
+}
+
+func TestUnitOfWorkRollsBackOnError(t *testing.T) {
+	db := connectTestDB(t)
+	userDB := NewUserDB(db)
+	ctx := context.Background()
+
+	user := newTestUser()
+	err := NewUnitOfWorkDB(db).Do(ctx, func(ctx context.Context) error {
+		if err := userDB.AddOne(ctx, user); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	_, err = userDB.FindOneById(ctx, user.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUnitOfWorkNestedCallJoinsTheTransaction(t *testing.T) {
+	db := connectTestDB(t)
+	userDB := NewUserDB(db)
+	unitOfWork := NewUnitOfWorkDB(db)
+	ctx := context.Background()
+
+	outer, inner := newTestUser(), newTestUser()
+	err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := userDB.AddOne(ctx, outer); err != nil {
+			return err
+		}
+
+		if err := unitOfWork.Do(ctx, func(ctx context.Context) error {
+			return userDB.AddOne(ctx, inner)
+		}); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	_, err = userDB.FindOneById(ctx, outer.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+	_, err = userDB.FindOneById(ctx, inner.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUnitOfWorkCommits(t *testing.T) {
+	db := connectTestDB(t)
+	userDB := NewUserDB(db)
+	ctx := context.Background()
+
+	user := newTestUser()
+	err := NewUnitOfWorkDB(db).Do(ctx, func(ctx context.Context) error {
+		return userDB.AddOne(ctx, user)
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() { userDB.DeleteOneById(ctx, user.Id) })
+
+	found, err := userDB.FindOneById(ctx, user.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Id, found.Id)
+}
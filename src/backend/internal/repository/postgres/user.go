@@ -21,18 +21,67 @@ func NewUserDB(db *bun.DB) *UserDB {
 	}
 }
 
+func (u *UserDB) AddOne(ctx context.Context, user *model.User) error {
+	sealed, err := sealUser(user)
+	if err != nil {
+		return err
+	}
+
+	return u.BaseDB.AddOne(ctx, sealed)
+}
+
+func (u *UserDB) AddBatch(ctx context.Context, users []*model.User) error {
+	sealedUsers := make([]*model.User, 0, len(users))
+	for _, user := range users {
+		sealed, err := sealUser(user)
+		if err != nil {
+			return err
+		}
+		sealedUsers = append(sealedUsers, sealed)
+	}
+
+	return u.BaseDB.AddBatch(ctx, sealedUsers)
+}
+
+func (u *UserDB) UpdateOne(ctx context.Context, user *model.User) error {
+	sealed, err := sealUser(user)
+	if err != nil {
+		return err
+	}
+
+	return u.BaseDB.UpdateOne(ctx, sealed)
+}
+
+func (u *UserDB) FindOneById(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, err := u.BaseDB.FindOneById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, openUsers(user)
+}
+
+func (u *UserDB) FindByIds(ctx context.Context, ids ...uuid.UUID) ([]*model.User, error) {
+	users, err := u.BaseDB.FindByIds(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, openUsers(users...)
+}
+
 func (u *UserDB) FindOneByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	if err := u.db.NewSelect().Model(&user).Where("email = ?", email).Scan(ctx, &user); err != nil {
+	if err := u.conn(ctx).NewSelect().Model(&user).Where("email = ?", email).Scan(ctx, &user); err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return &user, openUsers(&user)
 }
 
 func (u *UserDB) CheckExistenceByEmail(ctx context.Context, email string) (bool, error) {
 	var user model.User
 
-	exist, err := u.db.NewSelect().Model(&user).Where("email = ?", email).Exists(ctx)
+	exist, err := u.conn(ctx).NewSelect().Model(&user).Where("email = ?", email).Exists(ctx)
 	if err != nil {
 		return false, err
 	}
@@ -41,26 +90,25 @@ func (u *UserDB) CheckExistenceByEmail(ctx context.Context, email string) (bool,
 }
 
 func (u *UserDB) ListPendingPhotographers(ctx context.Context) ([]*model.User, error) {
-    var pendingPhotographers []*model.User
-    if err := u.db.NewSelect().Model(&pendingPhotographers).Where("verification_status = ? OR verification_status = ? OR verification_status = ?", model.PhotographerPendingStatus, model.PhotographerVerifiedStatus, model.PhotographerRejectedStatus).Scan(ctx, &pendingPhotographers); err != nil {
-        return nil, err
-    }
+	var pendingPhotographers []*model.User
+	if err := u.conn(ctx).NewSelect().Model(&pendingPhotographers).Where("verification_status = ? OR verification_status = ? OR verification_status = ?", model.PhotographerPendingStatus, model.PhotographerVerifiedStatus, model.PhotographerRejectedStatus).Scan(ctx, &pendingPhotographers); err != nil {
+		return nil, err
+	}
 
-    return pendingPhotographers, nil
+	return pendingPhotographers, openUsers(pendingPhotographers...)
 }
 
-
 func (u *UserDB) FindOneByUsername(ctx context.Context, username string) (*model.User, error) {
 	var user model.User
-	if err := u.db.NewSelect().Model(&user).Where("username = ?", username).Scan(ctx, &user); err != nil {
+	if err := u.conn(ctx).NewSelect().Model(&user).Where("username = ?", username).Scan(ctx, &user); err != nil {
 		return nil, err
 	}
-	return &user, nil
+	return &user, openUsers(&user)
 }
 
 func (u *UserDB) CheckUsernameAlreadyBeenUsed(ctx context.Context, username string, proposedUserId uuid.UUID) (bool, error) {
 	var user model.User
-	exist, err := u.db.NewSelect().Model(&user).Where("username = ? AND id != ?", username, proposedUserId).Exists(ctx)
+	exist, err := u.conn(ctx).NewSelect().Model(&user).Where("username = ? AND id != ?", username, proposedUserId).Exists(ctx)
 	return exist, err
 }
 
@@ -69,9 +117,40 @@ func (u *UserDB) FindPhotographerIdsNameAlike(ctx context.Context, name string)
 	var ids []uuid.UUID
 	nameCondition := fmt.Sprintf("%%%s%%", name)
 
-	if err := u.db.NewSelect().Model(&user).Where("verification_status = ? AND (firstname LIKE ? OR username LIKE ? OR lastname LIKE ?)", model.PhotographerVerifiedStatus, nameCondition, nameCondition, nameCondition).Column("id").Scan(ctx, &ids); err != nil {
+	if err := u.conn(ctx).NewSelect().Model(&user).Where("verification_status = ? AND (firstname LIKE ? OR username LIKE ? OR lastname LIKE ?)", model.PhotographerVerifiedStatus, nameCondition, nameCondition, nameCondition).Column("id").Scan(ctx, &ids); err != nil {
 		return nil, err
 	}
 
 	return ids, nil
 }
+
+// Reencrypt seals the rows still in plaintext or under a retired key with the current key
+func (u *UserDB) Reencrypt(ctx context.Context) (int, error) {
+	var users []*model.User
+	if err := u.conn(ctx).NewSelect().Model(&users).Column("id", "phone_number", "address", "tax_id").Scan(ctx, &users); err != nil {
+		return 0, err
+	}
+
+	reencrypted := 0
+	for _, user := range users {
+		if !needsReencryption(user.PhoneNumber) && !needsReencryption(user.Address) && !needsReencryption(user.TaxId) {
+			continue
+		}
+
+		if err := openUsers(user); err != nil {
+			return reencrypted, fmt.Errorf("could not decrypt user %s: %w", user.Id, err)
+		}
+
+		sealed, err := sealUser(user)
+		if err != nil {
+			return reencrypted, err
+		}
+
+		if _, err := u.conn(ctx).NewUpdate().Model(sealed).Column("phone_number", "address", "tax_id").WherePK().Exec(ctx); err != nil {
+			return reencrypted, err
+		}
+		reencrypted++
+	}
+
+	return reencrypted, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type UserIdentityDB struct {
+	*BaseDB[model.UserIdentity]
+}
+
+func NewUserIdentityDB(db *bun.DB) *UserIdentityDB {
+	type T = model.UserIdentity
+
+	return &UserIdentityDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (u *UserIdentityDB) FindOneByProviderAndSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error) {
+	var identity model.UserIdentity
+	if err := u.conn(ctx).NewSelect().Model(&identity).Where("provider = ? AND subject = ?", provider, subject).Scan(ctx, &identity); err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+func (u *UserIdentityDB) CheckExistenceByProviderAndSubject(ctx context.Context, provider, subject string) (bool, error) {
+	var identity model.UserIdentity
+	return u.conn(ctx).NewSelect().Model(&identity).Where("provider = ? AND subject = ?", provider, subject).Exists(ctx)
+}
+
+func (u *UserIdentityDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.UserIdentity, error) {
+	var identities []*model.UserIdentity
+	if err := u.conn(ctx).NewSelect().Model(&identities).Where("user_id = ?", userId).OrderExpr("created_at ASC").Scan(ctx, &identities); err != nil {
+		return nil, err
+	}
+
+	return identities, nil
+}
+
+func (u *UserIdentityDB) DeleteByUserIdAndProvider(ctx context.Context, userId uuid.UUID, provider string) error {
+	var identity model.UserIdentity
+	_, err := u.conn(ctx).NewDelete().Model(&identity).Where("user_id = ? AND provider = ?", userId, provider).Exec(ctx)
+	return err
+}
+
+func (u *UserIdentityDB) DeleteByUserId(ctx context.Context, userId uuid.UUID) error {
+	var identity model.UserIdentity
+	_, err := u.conn(ctx).NewDelete().Model(&identity).Where("user_id = ?", userId).Exec(ctx)
+	return err
+}
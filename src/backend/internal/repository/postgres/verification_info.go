@@ -2,8 +2,10 @@ package postgres
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
@@ -20,12 +22,172 @@ func NewVerificationInfoDB(db *bun.DB) *VerificationTicketDB {
 	}
 }
 
+func (v *VerificationTicketDB) AddOne(ctx context.Context, ticket *model.VerificationTicket) error {
+	sealed, err := sealVerificationTicket(ticket)
+	if err != nil {
+		return err
+	}
+
+	return v.BaseDB.AddOne(ctx, sealed)
+}
+
+func (v *VerificationTicketDB) AddBatch(ctx context.Context, tickets []*model.VerificationTicket) error {
+	sealedTickets := make([]*model.VerificationTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		sealed, err := sealVerificationTicket(ticket)
+		if err != nil {
+			return err
+		}
+		sealedTickets = append(sealedTickets, sealed)
+	}
+
+	return v.BaseDB.AddBatch(ctx, sealedTickets)
+}
+
+func (v *VerificationTicketDB) UpdateOne(ctx context.Context, ticket *model.VerificationTicket) error {
+	sealed, err := sealVerificationTicket(ticket)
+	if err != nil {
+		return err
+	}
+
+	return v.BaseDB.UpdateOne(ctx, sealed)
+}
+
+func (v *VerificationTicketDB) FindOneById(ctx context.Context, id uuid.UUID) (*model.VerificationTicket, error) {
+	ticket, err := v.BaseDB.FindOneById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return ticket, openVerificationTickets(ticket)
+}
+
+// LockOneById locks the ticket until the end of the transaction, so that it is not reviewed twice
+func (v *VerificationTicketDB) LockOneById(ctx context.Context, id uuid.UUID) (*model.VerificationTicket, error) {
+	var ticket model.VerificationTicket
+	if err := v.conn(ctx).NewSelect().Model(&ticket).Where("id = ?", id).For("UPDATE").Scan(ctx, &ticket); err != nil {
+		return nil, err
+	}
+
+	return &ticket, openVerificationTickets(&ticket)
+}
+
+func (v *VerificationTicketDB) FindByIds(ctx context.Context, ids ...uuid.UUID) ([]*model.VerificationTicket, error) {
+	tickets, err := v.BaseDB.FindByIds(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	return tickets, openVerificationTickets(tickets...)
+}
+
 func (v *VerificationTicketDB) FindByUserIds(ctx context.Context, phtgIds []uuid.UUID) ([]*model.VerificationTicket, error) {
 	var verificationTicket []*model.VerificationTicket
 
-	if err := v.db.NewSelect().Model(&verificationTicket).Where("user_id IN (?)", bun.In(phtgIds)).Scan(ctx, &verificationTicket); err != nil {
+	if err := v.conn(ctx).NewSelect().Model(&verificationTicket).Where("user_id IN (?)", bun.In(phtgIds)).Scan(ctx, &verificationTicket); err != nil {
+		return nil, err
+	}
+
+	return verificationTicket, openVerificationTickets(verificationTicket...)
+}
+
+func (v *VerificationTicketDB) FindWithFilter(ctx context.Context, filter model.VerificationTicketFilter) ([]*model.VerificationTicket, error) {
+	var verificationTickets []*model.VerificationTicket
+	query := v.conn(ctx).NewSelect().Model(&verificationTickets)
+
+	if filter.Status != nil {
+		query = query.Where("status = ?", *filter.Status)
+	}
+
+	if err := query.OrderExpr("due_date ASC").Scan(ctx, &verificationTickets); err != nil {
+		return nil, err
+	}
+
+	return verificationTickets, openVerificationTickets(verificationTickets...)
+}
+
+func (v *VerificationTicketDB) FindLatestByUserId(ctx context.Context, userId uuid.UUID) (*model.VerificationTicket, error) {
+	var verificationTicket model.VerificationTicket
+	if err := v.conn(ctx).NewSelect().Model(&verificationTicket).Where("user_id = ?", userId).OrderExpr("created_at DESC").Limit(1).Scan(ctx, &verificationTicket); err != nil {
+		return nil, err
+	}
+
+	return &verificationTicket, openVerificationTickets(&verificationTicket)
+}
+
+// CheckIdCardNumberUsedByOthers matches the blind index, the ID card numbers are only compared as stored in plaintext
+// when there is no key. The tickets written before the keys were added are indexed by Reencrypt.
+func (v *VerificationTicketDB) CheckIdCardNumberUsedByOthers(ctx context.Context, idCardNumber string, userId uuid.UUID) (bool, error) {
+	var verificationTicket model.VerificationTicket
+	query := v.conn(ctx).NewSelect().Model(&verificationTicket).Where("user_id != ?", userId)
+
+	if index := encryption.Default().BlindIndex(idCardNumber); index != "" {
+		query.Where("id_card_number_index = ?", index)
+	} else {
+		query.Where(`upper(regexp_replace(id_card_number, '\s', '', 'g')) = ?`, encryption.NormaliseIndexed(idCardNumber))
+	}
+
+	return query.Exists(ctx)
+}
+
+// Reencrypt seals the ID card numbers still in plaintext or under a retired key with the current key,
+// and indexes the tickets created before the index existed or written without a key
+func (v *VerificationTicketDB) Reencrypt(ctx context.Context) (int, error) {
+	var tickets []*model.VerificationTicket
+	if err := v.conn(ctx).NewSelect().Model(&tickets).Column("id", "id_card_number", "id_card_number_index").Scan(ctx, &tickets); err != nil {
+		return 0, err
+	}
+
+	reencrypted := 0
+	for _, ticket := range tickets {
+		if !needsReencryption(&ticket.IdCardNumber) && ticket.IdCardNumberIndex != "" {
+			continue
+		}
+
+		if err := openVerificationTickets(ticket); err != nil {
+			return reencrypted, fmt.Errorf("could not decrypt verification ticket %s: %w", ticket.Id, err)
+		}
+
+		sealed, err := sealVerificationTicket(ticket)
+		if err != nil {
+			return reencrypted, err
+		}
+
+		if _, err := v.conn(ctx).NewUpdate().Model(sealed).Column("id_card_number", "id_card_number_index").WherePK().Exec(ctx); err != nil {
+			return reencrypted, err
+		}
+		reencrypted++
+	}
+
+	return reencrypted, nil
+}
+
+type VerificationEventDB struct {
+	*BaseDB[model.VerificationEvent]
+}
+
+func NewVerificationEventDB(db *bun.DB) *VerificationEventDB {
+	type T = model.VerificationEvent
+
+	return &VerificationEventDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (v *VerificationEventDB) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.VerificationEvent, error) {
+	var events []*model.VerificationEvent
+	if err := v.conn(ctx).NewSelect().Model(&events).Where("user_id = ?", userId).OrderExpr("created_at ASC").Scan(ctx, &events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (v *VerificationEventDB) FindByTicketIds(ctx context.Context, ticketIds []uuid.UUID) ([]*model.VerificationEvent, error) {
+	var events []*model.VerificationEvent
+	if err := v.conn(ctx).NewSelect().Model(&events).Where("ticket_id IN (?)", bun.In(ticketIds)).OrderExpr("created_at ASC").Scan(ctx, &events); err != nil {
 		return nil, err
 	}
 
-	return verificationTicket, nil
+	return events, nil
 }
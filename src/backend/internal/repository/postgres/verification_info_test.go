@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckIdCardNumberUsedByOthersWithoutKey(t *testing.T) {
+	db := connectTestDB(t)
+	ticketDB := NewVerificationInfoDB(db)
+
+	err := NewUnitOfWorkDB(db).Do(context.Background(), func(ctx context.Context) error {
+		owner, other := newTestUser(), newTestUser()
+		assert.NoError(t, NewUserDB(db).AddBatch(ctx, []*model.User{owner, other}))
+
+		// the number is stored in plaintext and without an index, an unkeyed one would be easily reversed
+		idCardNumber := uuid.New().String()[:13]
+		ticket := &model.VerificationTicket{
+			Id:               uuid.New(),
+			UserId:           owner.Id,
+			IdCardNumber:     idCardNumber,
+			IdCardPictureKey: "id-card",
+			DueDate:          time.Now(),
+			Status:           model.VerificationTicketPendingStatus,
+		}
+		assert.NoError(t, ticketDB.AddOne(ctx, ticket))
+
+		stored := model.VerificationTicket{}
+		assert.NoError(t, connOf(ctx, db).NewSelect().Model(&stored).Where("id = ?", ticket.Id).Scan(ctx))
+		assert.Empty(t, stored.IdCardNumberIndex)
+
+		used, err := ticketDB.CheckIdCardNumberUsedByOthers(ctx, " "+idCardNumber+" ", other.Id)
+		assert.NoError(t, err)
+		assert.True(t, used)
+
+		used, err = ticketDB.CheckIdCardNumberUsedByOthers(ctx, idCardNumber, owner.Id)
+		assert.NoError(t, err)
+		assert.False(t, used)
+
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+type WebhookSubscriptionDB struct {
+	*BaseDB[model.WebhookSubscription]
+}
+
+func NewWebhookSubscriptionDB(db *bun.DB) *WebhookSubscriptionDB {
+	type T = model.WebhookSubscription
+
+	return &WebhookSubscriptionDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (w *WebhookSubscriptionDB) FindAll(ctx context.Context) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	if err := w.conn(ctx).NewSelect().Model(&subscriptions).OrderExpr("created_at ASC").Scan(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+func (w *WebhookSubscriptionDB) FindActiveByEventType(ctx context.Context, eventType string) ([]*model.WebhookSubscription, error) {
+	var subscriptions []*model.WebhookSubscription
+	if err := w.conn(ctx).NewSelect().Model(&subscriptions).Where("is_active AND ? = ANY(event_types)", eventType).Scan(ctx, &subscriptions); err != nil {
+		return nil, err
+	}
+
+	return subscriptions, nil
+}
+
+type WebhookDeliveryDB struct {
+	*BaseDB[model.WebhookDelivery]
+}
+
+func NewWebhookDeliveryDB(db *bun.DB) *WebhookDeliveryDB {
+	type T = model.WebhookDelivery
+
+	return &WebhookDeliveryDB{
+		BaseDB: NewBaseDB[T](db),
+	}
+}
+
+func (w *WebhookDeliveryDB) FindWithFilter(ctx context.Context, filter model.WebhookDeliveryFilter) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+	query := w.conn(ctx).NewSelect().Model(&deliveries)
+
+	if filter.SubscriptionId != nil {
+		query.Where("subscription_id = ?", *filter.SubscriptionId)
+	}
+
+	if filter.Status != nil {
+		query.Where("status = ?", *filter.Status)
+	}
+
+	if err := query.OrderExpr("created_at DESC").Scan(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// ClaimDue leases the pending deliveries whose time has come by pushing their next attempt to leaseUntil,
+// the rows locked by another server are skipped so that a delivery is only attempted by one of them
+func (w *WebhookDeliveryDB) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	var deliveries []*model.WebhookDelivery
+
+	due := w.conn(ctx).NewSelect().Model((*model.WebhookDelivery)(nil)).Column("id").
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPendingStatus, now).
+		OrderExpr("next_attempt_at ASC").
+		Limit(limit).
+		For("UPDATE SKIP LOCKED")
+
+	if _, err := w.conn(ctx).NewUpdate().Model((*model.WebhookDelivery)(nil)).
+		Set("next_attempt_at = ?", leaseUntil).
+		Where("id IN (?)", due).
+		Returning("*").
+		Exec(ctx, &deliveries); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// UpdateClaimed saves the attempt of a pending delivery as long as it is still claimed until the time given, once the
+// lease has passed the delivery may have been claimed again and the attempt is not saved
+func (w *WebhookDeliveryDB) UpdateClaimed(ctx context.Context, delivery *model.WebhookDelivery, claimedUntil time.Time) (bool, error) {
+	res, err := w.conn(ctx).NewUpdate().Model(delivery).WherePK().
+		Where("status = ? AND next_attempt_at = ?", model.WebhookDeliveryPendingStatus, claimedUntil).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
+
+func (w *WebhookDeliveryDB) CheckExistenceByEventId(ctx context.Context, eventId uuid.UUID) (bool, error) {
+	var delivery model.WebhookDelivery
+	return w.conn(ctx).NewSelect().Model(&delivery).Where("event_id = ?", eventId).Exists(ctx)
+}
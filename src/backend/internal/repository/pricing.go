@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type GalleryAddOn interface {
+	BaseRepo[model.GalleryAddOn]
+	FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.GalleryAddOn, error)
+}
+
+type PricingRule interface {
+	BaseRepo[model.PricingRule]
+	FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.PricingRule, error)
+}
+
+type BookingLineItem interface {
+	BaseRepo[model.BookingLineItem]
+	FindByBookingIds(ctx context.Context, bookingIds ...uuid.UUID) ([]*model.BookingLineItem, error)
+}
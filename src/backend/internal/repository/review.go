@@ -10,6 +10,7 @@ import (
 type Review interface {
 	BaseRepo[model.Review]
 	FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.Review, error)
+	ClearTextByUserId(ctx context.Context, userId uuid.UUID) error
 	FindByGalleryId(ctx context.Context, galleryId uuid.UUID) ([]*model.Review, error)
 	FindByPhotographerId(ctx context.Context, photographerId uuid.UUID) ([]*model.Review, error)
 	CheckExistenceByGalleryId(ctx context.Context, galleryId uuid.UUID) (bool, error)
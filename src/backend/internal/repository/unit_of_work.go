@@ -0,0 +1,9 @@
+package repository
+
+import "context"
+
+// UnitOfWork groups repository calls into one transaction, every repository called with the context
+// handed to fn writes through it and nothing is kept unless fn returns nil
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type UserIdentity interface {
+	BaseRepo[model.UserIdentity]
+	FindOneByProviderAndSubject(ctx context.Context, provider, subject string) (*model.UserIdentity, error)
+	CheckExistenceByProviderAndSubject(ctx context.Context, provider, subject string) (bool, error)
+	FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.UserIdentity, error)
+	DeleteByUserIdAndProvider(ctx context.Context, userId uuid.UUID, provider string) error
+	DeleteByUserId(ctx context.Context, userId uuid.UUID) error
+}
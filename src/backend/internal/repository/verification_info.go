@@ -9,5 +9,15 @@ import (
 
 type VerificationTicket interface {
 	BaseRepo[model.VerificationTicket]
+	LockOneById(ctx context.Context, id uuid.UUID) (*model.VerificationTicket, error)
 	FindByUserIds(ctx context.Context, phtgIds []uuid.UUID) ([]*model.VerificationTicket, error)
+	FindWithFilter(ctx context.Context, filter model.VerificationTicketFilter) ([]*model.VerificationTicket, error)
+	FindLatestByUserId(ctx context.Context, userId uuid.UUID) (*model.VerificationTicket, error)
+	CheckIdCardNumberUsedByOthers(ctx context.Context, idCardNumber string, userId uuid.UUID) (bool, error)
+}
+
+type VerificationEvent interface {
+	BaseRepo[model.VerificationEvent]
+	FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.VerificationEvent, error)
+	FindByTicketIds(ctx context.Context, ticketIds []uuid.UUID) ([]*model.VerificationEvent, error)
 }
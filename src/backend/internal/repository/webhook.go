@@ -0,0 +1,23 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+type WebhookSubscription interface {
+	BaseRepo[model.WebhookSubscription]
+	FindAll(ctx context.Context) ([]*model.WebhookSubscription, error)
+	FindActiveByEventType(ctx context.Context, eventType string) ([]*model.WebhookSubscription, error)
+}
+
+type WebhookDelivery interface {
+	BaseRepo[model.WebhookDelivery]
+	FindWithFilter(ctx context.Context, filter model.WebhookDeliveryFilter) ([]*model.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+	UpdateClaimed(ctx context.Context, delivery *model.WebhookDelivery, claimedUntil time.Time) (bool, error)
+	CheckExistenceByEventId(ctx context.Context, eventId uuid.UUID) (bool, error)
+}
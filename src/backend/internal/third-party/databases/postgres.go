@@ -1,13 +1,17 @@
 package databases
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
 	"github.com/uptrace/bun/driver/pgdriver"
-	"github.com/uptrace/bun/extra/bundebug"
+	"github.com/uptrace/bun/extra/bunotel"
 )
 
 func ConnectSQLDB(dsn string) *bun.DB {
@@ -15,7 +19,37 @@ func ConnectSQLDB(dsn string) *bun.DB {
 
 	db := bun.NewDB(sqldb, pgdialect.New())
 
-	db.AddQueryHook(bundebug.NewQueryHook(bundebug.WithVerbose(true)))
-	log.Println("✅ Postgres connected successfully")
+	db.AddQueryHook(bunotel.NewQueryHook())
+	db.AddQueryHook(queryLogHook{})
+	metrics.ObserveDB(sqldb, "postgres")
+	slog.Info("Postgres connected successfully")
 	return db
 }
+
+// queryLogHook logs the queries at the debug level and the failed ones at the warn level, with the request they
+// were run for. Only the operation and the table are logged, the query is formatted with its arguments which may
+// hold personal data or secrets.
+type queryLogHook struct{}
+
+func (queryLogHook) BeforeQuery(ctx context.Context, event *bun.QueryEvent) context.Context {
+	return ctx
+}
+
+func (queryLogHook) AfterQuery(ctx context.Context, event *bun.QueryEvent) {
+	attrs := []any{
+		slog.String("operation", event.Operation()),
+		slog.Duration("duration", time.Since(event.StartTime)),
+	}
+	if event.IQuery != nil {
+		if table := event.IQuery.GetTableName(); table != "" {
+			attrs = append(attrs, slog.String("table", table))
+		}
+	}
+
+	if event.Err != nil && !errors.Is(event.Err, sql.ErrNoRows) {
+		slog.WarnContext(ctx, "query failed", append(attrs, slog.String("error", event.Err.Error()))...)
+		return
+	}
+
+	slog.DebugContext(ctx, "query", attrs...)
+}
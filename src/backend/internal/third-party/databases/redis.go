@@ -2,8 +2,11 @@ package databases
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"os"
 
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/redis/go-redis/extra/redisotel/v9"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -18,15 +21,22 @@ func ConnectRedis(dsn string) *redis.Client {
 		Addr: dsn,
 	})
 
+	if err := redisotel.InstrumentTracing(RedisClient); err != nil {
+		slog.Warn("could not trace Redis", "error", err)
+	}
+	RedisClient.AddHook(metrics.RedisHook{})
+
 	if _, err := RedisClient.Ping(ctx).Result(); err != nil {
-		log.Fatalf("ERROR: could not connect to Redis database --> %s", err.Error())
+		slog.Error("could not connect to Redis database", "error", err)
+		os.Exit(1)
 	}
 
 	if err := RedisClient.Set(ctx, "health-check", "first key initiation", 0); err.Err() != nil {
-		log.Fatalf(err.Err().Error())
+		slog.Error("could not write to Redis database", "error", err.Err())
+		os.Exit(1)
 	}
 
-	log.Println("✅ Redis connected successfully")
+	slog.Info("Redis connected successfully")
 
 	return RedisClient
 }
@@ -0,0 +1,194 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// sealed values look like enc:v1:<key id>:<wrapped data key>:<ciphertext>
+const sealedPrefix = "enc:v1:"
+
+var ErrMalformedCiphertext = errors.New("malformed ciphertext")
+
+var defaultCipher *Cipher
+
+// SetDefault installs the cipher used by the repositories, without one the columns are kept in plaintext
+func SetDefault(c *Cipher) {
+	defaultCipher = c
+}
+
+func Default() *Cipher {
+	return defaultCipher
+}
+
+// Cipher seals every value with its own random data key, which is in turn wrapped by the key encryption key
+type Cipher struct {
+	provider KeyProvider
+}
+
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{provider}
+}
+
+func IsSealed(value string) bool {
+	return strings.HasPrefix(value, sealedPrefix)
+}
+
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	if c == nil || plaintext == "" {
+		return plaintext, nil
+	}
+
+	keyId := c.provider.CurrentKeyId()
+	kek, err := c.provider.KeyEncryptionKey(keyId)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey := make([]byte, keySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	wrappedKey, err := seal(kek, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s:%s:%s", sealedPrefix, keyId,
+		base64.RawStdEncoding.EncodeToString(wrappedKey),
+		base64.RawStdEncoding.EncodeToString(ciphertext),
+	), nil
+}
+
+// Decrypt passes values written before encryption was enabled through unchanged
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsSealed(value) {
+		return value, nil
+	}
+	if c == nil {
+		return "", errors.New("cannot decrypt without a key provider")
+	}
+
+	keyId, wrappedKey, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	kek, err := c.provider.KeyEncryptionKey(keyId)
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(kek, wrappedKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// NeedsReencryption tells whether the value is still in plaintext or sealed with a retired key
+func (c *Cipher) NeedsReencryption(value string) bool {
+	if c == nil || value == "" {
+		return false
+	}
+	if !IsSealed(value) {
+		return true
+	}
+
+	keyId, _, _, err := parse(value)
+	return err == nil && keyId != c.provider.CurrentKeyId()
+}
+
+// BlindIndex is a keyed hash of the normalised value, equal values can be matched without decrypting them. It is
+// empty without a key, a hash anyone can compute is easily reversed for short values such as ID card numbers.
+func (c *Cipher) BlindIndex(value string) string {
+	if c == nil || len(c.provider.BlindIndexKey()) == 0 {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, c.provider.BlindIndexKey())
+	mac.Write([]byte(NormaliseIndexed(value)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormaliseIndexed is the value hashed by BlindIndex, without spaces and in uppercase
+func NormaliseIndexed(value string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(value), ""))
+}
+
+func parse(value string) (string, []byte, []byte, error) {
+	parts := strings.Split(strings.TrimPrefix(value, sealedPrefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+
+	wrappedKey, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", nil, nil, ErrMalformedCiphertext
+	}
+
+	return parts[0], wrappedKey, ciphertext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// seal prepends the random nonce to the ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, sealed []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformedCiphertext
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
@@ -0,0 +1,132 @@
+package encryption
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestKey(t *testing.T) string {
+	key := make([]byte, keySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func writeKeyFile(t *testing.T, file keyFile) string {
+	content, err := json.Marshal(file)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	return path
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	provider, err := NewLocalKeyProvider(writeKeyFile(t, keyFile{
+		Current:       "k1",
+		Keys:          map[string]string{"k1": newTestKey(t)},
+		BlindIndexKey: newTestKey(t),
+	}))
+	require.NoError(t, err)
+	c := NewCipher(provider)
+
+	sealed, err := c.Encrypt("1103700012345")
+	require.NoError(t, err)
+	assert.True(t, IsSealed(sealed))
+	assert.NotContains(t, sealed, "1103700012345")
+
+	other, err := c.Encrypt("1103700012345")
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, other, "every value is sealed with its own data key")
+
+	plaintext, err := c.Decrypt(sealed)
+	require.NoError(t, err)
+	assert.Equal(t, "1103700012345", plaintext)
+
+	legacy, err := c.Decrypt("Bangkok")
+	require.NoError(t, err)
+	assert.Equal(t, "Bangkok", legacy)
+
+	tampered := sealed[:len(sealed)-2] + strings.Repeat("A", 2)
+	_, err = c.Decrypt(tampered)
+	assert.Error(t, err)
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey, newKey, indexKey := newTestKey(t), newTestKey(t), newTestKey(t)
+
+	oldProvider, err := NewLocalKeyProvider(writeKeyFile(t, keyFile{
+		Current:       "k1",
+		Keys:          map[string]string{"k1": oldKey},
+		BlindIndexKey: indexKey,
+	}))
+	require.NoError(t, err)
+	sealed, err := NewCipher(oldProvider).Encrypt("0812345678")
+	require.NoError(t, err)
+
+	rotatedProvider, err := NewLocalKeyProvider(writeKeyFile(t, keyFile{
+		Current:       "k2",
+		Keys:          map[string]string{"k1": oldKey, "k2": newKey},
+		BlindIndexKey: indexKey,
+	}))
+	require.NoError(t, err)
+	rotated := NewCipher(rotatedProvider)
+
+	assert.True(t, rotated.NeedsReencryption(sealed))
+	assert.True(t, rotated.NeedsReencryption("legacy plaintext"))
+
+	plaintext, err := rotated.Decrypt(sealed)
+	require.NoError(t, err)
+	resealed, err := rotated.Encrypt(plaintext)
+	require.NoError(t, err)
+	assert.False(t, rotated.NeedsReencryption(resealed))
+
+	assert.Equal(t, NewCipher(oldProvider).BlindIndex("1103700012345"), rotated.BlindIndex("1103700012345"))
+}
+
+func TestBlindIndex(t *testing.T) {
+	provider, err := NewLocalKeyProvider(writeKeyFile(t, keyFile{
+		Current:       "k1",
+		Keys:          map[string]string{"k1": newTestKey(t)},
+		BlindIndexKey: newTestKey(t),
+	}))
+	require.NoError(t, err)
+	c := NewCipher(provider)
+
+	assert.Equal(t, c.BlindIndex("1 1037 00012 34 5"), c.BlindIndex("1103700012345"))
+	assert.NotEqual(t, c.BlindIndex("1103700012345"), c.BlindIndex("1103700012346"))
+}
+
+func TestBlindIndexWithoutKey(t *testing.T) {
+	var c *Cipher
+
+	// an unkeyed hash of the 13 digits is found by trying them all
+	assert.Empty(t, c.BlindIndex("1103700012345"))
+	assert.Equal(t, "1103700012345", NormaliseIndexed(" 1 1037 00012 34 5 "))
+}
+
+func TestNewLocalKeyProviderRejectsMissingCurrentKey(t *testing.T) {
+	_, err := NewLocalKeyProvider(writeKeyFile(t, keyFile{
+		Current:       "k2",
+		Keys:          map[string]string{"k1": newTestKey(t)},
+		BlindIndexKey: newTestKey(t),
+	}))
+	assert.Error(t, err)
+}
+
+func TestNilCipherKeepsPlaintext(t *testing.T) {
+	var c *Cipher
+
+	value, err := c.Encrypt("0812345678")
+	require.NoError(t, err)
+	assert.Equal(t, "0812345678", value)
+	assert.False(t, c.NeedsReencryption(value))
+}
@@ -0,0 +1,115 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("unknown key encryption key")
+
+// KeyProvider hands out the key encryption keys that wrap the per-value data keys,
+// a KMS-backed provider only has to implement this interface
+type KeyProvider interface {
+	// CurrentKeyId names the key that new values are sealed with
+	CurrentKeyId() string
+	KeyEncryptionKey(keyId string) ([]byte, error)
+	// BlindIndexKey is kept apart from the encryption keys so that rotating them does not change the indexes
+	BlindIndexKey() []byte
+}
+
+type keyFile struct {
+	Current       string            `json:"current"`
+	Keys          map[string]string `json:"keys"`
+	BlindIndexKey string            `json:"blind_index_key"`
+}
+
+// LocalKeyProvider reads base64 encoded 256-bit keys from a JSON keyfile, e.g.
+//
+//	{"current": "2024-04", "keys": {"2024-04": "..."}, "blind_index_key": "..."}
+//
+// older keys stay in the file until `migrate reencrypt` has moved every row to the current one
+type LocalKeyProvider struct {
+	current       string
+	keys          map[string][]byte
+	blindIndexKey []byte
+}
+
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file := keyFile{}
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("invalid keyfile: %w", err)
+	}
+
+	provider := &LocalKeyProvider{
+		current: file.Current,
+		keys:    map[string][]byte{},
+	}
+
+	for keyId, encoded := range file.Keys {
+		key, err := decodeKey(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key %s: %w", keyId, err)
+		}
+		provider.keys[keyId] = key
+	}
+
+	if _, exist := provider.keys[file.Current]; !exist {
+		return nil, fmt.Errorf("the current key %q is not in the keyfile", file.Current)
+	}
+
+	if provider.blindIndexKey, err = decodeKey(file.BlindIndexKey); err != nil {
+		return nil, fmt.Errorf("invalid blind index key: %w", err)
+	}
+
+	return provider, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("the key must be %d bytes", keySize)
+	}
+
+	return key, nil
+}
+
+func (l *LocalKeyProvider) CurrentKeyId() string {
+	return l.current
+}
+
+func (l *LocalKeyProvider) KeyEncryptionKey(keyId string) ([]byte, error) {
+	key, exist := l.keys[keyId]
+	if !exist {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (l *LocalKeyProvider) BlindIndexKey() []byte {
+	return l.blindIndexKey
+}
+
+// InitFromKeyFile installs a cipher backed by the local keyfile as the default
+func InitFromKeyFile(path string) error {
+	provider, err := NewLocalKeyProvider(path)
+	if err != nil {
+		return err
+	}
+
+	SetDefault(NewCipher(provider))
+	return nil
+}
@@ -0,0 +1,539 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	StatusConfirmed = "CONFIRMED"
+	StatusTentative = "TENTATIVE"
+	StatusCancelled = "CANCELLED"
+)
+
+const (
+	utcLayout      = "20060102T150405Z"
+	localLayout    = "20060102T150405"
+	dateLayout     = "20060102"
+	maxLineOctets  = 75
+	maxOccurrences = 500
+)
+
+var ErrMalformedCalendar = errors.New("the file is not an iCalendar file")
+
+// Event is a VEVENT, the times of a parsed all-day event span its whole days in the time zone of the calendar
+type Event struct {
+	Uid          string
+	Summary      string
+	Description  string
+	Location     string
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Status       string
+	Transparent  bool
+	Sequence     int
+	LastModified time.Time
+	// the recurrence rule as it is written in the file, see Occurrences
+	Rule string
+}
+
+type Calendar struct {
+	Name   string
+	Events []*Event
+}
+
+// Write writes the calendar as an iCalendar file for the subscriptions of calendar apps
+func Write(w io.Writer, calendar Calendar, now time.Time) error {
+	out := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(out, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//Pic Keeper//Bookings//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if calendar.Name != "" {
+		line("X-WR-CALNAME", escape(calendar.Name))
+	}
+
+	for _, event := range calendar.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", escape(event.Uid))
+		line("DTSTAMP", now.UTC().Format(utcLayout))
+		line("DTSTART", event.Start.UTC().Format(utcLayout))
+		line("DTEND", event.End.UTC().Format(utcLayout))
+		line("SUMMARY", escape(event.Summary))
+		if event.Location != "" {
+			line("LOCATION", escape(event.Location))
+		}
+		if event.Description != "" {
+			line("DESCRIPTION", escape(event.Description))
+		}
+		if event.Status != "" {
+			line("STATUS", event.Status)
+		}
+		line("SEQUENCE", strconv.Itoa(event.Sequence))
+		if !event.LastModified.IsZero() {
+			line("LAST-MODIFIED", event.LastModified.UTC().Format(utcLayout))
+		}
+		line("END", "VEVENT")
+	}
+
+	line("END", "VCALENDAR")
+	return out.Flush()
+}
+
+// writeFolded splits the lines longer than 75 octets, the following parts start with a space
+func writeFolded(out *bufio.Writer, content string) {
+	limit := maxLineOctets
+	for len(content) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		out.WriteString(content[:cut])
+		out.WriteString("\r\n ")
+		content = content[cut:]
+		limit = maxLineOctets - 1
+	}
+	out.WriteString(content)
+	out.WriteString("\r\n")
+}
+
+func escape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+func unescape(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Parse reads the events of an iCalendar file. The floating times and the dates are read in the time zone named by
+// X-WR-TIMEZONE, or in UTC, as are the times whose TZID is not a known IANA zone.
+func Parse(r io.Reader) (*Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(lines[0], "BEGIN:VCALENDAR") {
+		return nil, ErrMalformedCalendar
+	}
+
+	calendar := &Calendar{}
+	location := time.UTC
+	var event []property
+	var components []string
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(prop.value))
+			if strings.EqualFold(prop.value, "VEVENT") {
+				event = []property{}
+			}
+			continue
+		case "END":
+			if len(components) == 0 {
+				return nil, ErrMalformedCalendar
+			}
+			components = components[:len(components)-1]
+			if strings.EqualFold(prop.value, "VEVENT") && event != nil {
+				parsed, err := parseEvent(event, location)
+				if err != nil {
+					return nil, err
+				}
+				if parsed != nil {
+					calendar.Events = append(calendar.Events, parsed)
+				}
+				event = nil
+			}
+			continue
+		}
+
+		switch {
+		case len(components) == 1 && prop.name == "X-WR-CALNAME":
+			calendar.Name = unescape(prop.value)
+		case len(components) == 1 && prop.name == "X-WR-TIMEZONE":
+			if loaded, err := time.LoadLocation(prop.value); err == nil {
+				location = loaded
+			}
+		case len(components) == 2 && components[1] == "VEVENT":
+			// the properties of the alarms of the event are left out
+			event = append(event, prop)
+		}
+	}
+
+	return calendar, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	lines := []string{}
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) == 0 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits NAME;PARAM=VALUE:VALUE, a colon within a quoted parameter value does not end the name
+func parseProperty(line string) (property, bool) {
+	quoted := false
+	for i, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ':' && !quoted:
+			parts := strings.Split(line[:i], ";")
+			prop := property{name: strings.ToUpper(parts[0]), params: map[string]string{}, value: line[i+1:]}
+			for _, param := range parts[1:] {
+				if key, value, ok := strings.Cut(param, "="); ok {
+					prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+				}
+			}
+			return prop, true
+		}
+	}
+
+	return property{}, false
+}
+
+// parseEvent returns nil for an event without a start
+func parseEvent(props []property, location *time.Location) (*Event, error) {
+	event := &Event{Status: StatusConfirmed}
+	var end *time.Time
+	var duration *time.Duration
+	for _, prop := range props {
+		var err error
+		switch prop.name {
+		case "UID":
+			event.Uid = prop.value
+		case "SUMMARY":
+			event.Summary = unescape(prop.value)
+		case "DESCRIPTION":
+			event.Description = unescape(prop.value)
+		case "LOCATION":
+			event.Location = unescape(prop.value)
+		case "STATUS":
+			event.Status = strings.ToUpper(prop.value)
+		case "TRANSP":
+			event.Transparent = strings.EqualFold(prop.value, "TRANSPARENT")
+		case "RRULE":
+			event.Rule = strings.ToUpper(prop.value)
+		case "SEQUENCE":
+			event.Sequence, _ = strconv.Atoi(prop.value)
+		case "DTSTART":
+			event.Start, event.AllDay, err = parseTime(prop, location)
+		case "DTEND":
+			var parsed time.Time
+			parsed, _, err = parseTime(prop, location)
+			end = &parsed
+		case "DURATION":
+			var parsed time.Duration
+			parsed, err = parseDuration(prop.value)
+			duration = &parsed
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s of the event %s", ErrMalformedCalendar, prop.name, event.Uid)
+		}
+	}
+
+	if event.Start.IsZero() {
+		return nil, nil
+	}
+
+	switch {
+	case end != nil:
+		event.End = *end
+	case duration != nil:
+		event.End = event.Start.Add(*duration)
+	case event.AllDay:
+		event.End = event.Start.AddDate(0, 0, 1)
+	default:
+		event.End = event.Start
+	}
+
+	return event, nil
+}
+
+func parseTime(prop property, location *time.Location) (time.Time, bool, error) {
+	if prop.params["VALUE"] == "DATE" || len(prop.value) == len(dateLayout) {
+		date, err := time.ParseInLocation(dateLayout, prop.value, location)
+		return date, true, err
+	}
+
+	if strings.HasSuffix(prop.value, "Z") {
+		parsed, err := time.Parse(utcLayout, prop.value)
+		return parsed, false, err
+	}
+
+	if tzid, ok := prop.params["TZID"]; ok {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+
+	parsed, err := time.ParseInLocation(localLayout, prop.value, location)
+	return parsed, false, err
+}
+
+var durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+
+// parseDuration reads the durations like P1W, P2DT3H or PT90M
+func parseDuration(value string) (time.Duration, error) {
+	match := durationPattern.FindStringSubmatch(value)
+	if match == nil || value == "P" || strings.HasSuffix(value, "T") {
+		return 0, ErrMalformedCalendar
+	}
+
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var duration time.Duration
+	for i, unit := range units {
+		if match[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(match[i+2])
+		if err != nil {
+			return 0, err
+		}
+		duration += time.Duration(n) * unit
+	}
+
+	if match[1] == "-" {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Occurrences lists the occurrences of the event that end after the start of the window and start before its horizon,
+// following its recurrence rule. The occurrences before the window are skipped without being listed, so that an old
+// event repeated every day still blocks the days of the window. Only the FREQ, INTERVAL, COUNT, UNTIL and WKST parts
+// of a rule and the BYDAY part of a weekly rule are understood, so the second result is false when the rule has any
+// other part and only the event itself is returned. EXDATE and the overridden instances are not taken into account.
+func (e *Event) Occurrences(after time.Time, horizon time.Time) ([]*Event, bool) {
+	if e.Rule == "" {
+		return []*Event{e}, true
+	}
+
+	rule, ok := parseRule(e.Rule, e.Start.Location())
+	if !ok {
+		return []*Event{e}, false
+	}
+
+	length := e.End.Sub(e.Start)
+	first := rule.periodsBefore(e.Start, after.Add(-length))
+	seen := 0
+	if first > 0 {
+		seen = len(rule.starts(e.Start, 0)) + (first-1)*rule.perPeriod()
+	}
+
+	occurrences := []*Event{}
+	for n := first; len(occurrences) < maxOccurrences; n++ {
+		for _, start := range rule.starts(e.Start, n) {
+			if rule.count > 0 && seen >= rule.count {
+				return occurrences, true
+			}
+			if !start.Before(horizon) || (rule.until != nil && start.After(*rule.until)) {
+				return occurrences, true
+			}
+			seen++
+
+			if !start.Add(length).After(after) {
+				continue
+			}
+
+			occurrence := *e
+			occurrence.Start, occurrence.End, occurrence.Rule = start, start.Add(length), ""
+			occurrences = append(occurrences, &occurrence)
+			if len(occurrences) == maxOccurrences {
+				break
+			}
+		}
+	}
+
+	return occurrences, true
+}
+
+type rule struct {
+	freq      string
+	interval  int
+	count     int
+	until     *time.Time
+	weekStart time.Weekday
+	// the days of the week of a weekly rule, sorted from the start of the week
+	days []time.Weekday
+}
+
+func parseRule(value string, location *time.Location) (*rule, bool) {
+	r := &rule{interval: 1, weekStart: time.Monday}
+	var byDay []string
+	for _, part := range strings.Split(value, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "FREQ":
+			switch value {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				r.freq = value
+			default:
+				return nil, false
+			}
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			r.interval = n
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, false
+			}
+			r.count = n
+		case "UNTIL":
+			parsed, _, err := parseTime(property{value: value, params: map[string]string{}}, location)
+			if err != nil {
+				return nil, false
+			}
+			r.until = &parsed
+		case "WKST":
+			day, ok := weekdays[value]
+			if !ok {
+				return nil, false
+			}
+			r.weekStart = day
+		case "BYDAY":
+			byDay = strings.Split(value, ",")
+		default:
+			return nil, false
+		}
+	}
+	if r.freq == "" {
+		return nil, false
+	}
+
+	// the days with an ordinal, like 1MO, belong to the monthly and yearly rules
+	if byDay != nil {
+		if r.freq != "WEEKLY" {
+			return nil, false
+		}
+		for _, name := range byDay {
+			day, ok := weekdays[name]
+			if !ok {
+				return nil, false
+			}
+			r.days = append(r.days, day)
+		}
+		sort.Slice(r.days, func(i, j int) bool {
+			return r.sinceWeekStart(r.days[i]) < r.sinceWeekStart(r.days[j])
+		})
+	}
+
+	return r, true
+}
+
+func (r *rule) sinceWeekStart(day time.Weekday) int {
+	return (int(day) - int(r.weekStart) + 7) % 7
+}
+
+// perPeriod is the number of occurrences of a whole period
+func (r *rule) perPeriod() int {
+	if len(r.days) > 0 {
+		return len(r.days)
+	}
+	return 1
+}
+
+// starts lists the starts of the occurrences of the nth period of the rule, none is before the start of the event
+func (r *rule) starts(start time.Time, n int) []time.Time {
+	switch r.freq {
+	case "DAILY":
+		return []time.Time{start.AddDate(0, 0, n*r.interval)}
+	case "MONTHLY":
+		return []time.Time{start.AddDate(0, n*r.interval, 0)}
+	case "YEARLY":
+		return []time.Time{start.AddDate(n*r.interval, 0, 0)}
+	}
+
+	if len(r.days) == 0 {
+		return []time.Time{start.AddDate(0, 0, 7*n*r.interval)}
+	}
+
+	week := start.AddDate(0, 0, 7*n*r.interval-r.sinceWeekStart(start.Weekday()))
+	starts := []time.Time{}
+	for _, day := range r.days {
+		occurrence := week.AddDate(0, 0, r.sinceWeekStart(day))
+		if !occurrence.Before(start) {
+			starts = append(starts, occurrence)
+		}
+	}
+
+	return starts
+}
+
+// periodsBefore is the number of whole periods of the rule that are over before the time, at most
+func (r *rule) periodsBefore(start time.Time, t time.Time) int {
+	if !t.After(start) {
+		return 0
+	}
+
+	var periods int
+	switch r.freq {
+	case "DAILY":
+		periods = int(t.Sub(start)/(24*time.Hour)) / r.interval
+	case "WEEKLY":
+		periods = int(t.Sub(start)/(7*24*time.Hour)) / r.interval
+	case "MONTHLY":
+		periods = ((t.Year()-start.Year())*12 + int(t.Month()) - int(start.Month())) / r.interval
+	case "YEARLY":
+		periods = (t.Year() - start.Year()) / r.interval
+	}
+
+	// a period is kept in hand for the days changed by the daylight saving time and the shorter months
+	if periods > 0 {
+		periods--
+	}
+	return periods
+}
@@ -0,0 +1,213 @@
+package ical
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	now := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 5, 3, 16, 0, 0, 0, time.FixedZone("ICT", 7*60*60))
+
+	out := &bytes.Buffer{}
+	require.NoError(t, Write(out, Calendar{
+		Name: "Bookings",
+		Events: []*Event{{
+			Uid:         "session-1@pic-keeper",
+			Summary:     "Wedding; Jane, John",
+			Description: strings.Repeat("a long description ", 10),
+			Start:       start,
+			End:         start.Add(2 * time.Hour),
+			Status:      StatusCancelled,
+			Sequence:    1,
+		}},
+	}, now))
+
+	file := out.String()
+	assert.True(t, strings.HasPrefix(file, "BEGIN:VCALENDAR\r\n"))
+	assert.True(t, strings.HasSuffix(file, "END:VCALENDAR\r\n"))
+	assert.Contains(t, file, "DTSTART:20240503T090000Z\r\n")
+	assert.Contains(t, file, "DTEND:20240503T110000Z\r\n")
+	assert.Contains(t, file, `SUMMARY:Wedding\; Jane\, John`)
+	assert.Contains(t, file, "STATUS:CANCELLED\r\n")
+	for _, line := range strings.Split(file, "\r\n") {
+		assert.LessOrEqual(t, len(line), maxLineOctets)
+	}
+
+	// what is written is read back
+	calendar, err := Parse(out)
+	require.NoError(t, err)
+	require.Len(t, calendar.Events, 1)
+	assert.Equal(t, "Wedding; Jane, John", calendar.Events[0].Summary)
+	assert.Equal(t, strings.Repeat("a long description ", 10), calendar.Events[0].Description)
+	assert.True(t, calendar.Events[0].Start.Equal(start))
+}
+
+func TestParse(t *testing.T) {
+	file := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"X-WR-TIMEZONE:Asia/Bangkok",
+		"BEGIN:VEVENT",
+		"UID:utc",
+		"DTSTART:20240510T020000Z",
+		"DTEND:20240510T030000Z",
+		"SUMMARY:Dentist",
+		"BEGIN:VALARM",
+		"SUMMARY:Reminder",
+		"END:VALARM",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:all-day",
+		"DTSTART;VALUE=DATE:20240511",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:zoned",
+		`DTSTART;TZID="Europe/London":20240512T090000`,
+		"DURATION:PT1H30M",
+		"STATUS:CANCELLED",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:no-start",
+		"END:VEVENT",
+		"END:VCALENDAR",
+	}, "\r\n")
+
+	calendar, err := Parse(strings.NewReader(file))
+	require.NoError(t, err)
+	require.Len(t, calendar.Events, 3)
+
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	require.NoError(t, err)
+	london, err := time.LoadLocation("Europe/London")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Dentist", calendar.Events[0].Summary)
+	assert.Equal(t, time.Hour, calendar.Events[0].End.Sub(calendar.Events[0].Start))
+
+	assert.True(t, calendar.Events[1].AllDay)
+	assert.True(t, calendar.Events[1].Transparent)
+	assert.True(t, calendar.Events[1].Start.Equal(time.Date(2024, 5, 11, 0, 0, 0, 0, bangkok)))
+	assert.True(t, calendar.Events[1].End.Equal(time.Date(2024, 5, 12, 0, 0, 0, 0, bangkok)))
+
+	assert.True(t, calendar.Events[2].Start.Equal(time.Date(2024, 5, 12, 9, 0, 0, 0, london)))
+	assert.Equal(t, 90*time.Minute, calendar.Events[2].End.Sub(calendar.Events[2].Start))
+	assert.Equal(t, StatusCancelled, calendar.Events[2].Status)
+
+	_, err = Parse(strings.NewReader("not a calendar"))
+	assert.ErrorIs(t, err, ErrMalformedCalendar)
+}
+
+func TestOccurrences(t *testing.T) {
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	event := &Event{Start: start, End: start.Add(time.Hour)}
+	horizon := start.AddDate(0, 3, 0)
+
+	event.Rule = "FREQ=WEEKLY;INTERVAL=2;COUNT=3"
+	occurrences, ok := event.Occurrences(start, horizon)
+	assert.True(t, ok)
+	require.Len(t, occurrences, 3)
+	assert.True(t, occurrences[2].Start.Equal(start.AddDate(0, 0, 28)))
+	assert.True(t, occurrences[2].End.Equal(start.AddDate(0, 0, 28).Add(time.Hour)))
+
+	event.Rule = "FREQ=DAILY;UNTIL=20240508T090000Z"
+	occurrences, ok = event.Occurrences(start, horizon)
+	assert.True(t, ok)
+	assert.Len(t, occurrences, 3)
+
+	// the rule without an end stops at the horizon
+	event.Rule = "FREQ=MONTHLY"
+	occurrences, ok = event.Occurrences(start, horizon)
+	assert.True(t, ok)
+	assert.Len(t, occurrences, 3)
+
+	event.Rule = "FREQ=MONTHLY;BYDAY=2TU"
+	occurrences, ok = event.Occurrences(start, horizon)
+	assert.False(t, ok)
+	assert.Equal(t, []*Event{event}, occurrences)
+}
+
+func TestOccurrencesOfOldEvent(t *testing.T) {
+	start := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	event := &Event{Start: start, End: start.Add(time.Hour), Rule: "FREQ=DAILY"}
+
+	// the days since 2020 are well over the occurrences listed at most
+	now := time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	occurrences, ok := event.Occurrences(now, now.AddDate(0, 0, 7))
+	assert.True(t, ok)
+	require.Len(t, occurrences, 7)
+	assert.True(t, occurrences[0].Start.Equal(time.Date(2024, 5, 7, 9, 0, 0, 0, time.UTC)))
+
+	// the occurrences skipped still count
+	event.Rule = "FREQ=DAILY;COUNT=1591"
+	occurrences, ok = event.Occurrences(now, now.AddDate(0, 0, 7))
+	assert.True(t, ok)
+	require.Len(t, occurrences, 3)
+	assert.True(t, occurrences[2].Start.Equal(time.Date(2024, 5, 9, 9, 0, 0, 0, time.UTC)))
+}
+
+// an event repeated every Monday and Thursday, as exported by Google Calendar
+const googleWeeklyEvent = `BEGIN:VCALENDAR
+PRODID:-//Google Inc//Google Calendar 70.9054//EN
+VERSION:2.0
+CALSCALE:GREGORIAN
+METHOD:PUBLISH
+X-WR-CALNAME:Studio
+X-WR-TIMEZONE:Asia/Bangkok
+BEGIN:VTIMEZONE
+TZID:Asia/Bangkok
+X-LIC-LOCATION:Asia/Bangkok
+BEGIN:STANDARD
+TZOFFSETFROM:+0700
+TZOFFSETTO:+0700
+TZNAME:+07
+DTSTART:19700101T000000
+END:STANDARD
+END:VTIMEZONE
+BEGIN:VEVENT
+DTSTART;TZID=Asia/Bangkok:20230109T100000
+DTEND;TZID=Asia/Bangkok:20230109T113000
+RRULE:FREQ=WEEKLY;WKST=SU;BYDAY=MO,TH
+DTSTAMP:20240501T020000Z
+UID:5f1b0s2v0pq1c9kq3r9d7ml2k8@google.com
+CREATED:20230105T031502Z
+DESCRIPTION:
+LAST-MODIFIED:20230105T031502Z
+LOCATION:
+SEQUENCE:0
+STATUS:CONFIRMED
+SUMMARY:Studio hours
+TRANSP:OPAQUE
+END:VEVENT
+END:VCALENDAR
+`
+
+func TestOccurrencesOfGoogleWeeklyEvent(t *testing.T) {
+	calendar, err := Parse(strings.NewReader(strings.ReplaceAll(googleWeeklyEvent, "\n", "\r\n")))
+	require.NoError(t, err)
+	require.Len(t, calendar.Events, 1)
+
+	bangkok, err := time.LoadLocation("Asia/Bangkok")
+	require.NoError(t, err)
+
+	// from Wednesday 1 May 2024 to the Wednesday after
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, bangkok)
+	occurrences, ok := calendar.Events[0].Occurrences(now, now.AddDate(0, 0, 7))
+	assert.True(t, ok)
+	require.Len(t, occurrences, 2)
+	assert.True(t, occurrences[0].Start.Equal(time.Date(2024, 5, 2, 10, 0, 0, 0, bangkok)))
+	assert.True(t, occurrences[0].End.Equal(time.Date(2024, 5, 2, 11, 30, 0, 0, bangkok)))
+	assert.True(t, occurrences[1].Start.Equal(time.Date(2024, 5, 6, 10, 0, 0, 0, bangkok)))
+
+	// the days of the first week before the start are left out
+	first, ok := calendar.Events[0].Occurrences(time.Date(2023, 1, 1, 0, 0, 0, 0, bangkok), time.Date(2023, 1, 14, 0, 0, 0, 0, bangkok))
+	assert.True(t, ok)
+	require.Len(t, first, 2)
+	assert.True(t, first[0].Start.Equal(time.Date(2023, 1, 9, 10, 0, 0, 0, bangkok)))
+	assert.True(t, first[1].Start.Equal(time.Date(2023, 1, 12, 10, 0, 0, 0, bangkok)))
+}
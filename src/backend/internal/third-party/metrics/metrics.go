@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+)
+
+const namespace = "pickeeper"
+
+var (
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "The requests served, by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "The time taken to serve the requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	HTTPRequestsInFlight = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "The requests being served.",
+	})
+
+	RedisCommandDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "redis_command_duration_seconds",
+		Help:      "The round trip of the Redis commands, a pipeline is observed as a whole.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"command"})
+
+	RedisCommandErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_command_errors_total",
+		Help:      "The Redis commands that failed, a missing key is not a failure.",
+	}, []string{"command"})
+
+	ChatRoomMembers = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chat_room_members",
+		Help:      "The chat rooms joined by the users connected to this server, one per user and room.",
+	})
+
+	ChatDroppedWrites = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chat_dropped_writes_total",
+		Help:      "The chat messages that could not be written to a session, the session is closed.",
+	})
+
+	ImageProcessingDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "image_processing_duration_seconds",
+		Help:      "The time taken to decode the uploaded photos and to resize and compress them.",
+		Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"step"})
+
+	BookingStatusTransitions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "booking_status_transitions_total",
+		Help:      "The bookings moved from a status to another, a new booking comes from NONE.",
+	}, []string{"from", "to"})
+
+	JobRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_runs_total",
+		Help:      "The jobs run by the worker, by kind and outcome.",
+	}, []string{"kind", "outcome"})
+
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "The time taken to run the jobs, by kind.",
+		Buckets:   []float64{.01, .05, .1, .5, 1, 5, 10, 30, 60, 300},
+	}, []string{"kind"})
+
+	JobLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "job_last_success_timestamp_seconds",
+		Help:      "When a job of the kind last succeeded, a periodic job falling behind its period is unhealthy.",
+	}, []string{"kind"})
+
+	JobScheduleErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "job_schedule_errors_total",
+		Help:      "The periodic jobs that could not be queued.",
+	}, []string{"kind"})
+)
+
+const (
+	JobSucceededOutcome = "succeeded"
+	JobFailedOutcome    = "failed"
+
+	// NoBookingStatus is where a new booking comes from
+	NoBookingStatus = "NONE"
+)
+
+// ObserveDB exports the stats of the connection pool
+func ObserveDB(db *sql.DB, name string) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveChat exports the sessions connected to this server and the messages waiting in the broadcast queue, it
+// is called once by the chat
+func ObserveChat(sessions func() int, queued func() int) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chat_sessions",
+		Help:      "The chat sessions connected to this server.",
+	}, func() float64 { return float64(sessions()) })
+
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "chat_queued_messages",
+		Help:      "The chat messages waiting in the broadcast queue.",
+	}, func() float64 { return float64(queued()) })
+}
+
+// ObserveImageProcessing is deferred with the time the step started
+func ObserveImageProcessing(step string, start time.Time) {
+	ImageProcessingDuration.WithLabelValues(step).Observe(time.Since(start).Seconds())
+}
+
+// Serve serves the metrics at /metrics on the address, apart from the API so that they are only reachable internally
+func Serve(address string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+
+	if err := http.ListenAndServe(address, mux); err != nil {
+		slog.Error("unable to serve the metrics", "address", address, "error", err)
+	}
+}
+
+func CountBookingTransition(from string, to string) {
+	if from == to {
+		return
+	}
+	if from == "" {
+		from = NoBookingStatus
+	}
+
+	BookingStatusTransitions.WithLabelValues(from, to).Inc()
+}
+
+// RedisHook times the commands sent to Redis
+type RedisHook struct{}
+
+func (RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		observeRedis(cmd.Name(), start, err)
+		return err
+	}
+}
+
+func (RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		observeRedis("pipeline", start, err)
+		return err
+	}
+}
+
+func observeRedis(command string, start time.Time, err error) {
+	command = strings.ToLower(command)
+	RedisCommandDuration.WithLabelValues(command).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, redis.Nil) {
+		RedisCommandErrors.WithLabelValues(command).Inc()
+	}
+}
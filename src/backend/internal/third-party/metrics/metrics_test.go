@@ -0,0 +1,18 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountBookingTransition(t *testing.T) {
+	CountBookingTransition("", "DRAFT")
+	CountBookingTransition("DRAFT", "USER_PAID")
+	CountBookingTransition("USER_PAID", "USER_PAID")
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(BookingStatusTransitions.WithLabelValues(NoBookingStatus, "DRAFT")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(BookingStatusTransitions.WithLabelValues("DRAFT", "USER_PAID")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(BookingStatusTransitions.WithLabelValues("USER_PAID", "USER_PAID")))
+}
@@ -0,0 +1,158 @@
+package oauth2
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const mockKeyId = "mock-key"
+
+// MockIdP is a minimal OIDC identity provider for local development and tests,
+// every authorization request is granted to User
+type MockIdP struct {
+	Issuer   string
+	ClientId string
+	User     UserInfo
+
+	mu    sync.Mutex
+	key   *rsa.PrivateKey
+	codes map[string]struct{}
+}
+
+func NewMockIdP(clientId string, user UserInfo) (*MockIdP, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MockIdP{
+		ClientId: clientId,
+		User:     user,
+		key:      key,
+		codes:    map[string]struct{}{},
+	}, nil
+}
+
+func (m *MockIdP) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, discoveryDocument{
+			Issuer:                m.Issuer,
+			AuthorizationEndpoint: m.Issuer + "/authorize",
+			TokenEndpoint:         m.Issuer + "/token",
+			UserInfoEndpoint:      m.Issuer + "/userinfo",
+			JWKSURI:               m.Issuer + "/jwks",
+		})
+	case "/authorize":
+		m.authorize(w, r)
+	case "/token":
+		m.token(w, r)
+	case "/userinfo":
+		writeJSON(w, m.claims())
+	case "/jwks":
+		writeJSON(w, map[string]any{
+			"keys": []map[string]string{{
+				"kid": mockKeyId,
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+			}},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (m *MockIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	redirectURI, err := url.Parse(r.URL.Query().Get("redirect_uri"))
+	if err != nil || redirectURI.String() == "" {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	code := m.IssueCode()
+	query := redirectURI.Query()
+	query.Set("code", code)
+	query.Set("state", r.URL.Query().Get("state"))
+	redirectURI.RawQuery = query.Encode()
+
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// IssueCode lets tests skip the browser redirect of the authorization step
+func (m *MockIdP) IssueCode() string {
+	code := uuid.New().String()
+
+	m.mu.Lock()
+	m.codes[code] = struct{}{}
+	m.mu.Unlock()
+
+	return code
+}
+
+func (m *MockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	m.mu.Lock()
+	_, exist := m.codes[code]
+	delete(m.codes, code)
+	m.mu.Unlock()
+
+	if !exist {
+		http.Error(w, "invalid_grant", http.StatusBadRequest)
+		return
+	}
+
+	claims := jwt.MapClaims(m.claims())
+	claims["iss"] = m.Issuer
+	claims["aud"] = m.ClientId
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(time.Hour).Unix()
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = mockKeyId
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]any{
+		"access_token": uuid.New().String(),
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func (m *MockIdP) claims() map[string]any {
+	return map[string]any{
+		"sub":            m.User.Subject,
+		"email":          m.User.Email,
+		"email_verified": m.User.VerifiedEmail,
+		"name":           m.User.Name,
+		"given_name":     m.User.GivenName,
+		"family_name":    m.User.FamilyName,
+	}
+}
+
+func writeJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,325 @@
+package oauth2
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+)
+
+const GoogleIssuer = "https://accounts.google.com"
+
+var httpClient = &http.Client{
+	Timeout: time.Second * 30,
+}
+
+type UserInfo struct {
+	Subject       string
+	Email         string
+	VerifiedEmail bool
+	Name          string
+	GivenName     string
+	FamilyName    string
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type Provider struct {
+	Name string
+
+	mu          sync.Mutex
+	discovered  bool
+	settings    config.OAuth2Provider
+	config      oauth2.Config
+	userInfoURL string
+	jwksURI     string
+	keys        map[string]*rsa.PublicKey
+}
+
+func NewProvider(name string, settings config.OAuth2Provider) *Provider {
+	scopes := settings.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "email", "profile"}
+	}
+
+	return &Provider{
+		Name:     name,
+		settings: settings,
+		config: oauth2.Config{
+			ClientID:     settings.ClientId,
+			ClientSecret: settings.ClientSecret,
+			RedirectURL:  settings.RedirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  settings.AuthURL,
+				TokenURL: settings.TokenURL,
+			},
+		},
+		userInfoURL: settings.UserInfoURL,
+	}
+}
+
+// resolves the endpoints lazily so that an unreachable provider does not prevent the server from starting
+func (p *Provider) discover(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovered || p.settings.Issuer == "" {
+		return nil
+	}
+
+	wellKnown := strings.TrimSuffix(p.settings.Issuer, "/") + "/.well-known/openid-configuration"
+	doc := discoveryDocument{}
+	if err := getJSON(ctx, wellKnown, "", &doc); err != nil {
+		return fmt.Errorf("could not discover %s: %w", p.Name, err)
+	}
+
+	if p.config.Endpoint.AuthURL == "" {
+		p.config.Endpoint.AuthURL = doc.AuthorizationEndpoint
+	}
+	if p.config.Endpoint.TokenURL == "" {
+		p.config.Endpoint.TokenURL = doc.TokenEndpoint
+	}
+	if p.userInfoURL == "" {
+		p.userInfoURL = doc.UserInfoEndpoint
+	}
+	p.jwksURI = doc.JWKSURI
+	p.discovered = true
+
+	return nil
+}
+
+func (p *Provider) AuthCodeURL(ctx context.Context, state string) (string, error) {
+	if err := p.discover(ctx); err != nil {
+		return "", err
+	}
+
+	return p.config.AuthCodeURL(state), nil
+}
+
+// Authenticate exchanges the authorization code and resolves the user behind it, preferring
+// the verified id_token claims and falling back to the userinfo endpoint
+func (p *Provider) Authenticate(ctx context.Context, code string) (*UserInfo, error) {
+	if err := p.discover(ctx); err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	token, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	if idToken, ok := token.Extra("id_token").(string); ok && idToken != "" && p.jwksURI != "" {
+		claims, err := p.verifyIdToken(ctx, idToken)
+		if err != nil {
+			return nil, err
+		}
+		if info := userInfoFromClaims(claims); info.Email != "" || p.userInfoURL == "" {
+			return info, nil
+		}
+	}
+
+	if p.userInfoURL == "" {
+		return nil, fmt.Errorf("%s provides neither an id_token nor a userinfo endpoint", p.Name)
+	}
+
+	claims := map[string]any{}
+	if err := getJSON(ctx, p.userInfoURL, token.AccessToken, &claims); err != nil {
+		return nil, err
+	}
+
+	info := userInfoFromClaims(claims)
+	if info.Subject == "" {
+		return nil, errors.New("could not retrieve user")
+	}
+
+	return info, nil
+}
+
+func (p *Provider) verifyIdToken(ctx context.Context, idToken string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	parserOpts := []jwt.ParserOption{
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithLeeway(30 * time.Second),
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512"}),
+	}
+	if p.settings.Issuer != "" {
+		parserOpts = append(parserOpts, jwt.WithIssuer(strings.TrimSuffix(p.settings.Issuer, "/")))
+	}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	}, parserOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id_token: %w", err)
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	// the key may have been rotated, refetch the key set once
+	jwks := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	if err := getJSON(ctx, p.jwksURI, "", &jwks); err != nil {
+		return nil, err
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func userInfoFromClaims(claims map[string]any) *UserInfo {
+	str := func(key string) string {
+		value, _ := claims[key].(string)
+		return value
+	}
+
+	info := &UserInfo{
+		Subject:    str("sub"),
+		Email:      str("email"),
+		Name:       str("name"),
+		GivenName:  str("given_name"),
+		FamilyName: str("family_name"),
+	}
+
+	// non-OIDC providers such as Facebook and Google's v1 userinfo use `id`
+	if info.Subject == "" {
+		info.Subject = str("id")
+		if id, ok := claims["id"].(float64); ok && info.Subject == "" {
+			info.Subject = fmt.Sprintf("%.0f", id)
+		}
+	}
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		info.VerifiedEmail = verified
+	case string:
+		info.VerifiedEmail = verified == "true"
+	}
+	if verified, ok := claims["verified_email"].(bool); ok {
+		info.VerifiedEmail = verified
+	}
+
+	return info
+}
+
+func getJSON(ctx context.Context, url, accessToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	if accessToken != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", accessToken))
+	}
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+type Registry struct {
+	providers map[string]*Provider
+}
+
+// NewRegistry builds the providers from config, the legacy `oauth2_google` section is registered as `google`
+func NewRegistry(appCfg *config.App) *Registry {
+	registry := &Registry{
+		providers: map[string]*Provider{},
+	}
+
+	for name, settings := range appCfg.OAuth2Providers {
+		registry.providers[strings.ToLower(name)] = NewProvider(strings.ToLower(name), settings)
+	}
+
+	if _, exist := registry.providers["google"]; !exist && appCfg.OAuth2Google.ClientId != "" {
+		registry.providers["google"] = NewProvider("google", config.OAuth2Provider{
+			ClientId:     appCfg.OAuth2Google.ClientId,
+			ClientSecret: appCfg.OAuth2Google.ClientSecret,
+			RedirectURL:  appCfg.OAuth2Google.RedirectURL,
+			Issuer:       GoogleIssuer,
+			Scopes:       []string{"openid", "email", "profile"},
+		})
+	}
+
+	return registry
+}
+
+func (r *Registry) Get(name string) (*Provider, bool) {
+	provider, ok := r.providers[strings.ToLower(name)]
+	return provider, ok
+}
+
+func (r *Registry) Names() []string {
+	names := []string{}
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	return names
+}
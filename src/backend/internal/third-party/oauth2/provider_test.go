@@ -0,0 +1,99 @@
+package oauth2
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newMockProvider(t *testing.T, user UserInfo) (*MockIdP, *Provider) {
+	idp, err := NewMockIdP("pic-keeper", user)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(idp)
+	t.Cleanup(server.Close)
+	idp.Issuer = server.URL
+
+	provider := NewProvider("mock", config.OAuth2Provider{
+		ClientId:     "pic-keeper",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:8080/authen/v1/oauth2/mock/callback",
+		Issuer:       server.URL,
+	})
+
+	return idp, provider
+}
+
+func TestAuthCodeURLUsesDiscoveredEndpoint(t *testing.T) {
+	idp, provider := newMockProvider(t, UserInfo{Subject: "1"})
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "some-state")
+	assert.NoError(t, err)
+
+	parsed, err := url.Parse(authURL)
+	assert.NoError(t, err)
+	assert.Equal(t, idp.Issuer+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "some-state", parsed.Query().Get("state"))
+	assert.Equal(t, "pic-keeper", parsed.Query().Get("client_id"))
+}
+
+func TestAuthenticateWithIdToken(t *testing.T) {
+	idp, provider := newMockProvider(t, UserInfo{
+		Subject:       "mock-subject",
+		Email:         "mock@mail.com",
+		VerifiedEmail: true,
+		GivenName:     "mock",
+		FamilyName:    "user",
+	})
+
+	info, err := provider.Authenticate(context.Background(), idp.IssueCode())
+	assert.NoError(t, err)
+	assert.Equal(t, "mock-subject", info.Subject)
+	assert.Equal(t, "mock@mail.com", info.Email)
+	assert.True(t, info.VerifiedEmail)
+	assert.Equal(t, "mock", info.GivenName)
+}
+
+func TestAuthenticateRejectsUnknownCode(t *testing.T) {
+	_, provider := newMockProvider(t, UserInfo{Subject: "1"})
+
+	_, err := provider.Authenticate(context.Background(), "not-issued")
+	assert.Error(t, err)
+}
+
+func TestAuthorizeRedirectsWithCode(t *testing.T) {
+	_, provider := newMockProvider(t, UserInfo{Subject: "1"})
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "some-state")
+	assert.NoError(t, err)
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	assert.NoError(t, err)
+	assert.Equal(t, "some-state", location.Query().Get("state"))
+
+	_, err = provider.Authenticate(context.Background(), location.Query().Get("code"))
+	assert.NoError(t, err)
+}
+
+func TestUserInfoFromFacebookStyleClaims(t *testing.T) {
+	info := userInfoFromClaims(map[string]any{
+		"id":    "12345",
+		"email": "fb@mail.com",
+		"name":  "Face Book",
+	})
+
+	assert.Equal(t, "12345", info.Subject)
+	assert.False(t, info.VerifiedEmail)
+}
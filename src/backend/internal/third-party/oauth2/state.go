@@ -0,0 +1,54 @@
+package oauth2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/google/uuid"
+)
+
+const stateTTL = 10 * time.Minute
+
+// State is carried through the authorization redirect, LinkUserId is set when
+// an already signed-in user attaches another provider to the account
+type State struct {
+	Provider   string     `json:"provider"`
+	LinkUserId *uuid.UUID `json:"link_user_id"`
+}
+
+func stateKey(state string) string {
+	return fmt.Sprintf("oauth2-state: %s", state)
+}
+
+func SaveState(ctx context.Context, state State) (string, error) {
+	key := uuid.New().String()
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+
+	if err := databases.RedisClient.Set(ctx, stateKey(key), value, stateTTL).Err(); err != nil {
+		return "", err
+	}
+
+	return key, nil
+}
+
+// ConsumeState returns the state once, replaying the same callback will fail
+func ConsumeState(ctx context.Context, key string) (*State, error) {
+	value, err := databases.RedisClient.GetDel(ctx, stateKey(key)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	state := State{}
+	if err := json.Unmarshal(value, &state); err != nil {
+		return nil, err
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	_ "embed"
+	"io"
+
+	"github.com/signintech/gopdf"
+)
+
+// A4 in points
+const (
+	PageWidth  = 595.0
+	PageHeight = 842.0
+)
+
+const (
+	fontFamily = "FreeSerif"
+	lineWidth  = 0.5
+)
+
+// freeSerif covers Latin and Thai among others, see fonts/LICENSE
+//
+//go:embed fonts/FreeSerif.ttf
+var freeSerif []byte
+
+// Document lays out text and rules on A4 pages. The font is embedded with only the glyphs used, so that any text
+// prints as written. The font has no bold face, bold text is printed twice slightly apart.
+type Document struct {
+	pdf *gopdf.GoPdf
+	err error
+}
+
+func New() *Document {
+	d := &Document{pdf: &gopdf.GoPdf{}}
+	d.pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4})
+	d.err = d.pdf.AddTTFFontData(fontFamily, freeSerif)
+	d.pdf.SetLineWidth(lineWidth)
+	d.AddPage()
+	return d
+}
+
+func (d *Document) AddPage() {
+	d.pdf.AddPage()
+}
+
+// Text writes a line on the current page, x and y being the distance in points of its baseline from the
+// left and the top edges of the page. An error is returned by WriteTo.
+func (d *Document) Text(x, y, size float64, bold bool, text string) {
+	if d.err != nil {
+		return
+	}
+
+	if d.err = d.pdf.SetFont(fontFamily, "", size); d.err != nil {
+		return
+	}
+
+	d.pdf.SetXY(x, y)
+	if d.err = d.pdf.Text(text); d.err != nil || !bold {
+		return
+	}
+
+	d.pdf.SetXY(x+size/40, y)
+	d.err = d.pdf.Text(text)
+}
+
+// Line draws a thin rule on the current page from (x1, y1) to (x2, y2), measured from the top left corner
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+	d.pdf.Line(x1, y1, x2, y2)
+}
+
+// WriteTo writes the document as a PDF file
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	if d.err != nil {
+		return 0, d.err
+	}
+
+	return d.pdf.WriteTo(w)
+}
@@ -0,0 +1,44 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTo(t *testing.T) {
+	doc := New()
+	doc.Text(40, 60, 18, true, "RECEIPT")
+	doc.Line(40, 70, 555, 70)
+	doc.AddPage()
+	doc.Text(40, 60, 10, false, "Total (THB) \\ 1,500")
+
+	out := &bytes.Buffer{}
+	_, err := doc.WriteTo(out)
+	require.NoError(t, err)
+
+	file := out.Bytes()
+	assert.True(t, bytes.HasPrefix(file, []byte("%PDF-")))
+	assert.True(t, bytes.HasSuffix(bytes.TrimSpace(file), []byte("%%EOF")))
+	assert.Contains(t, out.String(), "/Count 2")
+	assert.Contains(t, out.String(), "/FontFile2")
+}
+
+func TestWriteThai(t *testing.T) {
+	doc := New()
+	doc.Text(40, 60, 18, true, "ใบเสร็จรับเงิน")
+	doc.Text(40, 80, 10, false, "คุณสมชาย ใจดี, ฿1,500")
+
+	out := &bytes.Buffer{}
+	_, err := doc.WriteTo(out)
+	require.NoError(t, err)
+
+	// every character is mapped from a glyph of the embedded font back to its code point, so that it is printed
+	// and can be copied as written rather than as '?'
+	for _, r := range "ใบเสร็จรับเงินคุณสมชายดี฿" {
+		assert.Regexp(t, fmt.Sprintf(`<[0-9A-F]{4}><[0-9A-F]{4}><%04X>`, r), out.String(), string(r))
+	}
+}
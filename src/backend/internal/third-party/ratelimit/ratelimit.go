@@ -0,0 +1,134 @@
+// Package ratelimit keeps the request budgets and the failed logins in Redis. The callers fail open when Redis is
+// unavailable: the requests are let through and no account is locked out, the password is still checked.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/redis/go-redis/v9"
+)
+
+type Result struct {
+	Limit     int
+	Remaining int
+	ResetIn   time.Duration
+	Allowed   bool
+}
+
+// fixed window counter, the window starts at the first hit of the key
+var incrWindow = redis.NewScript(`
+local current = redis.call("INCR", KEYS[1])
+if current == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return {current, redis.call("PTTL", KEYS[1])}
+`)
+
+func Allow(ctx context.Context, key string, limit int, window time.Duration) (*Result, error) {
+	res, err := incrWindow.Run(ctx, databases.RedisClient, []string{rateKey(key)}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	count, ttl := int(res[0]), time.Duration(res[1])*time.Millisecond
+	if ttl < 0 {
+		ttl = window
+	}
+
+	remaining := limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	return &Result{
+		Limit:     limit,
+		Remaining: remaining,
+		ResetIn:   ttl,
+		Allowed:   count <= limit,
+	}, nil
+}
+
+func rateKey(key string) string {
+	return fmt.Sprintf("ratelimit: %s", key)
+}
+
+// NormaliseAccount makes the emails differing only by case or surrounding spaces the same account
+func NormaliseAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+func failureKey(account string) string {
+	return fmt.Sprintf("login-failures: %s", NormaliseAccount(account))
+}
+
+func lockoutKey(account string) string {
+	return fmt.Sprintf("login-lockout: %s", NormaliseAccount(account))
+}
+
+// LockoutDuration doubles the base lockout for every failure beyond maxFailures, capped at maxLockout
+func LockoutDuration(failures, maxFailures int, baseLockout, maxLockout time.Duration) time.Duration {
+	if failures < maxFailures {
+		return 0
+	}
+
+	lockout := baseLockout
+	for i := maxFailures; i < failures; i++ {
+		lockout *= 2
+		if lockout >= maxLockout {
+			return maxLockout
+		}
+	}
+
+	return lockout
+}
+
+// returns the remaining lockout of the account, zero if it is not locked
+func CheckLoginLockout(ctx context.Context, account string) (time.Duration, error) {
+	ttl, err := databases.RedisClient.PTTL(ctx, lockoutKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+// records a failed login and locks the account out once the failures exceed maxFailures, the lockout is turned off
+// without maxFailures or baseLockout and is not doubled without a maxLockout above baseLockout
+func RegisterLoginFailure(ctx context.Context, account string, maxFailures int, baseLockout, maxLockout time.Duration) (time.Duration, error) {
+	if maxFailures <= 0 || baseLockout <= 0 {
+		return 0, nil
+	}
+	maxLockout = max(maxLockout, baseLockout)
+
+	failures, err := databases.RedisClient.Incr(ctx, failureKey(account)).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	// failures are forgotten after a quiet period as long as the longest lockout
+	if err := databases.RedisClient.PExpire(ctx, failureKey(account), maxLockout).Err(); err != nil {
+		return 0, err
+	}
+
+	lockout := LockoutDuration(int(failures), maxFailures, baseLockout, maxLockout)
+	if lockout == 0 {
+		return 0, nil
+	}
+
+	if err := databases.RedisClient.Set(ctx, lockoutKey(account), failures, lockout).Err(); err != nil {
+		return 0, err
+	}
+
+	return lockout, nil
+}
+
+func ResetLoginFailures(ctx context.Context, account string) error {
+	return databases.RedisClient.Del(ctx, failureKey(account), lockoutKey(account)).Err()
+}
@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutDurationBelowThreshold(t *testing.T) {
+	assert.Equal(t, time.Duration(0), LockoutDuration(4, 5, 30*time.Second, time.Hour))
+}
+
+func TestLockoutDurationDoublesPerFailure(t *testing.T) {
+	assert.Equal(t, 30*time.Second, LockoutDuration(5, 5, 30*time.Second, time.Hour))
+	assert.Equal(t, 60*time.Second, LockoutDuration(6, 5, 30*time.Second, time.Hour))
+	assert.Equal(t, 120*time.Second, LockoutDuration(7, 5, 30*time.Second, time.Hour))
+}
+
+func TestLockoutDurationIsCapped(t *testing.T) {
+	assert.Equal(t, time.Hour, LockoutDuration(50, 5, 30*time.Second, time.Hour))
+}
+
+func TestNormaliseAccount(t *testing.T) {
+	assert.Equal(t, "jane@mail.com", NormaliseAccount("  Jane@Mail.com "))
+}
+
+func connectTestRedis(t *testing.T) {
+	dsn := os.Getenv("redis_dsn")
+	if dsn == "" {
+		t.Skip("redis_dsn is not set")
+	}
+
+	databases.RedisClient = redis.NewClient(&redis.Options{Addr: dsn})
+	t.Cleanup(func() { databases.RedisClient.Close() })
+}
+
+func TestLoginLockoutAndReset(t *testing.T) {
+	connectTestRedis(t)
+	ctx := context.Background()
+	account := uuid.New().String() + "@mail.com"
+	t.Cleanup(func() { ResetLoginFailures(ctx, account) })
+
+	for i := 1; i < 3; i++ {
+		lockout, err := RegisterLoginFailure(ctx, account, 3, 30*time.Second, time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, time.Duration(0), lockout)
+	}
+
+	lockedFor, err := CheckLoginLockout(ctx, account)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), lockedFor)
+
+	// the third failure locks the account out, whatever the case of the email
+	lockout, err := RegisterLoginFailure(ctx, strings.ToUpper(account), 3, 30*time.Second, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Second, lockout)
+
+	lockedFor, err = CheckLoginLockout(ctx, " "+account)
+	require.NoError(t, err)
+	assert.Greater(t, lockedFor, 29*time.Second)
+
+	// a successful login clears the failures and the lockout
+	require.NoError(t, ResetLoginFailures(ctx, account))
+	lockedFor, err = CheckLoginLockout(ctx, account)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), lockedFor)
+
+	lockout, err = RegisterLoginFailure(ctx, account, 3, 30*time.Second, time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), lockout)
+}
+
+func TestLoginFailuresWithoutMaxLockout(t *testing.T) {
+	connectTestRedis(t)
+	ctx := context.Background()
+	account := uuid.New().String() + "@mail.com"
+	t.Cleanup(func() { ResetLoginFailures(ctx, account) })
+
+	// the failures are still counted and the lockout stays at its base
+	for i := 1; i <= 3; i++ {
+		_, err := RegisterLoginFailure(ctx, account, 2, 30*time.Second, 0)
+		require.NoError(t, err)
+	}
+
+	lockedFor, err := CheckLoginLockout(ctx, account)
+	require.NoError(t, err)
+	assert.Greater(t, lockedFor, 29*time.Second)
+	assert.LessOrEqual(t, lockedFor, 30*time.Second)
+}
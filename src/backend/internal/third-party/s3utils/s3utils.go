@@ -4,13 +4,17 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/aws/aws-sdk-go-v2/otelaws"
 )
 
 type BucketBasics struct {
@@ -18,19 +22,35 @@ type BucketBasics struct {
 }
 
 const (
-	ProfilePicBucket   = "profile-picture"
-	IdCardBucket       = "id-card"
-	QRPaymentBucket    = "qr-payment"
-	GalleryPhotoBucket = "gallery-photos"
+	ProfilePicBucket      = "profile-picture"
+	IdCardBucket          = "id-card"
+	QRPaymentBucket       = "qr-payment"
+	GalleryPhotoBucket    = "gallery-photos"
+	DataExportBucket      = "data-export"
+	DocumentBucket        = "booking-documents"
+	IssueAttachmentBucket = "issue-attachments"
+	DisputeEvidenceBucket = "dispute-evidence"
 )
 
 const awsRegion = "us-east-1"
 
+// TODO: Add aws endpoint to config
+const publicUrl = "http://localhost:4566"
+
+// ObjectUrl is the public link to the object of the bucket
+func ObjectUrl(bucket string, key string) string {
+	return fmt.Sprintf("%s/%s/%s", publicUrl, bucket, key)
+}
+
 var requiredBuckets = []string{
 	ProfilePicBucket,
 	IdCardBucket,
 	GalleryPhotoBucket,
 	QRPaymentBucket,
+	DataExportBucket,
+	DocumentBucket,
+	IssueAttachmentBucket,
+	DisputeEvidenceBucket,
 }
 
 var (
@@ -62,6 +82,7 @@ func createS3Client() (*s3.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	otelaws.AppendMiddlewares(&awsCfg.APIOptions)
 
 	// Create the resource client
 	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
@@ -73,7 +94,7 @@ func createS3Client() (*s3.Client, error) {
 func newBucketBasics() (*BucketBasics, error) {
 	client, err := createS3Client()
 	if err != nil {
-		log.Printf("Failed to create S3 client: %v", err)
+		slog.Error("failed to create S3 client", "error", err)
 		return nil, err
 	}
 	return &BucketBasics{S3Client: client}, nil
@@ -97,8 +118,7 @@ func (basics *BucketBasics) CreateBucket(name string, region string) error {
 		Bucket: aws.String(name),
 	})
 	if err != nil {
-		log.Printf("Couldn't create bucket %v in Region %v. Here's why: %v\n",
-			name, region, err)
+		slog.Warn("couldn't create bucket", "bucket", name, "region", region, "error", err)
 	}
 	return err
 }
@@ -112,12 +132,12 @@ func InitializeS3() error {
 	for _, bucketName := range requiredBuckets {
 		err := basics.CreateBucket(bucketName, awsRegion)
 		if err != nil {
-			log.Printf("Failed to create bucket %s: %v", bucketName, err)
+			slog.Warn("failed to create bucket", "bucket", bucketName, "error", err)
 			continue
 		}
 	}
 
-	log.Println("✅ Localstack connected successfully")
+	slog.Info("Localstack connected successfully")
 	return nil
 }
 
@@ -131,7 +151,23 @@ func (basics *BucketBasics) UploadFile(ctx context.Context, bucketName string, o
 		ContentType: aws.String(contentType),
 	})
 	if err != nil {
-		log.Printf("Couldn't upload file to %v:%v. Here's why: %v\n", bucketName, objectKey, err)
+		slog.ErrorContext(ctx, "couldn't upload file", "bucket", bucketName, "key", objectKey, "error", err)
+		return err
+	}
+	return nil
+}
+
+// UploadStream uploads the content as it is read in parts, so that an object of unknown size is never held whole
+// in memory
+func (basics *BucketBasics) UploadStream(ctx context.Context, bucketName string, objectKey string, content io.Reader, contentType string) error {
+	_, err := manager.NewUploader(basics.S3Client).Upload(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(bucketName),
+		Key:         aws.String(objectKey),
+		Body:        content,
+		ContentType: aws.String(contentType),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "couldn't upload file", "bucket", bucketName, "key", objectKey, "error", err)
 		return err
 	}
 	return nil
@@ -145,3 +181,30 @@ func (basics *BucketBasics) DeleteFile(ctx context.Context, bucketName string, o
 
 	return err
 }
+
+// GetFile returns the content and size of the object, the caller has to close the content
+func (basics *BucketBasics) GetFile(ctx context.Context, bucketName string, objectKey string) (io.ReadCloser, int64, error) {
+	output, err := basics.S3Client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return output.Body, aws.ToInt64(output.ContentLength), nil
+}
+
+// PresignGetURL grants temporary read access to an object of a private bucket
+func (basics *BucketBasics) PresignGetURL(ctx context.Context, bucketName string, objectKey string, ttl time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(basics.S3Client)
+	request, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucketName),
+		Key:    aws.String(objectKey),
+	}, s3.WithPresignExpires(ttl))
+	if err != nil {
+		return "", err
+	}
+
+	return request.URL, nil
+}
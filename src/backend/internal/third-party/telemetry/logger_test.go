@@ -0,0 +1,31 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextHandler(t *testing.T) {
+	buffer := bytes.Buffer{}
+	logger := slog.New(contextHandler{slog.NewJSONHandler(&buffer, nil)}).With("component", "test")
+
+	logger.InfoContext(WithRequestId(context.Background(), "request-1"), "served")
+
+	record := map[string]any{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.Equal(t, "request-1", record["request_id"])
+	assert.Equal(t, "test", record["component"])
+	assert.NotContains(t, record, "trace_id")
+
+	buffer.Reset()
+	logger.Info("served")
+
+	record = map[string]any{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &record))
+	assert.NotContains(t, record, "request_id")
+}
@@ -0,0 +1,120 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	OTLPExporter   = "otlp"
+	StdoutExporter = "stdout"
+
+	defaultServiceName  = "pickeeper-backend"
+	instrumentationName = "github.com/Roongkun/software-eng-ii"
+
+	// the instrumentation of gin still records the path requested under the old name of the attribute
+	httpTargetKey = attribute.Key("http.target")
+)
+
+// Tracer starts the spans of the application's own code, the libraries are traced by their instrumentations
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// ServiceName is the name the spans of the component are reported under
+func ServiceName(cfg config.Tracing, component string) string {
+	name := cfg.ServiceName
+	if name == "" {
+		name = defaultServiceName
+	}
+
+	return fmt.Sprintf("%s-%s", name, component)
+}
+
+// InitTracer registers the global tracer provider, the returned function flushes the spans left and has to be
+// called before exiting. Without an exporter the spans are still propagated but not recorded. The server spans
+// record the route of the request rather than its path.
+func InitTracer(ctx context.Context, cfg config.Tracing, component string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case "":
+		return func(context.Context) error { return nil }, nil
+	case OTLPExporter:
+		options := []otlptracehttp.Option{}
+		if cfg.Endpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err = otlptracehttp.New(ctx, options...)
+	case StdoutExporter:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ratio := cfg.SampleRatio
+	if ratio <= 0 {
+		ratio = 1
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(routeTargetProcessor{}),
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceName(ServiceName(cfg, component)),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// routeTargetProcessor replaces the path requested with its route on the server spans, so that the secrets carried
+// by the path or the query, such as the token of a calendar feed or an OAuth2 code, are not recorded. The path of a
+// request that matches no route is left out.
+type routeTargetProcessor struct{}
+
+func (routeTargetProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	if s.SpanKind() != trace.SpanKindServer {
+		return
+	}
+
+	route := ""
+	for _, attr := range s.Attributes() {
+		if attr.Key == semconv.HTTPRouteKey {
+			route = attr.Value.AsString()
+		}
+	}
+	s.SetAttributes(httpTargetKey.String(route))
+}
+
+func (routeTargetProcessor) OnEnd(s sdktrace.ReadOnlySpan) {}
+
+func (routeTargetProcessor) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (routeTargetProcessor) ForceFlush(ctx context.Context) error {
+	return nil
+}
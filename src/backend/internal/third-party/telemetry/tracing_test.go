@@ -0,0 +1,45 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRouteTargetProcessor(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(routeTargetProcessor{}), sdktrace.WithSpanProcessor(recorder))
+	tracer := provider.Tracer("test")
+
+	_, span := tracer.Start(context.Background(), "/calendar/v1/:feed", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		httpTargetKey.String("/calendar/v1/secret-token.ics"),
+		semconv.HTTPRoute("/calendar/v1/:feed"),
+	))
+	span.End()
+
+	_, span = tracer.Start(context.Background(), "HTTP GET route not found", trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		httpTargetKey.String("/unknown/secret-token"),
+	))
+	span.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "/calendar/v1/:feed", targetOf(spans[0]))
+	assert.Equal(t, "", targetOf(spans[1]))
+}
+
+func targetOf(span sdktrace.ReadOnlySpan) string {
+	for _, attr := range span.Attributes() {
+		if attr.Key == httpTargetKey {
+			return attr.Value.AsString()
+		}
+	}
+
+	return "missing"
+}
@@ -0,0 +1,180 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultAnalyticsRange = 30 * 24 * time.Hour
+	defaultAnalyticsLimit = 10
+	maxAnalyticsPeriods   = 400
+	// the dashboard scans every booking of the range, so it is shared by the admins for a few minutes
+	analyticsCacheTTL = 5 * time.Minute
+)
+
+var (
+	ErrInvalidAnalyticsRange = errors.New("the start of the range must be before its end")
+	ErrAnalyticsRangeTooLong = fmt.Errorf("the range cannot span more than %d periods, please use a coarser granularity", maxAnalyticsPeriods)
+)
+
+type AnalyticsUseCase struct {
+	AnalyticsRepo repository.Analytics
+}
+
+func NewAnalyticsUseCase(db *bun.DB) *AnalyticsUseCase {
+	return &AnalyticsUseCase{
+		AnalyticsRepo: postgres.NewAnalyticsDB(db),
+	}
+}
+
+// Dashboard aggregates the bookings, the growth and the rankings of the range, the amounts are in the base currency
+func (a *AnalyticsUseCase) Dashboard(ctx context.Context, filter model.AnalyticsFilter) (*model.AnalyticsDashboard, error) {
+	from, to, granularity, err := analyticsRange(filter, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	limit := filter.Limit
+	if limit == 0 {
+		limit = defaultAnalyticsLimit
+	}
+
+	key := fmt.Sprintf("analytics:%s:%s:%s:%d", from.Format(time.DateOnly), to.Format(time.DateOnly), granularity, limit)
+	if dashboard := cached[model.AnalyticsDashboard](ctx, key); dashboard != nil {
+		return dashboard, nil
+	}
+
+	dashboard := &model.AnalyticsDashboard{
+		From:        from,
+		To:          to,
+		Granularity: granularity,
+		Currency:    model.BaseCurrency,
+		GeneratedAt: time.Now(),
+	}
+
+	if dashboard.Bookings, err = a.AnalyticsRepo.BookingSeries(ctx, from, to, granularity); err != nil {
+		return nil, err
+	}
+	if dashboard.Growth, err = a.AnalyticsRepo.GrowthSeries(ctx, from, to, granularity); err != nil {
+		return nil, err
+	}
+	if dashboard.TopPhotographers, err = a.AnalyticsRepo.TopPhotographers(ctx, from, to, limit); err != nil {
+		return nil, err
+	}
+	if dashboard.TopLocations, err = a.AnalyticsRepo.TopLocations(ctx, from, to, limit); err != nil {
+		return nil, err
+	}
+	dashboard.Summary = summarise(dashboard.Bookings, dashboard.Growth)
+
+	cache(ctx, key, dashboard, analyticsCacheTTL)
+	return dashboard, nil
+}
+
+// analyticsRange resolves the range of the filter to whole days in UTC
+func analyticsRange(filter model.AnalyticsFilter, now time.Time) (time.Time, time.Time, string, error) {
+	granularity := filter.Granularity
+	if granularity == "" {
+		granularity = model.AnalyticsDayGranularity
+	}
+
+	to := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	if filter.To != nil {
+		to = filter.To.UTC().Truncate(24 * time.Hour)
+	}
+	from := to.Add(-defaultAnalyticsRange)
+	if filter.From != nil {
+		from = filter.From.UTC().Truncate(24 * time.Hour)
+	}
+
+	if !from.Before(to) {
+		return time.Time{}, time.Time{}, "", ErrInvalidAnalyticsRange
+	}
+
+	periods := 0
+	switch granularity {
+	case model.AnalyticsDayGranularity:
+		periods = int(to.Sub(from) / (24 * time.Hour))
+	case model.AnalyticsWeekGranularity:
+		periods = int(to.Sub(from)/(7*24*time.Hour)) + 1
+	case model.AnalyticsMonthGranularity:
+		periods = (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	}
+	if periods > maxAnalyticsPeriods {
+		return time.Time{}, time.Time{}, "", ErrAnalyticsRangeTooLong
+	}
+
+	return from, to, granularity, nil
+}
+
+func summarise(bookings []*model.BookingAnalytics, growth []*model.GrowthAnalytics) model.AnalyticsSummary {
+	summary := model.AnalyticsSummary{}
+	cancelled, refunded := 0, 0
+	for _, period := range bookings {
+		summary.Bookings += period.Bookings
+		summary.PaidBookings += period.PaidBookings
+		summary.GrossVolume += period.GrossVolume
+		cancelled += period.CancelledBookings
+		refunded += period.RefundedBookings
+	}
+	for _, period := range growth {
+		summary.NewUsers += period.NewUsers
+	}
+
+	summary.ConversionRate = ratio(summary.PaidBookings, summary.Bookings)
+	summary.CancellationRate = ratio(cancelled, summary.Bookings)
+	summary.RefundRate = ratio(refunded, summary.PaidBookings)
+	return summary
+}
+
+func ratio(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return float64(part) / float64(whole)
+}
+
+// the cache is best effort, the aggregates are computed again when redis is not there or fails
+func cached[T any](ctx context.Context, key string) *T {
+	if databases.RedisClient == nil {
+		return nil
+	}
+
+	payload, err := databases.RedisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+
+	value := new(T)
+	if err := json.Unmarshal(payload, value); err != nil {
+		return nil
+	}
+
+	return value
+}
+
+func cache(ctx context.Context, key string, value any, ttl time.Duration) {
+	if databases.RedisClient == nil {
+		return
+	}
+
+	payload, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	if err := databases.RedisClient.Set(ctx, key, payload, ttl).Err(); err != nil {
+		slog.WarnContext(ctx, "unable to cache the analytics", "key", key, "error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsRange(t *testing.T) {
+	now := time.Date(2024, 4, 20, 15, 30, 0, 0, time.UTC)
+
+	from, to, granularity, err := analyticsRange(model.AnalyticsFilter{}, now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 3, 22, 0, 0, 0, 0, time.UTC), from)
+	assert.Equal(t, time.Date(2024, 4, 21, 0, 0, 0, 0, time.UTC), to)
+	assert.Equal(t, model.AnalyticsDayGranularity, granularity)
+
+	start, end := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _, _, err = analyticsRange(model.AnalyticsFilter{From: &start, To: &end}, now)
+	assert.ErrorIs(t, err, ErrInvalidAnalyticsRange)
+
+	start = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _, _, err = analyticsRange(model.AnalyticsFilter{From: &start}, now)
+	assert.ErrorIs(t, err, ErrAnalyticsRangeTooLong)
+
+	_, _, granularity, err = analyticsRange(model.AnalyticsFilter{From: &start, Granularity: model.AnalyticsMonthGranularity}, now)
+	require.NoError(t, err)
+	assert.Equal(t, model.AnalyticsMonthGranularity, granularity)
+}
+
+func TestSummarise(t *testing.T) {
+	summary := summarise([]*model.BookingAnalytics{
+		{Bookings: 6, PaidBookings: 3, CancelledBookings: 2, RefundedBookings: 1, GrossVolume: 9000},
+		{Bookings: 4, PaidBookings: 1, GrossVolume: 1500},
+	}, []*model.GrowthAnalytics{{NewUsers: 5}, {NewUsers: 2}})
+
+	assert.Equal(t, 10, summary.Bookings)
+	assert.Equal(t, int64(10500), summary.GrossVolume)
+	assert.InDelta(t, 0.4, summary.ConversionRate, 1e-9)
+	assert.InDelta(t, 0.2, summary.CancellationRate, 1e-9)
+	assert.InDelta(t, 0.25, summary.RefundRate, 1e-9)
+	assert.Equal(t, 7, summary.NewUsers)
+
+	assert.Zero(t, summarise(nil, nil).ConversionRate)
+}
@@ -0,0 +1,295 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	// AuditTrailKey is the key of the trail of the request in the gin context
+	AuditTrailKey        = "auditTrail"
+	defaultAuditLimit    = 50
+	auditVerifyBatchSize = 500
+)
+
+// AuditTrail is who sent the request being audited, the usecases record the actions they audit against it
+type AuditTrail struct {
+	ActorId   *uuid.UUID
+	IP        string
+	RequestId string
+	recorded  bool
+}
+
+// Recorded tells whether a usecase recorded an action of the request
+func (t *AuditTrail) Recorded() bool {
+	return t.recorded
+}
+
+type AuditUseCase struct {
+	AuditRepo  repository.AuditEntry
+	UnitOfWork repository.UnitOfWork
+}
+
+func NewAuditUseCase(db *bun.DB) *AuditUseCase {
+	return &AuditUseCase{
+		AuditRepo:  postgres.NewAuditEntryDB(db),
+		UnitOfWork: postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// RecordRequest appends the entry of a request to the admin routes that no usecase recorded or that failed
+func (a *AuditUseCase) RecordRequest(ctx context.Context, trail *AuditTrail, method string, path string, targetId *uuid.UUID, statusCode int) error {
+	entry := &model.AuditEntry{
+		ActorId:    trail.ActorId,
+		Action:     model.AuditRequestAction,
+		TargetId:   targetId,
+		IP:         trail.IP,
+		RequestId:  trail.RequestId,
+		Method:     &method,
+		Path:       &path,
+		StatusCode: &statusCode,
+	}
+
+	return a.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		return appendAuditEntry(ctx, a.AuditRepo, entry)
+	})
+}
+
+func (a *AuditUseCase) Search(ctx context.Context, filter model.AuditFilter) ([]*model.AuditEntry, error) {
+	if filter.Limit == 0 {
+		filter.Limit = defaultAuditLimit
+	}
+
+	return a.AuditRepo.FindWithFilter(ctx, filter)
+}
+
+// Verify walks the whole log and stops at the first entry that does not match its hash or does not follow the one
+// before it
+func (a *AuditUseCase) Verify(ctx context.Context) (*model.AuditVerification, error) {
+	verification := &model.AuditVerification{Valid: true}
+
+	var previous *model.AuditEntry
+	for {
+		lastSeq := int64(0)
+		if previous != nil {
+			lastSeq = previous.Seq
+		}
+
+		entries, err := a.AuditRepo.FindAfterSeq(ctx, lastSeq, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if reason := checkAuditLink(previous, entry); reason != "" {
+				verification.Valid = false
+				verification.BrokenAt = &entry.Seq
+				verification.Reason = reason
+				verification.VerifiedAt = time.Now()
+				return verification, nil
+			}
+
+			verification.Checked++
+			previous = entry
+		}
+
+		if len(entries) < auditVerifyBatchSize {
+			break
+		}
+	}
+
+	verification.VerifiedAt = time.Now()
+	return verification, nil
+}
+
+// recordAudit appends the action of the usecase with the fields it changed, it has to run in the transaction of
+// the change so that the entry is kept only if the change is
+func recordAudit(ctx context.Context, auditRepo repository.AuditEntry, action string, targetType string, targetId *uuid.UUID, before any, after any) error {
+	changes, err := auditChanges(before, after)
+	if err != nil {
+		return err
+	}
+
+	entry := &model.AuditEntry{
+		Action:     action,
+		TargetType: &targetType,
+		TargetId:   targetId,
+		Changes:    changes,
+	}
+
+	if trail, ok := ctx.Value(AuditTrailKey).(*AuditTrail); ok {
+		entry.ActorId = trail.ActorId
+		entry.IP = trail.IP
+		entry.RequestId = trail.RequestId
+		trail.recorded = true
+	}
+
+	return appendAuditEntry(ctx, auditRepo, entry)
+}
+
+// appendAuditEntry links the entry to the last one, the chain stays locked until the transaction ends
+func appendAuditEntry(ctx context.Context, auditRepo repository.AuditEntry, entry *model.AuditEntry) error {
+	if err := auditRepo.LockChain(ctx); err != nil {
+		return err
+	}
+
+	last, err := auditRepo.FindLast(ctx)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	entry.Id = uuid.New()
+	entry.Seq = 1
+	if last != nil {
+		entry.Seq = last.Seq + 1
+		entry.PrevHash = last.Hash
+	}
+	// the database keeps microseconds, the hash has to match the entry read back
+	entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+
+	if entry.Hash, err = auditHash(entry); err != nil {
+		return err
+	}
+
+	return auditRepo.AddOne(ctx, entry)
+}
+
+// auditHash is the SHA-256 of the hash of the previous entry followed by the canonical JSON of the entry
+func auditHash(entry *model.AuditEntry) (string, error) {
+	payload, err := json.Marshal(struct {
+		Seq        int64                        `json:"seq"`
+		ActorId    *uuid.UUID                   `json:"actor_id"`
+		Action     string                       `json:"action"`
+		TargetType *string                      `json:"target_type"`
+		TargetId   *uuid.UUID                   `json:"target_id"`
+		Changes    map[string]model.AuditChange `json:"changes"`
+		IP         string                       `json:"ip"`
+		RequestId  string                       `json:"request_id"`
+		Method     *string                      `json:"method"`
+		Path       *string                      `json:"path"`
+		StatusCode *int                         `json:"status_code"`
+		CreatedAt  string                       `json:"created_at"`
+	}{
+		Seq:        entry.Seq,
+		ActorId:    entry.ActorId,
+		Action:     entry.Action,
+		TargetType: entry.TargetType,
+		TargetId:   entry.TargetId,
+		Changes:    entry.Changes,
+		IP:         entry.IP,
+		RequestId:  entry.RequestId,
+		Method:     entry.Method,
+		Path:       entry.Path,
+		StatusCode: entry.StatusCode,
+		CreatedAt:  entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(append([]byte(entry.PrevHash), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// checkAuditLink returns why the entry does not follow the previous one, or an empty string
+func checkAuditLink(previous *model.AuditEntry, entry *model.AuditEntry) string {
+	expectedSeq, expectedPrevHash := int64(1), ""
+	if previous != nil {
+		expectedSeq, expectedPrevHash = previous.Seq+1, previous.Hash
+	}
+
+	if entry.Seq != expectedSeq {
+		return fmt.Sprintf("expected entry %d, found entry %d", expectedSeq, entry.Seq)
+	}
+	if entry.PrevHash != expectedPrevHash {
+		return "the entry does not link to the hash of the previous entry"
+	}
+
+	hash, err := auditHash(entry)
+	if err != nil || hash != entry.Hash {
+		return "the entry does not match its hash"
+	}
+
+	return ""
+}
+
+// auditChanges lists the fields whose value differs between the two states, a nil state is a target that did not
+// exist before or after the action
+func auditChanges(before any, after any) (map[string]model.AuditChange, error) {
+	from, err := auditSnapshot(before)
+	if err != nil {
+		return nil, err
+	}
+	to, err := auditSnapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]model.AuditChange{}
+	for field, value := range to {
+		if previous, ok := from[field]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[field] = model.AuditChange{From: from[field], To: value}
+		}
+	}
+	for field, value := range from {
+		if _, ok := to[field]; !ok {
+			changes[field] = model.AuditChange{From: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// auditSnapshot is the JSON of the columns of a model, the fields that are not stored or not shown are left out.
+// Anything else is snapshotted from its JSON as a whole.
+func auditSnapshot(state any) (map[string]any, error) {
+	snapshot := map[string]any{}
+
+	value := reflect.ValueOf(state)
+	if !value.IsValid() || (value.Kind() == reflect.Pointer && value.IsNil()) {
+		return snapshot, nil
+	}
+	value = reflect.Indirect(value)
+
+	if value.Kind() != reflect.Struct {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		return snapshot, json.Unmarshal(data, &snapshot)
+	}
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || field.Anonymous || name == "" || name == "-" || field.Tag.Get("bun") == "-" {
+			continue
+		}
+
+		data, err := json.Marshal(value.Field(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		var decoded any
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return nil, err
+		}
+		snapshot[name] = decoded
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func chainAuditEntries(t *testing.T, actions ...string) []*model.AuditEntry {
+	entries := []*model.AuditEntry{}
+	prevHash := ""
+	for i, action := range actions {
+		entry := &model.AuditEntry{
+			Id:        uuid.New(),
+			Seq:       int64(i + 1),
+			Action:    action,
+			IP:        "127.0.0.1",
+			CreatedAt: time.Date(2024, 4, 24, 9, 0, i, 0, time.UTC),
+			PrevHash:  prevHash,
+		}
+		hash, err := auditHash(entry)
+		assert.NoError(t, err)
+		entry.Hash = hash
+		prevHash = hash
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func TestCheckAuditLink(t *testing.T) {
+	entries := chainAuditEntries(t, model.AuditIssueCloseAction, model.AuditRefundApproveAction, model.AuditDisputeResolveAction)
+
+	var previous *model.AuditEntry
+	for _, entry := range entries {
+		assert.Empty(t, checkAuditLink(previous, entry))
+		previous = entry
+	}
+
+	entries[1].Action = model.AuditRefundRejectAction
+	assert.Equal(t, "the entry does not match its hash", checkAuditLink(entries[0], entries[1]))
+
+	// rehashing the tampered entry breaks the link of the next one
+	entries[1].Hash, _ = auditHash(entries[1])
+	assert.Empty(t, checkAuditLink(entries[0], entries[1]))
+	assert.Equal(t, "the entry does not link to the hash of the previous entry", checkAuditLink(entries[1], entries[2]))
+
+	assert.Equal(t, "expected entry 2, found entry 3", checkAuditLink(entries[0], entries[2]))
+}
+
+func TestAuditChanges(t *testing.T) {
+	reviewerId := uuid.New()
+	before := model.VerificationTicket{Status: model.VerificationTicketPendingStatus, IdCardPictureKey: "old"}
+	after := before
+	after.Status = model.VerificationTicketApprovedStatus
+	after.ReviewerId = &reviewerId
+	after.IdCardPictureKey = "new"
+	after.IdCardPictureURL = "https://example.com"
+
+	changes, err := auditChanges(&before, &after)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.AuditChange{
+		"status":      {From: model.VerificationTicketPendingStatus, To: model.VerificationTicketApprovedStatus},
+		"reviewer_id": {From: nil, To: reviewerId.String()},
+	}, changes)
+
+	changes, err = auditChanges(nil, map[string]int{"limit": 3})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]model.AuditChange{"limit": {To: float64(3)}}, changes)
+}
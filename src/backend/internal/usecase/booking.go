@@ -2,23 +2,614 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/repository"
 	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+var (
+	ErrBookingNotReviewable = errors.New("the booking can only be reviewed once one of its sessions has taken place")
+	ErrBookingNotRefundable = errors.New("the booking cannot be refunded")
+	ErrRefundNotRequested   = errors.New("the booking is not waiting for refund")
+)
+
 type BookingUseCase struct {
-	BookingRepo repository.Booking
+	BookingRepo        repository.Booking
+	BookingSessionRepo repository.BookingSession
+	LineItemRepo       repository.BookingLineItem
+	InstalmentRepo     repository.BookingInstalment
+	ExchangeRateRepo   repository.ExchangeRate
+	DocumentRepo       repository.BookingDocument
+	IssueRepo          repository.Issue
+	IssueSLARepo       repository.IssueSLATarget
+	BusyBlockRepo      repository.BusyBlock
+	LifecycleEventRepo repository.BookingLifecycleEvent
+	AuditRepo          repository.AuditEntry
+	DisputeRepo        repository.Dispute
+	JobRepo            repository.Job
+	UnitOfWork         repository.UnitOfWork
 }
 
 func NewBookingUseCase(db *bun.DB) *BookingUseCase {
 	return &BookingUseCase{
-		BookingRepo: postgres.NewBookingDB(db),
+		BookingRepo:        postgres.NewBookingDB(db),
+		BookingSessionRepo: postgres.NewBookingSessionDB(db),
+		LineItemRepo:       postgres.NewBookingLineItemDB(db),
+		InstalmentRepo:     postgres.NewBookingInstalmentDB(db),
+		ExchangeRateRepo:   postgres.NewExchangeRateDB(db),
+		DocumentRepo:       postgres.NewBookingDocumentDB(db),
+		IssueRepo:          postgres.NewIssueDB(db),
+		IssueSLARepo:       postgres.NewIssueSLATargetDB(db),
+		BusyBlockRepo:      postgres.NewBusyBlockDB(db),
+		LifecycleEventRepo: postgres.NewBookingLifecycleEventDB(db),
+		AuditRepo:          postgres.NewAuditEntryDB(db),
+		DisputeRepo:        postgres.NewDisputeDB(db),
+		JobRepo:            postgres.NewJobDB(db),
+		UnitOfWork:         postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// PlanSessions sets the sessions of a new booking from the proposal, the booking spans from the start
+// of its first session to the end of its last one
+func (b *BookingUseCase) PlanSessions(booking *model.Booking, proposal model.BookingProposal) error {
+	sessions, err := planBookingSessions(booking.Id, proposal, time.Now())
+	if err != nil {
+		return err
+	}
+
+	booking.Sessions = sessions
+	booking.StartTime = sessions[0].StartTime
+	booking.EndTime = sessions[0].EndTime
+	for _, session := range sessions {
+		if session.EndTime.After(booking.EndTime) {
+			booking.EndTime = session.EndTime
+		}
 	}
+
+	return nil
+}
+
+// CheckAvailability returns ErrPhotographerBusy when a planned session overlaps a busy block of the photographer
+func (b *BookingUseCase) CheckAvailability(ctx context.Context, photographerId uuid.UUID, booking *model.Booking) error {
+	return checkAvailability(ctx, b.BusyBlockRepo, photographerId, booking.Sessions)
+}
+
+// PlanInstalments sets the payment schedule of a new booking whose sessions and price are known
+func (b *BookingUseCase) PlanInstalments(booking *model.Booking, gallery *model.Gallery) {
+	booking.Instalments = planInstalments(booking, gallery, time.Now())
+}
+
+// Create stores a new booking along with its planned sessions, the line items of its quote and its payment
+// schedule, and notifies the customer of it
+func (b *BookingUseCase) Create(ctx context.Context, booking *model.Booking) error {
+	if len(booking.Instalments) == 0 {
+		return errInstalmentScheduleRequired
+	}
+
+	return doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		if err := b.BookingRepo.AddOne(ctx, booking); err != nil {
+			return err
+		}
+		recordTransition(ctx, "", booking.Status)
+
+		if err := b.BookingSessionRepo.AddBatch(ctx, booking.Sessions); err != nil {
+			return err
+		}
+
+		for _, lineItem := range booking.LineItems {
+			lineItem.BookingId = booking.Id
+		}
+		if err := b.LineItemRepo.AddBatch(ctx, booking.LineItems); err != nil {
+			return err
+		}
+
+		if err := b.InstalmentRepo.AddBatch(ctx, booking.Instalments); err != nil {
+			return err
+		}
+
+		if err := publishWebhook(ctx, b.JobRepo, model.WebhookBookingCreatedEvent, booking); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, booking.CustomerId, model.NotificationBookingCreatedType, &booking.Id, nil)
+	})
+}
+
+// ChangeStatus moves the booking to the status and notifies the other party of the booking
+func (b *BookingUseCase) ChangeStatus(ctx context.Context, booking *model.Booking, status string, recipientId uuid.UUID, notificationType string) error {
+	if err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		if err := b.updateStatus(ctx, booking, status); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, recipientId, notificationType, &booking.Id, nil)
+	}); err != nil {
+		return err
+	}
+
+	return b.PopulateDetails(ctx, booking)
+}
+
+// updateStatus also cancels the sessions and the instalments left of a cancelled booking, the sessions that
+// have taken place stay completed and the instalments paid stay paid
+func (b *BookingUseCase) updateStatus(ctx context.Context, booking *model.Booking, status string) error {
+	from := booking.Status
+	booking.Status = status
+	booking.UpdatedAt = time.Now()
+	if err := b.BookingRepo.UpdateOne(ctx, booking); err != nil {
+		return err
+	}
+	recordTransition(ctx, from, status)
+
+	if err := publishWebhook(ctx, b.JobRepo, model.WebhookBookingStatusChangedEvent, booking); err != nil {
+		return err
+	}
+
+	if status == model.BookingCancelledStatus {
+		if err := b.BookingSessionRepo.CancelScheduled(ctx, booking.Id); err != nil {
+			return err
+		}
+
+		return b.InstalmentRepo.CancelPending(ctx, booking.Id)
+	}
+
+	return nil
+}
+
+// bookingTransitionsKey is the key of the booking transitions made in a transaction
+type bookingTransitionsKey struct{}
+
+type bookingTransition struct {
+	from string
+	to   string
+}
+
+// doCountingTransitions runs fn in the unit of work and counts the booking transitions recorded in it once it has
+// committed, so that a rolled back transition is not counted. Nested in another one, the outer one counts them.
+func doCountingTransitions(ctx context.Context, unitOfWork repository.UnitOfWork, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(bookingTransitionsKey{}).(*[]bookingTransition); ok {
+		return unitOfWork.Do(ctx, fn)
+	}
+
+	transitions := &[]bookingTransition{}
+	if err := unitOfWork.Do(context.WithValue(ctx, bookingTransitionsKey{}, transitions), fn); err != nil {
+		return err
+	}
+
+	for _, transition := range *transitions {
+		metrics.CountBookingTransition(transition.from, transition.to)
+	}
+
+	return nil
+}
+
+// recordTransition keeps the transition to be counted by doCountingTransitions, outside of it the transition is
+// counted at once
+func recordTransition(ctx context.Context, from string, to string) {
+	if transitions, ok := ctx.Value(bookingTransitionsKey{}).(*[]bookingTransition); ok {
+		*transitions = append(*transitions, bookingTransition{from: from, to: to})
+		return
+	}
+
+	metrics.CountBookingTransition(from, to)
+}
+
+// lockBooking reloads the status of the booking from its row locked until the end of the transaction, so that the
+// status checked is not changed meanwhile by the routine or another request
+func (b *BookingUseCase) lockBooking(ctx context.Context, booking *model.Booking) error {
+	locked, err := b.BookingRepo.LockOneById(ctx, booking.Id)
+	if err != nil {
+		return err
+	}
+
+	locked.Room, locked.Sessions, locked.LineItems, locked.Instalments = booking.Room, booking.Sessions, booking.LineItems, booking.Instalments
+	*booking = *locked
+	return nil
+}
+
+// RequestRefund opens the refund issue of a paid or completed booking whose room has been populated and notifies the
+// photographer, or returns ErrBookingNotRefundable
+func (b *BookingUseCase) RequestRefund(ctx context.Context, booking *model.Booking, issue *model.Issue) error {
+	if err := applyIssueSLA(ctx, b.IssueSLARepo, issue); err != nil {
+		return err
+	}
+
+	if err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		// a request submitted twice opens a single issue
+		if err := b.lockBooking(ctx, booking); err != nil {
+			return err
+		}
+		if booking.Status != model.BookingPaidStatus && booking.Status != model.BookingCompletedStatus {
+			return ErrBookingNotRefundable
+		}
+
+		from := booking.Status
+		booking.Status = model.BookingRefundReqStatus
+		if err := b.BookingRepo.UpdateOne(ctx, booking); err != nil {
+			return err
+		}
+		recordTransition(ctx, from, booking.Status)
+
+		if err := publishWebhook(ctx, b.JobRepo, model.WebhookBookingStatusChangedEvent, booking); err != nil {
+			return err
+		}
+
+		if err := b.IssueRepo.AddOne(ctx, issue); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, booking.Room.Gallery.PhotographerId, model.NotificationRefundRequestedType, &booking.Id, nil)
+	}); err != nil {
+		return err
+	}
+
+	return b.PopulateDetails(ctx, booking)
+}
+
+// OutstandingInstalment returns the instalment the customer has to pay next, or ErrBookingNotPayable
+func (b *BookingUseCase) OutstandingInstalment(ctx context.Context, booking *model.Booking) (*model.BookingInstalment, error) {
+	if booking.Status != model.BookingDraftStatus && booking.Status != model.BookingPartiallyPaidStatus {
+		return nil, ErrBookingNotPayable
+	}
+
+	instalments, err := b.InstalmentRepo.FindByBookingIds(ctx, booking.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	instalment := outstandingInstalment(instalments)
+	if instalment == nil {
+		return nil, ErrBookingNotPayable
+	}
+
+	return instalment, nil
+}
+
+// PaymentAmount is the amount of the instalment in the currency it is paid in, an empty currency being the one of
+// the booking. Once the rate has been locked by the first payment, the booking can only be paid in its currency.
+func (b *BookingUseCase) PaymentAmount(ctx context.Context, booking *model.Booking, instalment *model.BookingInstalment, currency string) (*model.Price, error) {
+	currency, rate, err := b.paymentRate(ctx, booking, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Price{Amount: convertAmount(instalment.Amount, rate), Currency: currency}, nil
+}
+
+func (b *BookingUseCase) paymentRate(ctx context.Context, booking *model.Booking, currency string) (string, float64, error) {
+	if booking.ExchangeRate != nil {
+		if currency != "" && currency != *booking.PaymentCurrency {
+			return "", 0, ErrPaymentCurrencyMismatch
+		}
+
+		return *booking.PaymentCurrency, *booking.ExchangeRate, nil
+	}
+
+	if currency == "" {
+		currency = booking.Currency
+	}
+
+	rates, err := loadRates(ctx, b.ExchangeRateRepo)
+	if err != nil {
+		return "", 0, err
+	}
+
+	rate, err := exchangeRate(rates, booking.Currency, currency)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return currency, rate, nil
+}
+
+// PayInstalment records the payment of the outstanding instalment of a booking whose room has been populated, in
+// the currency given or the one of the booking. The first payment locks the exchange rate for the following ones.
+// The booking is paid once its last instalment is, until then it is partially paid. The QR code of the instalment is
+// removed and the photographer notified by the worker once the payment is committed. A scanned QR code names its
+// instalment, so that an old one cannot pay the next instalment. Every payment is given a receipt and the booking
+// its invoice once it is paid in full.
+func (b *BookingUseCase) PayInstalment(ctx context.Context, booking *model.Booking, instalmentId *uuid.UUID, currency string) error {
+	if err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		// the booking and its instalments are locked, so that two payments at once do not both go through
+		if err := b.lockBooking(ctx, booking); err != nil {
+			return err
+		}
+		if booking.Status != model.BookingDraftStatus && booking.Status != model.BookingPartiallyPaidStatus {
+			return ErrBookingNotPayable
+		}
+
+		instalments, err := b.InstalmentRepo.LockByBookingId(ctx, booking.Id)
+		if err != nil {
+			return err
+		}
+
+		instalment := outstandingInstalment(instalments)
+		if instalment == nil {
+			return ErrBookingNotPayable
+		}
+
+		if instalmentId != nil && *instalmentId != instalment.Id {
+			return ErrInstalmentNotOutstanding
+		}
+
+		paymentCurrency, rate, err := b.paymentRate(ctx, booking, currency)
+		if err != nil {
+			return err
+		}
+
+		paidAt := time.Now()
+		if booking.ExchangeRate == nil {
+			booking.PaymentCurrency = &paymentCurrency
+			booking.ExchangeRate = &rate
+			booking.RateLockedAt = &paidAt
+		}
+
+		paidAmount := convertAmount(instalment.Amount, rate)
+		instalment.Status = model.InstalmentPaidStatus
+		instalment.PaidAt = &paidAt
+		instalment.PaidAmount = &paidAmount
+		instalment.UpdatedAt = paidAt
+		if err := b.InstalmentRepo.UpdateOne(ctx, instalment); err != nil {
+			return err
+		}
+
+		bookingStatus, notificationType := model.BookingPaidStatus, model.NotificationBookingPaidType
+		if instalment.Kind == model.InstalmentDepositKind {
+			bookingStatus, notificationType = model.BookingPartiallyPaidStatus, model.NotificationDepositPaidType
+		}
+
+		from := booking.Status
+		booking.Status = bookingStatus
+		booking.UpdatedAt = paidAt
+		if err := b.BookingRepo.UpdateOne(ctx, booking); err != nil {
+			return err
+		}
+		recordTransition(ctx, from, bookingStatus)
+
+		if err := publishWebhook(ctx, b.JobRepo, model.WebhookPaymentSucceededEvent, booking); err != nil {
+			return err
+		}
+		if err := publishWebhook(ctx, b.JobRepo, model.WebhookBookingStatusChangedEvent, booking); err != nil {
+			return err
+		}
+
+		if err := issueDocument(ctx, b.DocumentRepo, b.JobRepo, booking, model.DocumentReceiptKind, &instalment.Id, model.Price{
+			Amount:   paidAmount,
+			Currency: paymentCurrency,
+		}); err != nil {
+			return err
+		}
+
+		if bookingStatus == model.BookingPaidStatus {
+			if err := issueDocument(ctx, b.DocumentRepo, b.JobRepo, booking, model.DocumentInvoiceKind, nil, model.Price{
+				Amount:   booking.ResultedPrice,
+				Currency: booking.Currency,
+			}); err != nil {
+				return err
+			}
+		}
+
+		if err := enqueueJob(ctx, b.JobRepo, model.JobDeleteS3ObjectKind, model.S3ObjectJob{
+			Bucket: s3utils.QRPaymentBucket,
+			Key:    PaymentQRKey(instalment),
+		}); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, booking.Room.Gallery.PhotographerId, notificationType, &booking.Id, nil)
+	}); err != nil {
+		return err
+	}
+
+	return b.PopulateDetails(ctx, booking)
+}
+
+// the status of the bookings waiting for an instalment, by its kind
+var overdueInstalmentBookingStatuses = map[string]string{
+	model.InstalmentDepositKind: model.BookingDraftStatus,
+	model.InstalmentBalanceKind: model.BookingPartiallyPaidStatus,
+}
+
+// the reasons given to the customers of the bookings cancelled for an overdue instalment, by its kind
+var overdueInstalmentReasons = map[string]string{
+	model.InstalmentDepositKind: "The deposit was not paid before it was due",
+	model.InstalmentBalanceKind: "The balance was not paid before it was due, the deposit is kept by the photographer",
+}
+
+// CancelOverduePayments cancels the bookings whose deposit or balance has not been paid in time and notifies their
+// customers, the instalments already paid stay paid
+func (b *BookingUseCase) CancelOverduePayments(ctx context.Context) ([]*model.Booking, error) {
+	bookings := []*model.Booking{}
+	err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		overdue, err := b.InstalmentRepo.FindOverdue(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, instalment := range overdue {
+			// the booking may have been paid since the instalments were read
+			booking := &model.Booking{Id: instalment.BookingId}
+			if err := b.lockBooking(ctx, booking); err != nil {
+				return err
+			}
+			if booking.Status != overdueInstalmentBookingStatuses[instalment.Kind] {
+				continue
+			}
+
+			if err := b.updateStatus(ctx, booking, model.BookingCancelledStatus); err != nil {
+				return err
+			}
+
+			reason := overdueInstalmentReasons[instalment.Kind]
+			if err := enqueueNotification(ctx, b.JobRepo, booking.CustomerId, model.NotificationBookingCancelledType, &booking.Id, &reason); err != nil {
+				return err
+			}
+			bookings = append(bookings, booking)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// ResolveRefund closes the refund issue, an approved refund cancels the booking and a rejected one puts it back to paid.
+// The payments of an approved refund are credited by a credit note, the room of the booking has to be populated. A
+// refund resolved meanwhile returns ErrRefundNotRequested or ErrIssueClosed.
+func (b *BookingUseCase) ResolveRefund(ctx context.Context, booking *model.Booking, issue *model.Issue, approved bool) error {
+	bookingStatus, notificationType, action, eventType := model.BookingPaidStatus, model.NotificationRefundRejectedType, model.AuditRefundRejectAction, model.WebhookRefundRejectedEvent
+	if approved {
+		bookingStatus, notificationType, action, eventType = model.BookingCancelledStatus, model.NotificationRefundApprovedType, model.AuditRefundApproveAction, model.WebhookRefundApprovedEvent
+	}
+
+	if err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		// two administrators resolving the refund at once credit the payments once
+		if err := b.lockBooking(ctx, booking); err != nil {
+			return err
+		}
+		if booking.Status != model.BookingRefundReqStatus {
+			return ErrRefundNotRequested
+		}
+
+		locked, err := b.IssueRepo.LockOneById(ctx, issue.Id)
+		if err != nil {
+			return err
+		}
+		if locked.Status != model.IssueOpenStatus {
+			return ErrIssueClosed
+		}
+		*issue = *locked
+
+		before := *booking
+		if err := b.updateStatus(ctx, booking, bookingStatus); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, b.AuditRepo, action, model.AuditBookingTarget, &booking.Id, &before, booking); err != nil {
+			return err
+		}
+
+		if err := publishWebhook(ctx, b.JobRepo, eventType, booking); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		issue.Status = model.IssueClosedStatus
+		issue.ClosedAt = &now
+		issue.UpdatedAt = now
+		if err := b.IssueRepo.UpdateOne(ctx, issue); err != nil {
+			return err
+		}
+
+		if approved {
+			if err := b.creditPayments(ctx, booking); err != nil {
+				return err
+			}
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, booking.CustomerId, notificationType, &booking.Id, nil)
+	}); err != nil {
+		return err
+	}
+
+	return b.PopulateDetails(ctx, booking)
+}
+
+// creditPayments issues the credit note of the amount paid for a booking, in the currency it has been paid in
+func (b *BookingUseCase) creditPayments(ctx context.Context, booking *model.Booking) error {
+	if booking.PaymentCurrency == nil {
+		return nil
+	}
+
+	instalments, err := b.InstalmentRepo.FindByBookingIds(ctx, booking.Id)
+	if err != nil {
+		return err
+	}
+
+	credited := 0
+	for _, instalment := range instalments {
+		if instalment.Status == model.InstalmentPaidStatus && instalment.PaidAmount != nil {
+			credited += *instalment.PaidAmount
+		}
+	}
+
+	return issueDocument(ctx, b.DocumentRepo, b.JobRepo, booking, model.DocumentCreditNoteKind, nil, model.Price{
+		Amount:   credited,
+		Currency: *booking.PaymentCurrency,
+	})
+}
+
+// PopulateDetails attaches the sessions of every booking, sorted by their start, its price breakdown and its payment schedule
+func (b *BookingUseCase) PopulateDetails(ctx context.Context, bookings ...*model.Booking) error {
+	bookingIds := []uuid.UUID{}
+	for _, booking := range bookings {
+		bookingIds = append(bookingIds, booking.Id)
+	}
+
+	sessions, err := b.BookingSessionRepo.FindByBookingIds(ctx, bookingIds...)
+	if err != nil {
+		return err
+	}
+
+	bookingIdToSessions := map[uuid.UUID][]*model.BookingSession{}
+	for _, session := range sessions {
+		bookingIdToSessions[session.BookingId] = append(bookingIdToSessions[session.BookingId], session)
+	}
+
+	lineItems, err := b.LineItemRepo.FindByBookingIds(ctx, bookingIds...)
+	if err != nil {
+		return err
+	}
+
+	bookingIdToLineItems := map[uuid.UUID][]*model.BookingLineItem{}
+	for _, lineItem := range lineItems {
+		bookingIdToLineItems[lineItem.BookingId] = append(bookingIdToLineItems[lineItem.BookingId], lineItem)
+	}
+
+	instalments, err := b.InstalmentRepo.FindByBookingIds(ctx, bookingIds...)
+	if err != nil {
+		return err
+	}
+
+	bookingIdToInstalments := map[uuid.UUID][]*model.BookingInstalment{}
+	for _, instalment := range instalments {
+		bookingIdToInstalments[instalment.BookingId] = append(bookingIdToInstalments[instalment.BookingId], instalment)
+	}
+
+	for _, booking := range bookings {
+		booking.Sessions = bookingIdToSessions[booking.Id]
+		booking.LineItems = bookingIdToLineItems[booking.Id]
+		booking.Instalments = bookingIdToInstalments[booking.Id]
+	}
+
+	return nil
+}
+
+// CheckReviewable returns ErrBookingNotReviewable until the booking is over or one of its sessions has taken place
+func (b *BookingUseCase) CheckReviewable(ctx context.Context, booking *model.Booking) error {
+	if err := b.PopulateDetails(ctx, booking); err != nil {
+		return err
+	}
+
+	if !isBookingReviewable(booking) {
+		return ErrBookingNotReviewable
+	}
+
+	return nil
 }
 
 func (b *BookingUseCase) PopulateBookingInReviews(ctx context.Context, galleryUsecase GalleryUseCase, roomUsecase RoomUseCase, reviews ...*model.Review) error {
@@ -59,6 +650,10 @@ func (b *BookingUseCase) FindByUserIdWithStatus(ctx context.Context, userId uuid
 		return nil, err
 	}
 
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
@@ -72,12 +667,115 @@ func (b *BookingUseCase) FindByPhotographerIdWithStatus(ctx context.Context, pht
 		return nil, err
 	}
 
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
-func (b *BookingUseCase) UpdateStatusRoutine() error {
+// UpdateStatusRoutine completes the sessions that are over, then the bookings left without a session to take place,
+// and records the amount paid out to the photographer of the bookings paid out. The balance left of a partially
+// paid booking is cancelled once it is completed, the photographer is paid out the deposit.
+func (b *BookingUseCase) UpdateStatusRoutine(ctx context.Context) ([]*model.Booking, error) {
 	currentTime := time.Now()
-	return b.BookingRepo.UpdateStatusRoutine(context.Background(), currentTime)
+
+	var updated map[string][]*model.Booking
+	bookings := []*model.Booking{}
+	err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if _, err := b.BookingSessionRepo.CompleteEnded(ctx, currentTime); err != nil {
+			return err
+		}
+
+		var err error
+		updated, err = b.BookingRepo.UpdateStatusRoutine(ctx, currentTime)
+		if err != nil {
+			return err
+		}
+
+		for _, booking := range updated[model.BookingPartiallyPaidStatus] {
+			if err := b.InstalmentRepo.CancelPending(ctx, booking.Id); err != nil {
+				return err
+			}
+		}
+
+		for _, changed := range updated {
+			bookings = append(bookings, changed...)
+		}
+
+		if err := b.recordPayouts(ctx, bookings); err != nil {
+			return err
+		}
+
+		for _, booking := range bookings {
+			if err := publishWebhook(ctx, b.JobRepo, model.WebhookBookingStatusChangedEvent, booking); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for from, changed := range updated {
+		for _, booking := range changed {
+			metrics.CountBookingTransition(from, booking.Status)
+		}
+	}
+
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// recordPayouts sets the payout amount of the bookings paid out, the photographer gets the share of a resolved
+// dispute rather than everything paid
+func (b *BookingUseCase) recordPayouts(ctx context.Context, bookings []*model.Booking) error {
+	paidOutIds := []uuid.UUID{}
+	for _, booking := range bookings {
+		if booking.Status == model.BookingPaidOutStatus {
+			paidOutIds = append(paidOutIds, booking.Id)
+		}
+	}
+	if len(paidOutIds) == 0 {
+		return nil
+	}
+
+	instalments, err := b.InstalmentRepo.FindByBookingIds(ctx, paidOutIds...)
+	if err != nil {
+		return err
+	}
+	bookingIdToInstalments := map[uuid.UUID][]*model.BookingInstalment{}
+	for _, instalment := range instalments {
+		bookingIdToInstalments[instalment.BookingId] = append(bookingIdToInstalments[instalment.BookingId], instalment)
+	}
+
+	disputes, err := b.DisputeRepo.FindResolvedByBookingIds(ctx, paidOutIds...)
+	if err != nil {
+		return err
+	}
+	bookingIdToDispute := map[uuid.UUID]*model.Dispute{}
+	for _, dispute := range disputes {
+		bookingIdToDispute[dispute.BookingId] = dispute
+	}
+
+	for _, booking := range bookings {
+		if booking.Status != model.BookingPaidOutStatus {
+			continue
+		}
+
+		amount := payoutAmount(bookingIdToInstalments[booking.Id], bookingIdToDispute[booking.Id])
+		booking.PayoutAmount = &amount
+		if err := b.BookingRepo.UpdateOne(ctx, booking); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (b *BookingUseCase) ListPendingRefundBookings(ctx context.Context, galleryUsecase GalleryUseCase, roomUsecase RoomUseCase) ([]*model.Booking, error) {
@@ -90,6 +788,10 @@ func (b *BookingUseCase) ListPendingRefundBookings(ctx context.Context, galleryU
 		return nil, err
 	}
 
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
@@ -103,5 +805,9 @@ func (b *BookingUseCase) FindByRoomId(ctx context.Context, roomUsecase RoomUseCa
 		return nil, err
 	}
 
+	if err := b.PopulateDetails(ctx, booking); err != nil {
+		return nil, err
+	}
+
 	return booking, nil
 }
@@ -0,0 +1,103 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+// the time the customer has to pay the deposit once the booking is created
+const depositDuePeriod = 72 * time.Hour
+
+var (
+	ErrBookingNotPayable          = errors.New("the booking has nothing left to pay")
+	ErrInstalmentNotOutstanding   = errors.New("this instalment is not the one to pay, please scan the latest QR code")
+	ErrPaymentCurrencyMismatch    = errors.New("the exchange rate of the booking has been locked by its first payment in another currency")
+	errInstalmentScheduleRequired = errors.New("the instalments of the booking must be planned before it is created")
+)
+
+// planInstalments splits the price into a deposit due shortly and the balance due BalanceDueDays before the first
+// session. A booking made too close to its first session for both is paid in full in a single instalment.
+func planInstalments(booking *model.Booking, gallery *model.Gallery, now time.Time) []*model.BookingInstalment {
+	balanceDueAt := booking.StartTime.AddDate(0, 0, -gallery.BalanceDueDays)
+	depositDueAt := now.Add(depositDuePeriod)
+	deposit := booking.ResultedPrice * gallery.DepositPercent / 100
+
+	newInstalment := func(position int, kind string, amount int, dueAt time.Time) *model.BookingInstalment {
+		return &model.BookingInstalment{
+			Id:        uuid.New(),
+			BookingId: booking.Id,
+			Position:  position,
+			Kind:      kind,
+			Amount:    amount,
+			DueAt:     dueAt,
+			Status:    model.InstalmentPendingStatus,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+	}
+
+	if deposit <= 0 || deposit >= booking.ResultedPrice || !balanceDueAt.After(depositDueAt) {
+		dueAt := balanceDueAt
+		if dueAt.Before(now) {
+			dueAt = booking.StartTime
+		}
+
+		return []*model.BookingInstalment{newInstalment(0, model.InstalmentFullKind, booking.ResultedPrice, dueAt)}
+	}
+
+	return []*model.BookingInstalment{
+		newInstalment(0, model.InstalmentDepositKind, deposit, depositDueAt),
+		newInstalment(1, model.InstalmentBalanceKind, booking.ResultedPrice-deposit, balanceDueAt),
+	}
+}
+
+// outstandingInstalment is the first instalment left to pay
+func outstandingInstalment(instalments []*model.BookingInstalment) *model.BookingInstalment {
+	for _, instalment := range instalments {
+		if instalment.Status == model.InstalmentPendingStatus {
+			return instalment
+		}
+	}
+
+	return nil
+}
+
+// PaymentQRKey is the key of the QR code of an instalment in the payment bucket
+func PaymentQRKey(instalment *model.BookingInstalment) string {
+	return fmt.Sprintf("%s/%s", instalment.BookingId, instalment.Id)
+}
+
+// rescaleInstalments spreads the new price, less what has been paid, over the pending instalments in the proportions
+// they had, the last one takes the rounding. It returns the instalments it changed.
+func rescaleInstalments(instalments []*model.BookingInstalment, price int) []*model.BookingInstalment {
+	pending := []*model.BookingInstalment{}
+	pendingTotal := 0
+	for _, instalment := range instalments {
+		switch instalment.Status {
+		case model.InstalmentPaidStatus:
+			price -= instalment.Amount
+		case model.InstalmentPendingStatus:
+			pending = append(pending, instalment)
+			pendingTotal += instalment.Amount
+		}
+	}
+
+	remaining := price
+	for i, instalment := range pending {
+		if i == len(pending)-1 {
+			instalment.Amount = remaining
+			break
+		}
+
+		if pendingTotal > 0 {
+			instalment.Amount = instalment.Amount * price / pendingTotal
+		}
+		remaining -= instalment.Amount
+	}
+
+	return pending
+}
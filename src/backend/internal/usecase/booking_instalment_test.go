@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanInstalments(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	gallery := &model.Gallery{DepositPercent: 30, BalanceDueDays: 7}
+	booking := &model.Booking{ResultedPrice: 10000, StartTime: now.AddDate(0, 1, 0)}
+
+	instalments := planInstalments(booking, gallery, now)
+	assert.Len(t, instalments, 2)
+	assert.Equal(t, model.InstalmentDepositKind, instalments[0].Kind)
+	assert.Equal(t, 3000, instalments[0].Amount)
+	assert.Equal(t, now.Add(depositDuePeriod), instalments[0].DueAt)
+	assert.Equal(t, 7000, instalments[1].Amount)
+	assert.Equal(t, booking.StartTime.AddDate(0, 0, -7), instalments[1].DueAt)
+
+	// the balance would be due before the deposit
+	booking.StartTime = now.AddDate(0, 0, 9)
+	instalments = planInstalments(booking, gallery, now)
+	assert.Len(t, instalments, 1)
+	assert.Equal(t, model.InstalmentFullKind, instalments[0].Kind)
+	assert.Equal(t, 10000, instalments[0].Amount)
+
+	// the balance due date has passed, the price is due by the first session
+	booking.StartTime = now.AddDate(0, 0, 2)
+	instalments = planInstalments(booking, gallery, now)
+	assert.Equal(t, booking.StartTime, instalments[0].DueAt)
+
+	gallery.DepositPercent = 0
+	booking.StartTime = now.AddDate(0, 1, 0)
+	instalments = planInstalments(booking, gallery, now)
+	assert.Len(t, instalments, 1)
+}
+
+func TestOutstandingInstalment(t *testing.T) {
+	deposit := &model.BookingInstalment{Kind: model.InstalmentDepositKind, Status: model.InstalmentPaidStatus}
+	balance := &model.BookingInstalment{Kind: model.InstalmentBalanceKind, Status: model.InstalmentPendingStatus}
+
+	assert.Equal(t, balance, outstandingInstalment([]*model.BookingInstalment{deposit, balance}))
+
+	balance.Status = model.InstalmentPaidStatus
+	assert.Nil(t, outstandingInstalment([]*model.BookingInstalment{deposit, balance}))
+}
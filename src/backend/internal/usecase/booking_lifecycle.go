@@ -0,0 +1,241 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+// the bookings that ended longer ago than this before the review delay are not asked for a review anymore, so that
+// turning the requests on does not reach every past booking
+const reviewRequestWindow = 7 * 24 * time.Hour
+
+var ErrBookingNotDeliverable = errors.New("the photos can only be delivered once the booking is completed")
+
+// RemindSessions reminds both parties of the sessions of the paid bookings that start within the lead time, every
+// session is reminded once
+func (b *BookingUseCase) RemindSessions(ctx context.Context, lead time.Duration, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) (int, error) {
+	now := time.Now()
+	sessions, err := b.BookingSessionRepo.FindStartingBetween(ctx, now, now.Add(lead))
+	if err != nil || len(sessions) == 0 {
+		return 0, err
+	}
+
+	idToBooking, err := b.findWithRooms(ctx, roomUsecase, galleryUsecase, sessionBookingIds(sessions)...)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, session := range sessions {
+		booking, exist := idToBooking[session.BookingId]
+		if !exist {
+			continue
+		}
+
+		body := fmt.Sprintf("%s starts on %s", sessionName(booking, session), session.StartTime.UTC().Format("2 Jan 2006 15:04 MST"))
+		ok, err := b.sendOnce(ctx, booking, session.Id, model.LifecycleSessionReminderKind, func(ctx context.Context) error {
+			for _, userId := range []uuid.UUID{booking.CustomerId, booking.Room.Gallery.PhotographerId} {
+				if err := enqueueNotification(ctx, b.JobRepo, userId, model.NotificationSessionReminderType, &booking.Id, &body); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// RequestReviews asks the customers of the bookings completed at least delay ago to review them, unless they already did
+func (b *BookingUseCase) RequestReviews(ctx context.Context, delay time.Duration, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) (int, error) {
+	endedBefore := time.Now().Add(-delay)
+	bookings, err := b.BookingRepo.FindAwaitingReview(ctx, endedBefore.Add(-reviewRequestWindow), endedBefore)
+	if err != nil || len(bookings) == 0 {
+		return 0, err
+	}
+
+	if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, bookings...); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, booking := range bookings {
+		body := fmt.Sprintf("How was %s? Let other customers know with a review", booking.Room.Gallery.Name)
+		ok, err := b.sendOnce(ctx, booking, booking.Id, model.LifecycleReviewRequestKind, func(ctx context.Context) error {
+			return enqueueNotification(ctx, b.JobRepo, booking.CustomerId, model.NotificationReviewRequestedType, &booking.Id, &body)
+		})
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// ExpireDrafts cancels the DRAFT bookings left unchanged for longer than the expiry and notifies both parties, the
+// drafts are locked while they are cancelled so that a deposit paid meanwhile is not lost
+func (b *BookingUseCase) ExpireDrafts(ctx context.Context, expiry time.Duration, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) ([]*model.Booking, error) {
+	var bookings []*model.Booking
+	reason := "The booking expired before it was paid"
+	if err := doCountingTransitions(ctx, b.UnitOfWork, func(ctx context.Context) error {
+		stale, err := b.BookingRepo.FindStaleDrafts(ctx, time.Now().Add(-expiry))
+		if err != nil || len(stale) == 0 {
+			return err
+		}
+		bookings = stale
+
+		if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, bookings...); err != nil {
+			return err
+		}
+
+		for _, booking := range bookings {
+			if err := b.updateStatus(ctx, booking, model.BookingCancelledStatus); err != nil {
+				return err
+			}
+
+			for _, userId := range []uuid.UUID{booking.CustomerId, booking.Room.Gallery.PhotographerId} {
+				if err := enqueueNotification(ctx, b.JobRepo, userId, model.NotificationBookingExpiredType, &booking.Id, &reason); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}); err != nil || len(bookings) == 0 {
+		return nil, err
+	}
+
+	if err := b.PopulateDetails(ctx, bookings...); err != nil {
+		return nil, err
+	}
+
+	return bookings, nil
+}
+
+// AlertOverdueDeliveries alerts the photographers of the completed bookings whose photos are past the delivery time
+// of the gallery, every booking is alerted once
+func (b *BookingUseCase) AlertOverdueDeliveries(ctx context.Context, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) (int, error) {
+	bookings, err := b.BookingRepo.FindOverdueDeliveries(ctx, time.Now())
+	if err != nil || len(bookings) == 0 {
+		return 0, err
+	}
+
+	if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, bookings...); err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, booking := range bookings {
+		gallery := booking.Room.Gallery
+		body := fmt.Sprintf("The photos of %s were due %d day(s) after the booking, please deliver them and mark the booking as delivered", gallery.Name, gallery.DeliveryTime)
+		ok, err := b.sendOnce(ctx, booking, booking.Id, model.LifecycleDeliveryOverdueKind, func(ctx context.Context) error {
+			return enqueueNotification(ctx, b.JobRepo, gallery.PhotographerId, model.NotificationDeliveryOverdueType, &booking.Id, &body)
+		})
+		if err != nil {
+			return sent, err
+		}
+		if ok {
+			sent++
+		}
+	}
+
+	return sent, nil
+}
+
+// MarkDelivered records that the photos of the completed booking have been handed over and notifies the customer
+func (b *BookingUseCase) MarkDelivered(ctx context.Context, booking *model.Booking) error {
+	if booking.Status != model.BookingCompletedStatus && booking.Status != model.BookingPaidOutStatus {
+		return ErrBookingNotDeliverable
+	}
+
+	if err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		booking.DeliveredAt = &now
+		booking.UpdatedAt = now
+		if err := b.BookingRepo.UpdateOne(ctx, booking); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, booking.CustomerId, model.NotificationPhotosDeliveredType, &booking.Id, nil)
+	}); err != nil {
+		return err
+	}
+
+	return b.PopulateDetails(ctx, booking)
+}
+
+// sendOnce records the lifecycle event and queues its notifications in one transaction, nothing is queued when the
+// event has already been recorded by another run
+func (b *BookingUseCase) sendOnce(ctx context.Context, booking *model.Booking, subjectId uuid.UUID, kind string, notify func(ctx context.Context) error) (bool, error) {
+	sent := false
+	err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		recorded, err := b.LifecycleEventRepo.AddUnique(ctx, &model.BookingLifecycleEvent{
+			Id:        uuid.New(),
+			BookingId: booking.Id,
+			SubjectId: subjectId,
+			Kind:      kind,
+			CreatedAt: time.Now(),
+		})
+		if err != nil || !recorded {
+			return err
+		}
+
+		sent = true
+		return notify(ctx)
+	})
+
+	return sent, err
+}
+
+func (b *BookingUseCase) findWithRooms(ctx context.Context, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase, ids ...uuid.UUID) (map[uuid.UUID]*model.Booking, error) {
+	bookings, err := b.BookingRepo.FindByIds(ctx, ids...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, bookings...); err != nil {
+		return nil, err
+	}
+
+	idToBooking := map[uuid.UUID]*model.Booking{}
+	for _, booking := range bookings {
+		idToBooking[booking.Id] = booking
+	}
+
+	return idToBooking, nil
+}
+
+func sessionBookingIds(sessions []*model.BookingSession) []uuid.UUID {
+	seen := map[uuid.UUID]bool{}
+	ids := []uuid.UUID{}
+	for _, session := range sessions {
+		if !seen[session.BookingId] {
+			seen[session.BookingId] = true
+			ids = append(ids, session.BookingId)
+		}
+	}
+
+	return ids
+}
+
+func sessionName(booking *model.Booking, session *model.BookingSession) string {
+	if session.Title != nil && *session.Title != "" {
+		return fmt.Sprintf("%s of %s", *session.Title, booking.Room.Gallery.Name)
+	}
+
+	return fmt.Sprintf("Your session of %s", booking.Room.Gallery.Name)
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionBookingIds(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	sessions := []*model.BookingSession{{BookingId: first}, {BookingId: second}, {BookingId: first}}
+
+	assert.Equal(t, []uuid.UUID{first, second}, sessionBookingIds(sessions))
+}
+
+func TestSessionName(t *testing.T) {
+	title := "Ceremony"
+	booking := &model.Booking{Room: model.Room{Gallery: model.Gallery{Name: "Weddings"}}}
+
+	assert.Equal(t, "Your session of Weddings", sessionName(booking, &model.BookingSession{}))
+	assert.Equal(t, "Ceremony of Weddings", sessionName(booking, &model.BookingSession{Title: &title}))
+}
+
+// fakeUnitOfWork runs fn without a transaction
+type fakeUnitOfWork struct{}
+
+func (fakeUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// fakeLifecycleEvents keeps the events in memory, one per kind and subject like the unique index
+type fakeLifecycleEvents struct {
+	repository.BookingLifecycleEvent
+	recorded map[string]bool
+}
+
+func (f *fakeLifecycleEvents) AddUnique(ctx context.Context, event *model.BookingLifecycleEvent) (bool, error) {
+	key := event.Kind + "/" + event.SubjectId.String()
+	if f.recorded[key] {
+		return false, nil
+	}
+
+	f.recorded[key] = true
+	return true, nil
+}
+
+func TestSendOnce(t *testing.T) {
+	bookingUsecase := &BookingUseCase{
+		LifecycleEventRepo: &fakeLifecycleEvents{recorded: map[string]bool{}},
+		UnitOfWork:         fakeUnitOfWork{},
+	}
+	booking := &model.Booking{Id: uuid.New()}
+	ctx := context.Background()
+
+	notified := 0
+	notify := func(ctx context.Context) error {
+		notified++
+		return nil
+	}
+
+	sent, err := bookingUsecase.sendOnce(ctx, booking, booking.Id, model.LifecycleReviewRequestKind, notify)
+	assert.NoError(t, err)
+	assert.True(t, sent)
+
+	sent, err = bookingUsecase.sendOnce(ctx, booking, booking.Id, model.LifecycleReviewRequestKind, notify)
+	assert.NoError(t, err)
+	assert.False(t, sent)
+
+	sent, err = bookingUsecase.sendOnce(ctx, booking, booking.Id, model.LifecycleDeliveryOverdueKind, notify)
+	assert.NoError(t, err)
+	assert.True(t, sent)
+
+	assert.Equal(t, 2, notified)
+}
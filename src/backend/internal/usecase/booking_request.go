@@ -0,0 +1,543 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const defaultQuoteExpiry = 72 * time.Hour
+
+var (
+	ErrBookingRequestClosed     = errors.New("the booking request is no longer open")
+	ErrBookingRequestInPast     = errors.New("the sessions of a booking request must start in the future")
+	ErrFirstQuoteByPhotographer = errors.New("the photographer has to quote the request before it can be countered")
+	ErrQuoteAwaitingAnswer      = errors.New("your quote is still waiting for an answer")
+	ErrNoPendingQuote           = errors.New("there is no quote waiting for an answer")
+	ErrOwnQuote                 = errors.New("a quote can only be accepted by the other party")
+	ErrQuoteExpired             = errors.New("the quote has expired")
+)
+
+type BookingRequestUseCase struct {
+	BookingRequestRepo repository.BookingRequest
+	BookingQuoteRepo   repository.BookingQuote
+	ConversationRepo   repository.Conversation
+	RoomRepo           repository.Room
+	LookupRepo         repository.Lookup
+	BusyBlockRepo      repository.BusyBlock
+	JobRepo            repository.Job
+	UnitOfWork         repository.UnitOfWork
+}
+
+func NewBookingRequestUseCase(db *bun.DB) *BookingRequestUseCase {
+	return &BookingRequestUseCase{
+		BookingRequestRepo: postgres.NewBookingRequestDB(db),
+		BookingQuoteRepo:   postgres.NewBookingQuoteDB(db),
+		ConversationRepo:   postgres.NewConversationDB(db),
+		RoomRepo:           postgres.NewRoomDB(db),
+		LookupRepo:         postgres.NewLookupDB(db),
+		BusyBlockRepo:      postgres.NewBusyBlockDB(db),
+		JobRepo:            postgres.NewJobDB(db),
+		UnitOfWork:         postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// Open stores the request of the customer in the room they share with the photographer of the gallery, the room
+// is opened if they have not talked yet. The request is posted as a card in the chat and the photographer notified.
+func (b *BookingRequestUseCase) Open(ctx context.Context, customerId uuid.UUID, gallery *model.Gallery, input model.BookingRequestInput, pricingUsecase PricingUseCase) (*model.BookingRequest, error) {
+	now := time.Now()
+	sessions, err := planRequestedSessions(model.BookingProposal{
+		StartTime:  input.StartTime,
+		EndTime:    input.EndTime,
+		Sessions:   input.Sessions,
+		Recurrence: input.Recurrence,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAvailability(ctx, b.BusyBlockRepo, gallery.PhotographerId, sessions); err != nil {
+		return nil, err
+	}
+
+	// the add-ons are checked against the gallery before the photographer sees them
+	if _, err := pricingUsecase.Quote(ctx, gallery, sessions, model.BookingProposal{AddOns: input.AddOns}); err != nil {
+		return nil, err
+	}
+
+	request := &model.BookingRequest{
+		Id:         uuid.New(),
+		CustomerId: customerId,
+		GalleryId:  gallery.Id,
+		Status:     model.BookingRequestOpenStatus,
+		Notes:      input.Notes,
+		Sessions:   sessionInputs(sessions),
+		AddOns:     input.AddOns,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+
+	if err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		roomId, err := b.findOrOpenRoom(ctx, customerId, gallery)
+		if err != nil {
+			return err
+		}
+		request.RoomId = roomId
+
+		if err := b.BookingRequestRepo.AddOne(ctx, request); err != nil {
+			return err
+		}
+
+		text := fmt.Sprintf("Booking request for %d session(s) of %s", len(request.Sessions), gallery.Name)
+		if err := b.postCard(ctx, request.RoomId, customerId, model.ConversationBookingRequestKind, request.Id, text); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, gallery.PhotographerId, model.NotificationBookingRequestedType, &request.Id, input.Notes)
+	}); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// findOrOpenRoom returns the room of the customer about the gallery, or opens one with its photographer
+func (b *BookingRequestUseCase) findOrOpenRoom(ctx context.Context, customerId uuid.UUID, gallery *model.Gallery) (uuid.UUID, error) {
+	lookups, err := b.LookupRepo.FindByUserId(ctx, customerId)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	roomIds := []uuid.UUID{}
+	for _, lookup := range lookups {
+		roomIds = append(roomIds, lookup.RoomId)
+	}
+
+	if len(roomIds) > 0 {
+		exist, err := b.RoomRepo.CheckRoomExistenceOfUserByGalleryId(ctx, roomIds, gallery.Id)
+		if err != nil {
+			return uuid.Nil, err
+		}
+
+		if exist {
+			room, err := b.RoomRepo.FindRoomOfUserByGalleryId(ctx, roomIds, gallery.Id)
+			if err != nil {
+				return uuid.Nil, err
+			}
+			return room.Id, nil
+		}
+	}
+
+	room := &model.Room{
+		Id:        uuid.New(),
+		GalleryId: gallery.Id,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := b.RoomRepo.AddOne(ctx, room); err != nil {
+		return uuid.Nil, err
+	}
+
+	lookups = []*model.UserRoomLookup{}
+	for _, memberId := range []uuid.UUID{customerId, gallery.PhotographerId} {
+		lookups = append(lookups, &model.UserRoomLookup{
+			Id:        uuid.New(),
+			UserId:    memberId,
+			RoomId:    room.Id,
+			CreatedAt: room.CreatedAt,
+			UpdatedAt: room.UpdatedAt,
+		})
+	}
+
+	if err := b.LookupRepo.AddBatch(ctx, lookups); err != nil {
+		return uuid.Nil, err
+	}
+
+	return room.Id, nil
+}
+
+// Offer answers the request with a quote of the next round, a pending quote of the other party is countered by it.
+// The sessions and the add-ons left out of the input are carried over from the previous round.
+func (b *BookingRequestUseCase) Offer(ctx context.Context, request *model.BookingRequest, gallery *model.Gallery, authorId uuid.UUID, input model.BookingQuoteInput, pricingUsecase PricingUseCase) (*model.BookingQuote, error) {
+	var quote *model.BookingQuote
+	if err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := b.lockOpenRequest(ctx, request); err != nil {
+			return err
+		}
+
+		latest, err := b.BookingQuoteRepo.FindLatestByRequestId(ctx, request.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			latest = nil
+		} else if err != nil {
+			return err
+		}
+
+		requested, addOns, round := request.Sessions, request.AddOns, 1
+		switch {
+		case latest == nil && authorId == request.CustomerId:
+			return ErrFirstQuoteByPhotographer
+		case latest != nil:
+			if latest.Status == model.BookingQuotePendingStatus {
+				if latest.AuthorId == authorId {
+					return ErrQuoteAwaitingAnswer
+				}
+
+				if err := b.answerQuote(ctx, latest, model.BookingQuoteCounteredStatus); err != nil {
+					return err
+				}
+			}
+
+			requested, addOns, round = latest.Sessions, latest.AddOns, latest.Round+1
+		}
+
+		if input.Sessions != nil {
+			requested = input.Sessions
+		}
+		if input.AddOns != nil {
+			addOns = input.AddOns
+		}
+
+		now := time.Now()
+		sessions, err := planRequestedSessions(model.BookingProposal{Sessions: requested}, now)
+		if err != nil {
+			return err
+		}
+		if err := checkAvailability(ctx, b.BusyBlockRepo, gallery.PhotographerId, sessions); err != nil {
+			return err
+		}
+
+		priced, err := pricingUsecase.Quote(ctx, gallery, sessions, model.BookingProposal{AddOns: addOns, NegotiatedPrice: input.NegotiatedPrice})
+		if err != nil {
+			return err
+		}
+
+		expiry := defaultQuoteExpiry
+		if input.ExpiresInHours > 0 {
+			expiry = time.Duration(input.ExpiresInHours) * time.Hour
+		}
+
+		quote = &model.BookingQuote{
+			Id:              uuid.New(),
+			RequestId:       request.Id,
+			AuthorId:        authorId,
+			Round:           round,
+			Status:          model.BookingQuotePendingStatus,
+			Sessions:        sessionInputs(sessions),
+			AddOns:          addOns,
+			NegotiatedPrice: input.NegotiatedPrice,
+			LineItems:       priced.LineItems,
+			Total:           priced.Total,
+			Currency:        priced.Currency,
+			Message:         input.Message,
+			ExpiresAt:       now.Add(expiry),
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		if err := b.BookingQuoteRepo.AddOne(ctx, quote); err != nil {
+			return err
+		}
+
+		text := fmt.Sprintf("Quote of %d for %d session(s)", quote.Total, len(quote.Sessions))
+		if err := b.postCard(ctx, request.RoomId, authorId, model.ConversationBookingQuoteKind, quote.Id, text); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, otherParty(request, gallery, authorId), model.NotificationQuoteReceivedType, &request.Id, input.Message)
+	}); err != nil {
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// Accept turns the pending quote of the other party into a DRAFT booking, which the customer then pays like
+// any booking created by the photographer
+func (b *BookingRequestUseCase) Accept(ctx context.Context, request *model.BookingRequest, gallery *model.Gallery, actorId uuid.UUID, bookingUsecase BookingUseCase) (*model.Booking, error) {
+	var booking *model.Booking
+	if err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := b.lockOpenRequest(ctx, request); err != nil {
+			return err
+		}
+
+		quote, err := b.findPendingQuote(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if quote.AuthorId == actorId {
+			return ErrOwnQuote
+		}
+		if !quote.ExpiresAt.After(time.Now()) {
+			return ErrQuoteExpired
+		}
+
+		booking = &model.Booking{
+			Id:            uuid.New(),
+			CustomerId:    request.CustomerId,
+			RoomId:        request.RoomId,
+			ResultedPrice: quote.Total,
+			Currency:      quote.Currency,
+			Status:        model.BookingDraftStatus,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		if err := bookingUsecase.PlanSessions(booking, model.BookingProposal{Sessions: quote.Sessions}); err != nil {
+			return err
+		}
+		if !booking.StartTime.After(booking.CreatedAt) {
+			return ErrBookingRequestInPast
+		}
+		// the photographer may have blocked the time since the quote was offered
+		if err := checkAvailability(ctx, b.BusyBlockRepo, gallery.PhotographerId, booking.Sessions); err != nil {
+			return err
+		}
+
+		for _, lineItem := range quote.LineItems {
+			lineItem := *lineItem
+			lineItem.Id = uuid.New()
+			booking.LineItems = append(booking.LineItems, &lineItem)
+		}
+
+		bookingUsecase.PlanInstalments(booking, gallery)
+		if err := bookingUsecase.Create(ctx, booking); err != nil {
+			return err
+		}
+
+		if err := b.answerQuote(ctx, quote, model.BookingQuoteAcceptedStatus); err != nil {
+			return err
+		}
+
+		request.Status = model.BookingRequestAcceptedStatus
+		request.BookingId = &booking.Id
+		request.UpdatedAt = time.Now()
+		if err := b.BookingRequestRepo.UpdateOne(ctx, request); err != nil {
+			return err
+		}
+		if err := b.republishCard(ctx, request.Id); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, otherParty(request, gallery, actorId), model.NotificationQuoteAcceptedType, &booking.Id, nil)
+	}); err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
+
+// Close ends the negotiation, the photographer declines the request and the customer withdraws it
+func (b *BookingRequestUseCase) Close(ctx context.Context, request *model.BookingRequest, gallery *model.Gallery, actorId uuid.UUID, status string) error {
+	return b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := b.lockOpenRequest(ctx, request); err != nil {
+			return err
+		}
+
+		quote, err := b.findPendingQuote(ctx, request)
+		if err == nil {
+			if err := b.answerQuote(ctx, quote, model.BookingQuoteDeclinedStatus); err != nil {
+				return err
+			}
+		} else if !errors.Is(err, ErrNoPendingQuote) {
+			return err
+		}
+
+		request.Status = status
+		request.UpdatedAt = time.Now()
+		if err := b.BookingRequestRepo.UpdateOne(ctx, request); err != nil {
+			return err
+		}
+		if err := b.republishCard(ctx, request.Id); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, b.JobRepo, otherParty(request, gallery, actorId), model.NotificationBookingRequestClosedType, &request.Id, nil)
+	})
+}
+
+// lockOpenRequest reloads the request from its row locked until the end of the transaction and checks that it is
+// still open, so that a request answered at the same time by the other party is not overwritten
+func (b *BookingRequestUseCase) lockOpenRequest(ctx context.Context, request *model.BookingRequest) error {
+	locked, err := b.BookingRequestRepo.LockOneById(ctx, request.Id)
+	if err != nil {
+		return err
+	}
+
+	locked.Quotes = request.Quotes
+	*request = *locked
+	if request.Status != model.BookingRequestOpenStatus {
+		return ErrBookingRequestClosed
+	}
+
+	return nil
+}
+
+// ExpireQuotes expires the quotes that have not been answered in time and refreshes their cards
+func (b *BookingRequestUseCase) ExpireQuotes(ctx context.Context) (int, error) {
+	var expired int
+	err := b.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		quotes, err := b.BookingQuoteRepo.ExpirePending(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, quote := range quotes {
+			if err := b.republishCard(ctx, quote.Id); err != nil {
+				return err
+			}
+		}
+
+		expired = len(quotes)
+		return nil
+	})
+
+	return expired, err
+}
+
+func (b *BookingRequestUseCase) FindByCustomerId(ctx context.Context, customerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error) {
+	requests, err := b.BookingRequestRepo.FindByCustomerId(ctx, customerId, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, b.PopulateQuotes(ctx, requests...)
+}
+
+func (b *BookingRequestUseCase) FindByPhotographerId(ctx context.Context, photographerId uuid.UUID, filter model.BookingRequestFilter) ([]*model.BookingRequest, error) {
+	requests, err := b.BookingRequestRepo.FindByPhotographerId(ctx, photographerId, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return requests, b.PopulateQuotes(ctx, requests...)
+}
+
+// PopulateQuotes attaches the quotes of every request, in the order of their rounds
+func (b *BookingRequestUseCase) PopulateQuotes(ctx context.Context, requests ...*model.BookingRequest) error {
+	requestIds := []uuid.UUID{}
+	for _, request := range requests {
+		requestIds = append(requestIds, request.Id)
+	}
+
+	quotes, err := b.BookingQuoteRepo.FindByRequestIds(ctx, requestIds...)
+	if err != nil {
+		return err
+	}
+
+	requestIdToQuotes := map[uuid.UUID][]*model.BookingQuote{}
+	for _, quote := range quotes {
+		requestIdToQuotes[quote.RequestId] = append(requestIdToQuotes[quote.RequestId], quote)
+	}
+
+	for _, request := range requests {
+		request.Quotes = requestIdToQuotes[request.Id]
+		if request.Quotes == nil {
+			request.Quotes = []*model.BookingQuote{}
+		}
+	}
+
+	return nil
+}
+
+func (b *BookingRequestUseCase) findPendingQuote(ctx context.Context, request *model.BookingRequest) (*model.BookingQuote, error) {
+	if request.Status != model.BookingRequestOpenStatus {
+		return nil, ErrBookingRequestClosed
+	}
+
+	quote, err := b.BookingQuoteRepo.FindLatestByRequestId(ctx, request.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNoPendingQuote
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if quote.Status != model.BookingQuotePendingStatus {
+		return nil, ErrNoPendingQuote
+	}
+
+	return quote, nil
+}
+
+func (b *BookingRequestUseCase) answerQuote(ctx context.Context, quote *model.BookingQuote, status string) error {
+	quote.Status = status
+	quote.UpdatedAt = time.Now()
+	if err := b.BookingQuoteRepo.UpdateOne(ctx, quote); err != nil {
+		return err
+	}
+
+	return b.republishCard(ctx, quote.Id)
+}
+
+// postCard posts the card in the chat of the room, it is pushed to the members by the worker once committed
+func (b *BookingRequestUseCase) postCard(ctx context.Context, roomId, userId uuid.UUID, kind string, resourceId uuid.UUID, text string) error {
+	card := &model.Conversation{
+		Id:         uuid.New(),
+		Text:       text,
+		Kind:       kind,
+		ResourceId: &resourceId,
+		UserId:     userId,
+		RoomId:     roomId,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := b.ConversationRepo.AddOne(ctx, card); err != nil {
+		return err
+	}
+
+	return enqueueJob(ctx, b.JobRepo, model.JobPublishChatCardKind, model.ChatCardJob{ConversationId: card.Id})
+}
+
+// republishCard pushes the card of a request or a quote again, so that the chat shows its new status
+func (b *BookingRequestUseCase) republishCard(ctx context.Context, resourceId uuid.UUID) error {
+	card, err := b.ConversationRepo.FindByResourceId(ctx, resourceId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return enqueueJob(ctx, b.JobRepo, model.JobPublishChatCardKind, model.ChatCardJob{ConversationId: card.Id})
+}
+
+// planRequestedSessions plans the sessions like those of a booking, but none of them can have started
+func planRequestedSessions(proposal model.BookingProposal, now time.Time) ([]*model.BookingSession, error) {
+	sessions, err := planBookingSessions(uuid.Nil, proposal, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sessions[0].StartTime.After(now) {
+		return nil, ErrBookingRequestInPast
+	}
+
+	return sessions, nil
+}
+
+func sessionInputs(sessions []*model.BookingSession) []model.BookingSessionInput {
+	inputs := []model.BookingSessionInput{}
+	for _, session := range sessions {
+		inputs = append(inputs, model.BookingSessionInput{
+			Title:     session.Title,
+			StartTime: session.StartTime,
+			EndTime:   session.EndTime,
+		})
+	}
+
+	return inputs
+}
+
+func otherParty(request *model.BookingRequest, gallery *model.Gallery, userId uuid.UUID) uuid.UUID {
+	if userId == request.CustomerId {
+		return gallery.PhotographerId
+	}
+
+	return request.CustomerId
+}
@@ -0,0 +1,34 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRequestedSessions(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	start := now.AddDate(0, 0, 3)
+
+	sessions, err := planRequestedSessions(model.BookingProposal{
+		StartTime:  start,
+		EndTime:    start.Add(2 * time.Hour),
+		Recurrence: &model.BookingRecurrence{Frequency: model.RecurrenceWeeklyFrequency, Count: 3},
+	}, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessionInputs(sessions), 3)
+
+	_, err = planRequestedSessions(model.BookingProposal{StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}, now)
+	assert.ErrorIs(t, err, ErrBookingRequestInPast)
+}
+
+func TestOtherParty(t *testing.T) {
+	request := &model.BookingRequest{CustomerId: uuid.New()}
+	gallery := &model.Gallery{PhotographerId: uuid.New()}
+
+	assert.Equal(t, gallery.PhotographerId, otherParty(request, gallery, request.CustomerId))
+	assert.Equal(t, request.CustomerId, otherParty(request, gallery, gallery.PhotographerId))
+}
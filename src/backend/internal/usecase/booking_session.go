@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+)
+
+const maxBookingSessions = 52
+
+var (
+	ErrBookingSessionMissing  = errors.New("a booking needs at least one session")
+	ErrInvalidBookingSession  = errors.New("a session must end after it starts")
+	ErrBookingSessionsOverlap = errors.New("the sessions of a booking must not overlap")
+	ErrTooManyBookingSessions = fmt.Errorf("a booking cannot have more than %d sessions", maxBookingSessions)
+	ErrInvalidRecurrence      = errors.New("the recurrence must be WEEKLY or MONTHLY with a positive interval and a count of at least 2")
+	ErrRecurrenceWithSessions = errors.New("the sessions cannot be listed along with a recurrence")
+)
+
+// planBookingSessions turns the proposal into the sessions of the booking, sorted by their start
+func planBookingSessions(bookingId uuid.UUID, proposal model.BookingProposal, now time.Time) ([]*model.BookingSession, error) {
+	inputs := proposal.Sessions
+	switch {
+	case len(inputs) > 0 && proposal.Recurrence != nil:
+		return nil, ErrRecurrenceWithSessions
+	case len(inputs) == 0 && proposal.Recurrence != nil:
+		recurring, err := expandRecurrence(proposal.StartTime, proposal.EndTime, *proposal.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		inputs = recurring
+	case len(inputs) == 0:
+		if proposal.StartTime.IsZero() && proposal.EndTime.IsZero() {
+			return nil, ErrBookingSessionMissing
+		}
+		inputs = []model.BookingSessionInput{{StartTime: proposal.StartTime, EndTime: proposal.EndTime}}
+	}
+
+	if len(inputs) > maxBookingSessions {
+		return nil, ErrTooManyBookingSessions
+	}
+
+	sessions := []*model.BookingSession{}
+	for _, input := range inputs {
+		if !input.EndTime.After(input.StartTime) {
+			return nil, ErrInvalidBookingSession
+		}
+
+		sessions = append(sessions, &model.BookingSession{
+			Id:        uuid.New(),
+			BookingId: bookingId,
+			Title:     input.Title,
+			StartTime: input.StartTime,
+			EndTime:   input.EndTime,
+			Status:    model.BookingSessionScheduledStatus,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.Before(sessions[j].StartTime)
+	})
+	for i := 1; i < len(sessions); i++ {
+		if sessions[i].StartTime.Before(sessions[i-1].EndTime) {
+			return nil, ErrBookingSessionsOverlap
+		}
+	}
+
+	return sessions, nil
+}
+
+func expandRecurrence(startTime, endTime time.Time, recurrence model.BookingRecurrence) ([]model.BookingSessionInput, error) {
+	interval := recurrence.Interval
+	if interval == 0 {
+		interval = 1
+	}
+	if interval < 0 || recurrence.Count < 2 {
+		return nil, ErrInvalidRecurrence
+	}
+	if recurrence.Count > maxBookingSessions {
+		return nil, ErrTooManyBookingSessions
+	}
+
+	var next func(t time.Time, n int) time.Time
+	switch recurrence.Frequency {
+	case model.RecurrenceWeeklyFrequency:
+		next = func(t time.Time, n int) time.Time { return t.AddDate(0, 0, 7*interval*n) }
+	case model.RecurrenceMonthlyFrequency:
+		next = func(t time.Time, n int) time.Time { return t.AddDate(0, interval*n, 0) }
+	default:
+		return nil, ErrInvalidRecurrence
+	}
+
+	inputs := []model.BookingSessionInput{}
+	for n := 0; n < recurrence.Count; n++ {
+		inputs = append(inputs, model.BookingSessionInput{
+			StartTime: next(startTime, n),
+			EndTime:   next(endTime, n),
+		})
+	}
+
+	return inputs, nil
+}
+
+// isBookingReviewable allows a review once the booking is over or one of its sessions has taken place
+func isBookingReviewable(booking *model.Booking) bool {
+	switch booking.Status {
+	case model.BookingCompletedStatus, model.BookingPaidOutStatus, model.BookingRefundReqStatus, model.BookingDisputedStatus:
+		return true
+	case model.BookingPaidStatus:
+		for _, session := range booking.Sessions {
+			if session.Status == model.BookingSessionCompletedStatus {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanBookingSessions(t *testing.T) {
+	start := time.Date(2024, 5, 4, 9, 0, 0, 0, time.UTC)
+	now := time.Now()
+
+	sessions, err := planBookingSessions(uuid.New(), model.BookingProposal{StartTime: start, EndTime: start.Add(2 * time.Hour)}, now)
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 1)
+	assert.Equal(t, model.BookingSessionScheduledStatus, sessions[0].Status)
+
+	sessions, err = planBookingSessions(uuid.New(), model.BookingProposal{Sessions: []model.BookingSessionInput{
+		{StartTime: start.AddDate(0, 1, 0), EndTime: start.AddDate(0, 1, 0).Add(8 * time.Hour)},
+		{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+	}}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, start, sessions[0].StartTime)
+
+	_, err = planBookingSessions(uuid.New(), model.BookingProposal{Sessions: []model.BookingSessionInput{
+		{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+		{StartTime: start.Add(time.Hour), EndTime: start.Add(3 * time.Hour)},
+	}}, now)
+	assert.ErrorIs(t, err, ErrBookingSessionsOverlap)
+
+	_, err = planBookingSessions(uuid.New(), model.BookingProposal{StartTime: start, EndTime: start}, now)
+	assert.ErrorIs(t, err, ErrInvalidBookingSession)
+
+	_, err = planBookingSessions(uuid.New(), model.BookingProposal{}, now)
+	assert.ErrorIs(t, err, ErrBookingSessionMissing)
+}
+
+func TestPlanRecurringBookingSessions(t *testing.T) {
+	start := time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC)
+	proposal := model.BookingProposal{
+		StartTime:  start,
+		EndTime:    start.Add(time.Hour),
+		Recurrence: &model.BookingRecurrence{Frequency: model.RecurrenceWeeklyFrequency, Interval: 2, Count: 3},
+	}
+
+	sessions, err := planBookingSessions(uuid.New(), proposal, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 3)
+	assert.Equal(t, start.AddDate(0, 0, 28), sessions[2].StartTime)
+
+	proposal.Recurrence = &model.BookingRecurrence{Frequency: model.RecurrenceMonthlyFrequency, Count: 2}
+	sessions, err = planBookingSessions(uuid.New(), proposal, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, start.AddDate(0, 1, 0), sessions[1].StartTime)
+
+	proposal.Recurrence = &model.BookingRecurrence{Frequency: "DAILY", Count: 2}
+	_, err = planBookingSessions(uuid.New(), proposal, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidRecurrence)
+}
+
+func TestIsBookingReviewable(t *testing.T) {
+	booking := &model.Booking{
+		Status:   model.BookingPaidStatus,
+		Sessions: []*model.BookingSession{{Status: model.BookingSessionScheduledStatus}},
+	}
+	assert.False(t, isBookingReviewable(booking))
+
+	booking.Sessions = append(booking.Sessions, &model.BookingSession{Status: model.BookingSessionCompletedStatus})
+	assert.True(t, isBookingReviewable(booking))
+
+	assert.True(t, isBookingReviewable(&model.Booking{Status: model.BookingCompletedStatus}))
+	assert.False(t, isBookingReviewable(&model.Booking{Status: model.BookingDraftStatus}))
+}
@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransitionsCountedAfterCommit(t *testing.T) {
+	counter := metrics.BookingStatusTransitions.WithLabelValues(model.BookingPaidStatus, model.BookingRefundReqStatus)
+	ctx := context.Background()
+	before := testutil.ToFloat64(counter)
+
+	// a transition rolled back is not counted
+	errAbort := errors.New("abort")
+	err := doCountingTransitions(ctx, fakeUnitOfWork{}, func(ctx context.Context) error {
+		recordTransition(ctx, model.BookingPaidStatus, model.BookingRefundReqStatus)
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+	assert.Equal(t, before, testutil.ToFloat64(counter))
+
+	// the transitions of a nested unit of work are counted once, when the outer one commits
+	err = doCountingTransitions(ctx, fakeUnitOfWork{}, func(ctx context.Context) error {
+		if err := doCountingTransitions(ctx, fakeUnitOfWork{}, func(ctx context.Context) error {
+			recordTransition(ctx, model.BookingPaidStatus, model.BookingRefundReqStatus)
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		assert.Equal(t, before, testutil.ToFloat64(counter))
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, before+1, testutil.ToFloat64(counter))
+}
+
+func TestResolveRefundRejectsRefundResolvedMeanwhile(t *testing.T) {
+	booking := &model.Booking{Id: uuid.New(), Status: model.BookingRefundReqStatus}
+	issue := &model.Issue{Id: uuid.New(), Status: model.IssueOpenStatus}
+
+	// another administrator has approved the refund
+	bookings := &fakeBookings{locked: &model.Booking{Id: booking.Id, Status: model.BookingCancelledStatus}}
+	issues := &fakeIssues{locked: issue}
+	bookingUsecase := &BookingUseCase{BookingRepo: bookings, IssueRepo: issues, UnitOfWork: fakeUnitOfWork{}}
+
+	err := bookingUsecase.ResolveRefund(context.Background(), booking, issue, true)
+	assert.ErrorIs(t, err, ErrRefundNotRequested)
+	assert.Nil(t, bookings.updated)
+	assert.Nil(t, issues.updated)
+
+	// the booking is waiting for refund but its issue has been closed
+	bookings.locked = &model.Booking{Id: booking.Id, Status: model.BookingRefundReqStatus}
+	issues.locked = &model.Issue{Id: issue.Id, Status: model.IssueClosedStatus}
+
+	err = bookingUsecase.ResolveRefund(context.Background(), booking, issue, true)
+	assert.ErrorIs(t, err, ErrIssueClosed)
+	assert.Nil(t, bookings.updated)
+	assert.Nil(t, issues.updated)
+}
+
+func TestRequestRefundRejectsRefundRequestedMeanwhile(t *testing.T) {
+	booking := &model.Booking{Id: uuid.New(), Status: model.BookingCompletedStatus}
+	bookings := &fakeBookings{locked: &model.Booking{Id: booking.Id, Status: model.BookingRefundReqStatus}}
+	bookingUsecase := &BookingUseCase{BookingRepo: bookings, IssueSLARepo: &fakeIssueSLATargets{}, UnitOfWork: fakeUnitOfWork{}}
+
+	err := bookingUsecase.RequestRefund(context.Background(), booking, &model.Issue{Id: uuid.New()})
+	assert.ErrorIs(t, err, ErrBookingNotRefundable)
+	assert.Nil(t, bookings.updated)
+}
@@ -0,0 +1,324 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/ical"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	calendarFeedTokenSize = 32
+	calendarFeedName      = "Pic Keeper bookings"
+	// the calendar apps remove the events that leave the feed, so the recent bookings are kept in it
+	calendarFeedLookback = 90 * 24 * time.Hour
+	busyBlockHorizon     = 365 * 24 * time.Hour
+	maxBusyBlocks        = 2000
+)
+
+var (
+	ErrCalendarFeedNotFound = errors.New("the calendar feed does not exist")
+	ErrPhotographerBusy     = errors.New("the photographer is busy during one of the sessions")
+	ErrTooManyBusyBlocks    = fmt.Errorf("a calendar cannot block more than %d periods in the coming year", maxBusyBlocks)
+)
+
+type CalendarUseCase struct {
+	CalendarFeedRepo repository.CalendarFeed
+	BusyBlockRepo    repository.BusyBlock
+	BookingRepo      repository.Booking
+	UserRepo         repository.User
+	UnitOfWork       repository.UnitOfWork
+}
+
+func NewCalendarUseCase(db *bun.DB) *CalendarUseCase {
+	return &CalendarUseCase{
+		CalendarFeedRepo: postgres.NewCalendarFeedDB(db),
+		BusyBlockRepo:    postgres.NewBusyBlockDB(db),
+		BookingRepo:      postgres.NewBookingDB(db),
+		UserRepo:         postgres.NewUserDB(db),
+		UnitOfWork:       postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// CreateFeed gives the user a new secret feed token, the previous feed of the user stops working
+func (c *CalendarUseCase) CreateFeed(ctx context.Context, userId uuid.UUID) (*model.CalendarFeed, string, error) {
+	tokenBytes := make([]byte, calendarFeedTokenSize)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	feed := &model.CalendarFeed{
+		Id:        uuid.New(),
+		UserId:    userId,
+		TokenHash: hashFeedToken(token),
+		CreatedAt: time.Now(),
+	}
+	if err := c.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := c.CalendarFeedRepo.DeleteByUserId(ctx, userId); err != nil {
+			return err
+		}
+
+		return c.CalendarFeedRepo.AddOne(ctx, feed)
+	}); err != nil {
+		return nil, "", err
+	}
+
+	return feed, token, nil
+}
+
+// FindFeed returns the feed of a token, ErrCalendarFeedNotFound when the token is unknown or has been replaced
+func (c *CalendarUseCase) FindFeed(ctx context.Context, token string) (*model.CalendarFeed, error) {
+	feed, err := c.CalendarFeedRepo.FindOneByTokenHash(ctx, hashFeedToken(token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	return feed, err
+}
+
+func hashFeedToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// WriteFeed writes the sessions of the recent and upcoming bookings of the user as an iCalendar file, along with the
+// gallery and the other party of each booking
+func (c *CalendarUseCase) WriteFeed(ctx context.Context, w io.Writer, userId uuid.UUID, bookingUsecase BookingUseCase, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) error {
+	now := time.Now()
+	bookings, err := c.BookingRepo.FindForCalendar(ctx, userId, now.Add(-calendarFeedLookback))
+	if err != nil {
+		return err
+	}
+
+	if len(bookings) > 0 {
+		if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, bookings...); err != nil {
+			return err
+		}
+		if err := bookingUsecase.PopulateDetails(ctx, bookings...); err != nil {
+			return err
+		}
+	}
+
+	userIds := []uuid.UUID{}
+	for _, booking := range bookings {
+		userIds = append(userIds, booking.CustomerId, booking.Room.Gallery.PhotographerId)
+	}
+
+	idToUser := map[uuid.UUID]*model.User{}
+	if len(userIds) > 0 {
+		users, err := c.UserRepo.FindByIds(ctx, userIds...)
+		if err != nil {
+			return err
+		}
+		for _, user := range users {
+			idToUser[user.Id] = user
+		}
+	}
+
+	return ical.Write(w, ical.Calendar{Name: calendarFeedName, Events: calendarEvents(userId, bookings, idToUser)}, now)
+}
+
+// calendarEvents turns every session of the bookings into an event, a session is cancelled along with its booking and
+// tentative until the booking is paid
+func calendarEvents(userId uuid.UUID, bookings []*model.Booking, idToUser map[uuid.UUID]*model.User) []*ical.Event {
+	events := []*ical.Event{}
+	for _, booking := range bookings {
+		gallery := booking.Room.Gallery
+
+		otherRole, otherId := "Photographer", gallery.PhotographerId
+		if gallery.PhotographerId == userId {
+			otherRole, otherId = "Customer", booking.CustomerId
+		}
+		other := calendarContact(idToUser[otherId])
+
+		description := []string{
+			fmt.Sprintf("Gallery: %s", gallery.Name),
+			fmt.Sprintf("%s: %s", otherRole, other),
+			fmt.Sprintf("Booking: %s (%s)", booking.Id, booking.Status),
+		}
+
+		for _, session := range booking.Sessions {
+			summary := fmt.Sprintf("%s with %s", gallery.Name, calendarName(idToUser[otherId]))
+			if session.Title != nil && *session.Title != "" {
+				summary = fmt.Sprintf("%s: %s", *session.Title, summary)
+			}
+
+			status, sequence := ical.StatusConfirmed, 0
+			switch {
+			case booking.Status == model.BookingCancelledStatus, session.Status == model.BookingSessionCancelledStatus:
+				status, sequence = ical.StatusCancelled, 1
+			case booking.Status == model.BookingDraftStatus:
+				status = ical.StatusTentative
+			}
+
+			lastModified := booking.UpdatedAt
+			if session.UpdatedAt.After(lastModified) {
+				lastModified = session.UpdatedAt
+			}
+
+			events = append(events, &ical.Event{
+				Uid:          fmt.Sprintf("%s@pic-keeper", session.Id),
+				Summary:      summary,
+				Description:  strings.Join(description, "\n"),
+				Location:     gallery.Location,
+				Start:        session.StartTime,
+				End:          session.EndTime,
+				Status:       status,
+				Sequence:     sequence,
+				LastModified: lastModified,
+			})
+		}
+	}
+
+	return events
+}
+
+func calendarName(user *model.User) string {
+	if user == nil {
+		return "a deleted user"
+	}
+
+	return strings.TrimSpace(fmt.Sprintf("%s %s", user.Firstname, user.Lastname))
+}
+
+// calendarContact is the name of the user followed by the email and the phone number
+func calendarContact(user *model.User) string {
+	contact := []string{calendarName(user)}
+	if user != nil {
+		contact = append(contact, user.Email)
+		if user.PhoneNumber != nil && *user.PhoneNumber != "" {
+			contact = append(contact, *user.PhoneNumber)
+		}
+	}
+
+	return strings.Join(contact, ", ")
+}
+
+// ImportBusyBlocks replaces the busy blocks of the photographer with the events of the iCalendar file that are not
+// over yet. The free and the cancelled events are left out, and the recurring ones are expanded over the coming year.
+func (c *CalendarUseCase) ImportBusyBlocks(ctx context.Context, photographerId uuid.UUID, r io.Reader) ([]*model.BusyBlock, error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := busyBlocksFromCalendar(calendar, photographerId, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := c.BusyBlockRepo.DeleteByPhotographerId(ctx, photographerId); err != nil {
+			return err
+		}
+
+		return c.BusyBlockRepo.AddBatch(ctx, blocks)
+	}); err != nil {
+		return nil, err
+	}
+
+	return blocks, nil
+}
+
+// FindUpcomingBusyBlocks returns the blocks of the photographer that are not over yet
+func (c *CalendarUseCase) FindUpcomingBusyBlocks(ctx context.Context, photographerId uuid.UUID) ([]*model.BusyBlock, error) {
+	now := time.Now()
+	return c.BusyBlockRepo.FindByPhotographerId(ctx, photographerId, now, now.Add(busyBlockHorizon))
+}
+
+func busyBlocksFromCalendar(calendar *ical.Calendar, photographerId uuid.UUID, now time.Time) ([]*model.BusyBlock, error) {
+	blocks := []*model.BusyBlock{}
+	for _, event := range calendar.Events {
+		if event.Transparent || event.Status == ical.StatusCancelled {
+			continue
+		}
+
+		// the rules that cannot be expanded still block their first occurrence
+		occurrences, _ := event.Occurrences(now, now.Add(busyBlockHorizon))
+		for _, occurrence := range occurrences {
+			if !occurrence.End.After(occurrence.Start) || !occurrence.End.After(now) {
+				continue
+			}
+
+			var summary *string
+			if occurrence.Summary != "" {
+				summary = &occurrence.Summary
+			}
+
+			blocks = append(blocks, &model.BusyBlock{
+				Id:             uuid.New(),
+				PhotographerId: photographerId,
+				Uid:            occurrence.Uid,
+				Summary:        summary,
+				StartTime:      occurrence.Start,
+				EndTime:        occurrence.End,
+				CreatedAt:      now,
+			})
+		}
+	}
+
+	if len(blocks) > maxBusyBlocks {
+		return nil, ErrTooManyBusyBlocks
+	}
+
+	return blocks, nil
+}
+
+// checkAvailability returns ErrPhotographerBusy when one of the sessions overlaps a busy block of the photographer
+func checkAvailability(ctx context.Context, busyBlockRepo repository.BusyBlock, photographerId uuid.UUID, sessions []*model.BookingSession) error {
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	from, to := sessions[0].StartTime, sessions[0].EndTime
+	for _, session := range sessions {
+		if session.StartTime.Before(from) {
+			from = session.StartTime
+		}
+		if session.EndTime.After(to) {
+			to = session.EndTime
+		}
+	}
+
+	blocks, err := busyBlockRepo.FindByPhotographerId(ctx, photographerId, from, to)
+	if err != nil {
+		return err
+	}
+
+	if block := busyBlockOverlap(sessions, blocks); block != nil {
+		return fmt.Errorf("%w, from %s to %s", ErrPhotographerBusy, block.StartTime.Format(time.RFC3339), block.EndTime.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// busyBlockOverlap returns the first block that overlaps one of the sessions which are not cancelled
+func busyBlockOverlap(sessions []*model.BookingSession, blocks []*model.BusyBlock) *model.BusyBlock {
+	for _, session := range sessions {
+		if session.Status == model.BookingSessionCancelledStatus {
+			continue
+		}
+
+		for _, block := range blocks {
+			if session.StartTime.Before(block.EndTime) && block.StartTime.Before(session.EndTime) {
+				return block
+			}
+		}
+	}
+
+	return nil
+}
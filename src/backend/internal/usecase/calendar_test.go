@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/ical"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendarEvents(t *testing.T) {
+	photographer := &model.User{Id: uuid.New(), Firstname: "Somchai", Lastname: "Dee", Email: "somchai@example.com"}
+	phone := "0812345678"
+	customer := &model.User{Id: uuid.New(), Firstname: "Anna", Lastname: "Lee", Email: "anna@example.com", PhoneNumber: &phone}
+	title := "Ceremony"
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	booking := &model.Booking{
+		Id:         uuid.New(),
+		CustomerId: customer.Id,
+		Status:     model.BookingPaidStatus,
+		Room:       model.Room{Gallery: model.Gallery{Name: "Weddings", Location: "Chiang Mai", PhotographerId: photographer.Id}},
+		Sessions: []*model.BookingSession{
+			{Id: uuid.New(), Title: &title, StartTime: start, EndTime: start.Add(2 * time.Hour)},
+			{Id: uuid.New(), StartTime: start.Add(24 * time.Hour), EndTime: start.Add(26 * time.Hour), Status: model.BookingSessionCancelledStatus},
+		},
+	}
+	idToUser := map[uuid.UUID]*model.User{photographer.Id: photographer, customer.Id: customer}
+
+	events := calendarEvents(photographer.Id, []*model.Booking{booking}, idToUser)
+	require.Len(t, events, 2)
+	assert.Equal(t, "Ceremony: Weddings with Anna Lee", events[0].Summary)
+	assert.Equal(t, "Chiang Mai", events[0].Location)
+	assert.Equal(t, ical.StatusConfirmed, events[0].Status)
+	assert.Contains(t, events[0].Description, "Customer: Anna Lee, anna@example.com, 0812345678")
+	assert.True(t, strings.HasSuffix(events[0].Uid, "@pic-keeper"))
+	assert.Equal(t, ical.StatusCancelled, events[1].Status)
+	assert.Equal(t, 1, events[1].Sequence)
+
+	events = calendarEvents(customer.Id, []*model.Booking{booking}, idToUser)
+	assert.Equal(t, "Ceremony: Weddings with Somchai Dee", events[0].Summary)
+
+	booking.Status = model.BookingCancelledStatus
+	events = calendarEvents(customer.Id, []*model.Booking{booking}, map[uuid.UUID]*model.User{})
+	assert.Equal(t, ical.StatusCancelled, events[0].Status)
+	assert.Equal(t, "Ceremony: Weddings with a deleted user", events[0].Summary)
+}
+
+func TestBusyBlocksFromCalendar(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	calendar := &ical.Calendar{Events: []*ical.Event{
+		{Uid: "past", Start: now.Add(-3 * time.Hour), End: now.Add(-time.Hour)},
+		{Uid: "ongoing", Summary: "Studio", Start: now.Add(-time.Hour), End: now.Add(time.Hour)},
+		{Uid: "free", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), Transparent: true},
+		{Uid: "cancelled", Start: now.Add(time.Hour), End: now.Add(2 * time.Hour), Status: ical.StatusCancelled},
+		{Uid: "weekly", Start: now.Add(-7 * 24 * time.Hour), End: now.Add(-7*24*time.Hour + time.Hour), Rule: "FREQ=WEEKLY;COUNT=3"},
+	}}
+
+	blocks, err := busyBlocksFromCalendar(calendar, uuid.New(), now)
+	require.NoError(t, err)
+	uids := []string{}
+	for _, block := range blocks {
+		uids = append(uids, block.Uid)
+	}
+	// the first occurrence of the weekly event is over
+	assert.Equal(t, []string{"ongoing", "weekly", "weekly"}, uids)
+	assert.Equal(t, "Studio", *blocks[0].Summary)
+	assert.Nil(t, blocks[1].Summary)
+	assert.Equal(t, now, blocks[1].StartTime)
+	assert.Equal(t, now.Add(7*24*time.Hour), blocks[2].StartTime)
+}
+
+func TestBusyBlockOverlap(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	block := &model.BusyBlock{StartTime: start.Add(2 * time.Hour), EndTime: start.Add(4 * time.Hour)}
+	blocks := []*model.BusyBlock{block}
+
+	before := &model.BookingSession{StartTime: start, EndTime: start.Add(2 * time.Hour)}
+	assert.Nil(t, busyBlockOverlap([]*model.BookingSession{before}, blocks))
+
+	during := &model.BookingSession{StartTime: start.Add(3 * time.Hour), EndTime: start.Add(5 * time.Hour)}
+	assert.Equal(t, block, busyBlockOverlap([]*model.BookingSession{before, during}, blocks))
+
+	during.Status = model.BookingSessionCancelledStatus
+	assert.Nil(t, busyBlockOverlap([]*model.BookingSession{before, during}, blocks))
+}
@@ -2,24 +2,108 @@ package usecase
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/repository"
 	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+// ChatCardChannel is the redis channel the chat servers listen on to push the cards to the members of a room
+const ChatCardChannel = "chat_cards"
+
 type ConversationUseCase struct {
-	ConversationRepo repository.Conversation
+	ConversationRepo   repository.Conversation
+	BookingRequestRepo repository.BookingRequest
+	BookingQuoteRepo   repository.BookingQuote
 }
 
 func NewConversationUseCase(db *bun.DB) *ConversationUseCase {
 	return &ConversationUseCase{
-		ConversationRepo: postgres.NewConversationDB(db),
+		ConversationRepo:   postgres.NewConversationDB(db),
+		BookingRequestRepo: postgres.NewBookingRequestDB(db),
+		BookingQuoteRepo:   postgres.NewBookingQuoteDB(db),
 	}
 }
 
 func (c *ConversationUseCase) ListByRoomId(ctx context.Context, roomId uuid.UUID) ([]*model.Conversation, error) {
-	return c.ConversationRepo.ListByRoomId(ctx, roomId)
+	conversations, err := c.ConversationRepo.ListByRoomId(ctx, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	return conversations, c.PopulateCards(ctx, conversations...)
+}
+
+// PopulateCards attaches the current state of the booking request or the quote of every card
+func (c *ConversationUseCase) PopulateCards(ctx context.Context, conversations ...*model.Conversation) error {
+	requestIds, quoteIds := []uuid.UUID{}, []uuid.UUID{}
+	for _, conversation := range conversations {
+		switch {
+		case conversation.ResourceId == nil:
+		case conversation.Kind == model.ConversationBookingRequestKind:
+			requestIds = append(requestIds, *conversation.ResourceId)
+		case conversation.Kind == model.ConversationBookingQuoteKind:
+			quoteIds = append(quoteIds, *conversation.ResourceId)
+		}
+	}
+
+	requestIdMapping := map[uuid.UUID]*model.BookingRequest{}
+	if len(requestIds) > 0 {
+		requests, err := c.BookingRequestRepo.FindByIds(ctx, requestIds...)
+		if err != nil {
+			return err
+		}
+		for _, request := range requests {
+			requestIdMapping[request.Id] = request
+		}
+	}
+
+	quoteIdMapping := map[uuid.UUID]*model.BookingQuote{}
+	if len(quoteIds) > 0 {
+		quotes, err := c.BookingQuoteRepo.FindByIds(ctx, quoteIds...)
+		if err != nil {
+			return err
+		}
+		for _, quote := range quotes {
+			quoteIdMapping[quote.Id] = quote
+		}
+	}
+
+	for _, conversation := range conversations {
+		if conversation.ResourceId == nil {
+			continue
+		}
+
+		conversation.BookingRequest = requestIdMapping[*conversation.ResourceId]
+		conversation.BookingQuote = quoteIdMapping[*conversation.ResourceId]
+	}
+
+	return nil
+}
+
+// PublishCard pushes the card with the current state of its request or quote to the chat servers
+func (c *ConversationUseCase) PublishCard(ctx context.Context, job model.ChatCardJob) error {
+	card, err := c.ConversationRepo.FindOneById(ctx, job.ConversationId)
+	if err != nil {
+		return err
+	}
+
+	if err := c.PopulateCards(ctx, card); err != nil {
+		return err
+	}
+
+	if databases.RedisClient == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(card)
+	if err != nil {
+		return err
+	}
+
+	return databases.RedisClient.Publish(ctx, ChatCardChannel, payload).Err()
 }
@@ -0,0 +1,376 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+var (
+	ErrInvalidCoupon          = errors.New("a coupon needs a code of 3 to 32 letters, digits, dashes or underscores, a kind of PERCENTAGE or FIXED and a positive value, a percentage is at most 100")
+	ErrInvalidCouponWindow    = errors.New("the coupon must end after it starts")
+	ErrInvalidCouponLimit     = errors.New("the minimum spend cannot be negative and the redemption limits must be positive")
+	ErrCouponCodeTaken        = errors.New("the coupon code is already taken")
+	ErrCouponGalleryNotOwned  = errors.New("the coupon can only be restricted to existing galleries of its photographer")
+	ErrCouponNotFound         = errors.New("the coupon code does not exist")
+	ErrCouponNotActive        = errors.New("the coupon is not valid at this time")
+	ErrCouponNotApplicable    = errors.New("the coupon does not apply to this gallery")
+	ErrCouponMinimumSpend     = errors.New("the price of the booking is below the minimum spend of the coupon")
+	ErrCouponExhausted        = errors.New("the coupon has reached its redemption limit")
+	ErrCouponUserLimitReached = errors.New("you have already used this coupon as many times as allowed")
+	ErrCouponAlreadyApplied   = errors.New("a coupon has already been applied to this booking")
+	ErrCouponBookingNotDraft  = errors.New("a coupon can only be applied to a booking that has not been paid yet")
+)
+
+var couponKinds = []string{model.CouponPercentageKind, model.CouponFixedKind}
+
+type CouponUseCase struct {
+	CouponRepo           repository.Coupon
+	CouponRedemptionRepo repository.CouponRedemption
+	GalleryRepo          repository.Gallery
+	ExchangeRateRepo     repository.ExchangeRate
+	UnitOfWork           repository.UnitOfWork
+}
+
+func NewCouponUseCase(db *bun.DB) *CouponUseCase {
+	return &CouponUseCase{
+		CouponRepo:           postgres.NewCouponDB(db),
+		CouponRedemptionRepo: postgres.NewCouponRedemptionDB(db),
+		GalleryRepo:          postgres.NewGalleryDB(db),
+		ExchangeRateRepo:     postgres.NewExchangeRateDB(db),
+		UnitOfWork:           postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// Create adds a coupon of the photographer, or a platform-wide one when the photographer is nil
+func (c *CouponUseCase) Create(ctx context.Context, creatorId uuid.UUID, photographerId *uuid.UUID, input model.CouponInput) (*model.Coupon, error) {
+	coupon := &model.Coupon{
+		Id:             uuid.New(),
+		PhotographerId: photographerId,
+		Currency:       model.BaseCurrency,
+		GalleryIds:     []uuid.UUID{},
+		Locations:      []string{},
+		IsActive:       true,
+		CreatedBy:      &creatorId,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+
+	if input.Code == nil || input.Kind == nil || input.Value == nil {
+		return nil, ErrInvalidCoupon
+	}
+	if err := c.applyInput(ctx, coupon, input); err != nil {
+		return nil, err
+	}
+
+	if err := c.CouponRepo.AddOne(ctx, coupon); err != nil {
+		return nil, err
+	}
+
+	return coupon, nil
+}
+
+func (c *CouponUseCase) Update(ctx context.Context, coupon *model.Coupon, input model.CouponInput) error {
+	if err := c.applyInput(ctx, coupon, input); err != nil {
+		return err
+	}
+
+	coupon.UpdatedAt = time.Now()
+	return c.CouponRepo.UpdateOne(ctx, coupon)
+}
+
+// applyInput also makes sure that the code is free, that the currency has an exchange rate and that the galleries
+// the coupon is restricted to exist and, for the coupon of a photographer, are theirs
+func (c *CouponUseCase) applyInput(ctx context.Context, coupon *model.Coupon, input model.CouponInput) error {
+	if err := applyCouponInput(coupon, input); err != nil {
+		return err
+	}
+
+	if input.Code != nil {
+		existing, err := c.CouponRepo.FindOneByCode(ctx, coupon.Code)
+		if err == nil && existing.Id != coupon.Id {
+			return ErrCouponCodeTaken
+		}
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+	}
+
+	if input.Currency != nil {
+		rates, err := loadRates(ctx, c.ExchangeRateRepo)
+		if err != nil {
+			return err
+		}
+		if _, ok := rates[coupon.Currency]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, coupon.Currency)
+		}
+	}
+
+	if len(input.GalleryIds) > 0 {
+		galleries, err := c.GalleryRepo.FindByIds(ctx, coupon.GalleryIds...)
+		if err != nil {
+			return err
+		}
+
+		if len(galleries) != len(coupon.GalleryIds) {
+			return ErrCouponGalleryNotOwned
+		}
+		for _, gallery := range galleries {
+			if coupon.PhotographerId != nil && gallery.PhotographerId != *coupon.PhotographerId {
+				return ErrCouponGalleryNotOwned
+			}
+		}
+	}
+
+	return nil
+}
+
+func applyCouponInput(coupon *model.Coupon, input model.CouponInput) error {
+	if input.Code != nil {
+		code := normaliseCouponCode(*input.Code)
+		if !couponCodePattern.MatchString(code) {
+			return ErrInvalidCoupon
+		}
+		coupon.Code = code
+	}
+
+	kind, value := coupon.Kind, coupon.Value
+	if input.Kind != nil {
+		kind = *input.Kind
+	}
+	if input.Value != nil {
+		value = *input.Value
+	}
+	if !slices.Contains(couponKinds, kind) || value <= 0 || (kind == model.CouponPercentageKind && value > 100) {
+		return ErrInvalidCoupon
+	}
+	coupon.Kind, coupon.Value = kind, value
+
+	if input.Currency != nil {
+		coupon.Currency = NormaliseCurrency(*input.Currency)
+	}
+
+	if input.MinSpend != nil {
+		if *input.MinSpend < 0 {
+			return ErrInvalidCouponLimit
+		}
+		coupon.MinSpend = *input.MinSpend
+	}
+	for _, limit := range []*int{input.MaxRedemptions, input.MaxRedemptionsPerUser} {
+		if limit != nil && *limit <= 0 {
+			return ErrInvalidCouponLimit
+		}
+	}
+	if input.MaxRedemptions != nil {
+		coupon.MaxRedemptions = input.MaxRedemptions
+	}
+	if input.MaxRedemptionsPerUser != nil {
+		coupon.MaxRedemptionsPerUser = input.MaxRedemptionsPerUser
+	}
+
+	if input.StartsAt != nil {
+		coupon.StartsAt = input.StartsAt
+	}
+	if input.EndsAt != nil {
+		coupon.EndsAt = input.EndsAt
+	}
+	if coupon.StartsAt != nil && coupon.EndsAt != nil && !coupon.EndsAt.After(*coupon.StartsAt) {
+		return ErrInvalidCouponWindow
+	}
+
+	if input.GalleryIds != nil {
+		galleryIds := slices.Clone(input.GalleryIds)
+		slices.SortFunc(galleryIds, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+		coupon.GalleryIds = slices.Compact(galleryIds)
+	}
+	if input.Locations != nil {
+		coupon.Locations = input.Locations
+	}
+	if input.IsActive != nil {
+		coupon.IsActive = *input.IsActive
+	}
+
+	return nil
+}
+
+// Redeem applies the coupon of the code to a DRAFT booking whose room has been populated. The discount is added to
+// the price breakdown and recorded as a redemption, and the pending instalments are scaled down to the new price
+// while keeping their due dates.
+func (c *CouponUseCase) Redeem(ctx context.Context, booking *model.Booking, code string, bookingUsecase BookingUseCase) (*model.CouponRedemption, error) {
+	var redemption *model.CouponRedemption
+	if err := c.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		// the booking may have been paid since it was read
+		if err := bookingUsecase.lockBooking(ctx, booking); err != nil {
+			return err
+		}
+		if booking.Status != model.BookingDraftStatus {
+			return ErrCouponBookingNotDraft
+		}
+
+		_, err := c.CouponRedemptionRepo.FindOneByBookingId(ctx, booking.Id)
+		if err == nil {
+			return ErrCouponAlreadyApplied
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		coupon, err := c.CouponRepo.FindOneByCode(ctx, normaliseCouponCode(code))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCouponNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		redeemed, err := c.CouponRedemptionRepo.CountRedeemed(ctx, coupon.Id, nil)
+		if err != nil {
+			return err
+		}
+
+		redeemedByUser, err := c.CouponRedemptionRepo.CountRedeemed(ctx, coupon.Id, &booking.CustomerId)
+		if err != nil {
+			return err
+		}
+
+		rates, err := loadRates(ctx, c.ExchangeRateRepo)
+		if err != nil {
+			return err
+		}
+
+		rate, err := exchangeRate(rates, coupon.Currency, booking.Currency)
+		if err != nil {
+			return err
+		}
+
+		discount, err := couponDiscount(couponInCurrency(coupon, rate), &booking.Room.Gallery, booking.ResultedPrice, redeemed, redeemedByUser, time.Now())
+		if err != nil {
+			return err
+		}
+
+		lineItems, err := bookingUsecase.LineItemRepo.FindByBookingIds(ctx, booking.Id)
+		if err != nil {
+			return err
+		}
+
+		if err := bookingUsecase.LineItemRepo.AddOne(ctx, &model.BookingLineItem{
+			Id:          uuid.New(),
+			BookingId:   booking.Id,
+			Position:    len(lineItems),
+			Kind:        model.LineItemCouponKind,
+			Description: fmt.Sprintf("Coupon %s", coupon.Code),
+			Quantity:    1,
+			UnitPrice:   -discount,
+			Amount:      -discount,
+		}); err != nil {
+			return err
+		}
+
+		redemption = &model.CouponRedemption{
+			Id:        uuid.New(),
+			CouponId:  coupon.Id,
+			BookingId: booking.Id,
+			UserId:    booking.CustomerId,
+			Code:      coupon.Code,
+			Subtotal:  booking.ResultedPrice,
+			Amount:    discount,
+			CreatedAt: time.Now(),
+		}
+		if err := c.CouponRedemptionRepo.AddOne(ctx, redemption); err != nil {
+			return err
+		}
+
+		booking.ResultedPrice -= discount
+		booking.UpdatedAt = time.Now()
+		if err := bookingUsecase.BookingRepo.UpdateOne(ctx, booking); err != nil {
+			return err
+		}
+
+		instalments, err := bookingUsecase.InstalmentRepo.FindByBookingIds(ctx, booking.Id)
+		if err != nil {
+			return err
+		}
+
+		for _, instalment := range rescaleInstalments(instalments, booking.ResultedPrice) {
+			instalment.UpdatedAt = time.Now()
+			if err := bookingUsecase.InstalmentRepo.UpdateOne(ctx, instalment); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if err := bookingUsecase.PopulateDetails(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	return redemption, nil
+}
+
+// couponDiscount is the amount the coupon takes off the price of a booking of the gallery, given the number of times
+// it has been redeemed in total and by the customer
+func couponDiscount(coupon *model.Coupon, gallery *model.Gallery, price, redeemed, redeemedByUser int, now time.Time) (int, error) {
+	if !coupon.IsActive || (coupon.StartsAt != nil && now.Before(*coupon.StartsAt)) || (coupon.EndsAt != nil && now.After(*coupon.EndsAt)) {
+		return 0, ErrCouponNotActive
+	}
+
+	if coupon.PhotographerId != nil && *coupon.PhotographerId != gallery.PhotographerId {
+		return 0, ErrCouponNotApplicable
+	}
+	if len(coupon.GalleryIds) > 0 && !slices.Contains(coupon.GalleryIds, gallery.Id) {
+		return 0, ErrCouponNotApplicable
+	}
+	if len(coupon.Locations) > 0 && !slices.ContainsFunc(coupon.Locations, func(location string) bool {
+		return strings.EqualFold(strings.TrimSpace(location), strings.TrimSpace(gallery.Location))
+	}) {
+		return 0, ErrCouponNotApplicable
+	}
+
+	if price <= 0 || price < coupon.MinSpend {
+		return 0, ErrCouponMinimumSpend
+	}
+
+	if coupon.MaxRedemptions != nil && redeemed >= *coupon.MaxRedemptions {
+		return 0, ErrCouponExhausted
+	}
+	if coupon.MaxRedemptionsPerUser != nil && redeemedByUser >= *coupon.MaxRedemptionsPerUser {
+		return 0, ErrCouponUserLimitReached
+	}
+
+	if coupon.Kind == model.CouponPercentageKind {
+		return price * coupon.Value / 100, nil
+	}
+
+	return min(coupon.Value, price), nil
+}
+
+// couponInCurrency converts the amount off and the minimum spend of the coupon at the rate, a percentage stays as is
+func couponInCurrency(coupon *model.Coupon, rate float64) *model.Coupon {
+	converted := *coupon
+	converted.MinSpend = convertAmount(coupon.MinSpend, rate)
+	if coupon.Kind == model.CouponFixedKind {
+		converted.Value = convertAmount(coupon.Value, rate)
+	}
+
+	return &converted
+}
+
+func normaliseCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCouponDiscount(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	photographerId := uuid.New()
+	gallery := &model.Gallery{Id: uuid.New(), PhotographerId: photographerId, Location: "Bangkok"}
+	maxPerUser := 1
+	coupon := &model.Coupon{
+		PhotographerId:        &photographerId,
+		Kind:                  model.CouponPercentageKind,
+		Value:                 10,
+		MinSpend:              5000,
+		MaxRedemptionsPerUser: &maxPerUser,
+		Locations:             []string{"bangkok"},
+		IsActive:              true,
+	}
+
+	discount, err := couponDiscount(coupon, gallery, 12000, 3, 0, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1200, discount)
+
+	_, err = couponDiscount(coupon, gallery, 4000, 3, 0, now)
+	assert.ErrorIs(t, err, ErrCouponMinimumSpend)
+
+	_, err = couponDiscount(coupon, gallery, 12000, 3, 1, now)
+	assert.ErrorIs(t, err, ErrCouponUserLimitReached)
+
+	_, err = couponDiscount(coupon, &model.Gallery{PhotographerId: uuid.New(), Location: "Bangkok"}, 12000, 0, 0, now)
+	assert.ErrorIs(t, err, ErrCouponNotApplicable)
+
+	endsAt := now.Add(-time.Hour)
+	coupon.EndsAt = &endsAt
+	_, err = couponDiscount(coupon, gallery, 12000, 0, 0, now)
+	assert.ErrorIs(t, err, ErrCouponNotActive)
+
+	// a fixed amount never takes the price below zero
+	coupon.EndsAt = nil
+	coupon.Kind, coupon.Value, coupon.MinSpend = model.CouponFixedKind, 20000, 0
+	discount, err = couponDiscount(coupon, gallery, 12000, 0, 0, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 12000, discount)
+}
+
+func TestApplyCouponInput(t *testing.T) {
+	code, kind, value := " summer-24 ", model.CouponPercentageKind, 15
+	coupon := &model.Coupon{}
+
+	assert.NoError(t, applyCouponInput(coupon, model.CouponInput{Code: &code, Kind: &kind, Value: &value}))
+	assert.Equal(t, "SUMMER-24", coupon.Code)
+
+	value = 150
+	assert.ErrorIs(t, applyCouponInput(coupon, model.CouponInput{Value: &value}), ErrInvalidCoupon)
+
+	startsAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	endsAt := startsAt.AddDate(0, 0, -1)
+	assert.ErrorIs(t, applyCouponInput(coupon, model.CouponInput{StartsAt: &startsAt, EndsAt: &endsAt}), ErrInvalidCouponWindow)
+}
+
+func TestRescaleInstalments(t *testing.T) {
+	deposit := &model.BookingInstalment{Amount: 3000, Status: model.InstalmentPendingStatus}
+	balance := &model.BookingInstalment{Amount: 7000, Status: model.InstalmentPendingStatus}
+
+	changed := rescaleInstalments([]*model.BookingInstalment{deposit, balance}, 9001)
+	assert.Len(t, changed, 2)
+	assert.Equal(t, 2700, deposit.Amount)
+	assert.Equal(t, 6301, balance.Amount)
+}
+
+func TestRedeemRejectsBookingPaidMeanwhile(t *testing.T) {
+	booking := &model.Booking{Id: uuid.New(), Status: model.BookingDraftStatus}
+	bookings := &fakeBookings{locked: &model.Booking{Id: booking.Id, Status: model.BookingPaidStatus}}
+	couponUsecase := &CouponUseCase{UnitOfWork: fakeUnitOfWork{}}
+
+	_, err := couponUsecase.Redeem(context.Background(), booking, "SUMMER", BookingUseCase{BookingRepo: bookings})
+	assert.ErrorIs(t, err, ErrCouponBookingNotDraft)
+	assert.Nil(t, bookings.updated)
+	assert.Equal(t, model.BookingPaidStatus, booking.Status)
+}
@@ -0,0 +1,146 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/uptrace/bun"
+)
+
+var currencyCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+var (
+	ErrUnsupportedCurrency = errors.New("the currency has no exchange rate")
+	ErrInvalidExchangeRate = errors.New("a rate needs the 3-letter ISO 4217 code of a currency other than THB and a positive value")
+)
+
+type CurrencyUseCase struct {
+	ExchangeRateRepo repository.ExchangeRate
+}
+
+func NewCurrencyUseCase(db *bun.DB) *CurrencyUseCase {
+	return &CurrencyUseCase{
+		ExchangeRateRepo: postgres.NewExchangeRateDB(db),
+	}
+}
+
+// CheckSupported returns ErrUnsupportedCurrency unless the currency is the base currency or has a rate
+func (c *CurrencyUseCase) CheckSupported(ctx context.Context, currency string) error {
+	rates, err := loadRates(ctx, c.ExchangeRateRepo)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := rates[currency]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	return nil
+}
+
+// UpdateRates replaces the rates of the currencies of the input, the rates of the other currencies are kept
+func (c *CurrencyUseCase) UpdateRates(ctx context.Context, input model.ExchangeRateInput, source string) ([]*model.ExchangeRate, error) {
+	if len(input.Rates) == 0 {
+		return nil, ErrInvalidExchangeRate
+	}
+
+	rates := []*model.ExchangeRate{}
+	for currency, rate := range input.Rates {
+		currency = NormaliseCurrency(currency)
+		if !currencyCodePattern.MatchString(currency) || currency == model.BaseCurrency || !(rate > 0) || math.IsInf(rate, 0) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidExchangeRate, currency)
+		}
+
+		rates = append(rates, &model.ExchangeRate{
+			Currency:  currency,
+			Rate:      rate,
+			Source:    source,
+			UpdatedAt: time.Now(),
+		})
+	}
+	slices.SortFunc(rates, func(a, b *model.ExchangeRate) int { return strings.Compare(a.Currency, b.Currency) })
+
+	if err := c.ExchangeRateRepo.Upsert(ctx, rates); err != nil {
+		return nil, err
+	}
+
+	return rates, nil
+}
+
+// EstimatePrices sets the price of every gallery in the currency of the filter, and keeps the galleries whose
+// estimated price is within the price bounds of the filter
+func (c *CurrencyUseCase) EstimatePrices(ctx context.Context, galleries []*model.Gallery, filter *model.SearchFilter) ([]*model.Gallery, error) {
+	rates, err := loadRates(ctx, c.ExchangeRateRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	estimated := []*model.Gallery{}
+	for _, gallery := range galleries {
+		rate, err := exchangeRate(rates, gallery.Currency, *filter.Currency)
+		if err != nil {
+			return nil, err
+		}
+
+		gallery.EstimatedPrice = &model.Price{Amount: convertAmount(gallery.Price, rate), Currency: *filter.Currency}
+		if filter.MinPrice != nil && gallery.EstimatedPrice.Amount < *filter.MinPrice {
+			continue
+		}
+		if filter.MaxPrice != nil && gallery.EstimatedPrice.Amount > *filter.MaxPrice {
+			continue
+		}
+
+		estimated = append(estimated, gallery)
+	}
+
+	return estimated, nil
+}
+
+// NormaliseCurrency upper-cases a currency code given by a user
+func NormaliseCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
+
+// loadRates returns the rates keyed by their currency, the base currency included
+func loadRates(ctx context.Context, repo repository.ExchangeRate) (map[string]float64, error) {
+	rates, err := repo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	currencyToRate := map[string]float64{model.BaseCurrency: 1}
+	for _, rate := range rates {
+		currencyToRate[rate.Currency] = rate.Rate
+	}
+
+	return currencyToRate, nil
+}
+
+// exchangeRate is the amount of the currency to that one unit of the currency from buys
+func exchangeRate(rates map[string]float64, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	for _, currency := range []string{from, to} {
+		if _, ok := rates[currency]; !ok {
+			return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+		}
+	}
+
+	return rates[to] / rates[from], nil
+}
+
+// convertAmount rounds the converted amount to the nearest whole unit, as every price is
+func convertAmount(amount int, rate float64) int {
+	return int(math.Round(float64(amount) * rate))
+}
@@ -0,0 +1,39 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExchangeRate(t *testing.T) {
+	rates := map[string]float64{model.BaseCurrency: 1, "USD": 0.025, "EUR": 0.02}
+
+	rate, err := exchangeRate(rates, model.BaseCurrency, "USD")
+	assert.NoError(t, err)
+	assert.Equal(t, 250, convertAmount(10000, rate))
+
+	rate, err = exchangeRate(rates, "USD", "EUR")
+	assert.NoError(t, err)
+	assert.Equal(t, 80, convertAmount(100, rate))
+
+	rate, err = exchangeRate(rates, "JPY", "JPY")
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	_, err = exchangeRate(rates, model.BaseCurrency, "JPY")
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestCouponInCurrency(t *testing.T) {
+	coupon := &model.Coupon{Kind: model.CouponFixedKind, Value: 10, MinSpend: 100, Currency: "USD"}
+
+	converted := couponInCurrency(coupon, 40)
+	assert.Equal(t, 400, converted.Value)
+	assert.Equal(t, 4000, converted.MinSpend)
+	assert.Equal(t, 10, coupon.Value)
+
+	coupon.Kind = model.CouponPercentageKind
+	assert.Equal(t, 10, couponInCurrency(coupon, 40).Value)
+}
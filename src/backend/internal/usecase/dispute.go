@@ -0,0 +1,541 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	MaxDisputePhotos       = 5
+	MaxDisputePhotoSize    = 5 << 20
+	MaxDisputeExcerptLines = 50
+	disputePhotoURLTTL     = 15 * time.Minute
+)
+
+// the bookings that can be disputed, the ones paid out are settled
+var disputableBookingStatuses = map[string]bool{
+	model.BookingPaidStatus:      true,
+	model.BookingCompletedStatus: true,
+}
+
+var (
+	ErrBookingNotDisputable   = errors.New("only a paid or completed booking that has not been paid out can be disputed")
+	ErrBookingAlreadyDisputed = errors.New("the booking is already disputed or its dispute has been resolved")
+	ErrDisputeClosed          = errors.New("the dispute is closed")
+	ErrEmptyEvidence          = errors.New("the evidence needs a text, a photo or a chat excerpt")
+	ErrInvalidDisputePhoto    = fmt.Errorf("a photo must be an image of up to %d MB", MaxDisputePhotoSize>>20)
+	ErrTooManyDisputePhotos   = fmt.Errorf("the evidence can have up to %d photos", MaxDisputePhotos)
+	ErrInvalidExcerpt         = fmt.Errorf("a chat excerpt can quote up to %d messages of the chat of the booking", MaxDisputeExcerptLines)
+	ErrNotDisputeOpener       = errors.New("only the party who opened the dispute can withdraw it")
+	ErrInvalidDisputeSplit    = errors.New("the customer cannot be credited more than the disputed amount")
+)
+
+type DisputeUseCase struct {
+	DisputeRepo      repository.Dispute
+	EvidenceRepo     repository.DisputeEvidence
+	InstalmentRepo   repository.BookingInstalment
+	ConversationRepo repository.Conversation
+	AuditRepo        repository.AuditEntry
+	JobRepo          repository.Job
+	UnitOfWork       repository.UnitOfWork
+}
+
+func NewDisputeUseCase(db *bun.DB) *DisputeUseCase {
+	return &DisputeUseCase{
+		DisputeRepo:      postgres.NewDisputeDB(db),
+		EvidenceRepo:     postgres.NewDisputeEvidenceDB(db),
+		InstalmentRepo:   postgres.NewBookingInstalmentDB(db),
+		ConversationRepo: postgres.NewConversationDB(db),
+		AuditRepo:        postgres.NewAuditEntryDB(db),
+		JobRepo:          postgres.NewJobDB(db),
+		UnitOfWork:       postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// Open disputes the amount paid for a booking whose room has been populated, the booking is DISPUTED and is not
+// paid out until the dispute is withdrawn or resolved. The other party of the booking is notified.
+func (d *DisputeUseCase) Open(ctx context.Context, booking *model.Booking, openerId uuid.UUID, input model.DisputeInput, bookingUsecase BookingUseCase) (*model.Dispute, error) {
+	if !disputableBookingStatuses[booking.Status] || booking.PaymentCurrency == nil {
+		return nil, ErrBookingNotDisputable
+	}
+	status := booking.Status
+
+	latest, err := d.DisputeRepo.FindLatestByBookingId(ctx, booking.Id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if latest != nil && latest.Status != model.DisputeWithdrawnStatus {
+		return nil, ErrBookingAlreadyDisputed
+	}
+
+	instalments, err := d.InstalmentRepo.FindByBookingIds(ctx, booking.Id)
+	if err != nil {
+		return nil, err
+	}
+	amount := disputedAmount(instalments)
+	if amount == 0 {
+		return nil, ErrBookingNotDisputable
+	}
+
+	now := time.Now()
+	dispute := &model.Dispute{
+		Id:             uuid.New(),
+		BookingId:      booking.Id,
+		OpenedById:     &openerId,
+		Reason:         input.Reason,
+		Description:    input.Description,
+		Status:         model.DisputeOpenStatus,
+		BookingStatus:  status,
+		DisputedAmount: amount,
+		Currency:       *booking.PaymentCurrency,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+		Evidence:       []*model.DisputeEvidence{},
+	}
+
+	if err := doCountingTransitions(ctx, d.UnitOfWork, func(ctx context.Context) error {
+		// the routine may have paid the booking out since it was read
+		if err := bookingUsecase.lockBooking(ctx, booking); err != nil {
+			return err
+		}
+		if booking.Status != status {
+			return ErrBookingNotDisputable
+		}
+
+		if err := d.DisputeRepo.AddOne(ctx, dispute); err != nil {
+			return err
+		}
+
+		if err := bookingUsecase.updateStatus(ctx, booking, model.BookingDisputedStatus); err != nil {
+			return err
+		}
+
+		return d.notify(ctx, dispute, booking, openerId, model.NotificationDisputeOpenedType, nil)
+	}); err != nil {
+		return nil, err
+	}
+
+	dispute.Booking = booking
+	return dispute, nil
+}
+
+// AddEvidence adds the text, the photos and the excerpt of the chat of the booking submitted by a party or the
+// mediator to an open dispute, the others involved in the dispute are notified
+func (d *DisputeUseCase) AddEvidence(ctx context.Context, dispute *model.Dispute, booking *model.Booking, submitterId uuid.UUID, party string, text string, photos [][]byte, conversationIds []uuid.UUID) (*model.DisputeEvidence, error) {
+	if dispute.Status != model.DisputeOpenStatus {
+		return nil, ErrDisputeClosed
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" && len(photos) == 0 && len(conversationIds) == 0 {
+		return nil, ErrEmptyEvidence
+	}
+
+	contentTypes, err := checkDisputePhotos(photos)
+	if err != nil {
+		return nil, err
+	}
+
+	excerpt, err := d.chatExcerpt(ctx, booking, conversationIds)
+	if err != nil {
+		return nil, err
+	}
+
+	evidence := &model.DisputeEvidence{
+		Id:            uuid.New(),
+		DisputeId:     dispute.Id,
+		SubmittedById: &submitterId,
+		Party:         party,
+		PhotoKeys:     []string{},
+		Excerpt:       excerpt,
+		CreatedAt:     time.Now(),
+	}
+	if text != "" {
+		evidence.Text = &text
+	}
+
+	var bucket *s3utils.BucketBasics
+	if len(photos) > 0 {
+		if bucket, err = s3utils.GetInstance(); err != nil {
+			return nil, err
+		}
+	}
+
+	for idx, photo := range photos {
+		key := fmt.Sprintf("%s/%s", dispute.Id, uuid.New())
+		if err := bucket.UploadFile(ctx, s3utils.DisputeEvidenceBucket, key, bytes.NewBuffer(photo), contentTypes[idx]); err != nil {
+			deleteDisputePhotos(ctx, bucket, evidence.PhotoKeys)
+			return nil, err
+		}
+		evidence.PhotoKeys = append(evidence.PhotoKeys, key)
+	}
+
+	if err := d.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := d.EvidenceRepo.AddOne(ctx, evidence); err != nil {
+			return err
+		}
+
+		dispute.UpdatedAt = evidence.CreatedAt
+		if err := d.DisputeRepo.UpdateOne(ctx, dispute); err != nil {
+			return err
+		}
+
+		return d.notify(ctx, dispute, booking, submitterId, model.NotificationDisputeEvidenceAddedType, evidence.Text)
+	}); err != nil {
+		deleteDisputePhotos(ctx, bucket, evidence.PhotoKeys)
+		return nil, err
+	}
+
+	if err := presignDisputeEvidence(ctx, evidence); err != nil {
+		return nil, err
+	}
+
+	return evidence, nil
+}
+
+// Withdraw closes the dispute at the request of the party who opened it, the booking goes back to the status it
+// had and the others involved are notified
+func (d *DisputeUseCase) Withdraw(ctx context.Context, dispute *model.Dispute, booking *model.Booking, userId uuid.UUID, bookingUsecase BookingUseCase) error {
+	if dispute.Status != model.DisputeOpenStatus {
+		return ErrDisputeClosed
+	}
+	if dispute.OpenedById == nil || *dispute.OpenedById != userId {
+		return ErrNotDisputeOpener
+	}
+
+	return doCountingTransitions(ctx, d.UnitOfWork, func(ctx context.Context) error {
+		if err := d.lockDisputedBooking(ctx, booking, bookingUsecase); err != nil {
+			return err
+		}
+
+		dispute.Status = model.DisputeWithdrawnStatus
+		dispute.UpdatedAt = time.Now()
+		if err := d.DisputeRepo.UpdateOne(ctx, dispute); err != nil {
+			return err
+		}
+
+		if err := bookingUsecase.updateStatus(ctx, booking, dispute.BookingStatus); err != nil {
+			return err
+		}
+
+		return d.notify(ctx, dispute, booking, userId, model.NotificationDisputeWithdrawnType, nil)
+	})
+}
+
+// Mediate makes the administrator the mediator of the dispute
+func (d *DisputeUseCase) Mediate(ctx context.Context, dispute *model.Dispute, mediatorId uuid.UUID) error {
+	if dispute.Status != model.DisputeOpenStatus {
+		return ErrDisputeClosed
+	}
+
+	before := *dispute
+	dispute.MediatorId = &mediatorId
+	dispute.UpdatedAt = time.Now()
+	return d.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := d.DisputeRepo.UpdateOne(ctx, dispute); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, d.AuditRepo, model.AuditDisputeMediateAction, model.AuditDisputeTarget, &dispute.Id, &before, dispute)
+	})
+}
+
+// Resolve splits the disputed amount between the parties, the customer's share is credited by a credit note. The
+// booking is cancelled when the customer gets everything back and completed otherwise, so that the photographer's
+// share is paid out. The room of the booking has to be populated.
+func (d *DisputeUseCase) Resolve(ctx context.Context, dispute *model.Dispute, booking *model.Booking, mediatorId uuid.UUID, input model.DisputeResolutionInput, bookingUsecase BookingUseCase) error {
+	if dispute.Status != model.DisputeOpenStatus {
+		return ErrDisputeClosed
+	}
+
+	customerAmount, photographerAmount, err := splitDispute(dispute.DisputedAmount, *input.CustomerAmount)
+	if err != nil {
+		return err
+	}
+
+	bookingStatus := model.BookingCompletedStatus
+	if photographerAmount == 0 {
+		bookingStatus = model.BookingCancelledStatus
+	}
+
+	before := *dispute
+	return doCountingTransitions(ctx, d.UnitOfWork, func(ctx context.Context) error {
+		if err := d.lockDisputedBooking(ctx, booking, bookingUsecase); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		dispute.Status = model.DisputeResolvedStatus
+		dispute.MediatorId = &mediatorId
+		dispute.CustomerAmount = &customerAmount
+		dispute.PhotographerAmount = &photographerAmount
+		dispute.Resolution = &input.Resolution
+		dispute.ResolvedAt = &now
+		dispute.UpdatedAt = now
+		if err := d.DisputeRepo.UpdateOne(ctx, dispute); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, d.AuditRepo, model.AuditDisputeResolveAction, model.AuditDisputeTarget, &dispute.Id, &before, dispute); err != nil {
+			return err
+		}
+
+		if err := bookingUsecase.updateStatus(ctx, booking, bookingStatus); err != nil {
+			return err
+		}
+
+		if customerAmount > 0 {
+			if err := issueDocument(ctx, bookingUsecase.DocumentRepo, d.JobRepo, booking, model.DocumentCreditNoteKind, nil, model.Price{
+				Amount:   customerAmount,
+				Currency: dispute.Currency,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return d.notify(ctx, dispute, booking, mediatorId, model.NotificationDisputeResolvedType, &input.Resolution)
+	})
+}
+
+// lockDisputedBooking locks the booking and checks that it is still disputed, so that a dispute is closed only once
+func (d *DisputeUseCase) lockDisputedBooking(ctx context.Context, booking *model.Booking, bookingUsecase BookingUseCase) error {
+	if err := bookingUsecase.lockBooking(ctx, booking); err != nil {
+		return err
+	}
+	if booking.Status != model.BookingDisputedStatus {
+		return ErrDisputeClosed
+	}
+
+	return nil
+}
+
+func (d *DisputeUseCase) FindWithFilter(ctx context.Context, filter model.DisputeFilter) ([]*model.Dispute, error) {
+	return d.DisputeRepo.FindWithFilter(ctx, filter)
+}
+
+// FindOneWithEvidence returns the dispute with its evidence, the links to the photos expire after a few minutes
+func (d *DisputeUseCase) FindOneWithEvidence(ctx context.Context, disputeId uuid.UUID) (*model.Dispute, error) {
+	dispute, err := d.DisputeRepo.FindOneById(ctx, disputeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.PopulateEvidence(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+// FindLatestWithEvidence returns the last dispute of the booking with its evidence
+func (d *DisputeUseCase) FindLatestWithEvidence(ctx context.Context, bookingId uuid.UUID) (*model.Dispute, error) {
+	dispute, err := d.DisputeRepo.FindLatestByBookingId(ctx, bookingId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.PopulateEvidence(ctx, dispute); err != nil {
+		return nil, err
+	}
+
+	return dispute, nil
+}
+
+func (d *DisputeUseCase) PopulateEvidence(ctx context.Context, disputes ...*model.Dispute) error {
+	disputeIds := []uuid.UUID{}
+	for _, dispute := range disputes {
+		disputeIds = append(disputeIds, dispute.Id)
+	}
+
+	evidence, err := d.EvidenceRepo.FindByDisputeIds(ctx, disputeIds...)
+	if err != nil {
+		return err
+	}
+
+	if err := presignDisputeEvidence(ctx, evidence...); err != nil {
+		return err
+	}
+
+	disputeIdToEvidence := map[uuid.UUID][]*model.DisputeEvidence{}
+	for _, item := range evidence {
+		disputeIdToEvidence[item.DisputeId] = append(disputeIdToEvidence[item.DisputeId], item)
+	}
+
+	for _, dispute := range disputes {
+		dispute.Evidence = disputeIdToEvidence[dispute.Id]
+		if dispute.Evidence == nil {
+			dispute.Evidence = []*model.DisputeEvidence{}
+		}
+	}
+
+	return nil
+}
+
+// chatExcerpt copies the quoted messages, which have to be in the chat of the booking, in the order they were sent
+func (d *DisputeUseCase) chatExcerpt(ctx context.Context, booking *model.Booking, conversationIds []uuid.UUID) ([]*model.DisputeChatLine, error) {
+	if len(conversationIds) == 0 {
+		return nil, nil
+	}
+	if len(conversationIds) > MaxDisputeExcerptLines {
+		return nil, ErrInvalidExcerpt
+	}
+
+	conversations, err := d.ConversationRepo.FindByIds(ctx, conversationIds...)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := map[uuid.UUID]bool{}
+	for _, id := range conversationIds {
+		unique[id] = true
+	}
+	if len(conversations) != len(unique) {
+		return nil, ErrInvalidExcerpt
+	}
+
+	excerpt := []*model.DisputeChatLine{}
+	for _, conversation := range conversations {
+		if conversation.RoomId != booking.RoomId {
+			return nil, ErrInvalidExcerpt
+		}
+		excerpt = append(excerpt, &model.DisputeChatLine{
+			ConversationId: conversation.Id,
+			UserId:         conversation.UserId,
+			Text:           conversation.Text,
+			CreatedAt:      conversation.CreatedAt,
+		})
+	}
+	sort.Slice(excerpt, func(i, j int) bool {
+		return excerpt[i].CreatedAt.Before(excerpt[j].CreatedAt)
+	})
+
+	return excerpt, nil
+}
+
+func (d *DisputeUseCase) notify(ctx context.Context, dispute *model.Dispute, booking *model.Booking, actorId uuid.UUID, notificationType string, body *string) error {
+	for _, recipientId := range disputeRecipients(dispute, booking, actorId) {
+		if err := enqueueNotification(ctx, d.JobRepo, recipientId, notificationType, &booking.Id, body); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disputeRecipients are the parties of the booking and the mediator, but the user who acted
+func disputeRecipients(dispute *model.Dispute, booking *model.Booking, actorId uuid.UUID) []uuid.UUID {
+	candidates := []uuid.UUID{booking.CustomerId, booking.Room.Gallery.PhotographerId}
+	if dispute.MediatorId != nil {
+		candidates = append(candidates, *dispute.MediatorId)
+	}
+
+	recipients := []uuid.UUID{}
+	for _, candidate := range candidates {
+		if candidate != actorId && candidate != uuid.Nil {
+			recipients = append(recipients, candidate)
+		}
+	}
+
+	return recipients
+}
+
+// disputedAmount is what has been paid for the booking, in the currency it has been paid in
+func disputedAmount(instalments []*model.BookingInstalment) int {
+	amount := 0
+	for _, instalment := range instalments {
+		if instalment.Status == model.InstalmentPaidStatus && instalment.PaidAmount != nil {
+			amount += *instalment.PaidAmount
+		}
+	}
+
+	return amount
+}
+
+// payoutAmount is what the photographer is paid out for a booking, the share of the resolved dispute of the booking
+// if there is one and everything paid otherwise
+func payoutAmount(instalments []*model.BookingInstalment, resolved *model.Dispute) int {
+	if resolved != nil && resolved.PhotographerAmount != nil {
+		return *resolved.PhotographerAmount
+	}
+
+	return disputedAmount(instalments)
+}
+
+// splitDispute gives the photographer what the customer is not credited
+func splitDispute(disputed int, customerAmount int) (int, int, error) {
+	if customerAmount < 0 || customerAmount > disputed {
+		return 0, 0, ErrInvalidDisputeSplit
+	}
+
+	return customerAmount, disputed - customerAmount, nil
+}
+
+func checkDisputePhotos(photos [][]byte) ([]string, error) {
+	if len(photos) > MaxDisputePhotos {
+		return nil, ErrTooManyDisputePhotos
+	}
+
+	contentTypes := []string{}
+	for _, photo := range photos {
+		if len(photo) == 0 || len(photo) > MaxDisputePhotoSize {
+			return nil, ErrInvalidDisputePhoto
+		}
+
+		contentType := http.DetectContentType(photo)
+		if !strings.HasPrefix(contentType, "image/") {
+			return nil, ErrInvalidDisputePhoto
+		}
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	return contentTypes, nil
+}
+
+// presignDisputeEvidence links the photos for a short while, the bucket is not public
+func presignDisputeEvidence(ctx context.Context, evidence ...*model.DisputeEvidence) error {
+	var bucket *s3utils.BucketBasics
+	for _, item := range evidence {
+		item.PhotoURLs = []string{}
+		for _, key := range item.PhotoKeys {
+			if bucket == nil {
+				instance, err := s3utils.GetInstance()
+				if err != nil {
+					return err
+				}
+				bucket = instance
+			}
+
+			url, err := bucket.PresignGetURL(ctx, s3utils.DisputeEvidenceBucket, key, disputePhotoURLTTL)
+			if err != nil {
+				return err
+			}
+			item.PhotoURLs = append(item.PhotoURLs, url)
+		}
+	}
+
+	return nil
+}
+
+// the photos uploaded for evidence that could not be added are removed on a best effort basis
+func deleteDisputePhotos(ctx context.Context, bucket *s3utils.BucketBasics, keys []string) {
+	for _, key := range keys {
+		if err := bucket.DeleteFile(ctx, s3utils.DisputeEvidenceBucket, key); err != nil {
+			slog.WarnContext(ctx, "unable to delete dispute photo", "key", key, "error", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitDispute(t *testing.T) {
+	customer, photographer, err := splitDispute(10000, 2500)
+	assert.NoError(t, err)
+	assert.Equal(t, 2500, customer)
+	assert.Equal(t, 7500, photographer)
+
+	customer, photographer, err = splitDispute(10000, 10000)
+	assert.NoError(t, err)
+	assert.Equal(t, 10000, customer)
+	assert.Equal(t, 0, photographer)
+
+	_, _, err = splitDispute(10000, 10001)
+	assert.ErrorIs(t, err, ErrInvalidDisputeSplit)
+
+	_, _, err = splitDispute(10000, -1)
+	assert.ErrorIs(t, err, ErrInvalidDisputeSplit)
+}
+
+func TestDisputedAmount(t *testing.T) {
+	deposit, balance := 3000, 7000
+	instalments := []*model.BookingInstalment{
+		{Status: model.InstalmentPaidStatus, Amount: 3000, PaidAmount: &deposit},
+		{Status: model.InstalmentPendingStatus, Amount: 7000},
+		{Status: model.InstalmentCancelledStatus, Amount: 7000, PaidAmount: &balance},
+	}
+
+	assert.Equal(t, 3000, disputedAmount(instalments))
+}
+
+func TestPayoutAmount(t *testing.T) {
+	deposit, balance := 3000, 7000
+	instalments := []*model.BookingInstalment{
+		{Status: model.InstalmentPaidStatus, Amount: 3000, PaidAmount: &deposit},
+		{Status: model.InstalmentPaidStatus, Amount: 7000, PaidAmount: &balance},
+	}
+	assert.Equal(t, 10000, payoutAmount(instalments, nil))
+
+	customer, photographer, err := splitDispute(disputedAmount(instalments), 4000)
+	assert.NoError(t, err)
+	resolved := &model.Dispute{Status: model.DisputeResolvedStatus, CustomerAmount: &customer, PhotographerAmount: &photographer}
+	assert.Equal(t, 6000, payoutAmount(instalments, resolved))
+}
+
+func TestDisputeRecipients(t *testing.T) {
+	customerId, photographerId, mediatorId := uuid.New(), uuid.New(), uuid.New()
+	booking := &model.Booking{CustomerId: customerId}
+	booking.Room.Gallery.PhotographerId = photographerId
+
+	dispute := &model.Dispute{}
+	assert.Equal(t, []uuid.UUID{photographerId}, disputeRecipients(dispute, booking, customerId))
+
+	dispute.MediatorId = &mediatorId
+	assert.Equal(t, []uuid.UUID{customerId, mediatorId}, disputeRecipients(dispute, booking, photographerId))
+	assert.Equal(t, []uuid.UUID{customerId, photographerId}, disputeRecipients(dispute, booking, mediatorId))
+}
+
+func TestCheckDisputePhotos(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+	contentTypes, err := checkDisputePhotos([][]byte{png})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"image/png"}, contentTypes)
+
+	_, err = checkDisputePhotos([][]byte{[]byte("%PDF-1.4")})
+	assert.ErrorIs(t, err, ErrInvalidDisputePhoto)
+
+	_, err = checkDisputePhotos(make([][]byte, MaxDisputePhotos+1))
+	assert.ErrorIs(t, err, ErrTooManyDisputePhotos)
+}
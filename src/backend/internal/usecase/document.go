@@ -0,0 +1,388 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/pdf"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	documentDateLayout     = "02 Jan 2006"
+	documentDateTimeLayout = "02 Jan 2006 15:04 MST"
+)
+
+var ErrDocumentNotReady = errors.New("the document is not ready yet")
+
+var documentPrefixes = map[string]string{
+	model.DocumentInvoiceKind:    "INV",
+	model.DocumentReceiptKind:    "RCT",
+	model.DocumentCreditNoteKind: "CN",
+}
+
+var documentTitles = map[string]string{
+	model.DocumentInvoiceKind:    "Invoice",
+	model.DocumentReceiptKind:    "Receipt",
+	model.DocumentCreditNoteKind: "Credit note",
+}
+
+var instalmentLabels = map[string]string{
+	model.InstalmentDepositKind: "Deposit",
+	model.InstalmentBalanceKind: "Balance",
+	model.InstalmentFullKind:    "Full",
+}
+
+type DocumentUseCase struct {
+	DocumentRepo repository.BookingDocument
+	BookingRepo  repository.Booking
+	UserRepo     repository.User
+}
+
+func NewDocumentUseCase(db *bun.DB) *DocumentUseCase {
+	return &DocumentUseCase{
+		DocumentRepo: postgres.NewBookingDocumentDB(db),
+		BookingRepo:  postgres.NewBookingDB(db),
+		UserRepo:     postgres.NewUserDB(db),
+	}
+}
+
+// issueDocument numbers a document of a booking whose room has been populated and queues the rendering of its pdf,
+// it is called in the transaction of the payment or the refund the document records
+func issueDocument(ctx context.Context, documentRepo repository.BookingDocument, jobRepo repository.Job, booking *model.Booking, kind string, instalmentId *uuid.UUID, price model.Price) error {
+	photographerId := booking.Room.Gallery.PhotographerId
+	sequence, err := documentRepo.NextSequence(ctx, photographerId, kind)
+	if err != nil {
+		return err
+	}
+
+	document := &model.BookingDocument{
+		Id:             uuid.New(),
+		BookingId:      booking.Id,
+		InstalmentId:   instalmentId,
+		PhotographerId: photographerId,
+		CustomerId:     booking.CustomerId,
+		Kind:           kind,
+		Sequence:       sequence,
+		Number:         documentNumber(kind, sequence),
+		Amount:         price.Amount,
+		Currency:       price.Currency,
+		Status:         model.DocumentPendingStatus,
+		IssuedAt:       time.Now(),
+	}
+	if err := documentRepo.AddOne(ctx, document); err != nil {
+		return err
+	}
+
+	return enqueueJob(ctx, jobRepo, model.JobRenderDocumentKind, model.DocumentJob{DocumentId: document.Id})
+}
+
+func documentNumber(kind string, sequence int) string {
+	return fmt.Sprintf("%s-%06d", documentPrefixes[kind], sequence)
+}
+
+// RenderQueued renders the pdf of a queued document, a failed rendering is attempted again when the job is retried
+func (d *DocumentUseCase) RenderQueued(ctx context.Context, documentId uuid.UUID, bookingUsecase BookingUseCase, roomUsecase RoomUseCase, galleryUsecase GalleryUseCase) error {
+	document, err := d.DocumentRepo.FindOneById(ctx, documentId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if document.Status == model.DocumentIssuedStatus {
+		return nil
+	}
+
+	booking, err := d.BookingRepo.FindOneById(ctx, document.BookingId)
+	if err != nil {
+		return err
+	}
+
+	if err := roomUsecase.PopulateRoomsInBookings(ctx, galleryUsecase, booking); err != nil {
+		return err
+	}
+
+	if err := bookingUsecase.PopulateDetails(ctx, booking); err != nil {
+		return err
+	}
+
+	photographer, err := d.UserRepo.FindOneById(ctx, document.PhotographerId)
+	if err != nil {
+		return err
+	}
+
+	customer, err := d.UserRepo.FindOneById(ctx, document.CustomerId)
+	if err != nil {
+		return err
+	}
+
+	return d.Render(ctx, document, booking, photographer, customer)
+}
+
+// Render writes the pdf of the document into the document bucket, the outcome is recorded on the document so it can
+// be run in the background
+func (d *DocumentUseCase) Render(ctx context.Context, document *model.BookingDocument, booking *model.Booking, photographer, customer *model.User) error {
+	doc := pdf.New()
+	writeDocument(doc, document, booking, photographer, customer)
+
+	file := &bytes.Buffer{}
+	_, err := doc.WriteTo(file)
+	if err == nil {
+		objectKey := fmt.Sprintf("%s/%s.pdf", document.PhotographerId, document.Number)
+		err = d.upload(ctx, objectKey, file)
+		document.ObjectKey = &objectKey
+	}
+
+	now := time.Now()
+	document.RenderedAt = &now
+	if err != nil {
+		errMsg := err.Error()
+		document.Status = model.DocumentFailedStatus
+		document.Error = &errMsg
+		document.ObjectKey = nil
+	} else {
+		document.Status = model.DocumentIssuedStatus
+		document.Error = nil
+	}
+
+	if updateErr := d.DocumentRepo.UpdateOne(ctx, document); updateErr != nil {
+		return updateErr
+	}
+
+	return err
+}
+
+func (d *DocumentUseCase) upload(ctx context.Context, objectKey string, file *bytes.Buffer) error {
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return err
+	}
+
+	return bucket.UploadFile(ctx, s3utils.DocumentBucket, objectKey, file, "application/pdf")
+}
+
+// Open returns the pdf of an issued document, the caller has to close it
+func (d *DocumentUseCase) Open(ctx context.Context, document *model.BookingDocument) (io.ReadCloser, int64, error) {
+	if document.Status != model.DocumentIssuedStatus || document.ObjectKey == nil {
+		return nil, 0, ErrDocumentNotReady
+	}
+
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bucket.GetFile(ctx, s3utils.DocumentBucket, *document.ObjectKey)
+}
+
+// documentPages are the pages a document is laid out on, a *pdf.Document
+type documentPages interface {
+	AddPage()
+	Text(x, y, size float64, bold bool, text string)
+	Line(x1, y1, x2, y2 float64)
+}
+
+// documentLayout writes the lines of a document from the top of its pages down
+type documentLayout struct {
+	doc documentPages
+	y   float64
+}
+
+const (
+	documentMargin     = 50.0
+	documentLineHeight = 14.0
+	documentBottom     = pdf.PageHeight - documentMargin
+)
+
+// column is a text starting at x points from the left edge of the page
+type column struct {
+	x    float64
+	text string
+}
+
+func (l *documentLayout) row(size float64, bold bool, columns ...column) {
+	if l.y+documentLineHeight > documentBottom {
+		l.doc.AddPage()
+		l.y = documentMargin
+	}
+
+	l.y += documentLineHeight
+	for _, column := range columns {
+		if column.text != "" {
+			l.doc.Text(column.x, l.y, size, bold, column.text)
+		}
+	}
+}
+
+func (l *documentLayout) text(bold bool, text string) {
+	l.row(10, bold, column{documentMargin, text})
+}
+
+func (l *documentLayout) rule() {
+	l.y += documentLineHeight / 2
+	l.doc.Line(documentMargin, l.y, pdf.PageWidth-documentMargin, l.y)
+}
+
+func (l *documentLayout) gap() {
+	l.y += documentLineHeight
+}
+
+// writeDocument lays out the parties, the booking and the amounts of a document on a booking whose details have
+// been populated
+func writeDocument(doc documentPages, document *model.BookingDocument, booking *model.Booking, photographer, customer *model.User) {
+	l := &documentLayout{doc: doc, y: documentMargin}
+
+	l.row(20, true, column{documentMargin, documentTitles[document.Kind]})
+	l.gap()
+	l.text(false, fmt.Sprintf("No. %s", document.Number))
+	l.text(false, fmt.Sprintf("Issued on %s", document.IssuedAt.Format(documentDateLayout)))
+	l.text(false, fmt.Sprintf("Booking %s", booking.Id))
+	l.gap()
+
+	const toColumn = pdf.PageWidth / 2
+	l.row(10, true, column{documentMargin, "From"}, column{toColumn, "To"})
+	from, to := documentParty(photographer), documentParty(customer)
+	for i := 0; i < len(from) || i < len(to); i++ {
+		l.row(10, false, column{documentMargin, at(from, i)}, column{toColumn, at(to, i)})
+	}
+	l.gap()
+
+	gallery := booking.Room.Gallery
+	l.text(true, fmt.Sprintf("%s, %s", gallery.Name, gallery.Location))
+	for _, session := range booking.Sessions {
+		period := fmt.Sprintf("%s - %s", session.StartTime.Format(documentDateTimeLayout), session.EndTime.Format(documentDateTimeLayout))
+		if session.Title != nil {
+			period = fmt.Sprintf("%s: %s", *session.Title, period)
+		}
+		l.text(false, period)
+	}
+	l.gap()
+
+	switch document.Kind {
+	case model.DocumentInvoiceKind:
+		writeInvoiceItems(l, document, booking)
+	case model.DocumentReceiptKind:
+		writeReceiptItems(l, document, booking)
+	case model.DocumentCreditNoteKind:
+		writeCreditNoteItems(l, document, booking)
+	}
+
+	l.gap()
+	l.row(8, false, column{documentMargin, "Issued through Pic Keeper on behalf of the photographer named above."})
+}
+
+const (
+	quantityColumn  = 330.0
+	unitPriceColumn = 390.0
+	amountColumn    = 470.0
+)
+
+func writeInvoiceItems(l *documentLayout, document *model.BookingDocument, booking *model.Booking) {
+	l.row(10, true, column{documentMargin, "Description"}, column{quantityColumn, "Qty"}, column{unitPriceColumn, "Unit price"}, column{amountColumn, "Amount"})
+	l.rule()
+	for _, item := range booking.LineItems {
+		l.row(10, false,
+			column{documentMargin, item.Description},
+			column{quantityColumn, strconv.Itoa(item.Quantity)},
+			column{unitPriceColumn, formatAmount(item.UnitPrice, booking.Currency)},
+			column{amountColumn, formatAmount(item.Amount, booking.Currency)},
+		)
+	}
+	l.rule()
+	l.row(10, true, column{unitPriceColumn, "Total"}, column{amountColumn, formatAmount(document.Amount, document.Currency)})
+
+	if booking.PaymentCurrency != nil && *booking.PaymentCurrency != booking.Currency && booking.ExchangeRate != nil {
+		l.gap()
+		l.text(false, fmt.Sprintf("Paid in %s at 1 %s = %s %s", *booking.PaymentCurrency, booking.Currency, strconv.FormatFloat(*booking.ExchangeRate, 'f', -1, 64), *booking.PaymentCurrency))
+	}
+}
+
+func writeReceiptItems(l *documentLayout, document *model.BookingDocument, booking *model.Booking) {
+	l.row(10, true, column{documentMargin, "Payment"}, column{amountColumn, "Amount"})
+	l.rule()
+	for _, instalment := range booking.Instalments {
+		if document.InstalmentId == nil || instalment.Id != *document.InstalmentId {
+			continue
+		}
+
+		description := fmt.Sprintf("%s payment", instalmentLabels[instalment.Kind])
+		if instalment.PaidAt != nil {
+			description = fmt.Sprintf("%s received on %s", description, instalment.PaidAt.Format(documentDateTimeLayout))
+		}
+		l.row(10, false, column{documentMargin, description}, column{amountColumn, formatAmount(document.Amount, document.Currency)})
+	}
+	l.rule()
+	l.row(10, true, column{unitPriceColumn, "Received"}, column{amountColumn, formatAmount(document.Amount, document.Currency)})
+	l.gap()
+	l.text(false, fmt.Sprintf("Booking total %s", formatAmount(booking.ResultedPrice, booking.Currency)))
+}
+
+func writeCreditNoteItems(l *documentLayout, document *model.BookingDocument, booking *model.Booking) {
+	l.row(10, true, column{documentMargin, "Refunded payment"}, column{amountColumn, "Amount"})
+	l.rule()
+	for _, instalment := range booking.Instalments {
+		if instalment.Status != model.InstalmentPaidStatus || instalment.PaidAmount == nil {
+			continue
+		}
+
+		description := fmt.Sprintf("%s payment", instalmentLabels[instalment.Kind])
+		if instalment.PaidAt != nil {
+			description = fmt.Sprintf("%s of %s", description, instalment.PaidAt.Format(documentDateLayout))
+		}
+		l.row(10, false, column{documentMargin, description}, column{amountColumn, formatAmount(*instalment.PaidAmount, document.Currency)})
+	}
+	l.rule()
+	l.row(10, true, column{unitPriceColumn, "Credited"}, column{amountColumn, formatAmount(document.Amount, document.Currency)})
+}
+
+// documentParty lists the name, the contact and the tax id of a party, each on its own line
+func documentParty(user *model.User) []string {
+	lines := []string{strings.TrimSpace(fmt.Sprintf("%s %s", user.Firstname, user.Lastname)), user.Email}
+	if user.Address != nil && *user.Address != "" {
+		lines = append(lines, *user.Address)
+	}
+	if user.TaxId != nil && *user.TaxId != "" {
+		lines = append(lines, fmt.Sprintf("Tax ID %s", *user.TaxId))
+	}
+
+	return lines
+}
+
+func at(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+
+	return ""
+}
+
+// formatAmount groups the thousands of a whole amount, "1,500 THB"
+func formatAmount(amount int, currency string) string {
+	sign := ""
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	digits := strconv.Itoa(amount)
+	grouped := []string{}
+	for len(digits) > 3 {
+		grouped = append([]string{digits[len(digits)-3:]}, grouped...)
+		digits = digits[:len(digits)-3]
+	}
+	grouped = append([]string{digits}, grouped...)
+
+	return fmt.Sprintf("%s%s %s", sign, strings.Join(grouped, ","), currency)
+}
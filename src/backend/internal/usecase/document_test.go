@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentNumber(t *testing.T) {
+	assert.Equal(t, "INV-000001", documentNumber(model.DocumentInvoiceKind, 1))
+	assert.Equal(t, "RCT-000042", documentNumber(model.DocumentReceiptKind, 42))
+	assert.Equal(t, "CN-1234567", documentNumber(model.DocumentCreditNoteKind, 1234567))
+}
+
+func TestFormatAmount(t *testing.T) {
+	assert.Equal(t, "0 THB", formatAmount(0, "THB"))
+	assert.Equal(t, "950 THB", formatAmount(950, "THB"))
+	assert.Equal(t, "1,500 THB", formatAmount(1500, "THB"))
+	assert.Equal(t, "1,234,567 USD", formatAmount(1234567, "USD"))
+	assert.Equal(t, "-12,000 THB", formatAmount(-12000, "THB"))
+}
+
+// fakePages keeps the text written
+type fakePages struct {
+	texts []string
+}
+
+func (f *fakePages) AddPage() {}
+
+func (f *fakePages) Text(x, y, size float64, bold bool, text string) {
+	f.texts = append(f.texts, text)
+}
+
+func (f *fakePages) Line(x1, y1, x2, y2 float64) {}
+
+func TestWriteDocument(t *testing.T) {
+	taxId := "0105556000000"
+	paidAt := time.Date(2024, 4, 2, 10, 0, 0, 0, time.UTC)
+	paidAmount := 1500
+	instalment := &model.BookingInstalment{Id: uuid.New(), Kind: model.InstalmentDepositKind, Status: model.InstalmentPaidStatus, PaidAt: &paidAt, PaidAmount: &paidAmount}
+	booking := &model.Booking{
+		Id:            uuid.New(),
+		ResultedPrice: 5000,
+		Currency:      "THB",
+		Room:          model.Room{Gallery: model.Gallery{Name: "Graduation", Location: "Bangkok"}},
+		LineItems:     []*model.BookingLineItem{{Description: "Graduation package", Quantity: 1, UnitPrice: 5000, Amount: 5000}},
+		Instalments:   []*model.BookingInstalment{instalment},
+	}
+	photographer := &model.User{Firstname: "Jane", Lastname: "Doe", Email: "jane@mail.com", TaxId: &taxId}
+	customer := &model.User{Firstname: "John", Lastname: "Smith", Email: "john@mail.com"}
+
+	for kind, expected := range map[string]string{
+		model.DocumentInvoiceKind:    "Graduation package",
+		model.DocumentReceiptKind:    "Deposit payment received on 02 Apr 2024 10:00 UTC",
+		model.DocumentCreditNoteKind: "Deposit payment of 02 Apr 2024",
+	} {
+		document := &model.BookingDocument{Kind: kind, Number: documentNumber(kind, 7), Amount: 1500, Currency: "THB", InstalmentId: &instalment.Id, IssuedAt: paidAt}
+
+		pages := &fakePages{}
+		writeDocument(pages, document, booking, photographer, customer)
+		assert.Contains(t, pages.texts, documentTitles[kind])
+		assert.Contains(t, pages.texts, "No. "+document.Number)
+		assert.Contains(t, pages.texts, "Tax ID 0105556000000")
+		assert.Contains(t, pages.texts, expected)
+	}
+}
@@ -50,3 +50,25 @@ func (g *GalleryUseCase) PopulateGalleryInRooms(ctx context.Context, rooms ...*m
 
 	return nil
 }
+
+// RecomputeRating sets the average rating of the gallery from its reviews, nil when it has none
+func (g *GalleryUseCase) RecomputeRating(ctx context.Context, reviewUsecase ReviewUseCase, galleryId uuid.UUID) error {
+	gallery, err := g.GalleryRepo.FindOneById(ctx, galleryId)
+	if err != nil {
+		return err
+	}
+
+	ratingSum, ratingCount, err := reviewUsecase.SumAndCountRatingByGalleryId(ctx, gallery.Id)
+	if err != nil {
+		return err
+	}
+
+	if ratingCount == 0 {
+		gallery.AvgRating = nil
+	} else {
+		averageRating := float32(ratingSum) / float32(ratingCount)
+		gallery.AvgRating = &averageRating
+	}
+
+	return g.GalleryRepo.UpdateOne(ctx, gallery)
+}
@@ -0,0 +1,128 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultInsightsMonths = 12
+	insightsWorkloadWeeks = 8
+	insightsCacheTTL      = 5 * time.Minute
+)
+
+type InsightsUseCase struct {
+	InsightsRepo repository.Insights
+	GalleryRepo  repository.Gallery
+	JobRepo      repository.Job
+}
+
+func NewInsightsUseCase(db *bun.DB) *InsightsUseCase {
+	return &InsightsUseCase{
+		InsightsRepo: postgres.NewInsightsDB(db),
+		GalleryRepo:  postgres.NewGalleryDB(db),
+		JobRepo:      postgres.NewJobDB(db),
+	}
+}
+
+// TrackView queues a view of the gallery for the current day in UTC, the views of a popular gallery would otherwise
+// all wait on the same row. The viewer is nil for the guests.
+func (i *InsightsUseCase) TrackView(ctx context.Context, galleryId uuid.UUID, viewerId *uuid.UUID, kind string) error {
+	return enqueueJob(ctx, i.JobRepo, model.JobTrackGalleryViewKind, model.GalleryViewJob{
+		GalleryId: galleryId,
+		ViewerId:  viewerId,
+		Kind:      kind,
+		Day:       time.Now().UTC().Truncate(24 * time.Hour),
+	})
+}
+
+// RecordView counts a queued view, the photographer looking at their own gallery is not counted
+func (i *InsightsUseCase) RecordView(ctx context.Context, view model.GalleryViewJob) error {
+	if view.ViewerId != nil {
+		gallery, err := i.GalleryRepo.FindOneById(ctx, view.GalleryId)
+		if errors.Is(err, sql.ErrNoRows) {
+			// deleted since it was viewed
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if gallery.PhotographerId == *view.ViewerId {
+			return nil
+		}
+	}
+
+	return i.InsightsRepo.AddView(ctx, &model.GalleryView{
+		GalleryId: view.GalleryId,
+		Day:       view.Day,
+		Kind:      view.Kind,
+		Views:     1,
+	})
+}
+
+// Insights aggregates the business of the photographer over the last months and the workload of the coming weeks,
+// the amounts are in the base currency
+func (i *InsightsUseCase) Insights(ctx context.Context, photographerId uuid.UUID, filter model.InsightsFilter) (*model.PhotographerInsights, error) {
+	months := filter.Months
+	if months == 0 {
+		months = defaultInsightsMonths
+	}
+
+	key := fmt.Sprintf("insights:%s:%d", photographerId, months)
+	if insights := cached[model.PhotographerInsights](ctx, key); insights != nil {
+		return insights, nil
+	}
+
+	now := time.Now()
+	from := insightsStart(months, now)
+	insights := &model.PhotographerInsights{
+		From:        from,
+		Currency:    model.BaseCurrency,
+		GeneratedAt: now,
+	}
+
+	var err error
+	if insights.Earnings, err = i.InsightsRepo.MonthlyEarnings(ctx, photographerId, from, now); err != nil {
+		return nil, err
+	}
+	if insights.Galleries, err = i.InsightsRepo.GalleryInsights(ctx, photographerId, from, now); err != nil {
+		return nil, err
+	}
+	if insights.RatingTrend, err = i.InsightsRepo.RatingTrend(ctx, photographerId, from, now); err != nil {
+		return nil, err
+	}
+	responseTime, err := i.InsightsRepo.ResponseTime(ctx, photographerId, from, now)
+	if err != nil {
+		return nil, err
+	}
+	insights.ResponseTime = *responseTime
+	if insights.Workload, err = i.InsightsRepo.WeeklyWorkload(ctx, photographerId, now, insightsWorkloadWeeks); err != nil {
+		return nil, err
+	}
+	computeConversions(insights.Galleries)
+
+	cache(ctx, key, insights, insightsCacheTTL)
+	return insights, nil
+}
+
+// insightsStart is the first day in UTC of the oldest of the months, the current month being the last one
+func insightsStart(months int, now time.Time) time.Time {
+	now = now.UTC()
+	return time.Date(now.Year(), now.Month()-time.Month(months-1), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func computeConversions(galleries []*model.GalleryInsights) {
+	for _, gallery := range galleries {
+		gallery.ViewConversion = ratio(gallery.PaidBookings, gallery.DetailViews)
+		gallery.ChatConversion = ratio(gallery.BookedChats, gallery.Chats)
+	}
+}
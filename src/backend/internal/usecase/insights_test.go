@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsightsStart(t *testing.T) {
+	now := time.Date(2024, 4, 21, 3, 0, 0, 0, time.FixedZone("ICT", 7*60*60))
+
+	assert.Equal(t, time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), insightsStart(1, now))
+	assert.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), insightsStart(12, now))
+
+	newYear := time.Date(2024, 1, 1, 0, 30, 0, 0, time.FixedZone("ICT", 7*60*60))
+	assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), insightsStart(1, newYear))
+}
+
+func TestComputeConversions(t *testing.T) {
+	galleries := []*model.GalleryInsights{
+		{DetailViews: 200, PaidBookings: 5, Chats: 10, BookedChats: 4},
+		{},
+	}
+
+	computeConversions(galleries)
+
+	assert.InDelta(t, 0.025, galleries[0].ViewConversion, 1e-9)
+	assert.InDelta(t, 0.4, galleries[0].ChatConversion, 1e-9)
+	assert.Zero(t, galleries[1].ViewConversion)
+	assert.Zero(t, galleries[1].ChatConversion)
+}
+
+// fakeInsights keeps the views counted
+type fakeInsights struct {
+	repository.Insights
+	views []*model.GalleryView
+}
+
+func (f *fakeInsights) AddView(ctx context.Context, view *model.GalleryView) error {
+	f.views = append(f.views, view)
+	return nil
+}
+
+// fakeGalleries finds the galleries stored
+type fakeGalleries struct {
+	repository.Gallery
+	stored map[uuid.UUID]*model.Gallery
+}
+
+func (f *fakeGalleries) FindOneById(ctx context.Context, id uuid.UUID) (*model.Gallery, error) {
+	return f.stored[id], nil
+}
+
+func TestTrackViewSkipsOwner(t *testing.T) {
+	owner, customer := uuid.New(), uuid.New()
+	gallery := &model.Gallery{Id: uuid.New(), PhotographerId: owner}
+	jobs, insights := &fakeJobs{}, &fakeInsights{}
+	insightsUsecase := &InsightsUseCase{
+		InsightsRepo: insights,
+		GalleryRepo:  &fakeGalleries{stored: map[uuid.UUID]*model.Gallery{gallery.Id: gallery}},
+		JobRepo:      jobs,
+	}
+	ctx := context.Background()
+
+	// the views are queued rather than counted while the gallery is served
+	for _, viewerId := range []*uuid.UUID{nil, &customer, &owner} {
+		require.NoError(t, insightsUsecase.TrackView(ctx, gallery.Id, viewerId, model.GalleryViewPhotosKind))
+	}
+	require.Len(t, jobs.queued, 3)
+	assert.Empty(t, insights.views)
+
+	for _, job := range jobs.queued {
+		assert.Equal(t, model.JobTrackGalleryViewKind, job.Kind)
+
+		var view model.GalleryViewJob
+		require.NoError(t, json.Unmarshal(job.Payload, &view))
+		require.NoError(t, insightsUsecase.RecordView(ctx, view))
+	}
+
+	// the guest and the customer are counted, the photographer is not
+	require.Len(t, insights.views, 2)
+	assert.Equal(t, model.GalleryViewPhotosKind, insights.views[0].Kind)
+	assert.Equal(t, insights.views[0].Day.Truncate(24*time.Hour), insights.views[0].Day)
+}
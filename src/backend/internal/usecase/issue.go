@@ -1,28 +1,513 @@
 package usecase
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/repository"
 	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+const (
+	MaxIssueAttachments    = 5
+	MaxIssueAttachmentSize = 5 << 20
+	issueAttachmentURLTTL  = 15 * time.Minute
+)
+
+// the targets used for a priority that has none in the database
+var defaultIssueSLATargets = map[string]model.IssueSLATarget{
+	model.IssueUrgentPriority: {FirstResponseMinutes: 60, ResolutionMinutes: 8 * 60},
+	model.IssueHighPriority:   {FirstResponseMinutes: 4 * 60, ResolutionMinutes: 24 * 60},
+	model.IssueNormalPriority: {FirstResponseMinutes: 8 * 60, ResolutionMinutes: 3 * 24 * 60},
+	model.IssueLowPriority:    {FirstResponseMinutes: 24 * 60, ResolutionMinutes: 7 * 24 * 60},
+}
+
+var issueAttachmentTypes = map[string]bool{
+	"image/jpeg":                true,
+	"image/png":                 true,
+	"image/gif":                 true,
+	"image/webp":                true,
+	"application/pdf":           true,
+	"text/plain; charset=utf-8": true,
+}
+
+var (
+	ErrIssueClosed         = errors.New("the issue is closed")
+	ErrInvalidAttachment   = fmt.Errorf("an attachment must be an image, a PDF or a text file of up to %d MB", MaxIssueAttachmentSize>>20)
+	ErrTooManyAttachments  = fmt.Errorf("a message can have up to %d attachments", MaxIssueAttachments)
+	ErrAssigneeNotAdmin    = errors.New("an issue can only be assigned to an administrator")
+	ErrRefundSubjectLocked = errors.New("the subject of a refund cannot be changed")
+	ErrInvalidSLATarget    = errors.New("the first response target cannot be longer than the resolution target")
+)
+
+// IssueUpload is a file attached to a message, its type is detected from its content
+type IssueUpload struct {
+	Filename string
+	Data     []byte
+}
+
 type IssueUseCase struct {
-	IssueRepo repository.Issue
+	IssueRepo           repository.Issue
+	IssueMessageRepo    repository.IssueMessage
+	IssueAttachmentRepo repository.IssueAttachment
+	IssueSLATargetRepo  repository.IssueSLATarget
+	UserRepo            repository.User
+	AuditRepo           repository.AuditEntry
+	JobRepo             repository.Job
+	UnitOfWork          repository.UnitOfWork
 }
 
 func NewIssueUseCase(db *bun.DB) *IssueUseCase {
 	return &IssueUseCase{
-		IssueRepo: postgres.NewIssueDB(db),
+		IssueRepo:           postgres.NewIssueDB(db),
+		IssueMessageRepo:    postgres.NewIssueMessageDB(db),
+		IssueAttachmentRepo: postgres.NewIssueAttachmentDB(db),
+		IssueSLATargetRepo:  postgres.NewIssueSLATargetDB(db),
+		UserRepo:            postgres.NewUserDB(db),
+		AuditRepo:           postgres.NewAuditEntryDB(db),
+		JobRepo:             postgres.NewJobDB(db),
+		UnitOfWork:          postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+// Report opens the issue with the due dates of the SLA target of its priority
+func (i *IssueUseCase) Report(ctx context.Context, issue *model.Issue) error {
+	if err := applyIssueSLA(ctx, i.IssueSLATargetRepo, issue); err != nil {
+		return err
+	}
+
+	return i.IssueRepo.AddOne(ctx, issue)
+}
+
+// lockIssue locks the issue until the end of the transaction and reloads it, keeping what has been populated, so that
+// concurrent changes of the issue are not overwritten
+func (i *IssueUseCase) lockIssue(ctx context.Context, issue *model.Issue) error {
+	locked, err := i.IssueRepo.LockOneById(ctx, issue.Id)
+	if err != nil {
+		return err
+	}
+
+	locked.Reporter, locked.Booking, locked.Messages = issue.Reporter, issue.Booking, issue.Messages
+	*issue = *locked
+	return nil
+}
+
+// Close closes the issue and notifies its reporter, or returns ErrIssueClosed
+func (i *IssueUseCase) Close(ctx context.Context, issue *model.Issue) error {
+	return i.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := i.lockIssue(ctx, issue); err != nil {
+			return err
+		}
+		if issue.Status == model.IssueClosedStatus {
+			return ErrIssueClosed
+		}
+
+		before := *issue
+		now := time.Now()
+		issue.Status = model.IssueClosedStatus
+		issue.ClosedAt = &now
+		issue.UpdatedAt = now
+		if err := i.IssueRepo.UpdateOne(ctx, issue); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, i.AuditRepo, model.AuditIssueCloseAction, model.AuditIssueTarget, &issue.Id, &before, issue); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, i.JobRepo, issue.ReporterId, model.NotificationIssueClosedType, &issue.Id, nil)
+	})
+}
+
+// Reply posts a message on the issue, the first message of an agent is its first response. An agent's message is
+// notified to the reporter and a reporter's one to the assignee.
+func (i *IssueUseCase) Reply(ctx context.Context, issue *model.Issue, authorId uuid.UUID, fromAgent bool, body string, uploads []IssueUpload) (*model.IssueMessage, error) {
+	if issue.Status == model.IssueClosedStatus {
+		return nil, ErrIssueClosed
+	}
+
+	contentTypes, err := checkIssueUploads(uploads)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	message := &model.IssueMessage{
+		Id:          uuid.New(),
+		IssueId:     issue.Id,
+		AuthorId:    &authorId,
+		FromAgent:   fromAgent,
+		Body:        body,
+		CreatedAt:   now,
+		Attachments: []*model.IssueAttachment{},
+	}
+
+	var bucket *s3utils.BucketBasics
+	if len(uploads) > 0 {
+		if bucket, err = s3utils.GetInstance(); err != nil {
+			return nil, err
+		}
 	}
+
+	for idx, upload := range uploads {
+		attachment := &model.IssueAttachment{
+			Id:          uuid.New(),
+			MessageId:   message.Id,
+			ObjectKey:   fmt.Sprintf("%s/%s", issue.Id, uuid.New()),
+			Filename:    upload.Filename,
+			ContentType: contentTypes[idx],
+			Size:        len(upload.Data),
+			CreatedAt:   now,
+		}
+		if err := bucket.UploadFile(ctx, s3utils.IssueAttachmentBucket, attachment.ObjectKey, bytes.NewBuffer(upload.Data), attachment.ContentType); err != nil {
+			deleteIssueAttachments(ctx, bucket, message.Attachments)
+			return nil, err
+		}
+		message.Attachments = append(message.Attachments, attachment)
+	}
+
+	if err := i.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		// the issue may have been closed while the attachments were uploaded
+		if err := i.lockIssue(ctx, issue); err != nil {
+			return err
+		}
+		if issue.Status == model.IssueClosedStatus {
+			return ErrIssueClosed
+		}
+
+		if err := i.IssueMessageRepo.AddOne(ctx, message); err != nil {
+			return err
+		}
+		if len(message.Attachments) > 0 {
+			if err := i.IssueAttachmentRepo.AddBatch(ctx, message.Attachments); err != nil {
+				return err
+			}
+		}
+
+		if fromAgent && issue.FirstRespondedAt == nil {
+			issue.FirstRespondedAt = &now
+		}
+		issue.UpdatedAt = now
+		if err := i.IssueRepo.UpdateOne(ctx, issue); err != nil {
+			return err
+		}
+
+		switch {
+		case fromAgent:
+			return enqueueNotification(ctx, i.JobRepo, issue.ReporterId, model.NotificationIssueRepliedType, &issue.Id, &body)
+		case issue.AssigneeId != nil:
+			return enqueueNotification(ctx, i.JobRepo, *issue.AssigneeId, model.NotificationIssueReporterRepliedType, &issue.Id, &body)
+		}
+		return nil
+	}); err != nil {
+		deleteIssueAttachments(ctx, bucket, message.Attachments)
+		return nil, err
+	}
+
+	if err := presignIssueAttachments(ctx, message.Attachments...); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Assign gives the issue to an administrator who is notified, without an assignee the issue is unassigned
+func (i *IssueUseCase) Assign(ctx context.Context, issue *model.Issue, assigneeId *uuid.UUID) error {
+	if assigneeId != nil {
+		assignee, err := i.UserRepo.FindOneById(ctx, *assigneeId)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAssigneeNotAdmin
+		}
+		if err != nil {
+			return err
+		}
+		if !assignee.IsAdmin {
+			return ErrAssigneeNotAdmin
+		}
+	}
+
+	return i.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := i.lockIssue(ctx, issue); err != nil {
+			return err
+		}
+
+		before := *issue
+		reassigned := assigneeId != nil && (issue.AssigneeId == nil || *issue.AssigneeId != *assigneeId)
+		issue.AssigneeId = assigneeId
+		issue.UpdatedAt = time.Now()
+		if err := i.IssueRepo.UpdateOne(ctx, issue); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, i.AuditRepo, model.AuditIssueAssignAction, model.AuditIssueTarget, &issue.Id, &before, issue); err != nil {
+			return err
+		}
+
+		if !reassigned {
+			return nil
+		}
+		return enqueueNotification(ctx, i.JobRepo, *assigneeId, model.NotificationIssueAssignedType, &issue.Id, nil)
+	})
+}
+
+// Triage changes the subject and the priority of the issue, a new priority moves the due dates to its SLA target
+func (i *IssueUseCase) Triage(ctx context.Context, issue *model.Issue, input model.IssueTriageInput) error {
+	return i.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := i.lockIssue(ctx, issue); err != nil {
+			return err
+		}
+
+		before := *issue
+		if input.Subject != nil && *input.Subject != issue.Subject {
+			if issue.Subject == model.IssueRefundSubject {
+				return ErrRefundSubjectLocked
+			}
+			issue.Subject = *input.Subject
+		}
+
+		if input.Priority != nil && *input.Priority != issue.Priority {
+			issue.Priority = *input.Priority
+			if err := applyIssueSLA(ctx, i.IssueSLATargetRepo, issue); err != nil {
+				return err
+			}
+		}
+
+		issue.UpdatedAt = time.Now()
+		if err := i.IssueRepo.UpdateOne(ctx, issue); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, i.AuditRepo, model.AuditIssueTriageAction, model.AuditIssueTarget, &issue.Id, &before, issue)
+	})
 }
 
 func (i *IssueUseCase) FindIssuesWithFilter(ctx context.Context, filter model.IssueFilter) ([]*model.Issue, error) {
-	return i.IssueRepo.FindIssuesWithFilter(ctx, filter)
+	issues, err := i.IssueRepo.FindIssuesWithFilter(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	markIssueBreaches(time.Now(), issues...)
+	return issues, nil
+}
+
+// FindOneWithThread finds the issue along with its messages and their attachments
+func (i *IssueUseCase) FindOneWithThread(ctx context.Context, issueId uuid.UUID) (*model.Issue, error) {
+	issue, err := i.IssueRepo.FindOneById(ctx, issueId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.PopulateThreads(ctx, issue); err != nil {
+		return nil, err
+	}
+
+	markIssueBreaches(time.Now(), issue)
+	return issue, nil
 }
 
-func (i *IssueUseCase) GetIssueHeaderMetadata(ctx context.Context) (*model.IssueHeaderMetadata, error) {
-	return i.IssueRepo.GetIssueHeaderMetadata(ctx)
+func (i *IssueUseCase) PopulateThreads(ctx context.Context, issues ...*model.Issue) error {
+	issueIds := []uuid.UUID{}
+	for _, issue := range issues {
+		issueIds = append(issueIds, issue.Id)
+		issue.Messages = []*model.IssueMessage{}
+	}
+
+	messages, err := i.IssueMessageRepo.FindByIssueIds(ctx, issueIds...)
+	if err != nil {
+		return err
+	}
+
+	messageIds := []uuid.UUID{}
+	idToMessage := map[uuid.UUID]*model.IssueMessage{}
+	for _, message := range messages {
+		messageIds = append(messageIds, message.Id)
+		idToMessage[message.Id] = message
+		message.Attachments = []*model.IssueAttachment{}
+	}
+
+	attachments, err := i.IssueAttachmentRepo.FindByMessageIds(ctx, messageIds...)
+	if err != nil {
+		return err
+	}
+	if err := presignIssueAttachments(ctx, attachments...); err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		if message, exist := idToMessage[attachment.MessageId]; exist {
+			message.Attachments = append(message.Attachments, attachment)
+		}
+	}
+
+	idToIssue := map[uuid.UUID]*model.Issue{}
+	for _, issue := range issues {
+		idToIssue[issue.Id] = issue
+	}
+	for _, message := range messages {
+		if issue, exist := idToIssue[message.IssueId]; exist {
+			issue.Messages = append(issue.Messages, message)
+		}
+	}
+
+	return nil
+}
+
+func (i *IssueUseCase) GetIssueHeaderMetadata(ctx context.Context, adminId uuid.UUID) (*model.IssueHeaderMetadata, error) {
+	return i.IssueRepo.GetIssueHeaderMetadata(ctx, adminId)
+}
+
+func (i *IssueUseCase) FindSLATargets(ctx context.Context) ([]*model.IssueSLATarget, error) {
+	return i.IssueSLATargetRepo.FindAll(ctx)
+}
+
+// UpdateSLATargets replaces the targets of the priorities given, the due dates of the open issues are kept
+func (i *IssueUseCase) UpdateSLATargets(ctx context.Context, input model.IssueSLAInput) ([]*model.IssueSLATarget, error) {
+	now := time.Now()
+	for _, target := range input.Targets {
+		if target.FirstResponseMinutes > target.ResolutionMinutes {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSLATarget, target.Priority)
+		}
+		target.UpdatedAt = now
+	}
+
+	before, err := i.IssueSLATargetRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*model.IssueSLATarget
+	if err := i.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := i.IssueSLATargetRepo.Upsert(ctx, input.Targets); err != nil {
+			return err
+		}
+
+		if targets, err = i.IssueSLATargetRepo.FindAll(ctx); err != nil {
+			return err
+		}
+
+		return recordAudit(ctx, i.AuditRepo, model.AuditIssueSLAUpdateAction, model.AuditIssueSLATarget, nil, issueSLAState(before), issueSLAState(targets))
+	}); err != nil {
+		return nil, err
+	}
+
+	return targets, nil
+}
+
+// issueSLAState keys the targets by their priority for the audit log
+func issueSLAState(targets []*model.IssueSLATarget) map[string]*model.IssueSLATarget {
+	state := map[string]*model.IssueSLATarget{}
+	for _, target := range targets {
+		state[target.Priority] = target
+	}
+
+	return state
+}
+
+// applyIssueSLA sets the due dates of the issue from the creation and the SLA target of its priority
+func applyIssueSLA(ctx context.Context, repo repository.IssueSLATarget, issue *model.Issue) error {
+	if issue.Priority == "" {
+		issue.Priority = model.IssueNormalPriority
+	}
+
+	targets, err := repo.FindAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	target := defaultIssueSLATargets[issue.Priority]
+	for _, t := range targets {
+		if t.Priority == issue.Priority {
+			target = *t
+		}
+	}
+
+	setIssueDueDates(issue, target)
+	return nil
+}
+
+func setIssueDueDates(issue *model.Issue, target model.IssueSLATarget) {
+	issue.FirstResponseDueAt = issue.CreatedAt.Add(time.Duration(target.FirstResponseMinutes) * time.Minute)
+	issue.DueDate = issue.CreatedAt.Add(time.Duration(target.ResolutionMinutes) * time.Minute)
+}
+
+// markIssueBreaches flags the targets an open issue is past and those a closed or answered one was reached too late
+func markIssueBreaches(now time.Time, issues ...*model.Issue) {
+	for _, issue := range issues {
+		open := issue.Status == model.IssueOpenStatus
+
+		if issue.FirstRespondedAt != nil {
+			issue.ResponseBreached = issue.FirstRespondedAt.After(issue.FirstResponseDueAt)
+		} else {
+			issue.ResponseBreached = open && now.After(issue.FirstResponseDueAt)
+		}
+
+		if issue.ClosedAt != nil {
+			issue.ResolutionBreached = issue.ClosedAt.After(issue.DueDate)
+		} else {
+			issue.ResolutionBreached = open && now.After(issue.DueDate)
+		}
+	}
+}
+
+// checkIssueUploads detects the content types of the uploads and checks they can be attached
+func checkIssueUploads(uploads []IssueUpload) ([]string, error) {
+	if len(uploads) > MaxIssueAttachments {
+		return nil, ErrTooManyAttachments
+	}
+
+	contentTypes := []string{}
+	for _, upload := range uploads {
+		if len(upload.Data) == 0 || len(upload.Data) > MaxIssueAttachmentSize {
+			return nil, ErrInvalidAttachment
+		}
+
+		contentType := http.DetectContentType(upload.Data)
+		if !issueAttachmentTypes[contentType] {
+			return nil, ErrInvalidAttachment
+		}
+		contentTypes = append(contentTypes, contentType)
+	}
+
+	return contentTypes, nil
+}
+
+// presignIssueAttachments links the attachments for a short while, the bucket is not public
+func presignIssueAttachments(ctx context.Context, attachments ...*model.IssueAttachment) error {
+	if len(attachments) == 0 {
+		return nil
+	}
+
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		url, err := bucket.PresignGetURL(ctx, s3utils.IssueAttachmentBucket, attachment.ObjectKey, issueAttachmentURLTTL)
+		if err != nil {
+			return err
+		}
+		attachment.URL = url
+	}
+
+	return nil
+}
+
+// the attachments uploaded for a message that could not be posted are removed on a best effort basis
+func deleteIssueAttachments(ctx context.Context, bucket *s3utils.BucketBasics, attachments []*model.IssueAttachment) {
+	for _, attachment := range attachments {
+		if err := bucket.DeleteFile(ctx, s3utils.IssueAttachmentBucket, attachment.ObjectKey); err != nil {
+			slog.WarnContext(ctx, "unable to delete attachment", "key", attachment.ObjectKey, "error", err)
+		}
+	}
 }
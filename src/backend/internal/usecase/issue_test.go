@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeIssues locks a copy of the issue given and keeps the issue updated
+type fakeIssues struct {
+	repository.Issue
+	locked  *model.Issue
+	updated *model.Issue
+}
+
+func (f *fakeIssues) LockOneById(ctx context.Context, id uuid.UUID) (*model.Issue, error) {
+	locked := *f.locked
+	return &locked, nil
+}
+
+func (f *fakeIssues) UpdateOne(ctx context.Context, issue *model.Issue) error {
+	f.updated = issue
+	return nil
+}
+
+// fakeIssueSLATargets has no target set, so that the default ones apply
+type fakeIssueSLATargets struct {
+	repository.IssueSLATarget
+}
+
+func (f *fakeIssueSLATargets) FindAll(ctx context.Context) ([]*model.IssueSLATarget, error) {
+	return nil, nil
+}
+
+func TestSetIssueDueDates(t *testing.T) {
+	createdAt := time.Date(2024, 4, 22, 9, 0, 0, 0, time.UTC)
+	issue := &model.Issue{CreatedAt: createdAt}
+
+	setIssueDueDates(issue, model.IssueSLATarget{FirstResponseMinutes: 60, ResolutionMinutes: 8 * 60})
+
+	assert.Equal(t, createdAt.Add(time.Hour), issue.FirstResponseDueAt)
+	assert.Equal(t, createdAt.Add(8*time.Hour), issue.DueDate)
+}
+
+func TestMarkIssueBreaches(t *testing.T) {
+	createdAt := time.Date(2024, 4, 22, 9, 0, 0, 0, time.UTC)
+	now := createdAt.Add(6 * time.Hour)
+	newIssue := func(status string) *model.Issue {
+		return &model.Issue{
+			Status:             status,
+			CreatedAt:          createdAt,
+			FirstResponseDueAt: createdAt.Add(4 * time.Hour),
+			DueDate:            createdAt.Add(24 * time.Hour),
+		}
+	}
+
+	waiting := newIssue(model.IssueOpenStatus)
+
+	answered := newIssue(model.IssueOpenStatus)
+	answeredAt := createdAt.Add(time.Hour)
+	answered.FirstRespondedAt = &answeredAt
+
+	closedLate := newIssue(model.IssueClosedStatus)
+	closedAt := createdAt.Add(30 * time.Hour)
+	closedLate.FirstRespondedAt = &answeredAt
+	closedLate.ClosedAt = &closedAt
+
+	// closed before the due dates were tracked
+	legacy := newIssue(model.IssueClosedStatus)
+
+	markIssueBreaches(now, waiting, answered, closedLate, legacy)
+
+	assert.True(t, waiting.ResponseBreached)
+	assert.False(t, waiting.ResolutionBreached)
+	assert.False(t, answered.ResponseBreached)
+	assert.False(t, answered.ResolutionBreached)
+	assert.False(t, closedLate.ResponseBreached)
+	assert.True(t, closedLate.ResolutionBreached)
+	assert.False(t, legacy.ResponseBreached)
+	assert.False(t, legacy.ResolutionBreached)
+}
+
+func TestCheckIssueUploads(t *testing.T) {
+	png := append([]byte("\x89PNG\r\n\x1a\n"), make([]byte, 16)...)
+	contentTypes, err := checkIssueUploads([]IssueUpload{
+		{Filename: "screenshot.png", Data: png},
+		{Filename: "steps.txt", Data: []byte("open the gallery and press pay")},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"image/png", "text/plain; charset=utf-8"}, contentTypes)
+
+	_, err = checkIssueUploads([]IssueUpload{{Filename: "run.exe", Data: []byte("MZ\x90\x00\x03\x00\x00\x00\x04\x00")}})
+	assert.ErrorIs(t, err, ErrInvalidAttachment)
+
+	_, err = checkIssueUploads([]IssueUpload{{Filename: "big.txt", Data: bytes.Repeat([]byte("a"), MaxIssueAttachmentSize+1)}})
+	assert.ErrorIs(t, err, ErrInvalidAttachment)
+
+	_, err = checkIssueUploads(make([]IssueUpload, MaxIssueAttachments+1))
+	assert.ErrorIs(t, err, ErrTooManyAttachments)
+}
+
+func TestCloseRejectsClosedIssue(t *testing.T) {
+	issue := &model.Issue{Id: uuid.New(), Status: model.IssueOpenStatus}
+
+	// another agent has closed the issue meanwhile
+	issues := &fakeIssues{locked: &model.Issue{Id: issue.Id, Status: model.IssueClosedStatus}}
+	issueUsecase := &IssueUseCase{IssueRepo: issues, UnitOfWork: fakeUnitOfWork{}}
+
+	assert.ErrorIs(t, issueUsecase.Close(context.Background(), issue), ErrIssueClosed)
+	assert.Nil(t, issues.updated)
+}
+
+func TestReplyDoesNotReopenClosedIssue(t *testing.T) {
+	issue := &model.Issue{Id: uuid.New(), Status: model.IssueOpenStatus}
+	closedAt := time.Now()
+	issues := &fakeIssues{locked: &model.Issue{Id: issue.Id, Status: model.IssueClosedStatus, ClosedAt: &closedAt}}
+	issueUsecase := &IssueUseCase{IssueRepo: issues, UnitOfWork: fakeUnitOfWork{}}
+
+	_, err := issueUsecase.Reply(context.Background(), issue, uuid.New(), false, "any news?", nil)
+	assert.ErrorIs(t, err, ErrIssueClosed)
+	assert.Nil(t, issues.updated)
+}
@@ -0,0 +1,149 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	defaultJobMaxAttempts = 5
+	jobBaseBackoff        = 10 * time.Second
+	jobMaxBackoff         = time.Hour
+	jobMaxErrorSize       = 2000
+
+	// the failed jobs are kept for inspection
+	succeededJobRetention = 7 * 24 * time.Hour
+)
+
+// ErrJobClaimLost is returned when the lease of a job has passed and the job has been claimed again, the outcome of the
+// run is dropped so that it does not overwrite the one of the worker the job has been handed to
+var ErrJobClaimLost = errors.New("the job has been claimed by another worker")
+
+type JobUseCase struct {
+	JobRepo repository.Job
+}
+
+func NewJobUseCase(db *bun.DB) *JobUseCase {
+	return &JobUseCase{
+		JobRepo: postgres.NewJobDB(db),
+	}
+}
+
+// enqueueJob writes the job to the outbox, called with the context of a transaction the job is
+// only picked up by the workers if the transaction commits
+func enqueueJob(ctx context.Context, jobRepo repository.Job, kind string, payload any) error {
+	job, err := newJob(kind, payload, time.Now())
+	if err != nil {
+		return err
+	}
+
+	return jobRepo.AddOne(ctx, job)
+}
+
+func newJob(kind string, payload any, runAt time.Time) (*model.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Job{
+		Id:          uuid.New(),
+		Kind:        kind,
+		Payload:     encoded,
+		Status:      model.JobPendingStatus,
+		MaxAttempts: defaultJobMaxAttempts,
+		RunAt:       runAt,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+func (j *JobUseCase) Enqueue(ctx context.Context, kind string, payload any) error {
+	return enqueueJob(ctx, j.JobRepo, kind, payload)
+}
+
+// EnqueuePeriodic queues the job for the period that runAt falls in, the other workers asking for the same period are ignored
+func (j *JobUseCase) EnqueuePeriodic(ctx context.Context, kind string, period time.Duration, runAt time.Time) (bool, error) {
+	job, err := newJob(kind, struct{}{}, runAt)
+	if err != nil {
+		return false, err
+	}
+
+	dedupKey := periodicDedupKey(kind, period, runAt)
+	job.DedupKey = &dedupKey
+	job.MaxAttempts = 1
+
+	return j.JobRepo.AddUnique(ctx, job)
+}
+
+func periodicDedupKey(kind string, period time.Duration, runAt time.Time) string {
+	return kind + "@" + runAt.Truncate(period).UTC().Format(time.RFC3339)
+}
+
+func (j *JobUseCase) Claim(ctx context.Context, workerId string, lease time.Duration, limit int) ([]*model.Job, error) {
+	now := time.Now()
+	return j.JobRepo.ClaimDue(ctx, workerId, now, now.Add(lease), limit)
+}
+
+// Finish records the outcome of a run by the worker, a failed job is retried with an exponential backoff until it runs
+// out of attempts. A job no longer claimed by the worker returns ErrJobClaimLost.
+func (j *JobUseCase) Finish(ctx context.Context, workerId string, job *model.Job, runErr error) error {
+	now := time.Now()
+	job.LockedBy = nil
+	job.LockedUntil = nil
+
+	if runErr == nil {
+		job.Status = model.JobSucceededStatus
+		job.CompletedAt = &now
+		job.LastError = nil
+		return j.updateClaimed(ctx, workerId, job)
+	}
+
+	lastError := runErr.Error()
+	if len(lastError) > jobMaxErrorSize {
+		lastError = lastError[:jobMaxErrorSize]
+	}
+	job.LastError = &lastError
+
+	if job.Attempts >= job.MaxAttempts {
+		job.Status = model.JobFailedStatus
+		job.CompletedAt = &now
+	} else {
+		job.Status = model.JobPendingStatus
+		job.RunAt = now.Add(jobBackoff(job.Attempts))
+	}
+
+	return j.updateClaimed(ctx, workerId, job)
+}
+
+func (j *JobUseCase) updateClaimed(ctx context.Context, workerId string, job *model.Job) error {
+	updated, err := j.JobRepo.UpdateClaimed(ctx, job, workerId)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrJobClaimLost
+	}
+
+	return nil
+}
+
+func (j *JobUseCase) PurgeSucceeded(ctx context.Context) (int, error) {
+	return j.JobRepo.DeleteSucceededBefore(ctx, time.Now().Add(-succeededJobRetention))
+}
+
+func jobBackoff(attempts int) time.Duration {
+	backoff := jobBaseBackoff
+	for i := 1; i < attempts && backoff < jobMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, jobMaxBackoff)
+}
@@ -0,0 +1,88 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJobBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, jobBackoff(1))
+	assert.Equal(t, 20*time.Second, jobBackoff(2))
+	assert.Equal(t, jobMaxBackoff, jobBackoff(30))
+}
+
+func TestPeriodicDedupKey(t *testing.T) {
+	first := time.Date(2024, 4, 10, 9, 0, 5, 0, time.UTC)
+	second := time.Date(2024, 4, 10, 9, 0, 55, 0, time.UTC)
+	next := time.Date(2024, 4, 10, 9, 1, 0, 0, time.UTC)
+
+	assert.Equal(t, "booking.update_status@2024-04-10T09:00:00Z", periodicDedupKey("booking.update_status", time.Minute, first))
+	assert.Equal(t, periodicDedupKey("booking.update_status", time.Minute, first), periodicDedupKey("booking.update_status", time.Minute, second))
+	assert.NotEqual(t, periodicDedupKey("booking.update_status", time.Minute, first), periodicDedupKey("booking.update_status", time.Minute, next))
+}
+
+// fakeJobs keeps the jobs queued and the last job updated by the worker that has claimed it
+type fakeJobs struct {
+	repository.Job
+	queued    []*model.Job
+	claimedBy string
+	updated   *model.Job
+}
+
+func (f *fakeJobs) AddOne(ctx context.Context, job *model.Job) error {
+	f.queued = append(f.queued, job)
+	return nil
+}
+
+func (f *fakeJobs) UpdateClaimed(ctx context.Context, job *model.Job, workerId string) (bool, error) {
+	if workerId != f.claimedBy {
+		return false, nil
+	}
+
+	updated := *job
+	f.updated = &updated
+	return true, nil
+}
+
+func TestFinishRetriesFailedJob(t *testing.T) {
+	workerId, leaseUntil := "worker", time.Now().Add(time.Minute)
+	jobs := &fakeJobs{claimedBy: workerId}
+	jobUsecase := &JobUseCase{JobRepo: jobs}
+	job := &model.Job{Id: uuid.New(), Status: model.JobRunningStatus, Attempts: 2, MaxAttempts: 3, LockedBy: &workerId, LockedUntil: &leaseUntil}
+
+	before := time.Now()
+	assert.NoError(t, jobUsecase.Finish(context.Background(), workerId, job, errors.New("smtp timeout")))
+	assert.Equal(t, model.JobPendingStatus, jobs.updated.Status)
+	assert.Equal(t, "smtp timeout", *jobs.updated.LastError)
+	assert.Nil(t, jobs.updated.LockedBy)
+	assert.Nil(t, jobs.updated.LockedUntil)
+	assert.False(t, jobs.updated.RunAt.Before(before.Add(jobBackoff(2))))
+
+	// the last attempt fails the job for good
+	job.Attempts = 3
+	assert.NoError(t, jobUsecase.Finish(context.Background(), workerId, job, errors.New("smtp timeout")))
+	assert.Equal(t, model.JobFailedStatus, jobs.updated.Status)
+	assert.NotNil(t, jobs.updated.CompletedAt)
+
+	assert.NoError(t, jobUsecase.Finish(context.Background(), workerId, job, nil))
+	assert.Equal(t, model.JobSucceededStatus, jobs.updated.Status)
+	assert.Nil(t, jobs.updated.LastError)
+}
+
+func TestFinishDropsJobClaimedAgain(t *testing.T) {
+	// the lease of the first worker has passed and the job has been handed to another one
+	jobs := &fakeJobs{claimedBy: "second"}
+	jobUsecase := &JobUseCase{JobRepo: jobs}
+	firstWorker, leaseUntil := "first", time.Now().Add(-time.Minute)
+	job := &model.Job{Id: uuid.New(), Status: model.JobRunningStatus, Attempts: 1, MaxAttempts: 3, LockedBy: &firstWorker, LockedUntil: &leaseUntil}
+
+	assert.ErrorIs(t, jobUsecase.Finish(context.Background(), firstWorker, job, nil), ErrJobClaimLost)
+	assert.Nil(t, jobs.updated)
+}
@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+// NotificationChannel is the redis channel the chat servers listen on to push notifications to the connected users
+const NotificationChannel = "notifications"
+
+var notificationTitles = map[string]string{
+	model.NotificationBookingCreatedType:            "A photographer created a booking for you",
+	model.NotificationBookingPaidType:               "Your booking has been paid",
+	model.NotificationBookingCancelRequestedType:    "A cancellation of your booking was requested",
+	model.NotificationBookingCancelledType:          "Your booking has been cancelled",
+	model.NotificationRefundRequestedType:           "A refund of your booking was requested",
+	model.NotificationRefundApprovedType:            "Your refund has been approved",
+	model.NotificationRefundRejectedType:            "Your refund has been rejected",
+	model.NotificationReviewReceivedType:            "You received a new review",
+	model.NotificationIssueClosedType:               "Your issue has been closed",
+	model.NotificationVerificationApprovedType:      "Your photographer verification has been approved",
+	model.NotificationVerificationRejectedType:      "Your photographer verification has been rejected",
+	model.NotificationVerificationInfoRequestedType: "More information is needed for your photographer verification",
+	model.NotificationBookingRequestedType:          "A customer requested a booking of your gallery",
+	model.NotificationBookingRequestClosedType:      "A booking request has been closed",
+	model.NotificationQuoteReceivedType:             "You received a quote for a booking request",
+	model.NotificationQuoteAcceptedType:             "Your quote has been accepted",
+	model.NotificationDepositPaidType:               "The deposit of your booking has been paid",
+	model.NotificationSessionReminderType:           "Your session is coming up",
+	model.NotificationReviewRequestedType:           "Tell us about your booking",
+	model.NotificationBookingExpiredType:            "A booking expired before it was paid",
+	model.NotificationDeliveryOverdueType:           "The photos of a booking are overdue",
+	model.NotificationPhotosDeliveredType:           "The photos of your booking have been delivered",
+	model.NotificationIssueRepliedType:              "Support replied to your issue",
+	model.NotificationIssueReporterRepliedType:      "The reporter replied to an issue assigned to you",
+	model.NotificationIssueAssignedType:             "An issue has been assigned to you",
+	model.NotificationDisputeOpenedType:             "A dispute has been opened on your booking",
+	model.NotificationDisputeEvidenceAddedType:      "New evidence has been added to a dispute",
+	model.NotificationDisputeWithdrawnType:          "A dispute on your booking has been withdrawn",
+	model.NotificationDisputeResolvedType:           "A dispute on your booking has been resolved",
+}
+
+// NotificationEvent is published on NotificationChannel whenever a notification is created
+type NotificationEvent struct {
+	UserId       uuid.UUID           `json:"user_id"`
+	Notification *model.Notification `json:"notification"`
+}
+
+type NotificationUseCase struct {
+	NotificationRepo repository.Notification
+	JobRepo          repository.Job
+}
+
+func NewNotificationUseCase(db *bun.DB) *NotificationUseCase {
+	return &NotificationUseCase{
+		NotificationRepo: postgres.NewNotificationDB(db),
+		JobRepo:          postgres.NewJobDB(db),
+	}
+}
+
+// Notify queues the notification, it is stored and pushed to the user's open connections by the worker
+func (n *NotificationUseCase) Notify(ctx context.Context, userId uuid.UUID, notificationType string, resourceId *uuid.UUID, body *string) error {
+	return enqueueNotification(ctx, n.JobRepo, userId, notificationType, resourceId, body)
+}
+
+func enqueueNotification(ctx context.Context, jobRepo repository.Job, userId uuid.UUID, notificationType string, resourceId *uuid.UUID, body *string) error {
+	return enqueueJob(ctx, jobRepo, model.JobSendNotificationKind, model.NotificationJob{
+		Id:         uuid.New(),
+		UserId:     userId,
+		Type:       notificationType,
+		ResourceId: resourceId,
+		Body:       body,
+	})
+}
+
+// Send stores the queued notification and pushes it, a retried job does not store it twice
+func (n *NotificationUseCase) Send(ctx context.Context, job model.NotificationJob) error {
+	if _, err := n.NotificationRepo.FindOneById(ctx, job.Id); err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	notification := &model.Notification{
+		Id:         job.Id,
+		UserId:     job.UserId,
+		Type:       job.Type,
+		Title:      notificationTitles[job.Type],
+		Body:       job.Body,
+		ResourceId: job.ResourceId,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := n.NotificationRepo.AddOne(ctx, notification); err != nil {
+		return err
+	}
+
+	// the notification is already in the list of the user, missing the live push is not worth a retry
+	if err := publishNotification(ctx, notification); err != nil {
+		slog.WarnContext(ctx, "unable to publish notification", "notification", notification.Id, "error", err)
+	}
+
+	return nil
+}
+
+func publishNotification(ctx context.Context, notification *model.Notification) error {
+	if databases.RedisClient == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(NotificationEvent{
+		UserId:       notification.UserId,
+		Notification: notification,
+	})
+	if err != nil {
+		return err
+	}
+
+	return databases.RedisClient.Publish(ctx, NotificationChannel, payload).Err()
+}
+
+func (n *NotificationUseCase) FindWithFilter(ctx context.Context, userId uuid.UUID, filter model.NotificationFilter) ([]*model.Notification, error) {
+	return n.NotificationRepo.FindWithFilter(ctx, userId, filter)
+}
+
+func (n *NotificationUseCase) CountUnreadByUserId(ctx context.Context, userId uuid.UUID) (int, error) {
+	return n.NotificationRepo.CountUnreadByUserId(ctx, userId)
+}
@@ -0,0 +1,518 @@
+package usecase
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	DataExportTTL   = time.Hour * 24 * 7
+	redactedMessage = "[deleted]"
+)
+
+var (
+	ErrActiveBookings = errors.New("the account still has ongoing bookings, please wait until they are completed or cancelled")
+	ErrExportNotReady = errors.New("the export is not ready yet")
+	ErrExportExpired  = errors.New("the export has expired, please request a new one")
+)
+
+// bookings in these statuses still need both parties to be reachable
+var activeBookingStatuses = map[string]bool{
+	model.BookingPaidStatus:                  true,
+	model.BookingPartiallyPaidStatus:         true,
+	model.BookingCustomerReqCancelStatus:     true,
+	model.BookingPhotographerReqCancelStatus: true,
+	model.BookingRefundReqStatus:             true,
+	model.BookingDisputedStatus:              true,
+}
+
+type s3Object struct {
+	Bucket string
+	Key    string
+}
+
+// PersonalDataUseCase gathers everything the platform stores about a user for the data export and the erasure
+type PersonalDataUseCase struct {
+	DataExportRepo         repository.DataExport
+	UserRepo               repository.User
+	UserIdentityRepo       repository.UserIdentity
+	BookingRepo            repository.Booking
+	ReviewRepo             repository.Review
+	ConversationRepo       repository.Conversation
+	IssueRepo              repository.Issue
+	IssueMessageRepo       repository.IssueMessage
+	IssueAttachmentRepo    repository.IssueAttachment
+	DisputeEvidenceRepo    repository.DisputeEvidence
+	VerificationTicketRepo repository.VerificationTicket
+	VerificationEventRepo  repository.VerificationEvent
+	GalleryRepo            repository.Gallery
+	PhotoRepo              repository.Photo
+	NotificationRepo       repository.Notification
+	CalendarFeedRepo       repository.CalendarFeed
+	BusyBlockRepo          repository.BusyBlock
+	JobRepo                repository.Job
+	UnitOfWork             repository.UnitOfWork
+}
+
+func NewPersonalDataUseCase(db *bun.DB) *PersonalDataUseCase {
+	return &PersonalDataUseCase{
+		DataExportRepo:         postgres.NewDataExportDB(db),
+		UserRepo:               postgres.NewUserDB(db),
+		UserIdentityRepo:       postgres.NewUserIdentityDB(db),
+		BookingRepo:            postgres.NewBookingDB(db),
+		ReviewRepo:             postgres.NewReviewDB(db),
+		ConversationRepo:       postgres.NewConversationDB(db),
+		IssueRepo:              postgres.NewIssueDB(db),
+		IssueMessageRepo:       postgres.NewIssueMessageDB(db),
+		IssueAttachmentRepo:    postgres.NewIssueAttachmentDB(db),
+		DisputeEvidenceRepo:    postgres.NewDisputeEvidenceDB(db),
+		VerificationTicketRepo: postgres.NewVerificationInfoDB(db),
+		VerificationEventRepo:  postgres.NewVerificationEventDB(db),
+		GalleryRepo:            postgres.NewGalleryDB(db),
+		PhotoRepo:              postgres.NewPhotoDB(db),
+		NotificationRepo:       postgres.NewNotificationDB(db),
+		CalendarFeedRepo:       postgres.NewCalendarFeedDB(db),
+		BusyBlockRepo:          postgres.NewBusyBlockDB(db),
+		JobRepo:                postgres.NewJobDB(db),
+		UnitOfWork:             postgres.NewUnitOfWorkDB(db),
+	}
+}
+
+type personalData struct {
+	Profile               *model.User
+	Identities            []*model.UserIdentity
+	Bookings              []*model.Booking
+	PhotographerBookings  []*model.Booking
+	Reviews               []*model.Review
+	Conversations         []*model.Conversation
+	Issues                []*model.Issue
+	DisputeEvidence       []*model.DisputeEvidence
+	VerificationTickets   []*model.VerificationTicket
+	VerificationEvents    []*model.VerificationEvent
+	Galleries             []*model.Gallery
+	Photos                []*model.Photo
+	DataExports           []*model.DataExport
+	Objects               []s3Object
+	hasActiveBookings     bool
+	verificationTicketIds []uuid.UUID
+	issueAttachmentIds    []uuid.UUID
+	photoIds              []uuid.UUID
+}
+
+func (p *PersonalDataUseCase) collect(ctx context.Context, user *model.User) (*personalData, error) {
+	data := &personalData{
+		Profile: user,
+	}
+
+	var err error
+	if data.Identities, err = p.UserIdentityRepo.FindByUserId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.Bookings, err = p.BookingRepo.FindByUserIdWithStatus(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.PhotographerBookings, err = p.BookingRepo.FindByPhotographerIdWithStatus(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.Reviews, err = p.ReviewRepo.FindByUserId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.Conversations, err = p.ConversationRepo.FindByUserId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+
+	reporterId := user.Id.String()
+	if data.Issues, err = p.IssueRepo.FindIssuesWithFilter(ctx, model.IssueFilter{ReporterId: &reporterId}); err != nil {
+		return nil, err
+	}
+	if err := p.collectIssueThreads(ctx, user, data); err != nil {
+		return nil, err
+	}
+	if data.DisputeEvidence, err = p.DisputeEvidenceRepo.FindBySubmitterId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.VerificationTickets, err = p.VerificationTicketRepo.FindByUserIds(ctx, []uuid.UUID{user.Id}); err != nil {
+		return nil, err
+	}
+	if data.VerificationEvents, err = p.VerificationEventRepo.FindByUserId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	if data.Galleries, err = p.GalleryRepo.FindByPhotographerId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+	for _, gallery := range data.Galleries {
+		photos, err := p.PhotoRepo.FindByGalleryId(ctx, gallery.Id)
+		if err != nil {
+			return nil, err
+		}
+		data.Photos = append(data.Photos, photos...)
+	}
+	if data.DataExports, err = p.DataExportRepo.FindByUserId(ctx, user.Id); err != nil {
+		return nil, err
+	}
+
+	for _, bookings := range [][]*model.Booking{data.Bookings, data.PhotographerBookings} {
+		for _, booking := range bookings {
+			if activeBookingStatuses[booking.Status] {
+				data.hasActiveBookings = true
+			}
+		}
+	}
+
+	if user.ProfilePictureKey != nil {
+		data.Objects = append(data.Objects, s3Object{s3utils.ProfilePicBucket, *user.ProfilePictureKey})
+	}
+	for _, ticket := range data.VerificationTickets {
+		data.verificationTicketIds = append(data.verificationTicketIds, ticket.Id)
+		data.Objects = append(data.Objects, s3Object{s3utils.IdCardBucket, ticket.IdCardPictureKey})
+	}
+	for _, evidence := range data.DisputeEvidence {
+		for _, key := range evidence.PhotoKeys {
+			data.Objects = append(data.Objects, s3Object{s3utils.DisputeEvidenceBucket, key})
+		}
+	}
+	for _, photo := range data.Photos {
+		data.photoIds = append(data.photoIds, photo.Id)
+		data.Objects = append(data.Objects, s3Object{s3utils.GalleryPhotoBucket, photo.PhotoKey})
+	}
+
+	return data, nil
+}
+
+// collectIssueThreads adds the replies to the issues of the user and the files the user attached to replies
+func (p *PersonalDataUseCase) collectIssueThreads(ctx context.Context, user *model.User, data *personalData) error {
+	issueIds := []uuid.UUID{}
+	idToIssue := map[uuid.UUID]*model.Issue{}
+	for _, issue := range data.Issues {
+		issueIds = append(issueIds, issue.Id)
+		idToIssue[issue.Id] = issue
+	}
+
+	messages, err := p.IssueMessageRepo.FindByIssueIds(ctx, issueIds...)
+	if err != nil {
+		return err
+	}
+	for _, message := range messages {
+		idToIssue[message.IssueId].Messages = append(idToIssue[message.IssueId].Messages, message)
+	}
+
+	attachments, err := p.IssueAttachmentRepo.FindByAuthorId(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+	for _, attachment := range attachments {
+		data.issueAttachmentIds = append(data.issueAttachmentIds, attachment.Id)
+		data.Objects = append(data.Objects, s3Object{s3utils.IssueAttachmentBucket, attachment.ObjectKey})
+	}
+
+	return nil
+}
+
+func (p *PersonalDataUseCase) FindExportsByUserId(ctx context.Context, userId uuid.UUID) ([]*model.DataExport, error) {
+	return p.DataExportRepo.FindByUserId(ctx, userId)
+}
+
+func (p *PersonalDataUseCase) RequestExport(ctx context.Context, userId uuid.UUID) (*model.DataExport, error) {
+	export := &model.DataExport{
+		Id:        uuid.New(),
+		UserId:    userId,
+		Status:    model.DataExportPendingStatus,
+		CreatedAt: time.Now(),
+	}
+
+	// the archive may take a while for photographers with large galleries, it is built by the worker
+	if err := p.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := p.DataExportRepo.AddOne(ctx, export); err != nil {
+			return err
+		}
+
+		return enqueueJob(ctx, p.JobRepo, model.JobBuildDataExportKind, model.DataExportJob{ExportId: export.Id})
+	}); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// BuildQueuedExport builds the archive of a queued export, an export that is no longer pending has already been attempted
+// and its outcome is kept on the export
+func (p *PersonalDataUseCase) BuildQueuedExport(ctx context.Context, exportId uuid.UUID) error {
+	export, err := p.DataExportRepo.FindOneById(ctx, exportId)
+	if errors.Is(err, sql.ErrNoRows) {
+		// erased along with the account before the worker got to it
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status != model.DataExportPendingStatus {
+		return nil
+	}
+
+	user, err := p.UserRepo.FindOneById(ctx, export.UserId)
+	if err != nil {
+		return err
+	}
+
+	return p.BuildExport(ctx, export, user)
+}
+
+// BuildExport bundles the personal data of the user into a zip archive in the data-export bucket,
+// the outcome is recorded on the export so it can be run in the background
+func (p *PersonalDataUseCase) BuildExport(ctx context.Context, export *model.DataExport, user *model.User) error {
+	objectKey := fmt.Sprintf("%s/%s.zip", user.Id, export.Id)
+	err := p.uploadArchive(ctx, user, objectKey)
+	export.ObjectKey = &objectKey
+
+	now := time.Now()
+	export.CompletedAt = &now
+	if err != nil {
+		errMsg := err.Error()
+		export.Status = model.DataExportFailedStatus
+		export.Error = &errMsg
+		export.ObjectKey = nil
+	} else {
+		expiresAt := now.Add(DataExportTTL)
+		export.Status = model.DataExportCompletedStatus
+		export.ExpiresAt = &expiresAt
+	}
+
+	if updateErr := p.DataExportRepo.UpdateOne(ctx, export); updateErr != nil {
+		return updateErr
+	}
+
+	return err
+}
+
+// archiveObjects opens the files of the user added to the archive
+type archiveObjects interface {
+	GetFile(ctx context.Context, bucketName string, objectKey string) (io.ReadCloser, int64, error)
+}
+
+// uploadArchive uploads the archive while it is written, the files of a photographer with large galleries would not
+// fit in memory
+func (p *PersonalDataUseCase) uploadArchive(ctx context.Context, user *model.User, objectKey string) error {
+	data, err := p.collect(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return err
+	}
+
+	return streamArchive(ctx, data, bucket, func(archive io.Reader) error {
+		return bucket.UploadStream(ctx, s3utils.DataExportBucket, objectKey, archive, "application/zip")
+	})
+}
+
+// streamArchive writes the archive into a pipe read by upload, a failure on either end stops the other one
+func streamArchive(ctx context.Context, data *personalData, objects archiveObjects, upload func(archive io.Reader) error) error {
+	reader, writer := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		err := writeArchive(ctx, writer, data, objects)
+		writer.CloseWithError(err)
+		written <- err
+	}()
+
+	uploadErr := upload(reader)
+	reader.CloseWithError(uploadErr)
+	if err := <-written; err != nil {
+		return err
+	}
+
+	return uploadErr
+}
+
+func writeArchive(ctx context.Context, w io.Writer, data *personalData, objects archiveObjects) error {
+	writer := zip.NewWriter(w)
+
+	documents := map[string]any{
+		"profile.json":               data.Profile,
+		"identities.json":            data.Identities,
+		"bookings.json":              data.Bookings,
+		"photographer_bookings.json": data.PhotographerBookings,
+		"reviews.json":               data.Reviews,
+		"conversations.json":         data.Conversations,
+		"issues.json":                data.Issues,
+		"dispute_evidence.json":      data.DisputeEvidence,
+		"verification_tickets.json":  data.VerificationTickets,
+		"verification_history.json":  data.VerificationEvents,
+		"galleries.json":             data.Galleries,
+	}
+	for name, document := range documents {
+		file, err := writer.Create(name)
+		if err != nil {
+			return err
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return err
+		}
+	}
+
+	for _, object := range data.Objects {
+		content, _, err := objects.GetFile(ctx, object.Bucket, object.Key)
+		if err != nil {
+			var noSuchKey *types.NoSuchKey
+			if errors.As(err, &noSuchKey) {
+				continue
+			}
+			return err
+		}
+
+		file, err := writer.Create(path.Join("files", object.Bucket, object.Key))
+		if err == nil {
+			_, err = io.Copy(file, content)
+		}
+		content.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// OpenExport returns the archive of a completed export, the caller has to close it
+func (p *PersonalDataUseCase) OpenExport(ctx context.Context, export *model.DataExport) (io.ReadCloser, int64, error) {
+	if export.Status != model.DataExportCompletedStatus || export.ObjectKey == nil {
+		return nil, 0, ErrExportNotReady
+	}
+	if export.ExpiresAt != nil && export.ExpiresAt.Before(time.Now()) {
+		return nil, 0, ErrExportExpired
+	}
+
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bucket.GetFile(ctx, s3utils.DataExportBucket, *export.ObjectKey)
+}
+
+// Erase anonymises the account and removes the personal data that is not needed for the financial records,
+// bookings, issues and galleries are kept but no longer point to anything identifying, and the galleries are hidden
+func (p *PersonalDataUseCase) Erase(ctx context.Context, user *model.User) error {
+	data, err := p.collect(ctx, user)
+	if err != nil {
+		return err
+	}
+
+	if data.hasActiveBookings {
+		return ErrActiveBookings
+	}
+
+	for _, export := range data.DataExports {
+		if export.ObjectKey != nil {
+			data.Objects = append(data.Objects, s3Object{s3utils.DataExportBucket, *export.ObjectKey})
+		}
+	}
+
+	// the objects are purged by the worker once the account is anonymised
+	return p.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := p.ConversationRepo.RedactByUserId(ctx, user.Id, redactedMessage); err != nil {
+			return err
+		}
+		if err := p.ReviewRepo.ClearTextByUserId(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := p.IssueRepo.RedactByReporterId(ctx, user.Id, redactedMessage); err != nil {
+			return err
+		}
+		if err := p.IssueMessageRepo.RedactByAuthorId(ctx, user.Id, redactedMessage); err != nil {
+			return err
+		}
+		if err := p.DisputeEvidenceRepo.RedactBySubmitterId(ctx, user.Id, redactedMessage); err != nil {
+			return err
+		}
+		if len(data.issueAttachmentIds) > 0 {
+			if _, err := p.IssueAttachmentRepo.DeleteByIds(ctx, data.issueAttachmentIds...); err != nil {
+				return err
+			}
+		}
+		if err := p.UserIdentityRepo.DeleteByUserId(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := p.NotificationRepo.DeleteByUserId(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := p.CalendarFeedRepo.DeleteByUserId(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := p.BusyBlockRepo.DeleteByPhotographerId(ctx, user.Id); err != nil {
+			return err
+		}
+		if err := p.GalleryRepo.HideByPhotographerId(ctx, user.Id); err != nil {
+			return err
+		}
+		if len(data.verificationTicketIds) > 0 {
+			if _, err := p.VerificationTicketRepo.DeleteByIds(ctx, data.verificationTicketIds...); err != nil {
+				return err
+			}
+		}
+		if len(data.photoIds) > 0 {
+			if _, err := p.PhotoRepo.DeleteByIds(ctx, data.photoIds...); err != nil {
+				return err
+			}
+		}
+		for _, export := range data.DataExports {
+			if _, err := p.DataExportRepo.DeleteOneById(ctx, export.Id); err != nil {
+				return err
+			}
+		}
+
+		anonymiseUser(user, time.Now())
+		if err := p.UserRepo.UpdateOne(ctx, user); err != nil {
+			return err
+		}
+
+		for _, object := range data.Objects {
+			if err := enqueueJob(ctx, p.JobRepo, model.JobDeleteS3ObjectKind, model.S3ObjectJob{
+				Bucket: object.Bucket,
+				Key:    object.Key,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func anonymiseUser(user *model.User, now time.Time) {
+	user.Username = fmt.Sprintf("deleted-%s", user.Id)
+	user.Email = fmt.Sprintf("deleted-%s@deleted.invalid", user.Id)
+	user.Provider = nil
+	user.Password = nil
+	user.LoggedOut = true
+	user.ProfilePictureKey = nil
+	user.Firstname = "Deleted"
+	user.Lastname = "User"
+	user.VerificationStatus = model.PhotographerNotVerifiedStatus
+	user.IsAdmin = false
+	user.About = nil
+	user.Address = nil
+	user.PhoneNumber = nil
+	user.Gender = nil
+	user.TaxId = nil
+	user.DeletedAt = &now
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymiseUser(t *testing.T) {
+	password := "hashed"
+	phone := "0812345678"
+	profileKey := "profile-key"
+	taxId := "1234567890123"
+	now := time.Now()
+
+	user := &model.User{
+		Id:                 uuid.New(),
+		Username:           "jdoe",
+		Email:              "jdoe@mail.com",
+		Password:           &password,
+		PhoneNumber:        &phone,
+		ProfilePictureKey:  &profileKey,
+		Firstname:          "John",
+		Lastname:           "Doe",
+		VerificationStatus: model.PhotographerVerifiedStatus,
+		IsAdmin:            true,
+		TaxId:              &taxId,
+	}
+
+	anonymiseUser(user, now)
+
+	assert.True(t, strings.HasSuffix(user.Email, "@deleted.invalid"))
+	assert.Contains(t, user.Email, user.Id.String())
+	assert.NotEqual(t, "jdoe", user.Username)
+	assert.Nil(t, user.Password)
+	assert.Nil(t, user.PhoneNumber)
+	assert.Nil(t, user.ProfilePictureKey)
+	assert.Nil(t, user.TaxId)
+	assert.Equal(t, "Deleted", user.Firstname)
+	assert.Equal(t, model.PhotographerNotVerifiedStatus, user.VerificationStatus)
+	assert.False(t, user.IsAdmin)
+	assert.True(t, user.LoggedOut)
+	assert.Equal(t, &now, user.DeletedAt)
+}
+
+// fakeObjects serves the files stored, any other key is missing like in S3
+type fakeObjects struct {
+	files map[string]string
+}
+
+func (f *fakeObjects) GetFile(ctx context.Context, bucketName string, objectKey string) (io.ReadCloser, int64, error) {
+	content, ok := f.files[path.Join(bucketName, objectKey)]
+	if !ok {
+		return nil, 0, &types.NoSuchKey{}
+	}
+
+	return io.NopCloser(strings.NewReader(content)), int64(len(content)), nil
+}
+
+func TestStreamArchive(t *testing.T) {
+	user := &model.User{Id: uuid.New(), Username: "jdoe"}
+	data := &personalData{
+		Profile: user,
+		Objects: []s3Object{
+			{s3utils.ProfilePicBucket, "jdoe.png"},
+			{s3utils.IdCardBucket, "purged.png"},
+		},
+	}
+	objects := &fakeObjects{files: map[string]string{path.Join(s3utils.ProfilePicBucket, "jdoe.png"): "picture"}}
+
+	archive := &bytes.Buffer{}
+	require.NoError(t, streamArchive(context.Background(), data, objects, func(content io.Reader) error {
+		_, err := io.Copy(archive, content)
+		return err
+	}))
+
+	reader, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	require.NoError(t, err)
+
+	files := map[string]string{}
+	for _, file := range reader.File {
+		content, err := file.Open()
+		require.NoError(t, err)
+		read, err := io.ReadAll(content)
+		require.NoError(t, err)
+		files[file.Name] = string(read)
+	}
+
+	assert.Contains(t, files["profile.json"], `"username": "jdoe"`)
+	assert.Equal(t, "picture", files["files/profile-picture/jdoe.png"])
+	// the files removed since are left out
+	assert.NotContains(t, files, "files/id-card/purged.png")
+}
+
+func TestStreamArchiveStopsWhenUploadFails(t *testing.T) {
+	data := &personalData{Profile: &model.User{Id: uuid.New()}}
+	errUpload := errors.New("connection reset")
+
+	// the archive is not written any further once the upload gives up
+	err := streamArchive(context.Background(), data, &fakeObjects{}, func(content io.Reader) error {
+		_, err := content.Read(make([]byte, 16))
+		require.NoError(t, err)
+		return errUpload
+	})
+	assert.ErrorIs(t, err, errUpload)
+}
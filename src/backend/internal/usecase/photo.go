@@ -3,9 +3,9 @@ package usecase
 import (
 	"context"
 
-	"github.com/Roongkun/software-eng-ii/internal/controller/util"
 	"github.com/Roongkun/software-eng-ii/internal/repository"
 	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
@@ -28,7 +28,7 @@ func (p *PhotoUseCase) FindUrlsByGalleryId(ctx context.Context, galleryId uuid.U
 
 	var urls []string
 	for _, photo := range photos {
-		urls = append(urls, util.GetGalleryPictureUrl(&photo.PhotoKey))
+		urls = append(urls, s3utils.ObjectUrl(s3utils.GalleryPhotoBucket, photo.PhotoKey))
 	}
 
 	return urls, nil
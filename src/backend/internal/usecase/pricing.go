@@ -0,0 +1,261 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const pricingDateLayout = "2006-01-02"
+
+var (
+	ErrUnknownAddOn           = errors.New("the add-on is not offered by the gallery")
+	ErrInvalidAddOnQuantity   = errors.New("the quantity of an add-on must be positive")
+	ErrInvalidAddOn           = errors.New("an add-on needs a name, a price of at least 0 and a unit of PER_BOOKING, PER_SESSION or PER_HOUR")
+	ErrInvalidPricingRule     = errors.New("the kind must be WEEKEND_SURCHARGE, HOLIDAY_SURCHARGE or SEASONAL_DISCOUNT, holidays and seasons need a start and an end date")
+	ErrInvalidNegotiatedPrice = errors.New("the negotiated price cannot be negative")
+)
+
+var addOnUnits = []string{model.AddOnPerBookingUnit, model.AddOnPerSessionUnit, model.AddOnPerHourUnit}
+
+type PricingUseCase struct {
+	GalleryAddOnRepo repository.GalleryAddOn
+	PricingRuleRepo  repository.PricingRule
+}
+
+func NewPricingUseCase(db *bun.DB) *PricingUseCase {
+	return &PricingUseCase{
+		GalleryAddOnRepo: postgres.NewGalleryAddOnDB(db),
+		PricingRuleRepo:  postgres.NewPricingRuleDB(db),
+	}
+}
+
+func (p *PricingUseCase) FindByGalleryId(ctx context.Context, galleryId uuid.UUID) (*model.GalleryPricing, error) {
+	addOns, err := p.GalleryAddOnRepo.FindByGalleryId(ctx, galleryId)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := p.PricingRuleRepo.FindByGalleryId(ctx, galleryId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.GalleryPricing{AddOns: addOns, Rules: rules}, nil
+}
+
+// Quote prices the planned sessions of a booking with the add-ons and the rules of the gallery
+func (p *PricingUseCase) Quote(ctx context.Context, gallery *model.Gallery, sessions []*model.BookingSession, proposal model.BookingProposal) (*model.Quote, error) {
+	pricing, err := p.FindByGalleryId(ctx, gallery.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return quoteBooking(gallery, pricing, sessions, proposal.AddOns, proposal.NegotiatedPrice)
+}
+
+func (p *PricingUseCase) CreateAddOn(ctx context.Context, galleryId uuid.UUID, input model.GalleryAddOnInput) (*model.GalleryAddOn, error) {
+	addOn := &model.GalleryAddOn{
+		Id:        uuid.New(),
+		GalleryId: galleryId,
+		Unit:      model.AddOnPerBookingUnit,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if input.Name == nil || input.Price == nil {
+		return nil, ErrInvalidAddOn
+	}
+	if err := applyAddOnInput(addOn, input); err != nil {
+		return nil, err
+	}
+
+	if err := p.GalleryAddOnRepo.AddOne(ctx, addOn); err != nil {
+		return nil, err
+	}
+
+	return addOn, nil
+}
+
+func (p *PricingUseCase) UpdateAddOn(ctx context.Context, addOn *model.GalleryAddOn, input model.GalleryAddOnInput) error {
+	if err := applyAddOnInput(addOn, input); err != nil {
+		return err
+	}
+
+	addOn.UpdatedAt = time.Now()
+	return p.GalleryAddOnRepo.UpdateOne(ctx, addOn)
+}
+
+func applyAddOnInput(addOn *model.GalleryAddOn, input model.GalleryAddOnInput) error {
+	if input.Name != nil {
+		if *input.Name == "" {
+			return ErrInvalidAddOn
+		}
+		addOn.Name = *input.Name
+	}
+	if input.Description != nil {
+		addOn.Description = input.Description
+	}
+	if input.Price != nil {
+		if *input.Price < 0 {
+			return ErrInvalidAddOn
+		}
+		addOn.Price = *input.Price
+	}
+	if input.Unit != nil {
+		if !slices.Contains(addOnUnits, *input.Unit) {
+			return ErrInvalidAddOn
+		}
+		addOn.Unit = *input.Unit
+	}
+
+	return nil
+}
+
+func (p *PricingUseCase) CreateRule(ctx context.Context, galleryId uuid.UUID, input model.PricingRuleInput) (*model.PricingRule, error) {
+	switch input.Kind {
+	case model.PricingWeekendSurchargeKind:
+	case model.PricingHolidaySurchargeKind, model.PricingSeasonalDiscountKind:
+		if input.StartDate == nil || input.EndDate == nil {
+			return nil, ErrInvalidPricingRule
+		}
+	default:
+		return nil, ErrInvalidPricingRule
+	}
+	if input.StartDate != nil && input.EndDate != nil && input.EndDate.Before(*input.StartDate) {
+		return nil, ErrInvalidPricingRule
+	}
+
+	rule := &model.PricingRule{
+		Id:        uuid.New(),
+		GalleryId: galleryId,
+		Kind:      input.Kind,
+		Name:      input.Name,
+		Percent:   input.Percent,
+		StartDate: input.StartDate,
+		EndDate:   input.EndDate,
+		CreatedAt: time.Now(),
+	}
+	if err := p.PricingRuleRepo.AddOne(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// quoteBooking charges the price of the gallery for every session, then the add-ons, a weekend or holiday surcharge
+// and a seasonal discount on the sessions they fall on. Only the largest surcharge and the largest discount apply
+// to a session. A negotiated price replaces the total and the difference is shown as an adjustment.
+func quoteBooking(gallery *model.Gallery, pricing *model.GalleryPricing, sessions []*model.BookingSession, selected []model.BookingAddOnInput, negotiatedPrice *int) (*model.Quote, error) {
+	quote := &model.Quote{Currency: gallery.Currency}
+	addLineItem := func(kind, description string, quantity, unitPrice int) {
+		quote.LineItems = append(quote.LineItems, &model.BookingLineItem{
+			Id:          uuid.New(),
+			Position:    len(quote.LineItems),
+			Kind:        kind,
+			Description: description,
+			Quantity:    quantity,
+			UnitPrice:   unitPrice,
+			Amount:      quantity * unitPrice,
+		})
+		quote.Total += quantity * unitPrice
+	}
+
+	addLineItem(model.LineItemBaseKind, fmt.Sprintf("%s (%d hours)", gallery.Name, gallery.Hours), len(sessions), gallery.Price)
+
+	for _, selection := range selected {
+		addOnIndex := slices.IndexFunc(pricing.AddOns, func(addOn *model.GalleryAddOn) bool { return addOn.Id == selection.AddOnId })
+		if addOnIndex < 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownAddOn, selection.AddOnId)
+		}
+		addOn := pricing.AddOns[addOnIndex]
+
+		quantity := selection.Quantity
+		if quantity == 0 {
+			quantity = 1
+		}
+		if quantity < 0 {
+			return nil, ErrInvalidAddOnQuantity
+		}
+		if addOn.Unit == model.AddOnPerSessionUnit {
+			quantity *= len(sessions)
+		}
+
+		addLineItem(model.LineItemAddOnKind, addOn.Name, quantity, addOn.Price)
+	}
+
+	// the number of sessions every rule applies to, in the order of the rules
+	ruleSessions := make([]int, len(pricing.Rules))
+	for _, session := range sessions {
+		surcharge, discount := -1, -1
+		for i, rule := range pricing.Rules {
+			if !ruleAppliesOn(rule, session.StartTime) {
+				continue
+			}
+
+			if rule.Kind == model.PricingSeasonalDiscountKind {
+				if discount < 0 || rule.Percent > pricing.Rules[discount].Percent {
+					discount = i
+				}
+			} else if surcharge < 0 || rule.Percent > pricing.Rules[surcharge].Percent {
+				surcharge = i
+			}
+		}
+
+		for _, i := range []int{surcharge, discount} {
+			if i >= 0 {
+				ruleSessions[i]++
+			}
+		}
+	}
+
+	for i, rule := range pricing.Rules {
+		if ruleSessions[i] == 0 {
+			continue
+		}
+
+		unitPrice := gallery.Price * rule.Percent / 100
+		if rule.Kind == model.PricingSeasonalDiscountKind {
+			addLineItem(model.LineItemDiscountKind, fmt.Sprintf("%s (-%d%%)", rule.Name, rule.Percent), ruleSessions[i], -unitPrice)
+		} else {
+			addLineItem(model.LineItemSurchargeKind, fmt.Sprintf("%s (+%d%%)", rule.Name, rule.Percent), ruleSessions[i], unitPrice)
+		}
+	}
+
+	if negotiatedPrice != nil {
+		if *negotiatedPrice < 0 {
+			return nil, ErrInvalidNegotiatedPrice
+		}
+		if adjustment := *negotiatedPrice - quote.Total; adjustment != 0 {
+			addLineItem(model.LineItemAdjustmentKind, "Negotiated price", 1, adjustment)
+		}
+	}
+
+	return quote, nil
+}
+
+// ruleAppliesOn compares the calendar date of the session, in the time zone it was booked in, with the dates of the rule
+func ruleAppliesOn(rule *model.PricingRule, startTime time.Time) bool {
+	date := startTime.Format(pricingDateLayout)
+	if rule.StartDate != nil && date < rule.StartDate.UTC().Format(pricingDateLayout) {
+		return false
+	}
+	if rule.EndDate != nil && date > rule.EndDate.UTC().Format(pricingDateLayout) {
+		return false
+	}
+
+	if rule.Kind == model.PricingWeekendSurchargeKind {
+		weekday := startTime.Weekday()
+		return weekday == time.Saturday || weekday == time.Sunday
+	}
+
+	return rule.StartDate != nil && rule.EndDate != nil
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuoteBooking(t *testing.T) {
+	gallery := &model.Gallery{Name: "Wedding", Hours: 4, Price: 10000}
+	saturday := time.Date(2024, 5, 4, 9, 0, 0, 0, time.UTC)
+	monday := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	sessions := []*model.BookingSession{{StartTime: saturday}, {StartTime: monday}}
+
+	album := &model.GalleryAddOn{Id: uuid.New(), Name: "Album", Price: 2000, Unit: model.AddOnPerBookingUnit}
+	drone := &model.GalleryAddOn{Id: uuid.New(), Name: "Drone", Price: 500, Unit: model.AddOnPerSessionUnit}
+	mayStart, mayEnd := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 5, 4, 0, 0, 0, 0, time.UTC)
+	pricing := &model.GalleryPricing{
+		AddOns: []*model.GalleryAddOn{album, drone},
+		Rules: []*model.PricingRule{
+			{Kind: model.PricingWeekendSurchargeKind, Name: "Weekend", Percent: 10},
+			{Kind: model.PricingHolidaySurchargeKind, Name: "Coronation Day", Percent: 20, StartDate: &mayEnd, EndDate: &mayEnd},
+			{Kind: model.PricingSeasonalDiscountKind, Name: "Low season", Percent: 5, StartDate: &mayStart, EndDate: &mayEnd},
+		},
+	}
+
+	quote, err := quoteBooking(gallery, pricing, sessions, []model.BookingAddOnInput{{AddOnId: album.Id}, {AddOnId: drone.Id}}, nil)
+	assert.NoError(t, err)
+
+	kinds := []string{}
+	for _, lineItem := range quote.LineItems {
+		kinds = append(kinds, lineItem.Kind)
+	}
+	// the holiday outweighs the weekend on Saturday and the discount ends before Monday
+	assert.Equal(t, []string{model.LineItemBaseKind, model.LineItemAddOnKind, model.LineItemAddOnKind, model.LineItemSurchargeKind, model.LineItemDiscountKind}, kinds)
+	assert.Equal(t, 2, quote.LineItems[2].Quantity)
+	assert.Equal(t, 2000, quote.LineItems[3].Amount)
+	assert.Equal(t, -500, quote.LineItems[4].Amount)
+	assert.Equal(t, 20000+2000+1000+2000-500, quote.Total)
+
+	negotiated := 20000
+	quote, err = quoteBooking(gallery, pricing, sessions, nil, &negotiated)
+	assert.NoError(t, err)
+	assert.Equal(t, negotiated, quote.Total)
+	assert.Equal(t, model.LineItemAdjustmentKind, quote.LineItems[len(quote.LineItems)-1].Kind)
+
+	_, err = quoteBooking(gallery, pricing, sessions, []model.BookingAddOnInput{{AddOnId: uuid.New()}}, nil)
+	assert.ErrorIs(t, err, ErrUnknownAddOn)
+
+	_, err = quoteBooking(gallery, pricing, sessions, []model.BookingAddOnInput{{AddOnId: album.Id, Quantity: -1}}, nil)
+	assert.ErrorIs(t, err, ErrInvalidAddOnQuantity)
+}
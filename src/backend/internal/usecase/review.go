@@ -12,14 +12,64 @@ import (
 
 type ReviewUseCase struct {
 	ReviewRepo repository.Review
+	JobRepo    repository.Job
+	UnitOfWork repository.UnitOfWork
 }
 
 func NewReviewUseCase(db *bun.DB) *ReviewUseCase {
 	return &ReviewUseCase{
 		ReviewRepo: postgres.NewReviewDB(db),
+		JobRepo:    postgres.NewJobDB(db),
+		UnitOfWork: postgres.NewUnitOfWorkDB(db),
 	}
 }
 
+// Create, Update and Delete expect the booking of the review to be populated,
+// the rating of its gallery is recomputed by the worker once the change is committed
+func (r *ReviewUseCase) Create(ctx context.Context, review *model.Review) error {
+	return r.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := r.ReviewRepo.AddOne(ctx, review); err != nil {
+			return err
+		}
+
+		if err := r.enqueueRatingRecompute(ctx, review); err != nil {
+			return err
+		}
+
+		if err := publishWebhook(ctx, r.JobRepo, model.WebhookReviewCreatedEvent, review); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, r.JobRepo, review.Booking.Room.Gallery.PhotographerId, model.NotificationReviewReceivedType, &review.Id, nil)
+	})
+}
+
+func (r *ReviewUseCase) Update(ctx context.Context, review *model.Review) error {
+	return r.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := r.ReviewRepo.UpdateOne(ctx, review); err != nil {
+			return err
+		}
+
+		return r.enqueueRatingRecompute(ctx, review)
+	})
+}
+
+func (r *ReviewUseCase) Delete(ctx context.Context, review *model.Review) error {
+	return r.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if _, err := r.ReviewRepo.DeleteOneById(ctx, review.Id); err != nil {
+			return err
+		}
+
+		return r.enqueueRatingRecompute(ctx, review)
+	})
+}
+
+func (r *ReviewUseCase) enqueueRatingRecompute(ctx context.Context, review *model.Review) error {
+	return enqueueJob(ctx, r.JobRepo, model.JobRecomputeGalleryRatingKind, model.GalleryRatingJob{
+		GalleryId: review.Booking.Room.GalleryId,
+	})
+}
+
 func (r *ReviewUseCase) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.Review, error) {
 	return r.ReviewRepo.FindByUserId(ctx, userId)
 }
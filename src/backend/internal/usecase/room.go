@@ -11,15 +11,41 @@ import (
 )
 
 type RoomUseCase struct {
-	RoomRepo repository.Room
+	RoomRepo   repository.Room
+	LookupRepo repository.Lookup
+	UnitOfWork repository.UnitOfWork
 }
 
 func NewRoomUseCase(db *bun.DB) *RoomUseCase {
 	return &RoomUseCase{
-		RoomRepo: postgres.NewRoomDB(db),
+		RoomRepo:   postgres.NewRoomDB(db),
+		LookupRepo: postgres.NewLookupDB(db),
+		UnitOfWork: postgres.NewUnitOfWorkDB(db),
 	}
 }
 
+// Initialize creates the room along with the membership of every member, a room is never left without its members
+func (r *RoomUseCase) Initialize(ctx context.Context, room *model.Room, memberIds []uuid.UUID) error {
+	return r.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := r.RoomRepo.AddOne(ctx, room); err != nil {
+			return err
+		}
+
+		lookups := []*model.UserRoomLookup{}
+		for _, memberId := range memberIds {
+			lookups = append(lookups, &model.UserRoomLookup{
+				Id:        uuid.New(),
+				UserId:    memberId,
+				RoomId:    room.Id,
+				CreatedAt: room.CreatedAt,
+				UpdatedAt: room.UpdatedAt,
+			})
+		}
+
+		return r.LookupRepo.AddBatch(ctx, lookups)
+	})
+}
+
 func (r *RoomUseCase) PopulateRoomsInBookings(ctx context.Context, galleryUsecase GalleryUseCase, bookings ...*model.Booking) error {
 	roomIds := []uuid.UUID{}
 
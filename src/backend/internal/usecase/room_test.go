@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/databases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeRoomRollsBackWhenAMemberIsMissing(t *testing.T) {
+	dsn := os.Getenv("dsn")
+	if dsn == "" {
+		t.Skip("dsn is not set")
+	}
+
+	roomUsecase := NewRoomUseCase(databases.ConnectSQLDB(dsn))
+	ctx := context.Background()
+
+	room := &model.Room{
+		Id:        uuid.New(),
+		GalleryId: uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	// the lookup of the unknown member violates its foreign key after the room has been inserted
+	err := roomUsecase.Initialize(ctx, room, []uuid.UUID{uuid.New()})
+	assert.Error(t, err)
+
+	_, err = roomUsecase.RoomRepo.FindOneById(ctx, room.Id)
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
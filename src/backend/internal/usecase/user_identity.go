@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+var (
+	ErrIdentityLinkedToOtherUser = errors.New("this account of the provider is already linked to another user")
+	ErrLastSignInMethod          = errors.New("cannot unlink the only sign-in method of the account, please set a password first")
+	ErrIdentityNotLinked         = errors.New("the provider is not linked to your account")
+)
+
+type UserIdentityUseCase struct {
+	UserIdentityRepo repository.UserIdentity
+}
+
+func NewUserIdentityUseCase(db *bun.DB) *UserIdentityUseCase {
+	return &UserIdentityUseCase{
+		UserIdentityRepo: postgres.NewUserIdentityDB(db),
+	}
+}
+
+func (u *UserIdentityUseCase) FindByUserId(ctx context.Context, userId uuid.UUID) ([]*model.UserIdentity, error) {
+	return u.UserIdentityRepo.FindByUserId(ctx, userId)
+}
+
+// FindUserIdByIdentity returns uuid.Nil when nobody has linked the identity yet
+func (u *UserIdentityUseCase) FindUserIdByIdentity(ctx context.Context, provider, subject string) (uuid.UUID, error) {
+	exist, err := u.UserIdentityRepo.CheckExistenceByProviderAndSubject(ctx, provider, subject)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	if !exist {
+		return uuid.Nil, nil
+	}
+
+	identity, err := u.UserIdentityRepo.FindOneByProviderAndSubject(ctx, provider, subject)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return identity.UserId, nil
+}
+
+func (u *UserIdentityUseCase) Link(ctx context.Context, userId uuid.UUID, provider, subject string, email *string) (*model.UserIdentity, error) {
+	linkedUserId, err := u.FindUserIdByIdentity(ctx, provider, subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if linkedUserId != uuid.Nil && linkedUserId != userId {
+		return nil, ErrIdentityLinkedToOtherUser
+	}
+
+	identities, err := u.UserIdentityRepo.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			// one account per provider, re-linking the same subject is a no-op
+			if identity.Subject == subject {
+				return identity, nil
+			}
+			if err := u.UserIdentityRepo.DeleteByUserIdAndProvider(ctx, userId, provider); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	identity := &model.UserIdentity{
+		Id:        uuid.New(),
+		UserId:    userId,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: time.Now(),
+	}
+
+	if err := u.UserIdentityRepo.AddOne(ctx, identity); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (u *UserIdentityUseCase) Unlink(ctx context.Context, user *model.User, provider string) error {
+	identities, err := u.UserIdentityRepo.FindByUserId(ctx, user.Id)
+	if err != nil {
+		return err
+	}
+
+	linked := false
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			linked = true
+		}
+	}
+
+	if !linked {
+		return ErrIdentityNotLinked
+	}
+
+	if user.Password == nil && len(identities) == 1 {
+		return ErrLastSignInMethod
+	}
+
+	return u.UserIdentityRepo.DeleteByUserIdAndProvider(ctx, user.Id, provider)
+}
@@ -2,24 +2,257 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"sort"
+	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/model"
 	"github.com/Roongkun/software-eng-ii/internal/repository"
 	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
 )
 
+const idCardURLTTL = time.Minute * 15
+
+var (
+	ErrTicketAlreadyReviewed = errors.New("the verification ticket has already been reviewed")
+	ErrDecisionReasonMissing = errors.New("a reason is required to reject or to request more information")
+	ErrInvalidDecision       = errors.New("the decision must be APPROVED, REJECTED or INFO_REQUESTED")
+)
+
+// the decision on a ticket and the verification status the photographer ends up with
+var verificationDecisions = map[string]string{
+	model.VerificationApprovedAction:      model.PhotographerVerifiedStatus,
+	model.VerificationRejectedAction:      model.PhotographerRejectedStatus,
+	model.VerificationInfoRequestedAction: model.PhotographerInfoRequestedStatus,
+}
+
+var verificationNotificationTypes = map[string]string{
+	model.VerificationApprovedAction:      model.NotificationVerificationApprovedType,
+	model.VerificationRejectedAction:      model.NotificationVerificationRejectedType,
+	model.VerificationInfoRequestedAction: model.NotificationVerificationInfoRequestedType,
+}
+
 type VerificationTicketUseCase struct {
-	VerificationInfoRepo repository.VerificationTicket
+	VerificationInfoRepo  repository.VerificationTicket
+	VerificationEventRepo repository.VerificationEvent
+	UserRepo              repository.User
+	AuditRepo             repository.AuditEntry
+	JobRepo               repository.Job
+	UnitOfWork            repository.UnitOfWork
 }
 
 func NewVerificationTicketUseCase(db *bun.DB) *VerificationTicketUseCase {
 	return &VerificationTicketUseCase{
-		VerificationInfoRepo: postgres.NewVerificationInfoDB(db),
+		VerificationInfoRepo:  postgres.NewVerificationInfoDB(db),
+		VerificationEventRepo: postgres.NewVerificationEventDB(db),
+		UserRepo:              postgres.NewUserDB(db),
+		AuditRepo:             postgres.NewAuditEntryDB(db),
+		JobRepo:               postgres.NewJobDB(db),
+		UnitOfWork:            postgres.NewUnitOfWorkDB(db),
 	}
 }
 
 func (v *VerificationTicketUseCase) FindByUserIds(ctx context.Context, phtgIds []uuid.UUID) ([]*model.VerificationTicket, error) {
 	return v.VerificationInfoRepo.FindByUserIds(ctx, phtgIds)
 }
+
+func (v *VerificationTicketUseCase) FindWithFilter(ctx context.Context, filter model.VerificationTicketFilter) ([]*model.VerificationTicket, error) {
+	return v.VerificationInfoRepo.FindWithFilter(ctx, filter)
+}
+
+// Submit files a new ticket for the user, a ticket sent after a rejection or a request for more information
+// is linked to the previous one so that the reviewer can follow the whole case
+func (v *VerificationTicketUseCase) Submit(ctx context.Context, user *model.User, ticket *model.VerificationTicket) error {
+	action := model.VerificationSubmittedAction
+	if user.VerificationStatus == model.PhotographerRejectedStatus || user.VerificationStatus == model.PhotographerInfoRequestedStatus {
+		previousTicket, err := v.VerificationInfoRepo.FindLatestByUserId(ctx, user.Id)
+		if err == nil {
+			ticket.PreviousTicketId = &previousTicket.Id
+			action = model.VerificationResubmittedAction
+		}
+	}
+
+	ticket.Status = model.VerificationTicketPendingStatus
+	return v.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		if err := v.VerificationInfoRepo.AddOne(ctx, ticket); err != nil {
+			return err
+		}
+
+		if err := v.addEvent(ctx, user.Id, ticket.Id, user.Id, action, nil); err != nil {
+			return err
+		}
+
+		user.VerificationStatus = model.PhotographerPendingStatus
+		return v.UserRepo.UpdateOne(ctx, user)
+	})
+}
+
+// Review records the decision of the reviewer on a pending ticket, moves the photographer to the matching status and notifies them
+func (v *VerificationTicketUseCase) Review(ctx context.Context, reviewer *model.User, ticket *model.VerificationTicket, decision string, reason *string) error {
+	userStatus, ok := verificationDecisions[decision]
+	if !ok {
+		return ErrInvalidDecision
+	}
+
+	if decision != model.VerificationApprovedAction && (reason == nil || *reason == "") {
+		return ErrDecisionReasonMissing
+	}
+
+	return v.UnitOfWork.Do(ctx, func(ctx context.Context) error {
+		// the ticket is read again under lock, two administrators reviewing it at once would both see it pending
+		locked, err := v.VerificationInfoRepo.LockOneById(ctx, ticket.Id)
+		if err != nil {
+			return err
+		}
+
+		if locked.Status != model.VerificationTicketPendingStatus {
+			return ErrTicketAlreadyReviewed
+		}
+
+		*ticket = *locked
+		before := *ticket
+		now := time.Now()
+		ticket.Status = decision
+		ticket.ReviewerId = &reviewer.Id
+		ticket.DecisionReason = reason
+		ticket.ReviewedAt = &now
+
+		if err := v.VerificationInfoRepo.UpdateOne(ctx, ticket); err != nil {
+			return err
+		}
+
+		if err := recordAudit(ctx, v.AuditRepo, model.AuditVerificationReviewAction, model.AuditVerificationTicketTarget, &ticket.Id, &before, ticket); err != nil {
+			return err
+		}
+
+		if err := v.addEvent(ctx, ticket.UserId, ticket.Id, reviewer.Id, decision, reason); err != nil {
+			return err
+		}
+
+		photographer, err := v.UserRepo.FindOneById(ctx, ticket.UserId)
+		if err != nil {
+			return err
+		}
+
+		photographer.VerificationStatus = userStatus
+		if err := v.UserRepo.UpdateOne(ctx, photographer); err != nil {
+			return err
+		}
+
+		ticket.User = *photographer
+		if err := publishWebhook(ctx, v.JobRepo, model.WebhookVerificationDecidedEvent, map[string]any{
+			"ticket_id":       ticket.Id,
+			"photographer_id": ticket.UserId,
+			"decision":        decision,
+			"reason":          reason,
+			"reviewed_at":     ticket.ReviewedAt,
+		}); err != nil {
+			return err
+		}
+
+		return enqueueNotification(ctx, v.JobRepo, ticket.UserId, verificationNotificationTypes[decision], &ticket.Id, reason)
+	})
+}
+
+func (v *VerificationTicketUseCase) addEvent(ctx context.Context, userId, ticketId, actorId uuid.UUID, action string, reason *string) error {
+	return v.VerificationEventRepo.AddOne(ctx, &model.VerificationEvent{
+		Id:        uuid.New(),
+		UserId:    userId,
+		TicketId:  ticketId,
+		ActorId:   actorId,
+		Action:    action,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+}
+
+// FindHistoryByUserId returns every ticket of the user, newest first, with its events
+func (v *VerificationTicketUseCase) FindHistoryByUserId(ctx context.Context, userId uuid.UUID) ([]*model.VerificationTicket, error) {
+	tickets, err := v.VerificationInfoRepo.FindByUserIds(ctx, []uuid.UUID{userId})
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := v.VerificationEventRepo.FindByUserId(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	ticketIdToTicket := map[uuid.UUID]*model.VerificationTicket{}
+	for _, ticket := range tickets {
+		ticketIdToTicket[ticket.Id] = ticket
+	}
+	for _, event := range events {
+		if ticket, exist := ticketIdToTicket[event.TicketId]; exist {
+			ticket.Events = append(ticket.Events, event)
+		}
+	}
+
+	sort.Slice(tickets, func(i, j int) bool {
+		return tickets[i].CreatedAt.After(tickets[j].CreatedAt)
+	})
+
+	return tickets, nil
+}
+
+// PopulateTickets attaches the photographer, the events and a presigned link to the ID card of every ticket
+func (v *VerificationTicketUseCase) PopulateTickets(ctx context.Context, tickets ...*model.VerificationTicket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	userIds := []uuid.UUID{}
+	ticketIds := []uuid.UUID{}
+	for _, ticket := range tickets {
+		userIds = append(userIds, ticket.UserId)
+		ticketIds = append(ticketIds, ticket.Id)
+	}
+
+	users, err := v.UserRepo.FindByIds(ctx, userIds...)
+	if err != nil {
+		return err
+	}
+	userIdToUser := map[uuid.UUID]*model.User{}
+	for _, user := range users {
+		userIdToUser[user.Id] = user
+	}
+
+	events, err := v.VerificationEventRepo.FindByTicketIds(ctx, ticketIds)
+	if err != nil {
+		return err
+	}
+	ticketIdToEvents := map[uuid.UUID][]*model.VerificationEvent{}
+	for _, event := range events {
+		ticketIdToEvents[event.TicketId] = append(ticketIdToEvents[event.TicketId], event)
+	}
+
+	for _, ticket := range tickets {
+		if user, exist := userIdToUser[ticket.UserId]; exist {
+			ticket.User = *user
+		}
+		ticket.Events = ticketIdToEvents[ticket.Id]
+	}
+
+	return v.PresignIdCardURLs(ctx, tickets...)
+}
+
+// PresignIdCardURLs replaces the ID card links with short-lived ones, the id-card bucket is not meant to be public
+func (v *VerificationTicketUseCase) PresignIdCardURLs(ctx context.Context, tickets ...*model.VerificationTicket) error {
+	bucket, err := s3utils.GetInstance()
+	if err != nil {
+		return err
+	}
+
+	for _, ticket := range tickets {
+		url, err := bucket.PresignGetURL(ctx, s3utils.IdCardBucket, ticket.IdCardPictureKey, idCardURLTTL)
+		if err != nil {
+			return err
+		}
+		ticket.IdCardPictureURL = url
+	}
+
+	return nil
+}
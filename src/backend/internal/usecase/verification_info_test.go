@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVerificationTickets keeps the tickets stored, as read under lock, and counts the updates
+type fakeVerificationTickets struct {
+	repository.VerificationTicket
+	stored  map[uuid.UUID]model.VerificationTicket
+	updated int
+}
+
+func (f *fakeVerificationTickets) LockOneById(ctx context.Context, id uuid.UUID) (*model.VerificationTicket, error) {
+	ticket := f.stored[id]
+	return &ticket, nil
+}
+
+func (f *fakeVerificationTickets) UpdateOne(ctx context.Context, ticket *model.VerificationTicket) error {
+	f.updated++
+	return nil
+}
+
+func TestReviewRejectsTicketReviewedMeanwhile(t *testing.T) {
+	ticketId := uuid.New()
+	tickets := &fakeVerificationTickets{stored: map[uuid.UUID]model.VerificationTicket{
+		ticketId: {Id: ticketId, Status: model.VerificationTicketApprovedStatus},
+	}}
+	verificationUsecase := &VerificationTicketUseCase{VerificationInfoRepo: tickets, UnitOfWork: fakeUnitOfWork{}}
+	reviewer := &model.User{Id: uuid.New()}
+	reason := "the picture is blurred"
+
+	// the ticket was read pending before another administrator approved it
+	ticket := &model.VerificationTicket{Id: ticketId, Status: model.VerificationTicketPendingStatus}
+	err := verificationUsecase.Review(context.Background(), reviewer, ticket, model.VerificationRejectedAction, &reason)
+	assert.ErrorIs(t, err, ErrTicketAlreadyReviewed)
+	assert.Zero(t, tickets.updated)
+	assert.Nil(t, ticket.ReviewerId)
+}
+
+func TestReviewChecksDecision(t *testing.T) {
+	verificationUsecase := &VerificationTicketUseCase{VerificationInfoRepo: &fakeVerificationTickets{}, UnitOfWork: fakeUnitOfWork{}}
+	reviewer := &model.User{Id: uuid.New()}
+	ticket := &model.VerificationTicket{Id: uuid.New(), Status: model.VerificationTicketPendingStatus}
+	empty := ""
+
+	assert.ErrorIs(t, verificationUsecase.Review(context.Background(), reviewer, ticket, "ESCALATED", nil), ErrInvalidDecision)
+	assert.ErrorIs(t, verificationUsecase.Review(context.Background(), reviewer, ticket, model.VerificationRejectedAction, nil), ErrDecisionReasonMissing)
+	assert.ErrorIs(t, verificationUsecase.Review(context.Background(), reviewer, ticket, model.VerificationInfoRequestedAction, &empty), ErrDecisionReasonMissing)
+}
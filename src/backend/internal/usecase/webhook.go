@@ -0,0 +1,363 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/Roongkun/software-eng-ii/internal/repository/postgres"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/encryption"
+	"github.com/google/uuid"
+	"github.com/uptrace/bun"
+)
+
+const (
+	maxWebhookAttempts  = 8
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	webhookBatchSize    = 50
+	webhookSenders      = 10
+	webhookLease        = 2 * time.Minute
+	webhookSecretSize   = 32
+	webhookMaxErrorSize = 2000
+)
+
+const (
+	WebhookEventHeader   = "X-PicKeeper-Event"
+	WebhookIdHeader      = "X-PicKeeper-Delivery"
+	WebhookSigningHeader = "X-PicKeeper-Signature"
+)
+
+var (
+	ErrInvalidWebhookURL       = errors.New("the url must be an absolute http or https url")
+	ErrInvalidWebhookEventType = errors.New("unknown webhook event type")
+	ErrWebhookEventTypeMissing = errors.New("at least one event type must be chosen")
+	ErrDeliveryInProgress      = errors.New("the delivery is still being attempted")
+)
+
+var webhookClient = &http.Client{Timeout: 10 * time.Second}
+
+// WebhookEvent is the body posted to the subscribers, the id is shared by the deliveries of the same event
+type WebhookEvent struct {
+	Id        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+type WebhookUseCase struct {
+	WebhookSubscriptionRepo repository.WebhookSubscription
+	WebhookDeliveryRepo     repository.WebhookDelivery
+}
+
+func NewWebhookUseCase(db *bun.DB) *WebhookUseCase {
+	return &WebhookUseCase{
+		WebhookSubscriptionRepo: postgres.NewWebhookSubscriptionDB(db),
+		WebhookDeliveryRepo:     postgres.NewWebhookDeliveryDB(db),
+	}
+}
+
+// CreateSubscription registers the url and returns the signing secret, which is only ever shown this once
+func (w *WebhookUseCase) CreateSubscription(ctx context.Context, creator *model.User, input model.WebhookSubscriptionInput) (*model.WebhookSubscription, string, error) {
+	subscription := &model.WebhookSubscription{
+		Id:        uuid.New(),
+		IsActive:  true,
+		CreatedBy: &creator.Id,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	if input.Url == nil {
+		return nil, "", ErrInvalidWebhookURL
+	}
+	if err := applyWebhookInput(subscription, input); err != nil {
+		return nil, "", err
+	}
+
+	secretBytes := make([]byte, webhookSecretSize)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, "", err
+	}
+	secret := "whsec_" + hex.EncodeToString(secretBytes)
+
+	sealedSecret, err := encryption.Default().Encrypt(secret)
+	if err != nil {
+		return nil, "", err
+	}
+	subscription.Secret = sealedSecret
+
+	if err := w.WebhookSubscriptionRepo.AddOne(ctx, subscription); err != nil {
+		return nil, "", err
+	}
+
+	return subscription, secret, nil
+}
+
+func (w *WebhookUseCase) UpdateSubscription(ctx context.Context, subscription *model.WebhookSubscription, input model.WebhookSubscriptionInput) error {
+	if err := applyWebhookInput(subscription, input); err != nil {
+		return err
+	}
+
+	subscription.UpdatedAt = time.Now()
+	return w.WebhookSubscriptionRepo.UpdateOne(ctx, subscription)
+}
+
+func applyWebhookInput(subscription *model.WebhookSubscription, input model.WebhookSubscriptionInput) error {
+	if input.Url != nil {
+		parsed, err := url.Parse(*input.Url)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return ErrInvalidWebhookURL
+		}
+		subscription.Url = *input.Url
+	}
+
+	if input.EventTypes != nil {
+		if len(input.EventTypes) == 0 {
+			return ErrWebhookEventTypeMissing
+		}
+		for _, eventType := range input.EventTypes {
+			if !slices.Contains(model.WebhookEventTypes, eventType) {
+				return fmt.Errorf("%w: %s", ErrInvalidWebhookEventType, eventType)
+			}
+		}
+		subscription.EventTypes = input.EventTypes
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return ErrWebhookEventTypeMissing
+	}
+
+	if input.IsActive != nil {
+		subscription.IsActive = *input.IsActive
+	}
+
+	return nil
+}
+
+// publishWebhook queues the event in the transaction of the change it reports, so that the event is only published
+// once the change is committed and is not lost if it fails
+func publishWebhook(ctx context.Context, jobRepo repository.Job, eventType string, data any) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return enqueueJob(ctx, jobRepo, model.JobPublishWebhookKind, model.WebhookEventJob{
+		Id:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      encoded,
+	})
+}
+
+// PublishQueued queues a delivery of the event to every active subscriber, the deliveries are sent by DeliverDue.
+// A retried job does not queue the deliveries of the event twice.
+func (w *WebhookUseCase) PublishQueued(ctx context.Context, job model.WebhookEventJob) error {
+	if queued, err := w.WebhookDeliveryRepo.CheckExistenceByEventId(ctx, job.Id); err != nil || queued {
+		return err
+	}
+
+	subscriptions, err := w.WebhookSubscriptionRepo.FindActiveByEventType(ctx, job.Type)
+	if err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(WebhookEvent{
+		Id:        job.Id,
+		Type:      job.Type,
+		CreatedAt: job.CreatedAt,
+		Data:      job.Data,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := []*model.WebhookDelivery{}
+	for _, subscription := range subscriptions {
+		deliveries = append(deliveries, &model.WebhookDelivery{
+			Id:             uuid.New(),
+			SubscriptionId: subscription.Id,
+			EventId:        job.Id,
+			EventType:      job.Type,
+			Payload:        payload,
+			Status:         model.WebhookDeliveryPendingStatus,
+			NextAttemptAt:  time.Now(),
+			CreatedAt:      time.Now(),
+		})
+	}
+
+	return w.WebhookDeliveryRepo.AddBatch(ctx, deliveries)
+}
+
+// DeliverDue attempts the deliveries whose time has come, it is run periodically by every server. The batch is sent by
+// several senders at once so that it is done within its lease even when every subscriber times out.
+func (w *WebhookUseCase) DeliverDue(ctx context.Context) {
+	now := time.Now()
+	deliveries, err := w.WebhookDeliveryRepo.ClaimDue(ctx, now, now.Add(webhookLease), webhookBatchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "unable to claim webhook deliveries", "error", err)
+		return
+	}
+
+	senders := make(chan struct{}, webhookSenders)
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		senders <- struct{}{}
+		wg.Add(1)
+		go func(delivery *model.WebhookDelivery) {
+			defer func() {
+				<-senders
+				wg.Done()
+			}()
+
+			if err := w.attempt(ctx, delivery); err != nil {
+				slog.ErrorContext(ctx, "unable to record webhook delivery", "delivery", delivery.Id, "error", err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+// attempt sends a claimed delivery and saves the outcome unless the lease of the claim has passed meanwhile, a delivery
+// without the time left for a whole attempt is left to be claimed again
+func (w *WebhookUseCase) attempt(ctx context.Context, delivery *model.WebhookDelivery) error {
+	claimedUntil := delivery.NextAttemptAt
+	if time.Until(claimedUntil) < webhookClient.Timeout {
+		return nil
+	}
+
+	delivery.Attempts++
+
+	statusCode, err := w.send(ctx, delivery)
+	// an attempt cut off by the worker stopping is not counted against the delivery
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if statusCode != 0 {
+		delivery.LastStatusCode = &statusCode
+	}
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.WebhookDeliverySucceededStatus
+		delivery.DeliveredAt = &now
+		delivery.LastError = nil
+		return w.saveAttempt(ctx, delivery, claimedUntil)
+	}
+
+	lastError := err.Error()
+	if len(lastError) > webhookMaxErrorSize {
+		lastError = lastError[:webhookMaxErrorSize]
+	}
+	delivery.LastError = &lastError
+
+	// the dead deliveries are the dead-letter log, they stay until an admin replays them
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Status = model.WebhookDeliveryDeadStatus
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+	}
+
+	return w.saveAttempt(ctx, delivery, claimedUntil)
+}
+
+func (w *WebhookUseCase) saveAttempt(ctx context.Context, delivery *model.WebhookDelivery, claimedUntil time.Time) error {
+	saved, err := w.WebhookDeliveryRepo.UpdateClaimed(ctx, delivery, claimedUntil)
+	if err != nil {
+		return err
+	}
+	if !saved {
+		slog.WarnContext(ctx, "the webhook delivery has been claimed again, its attempt is dropped", "delivery", delivery.Id)
+	}
+
+	return nil
+}
+
+func (w *WebhookUseCase) send(ctx context.Context, delivery *model.WebhookDelivery) (int, error) {
+	subscription, err := w.WebhookSubscriptionRepo.FindOneById(ctx, delivery.SubscriptionId)
+	if err != nil {
+		return 0, err
+	}
+	if !subscription.IsActive {
+		return 0, errors.New("the subscription is disabled")
+	}
+
+	secret, err := encryption.Default().Decrypt(subscription.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Url, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookIdHeader, delivery.Id.String())
+	req.Header.Set(WebhookSigningHeader, signWebhookPayload(secret, time.Now(), delivery.Payload))
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("the subscriber responded with %s", res.Status)
+	}
+
+	return res.StatusCode, nil
+}
+
+// Replay queues the delivery again from its first attempt
+func (w *WebhookUseCase) Replay(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if delivery.Status == model.WebhookDeliveryPendingStatus {
+		return ErrDeliveryInProgress
+	}
+
+	delivery.Status = model.WebhookDeliveryPendingStatus
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.DeliveredAt = nil
+	return w.WebhookDeliveryRepo.UpdateOne(ctx, delivery)
+}
+
+// webhookBackoff doubles the wait after every failed attempt, starting from 30 seconds
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempts && backoff < webhookMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, webhookMaxBackoff)
+}
+
+// signWebhookPayload follows the `t=<unix time>,v1=<hex hmac>` format, the subscriber recomputes the HMAC-SHA256
+// of "<unix time>.<body>" with its secret and rejects old timestamps to prevent replays
+func signWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	unix := fmt.Sprintf("%d", timestamp.Unix())
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%s,v1=%s", unix, hex.EncodeToString(mac.Sum(nil)))
+}
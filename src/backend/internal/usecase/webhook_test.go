@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhookBackoff(1))
+	assert.Equal(t, time.Minute, webhookBackoff(2))
+	assert.Equal(t, 2*time.Minute, webhookBackoff(3))
+	assert.Equal(t, webhookMaxBackoff, webhookBackoff(20))
+}
+
+func TestSignWebhookPayload(t *testing.T) {
+	body := []byte(`{"type":"booking.created"}`)
+	timestamp := time.Unix(1712000000, 0)
+
+	mac := hmac.New(sha256.New, []byte("whsec_test"))
+	mac.Write([]byte("1712000000." + string(body)))
+
+	assert.Equal(t, "t=1712000000,v1="+hex.EncodeToString(mac.Sum(nil)), signWebhookPayload("whsec_test", timestamp, body))
+	assert.NotEqual(t, signWebhookPayload("whsec_test", timestamp, body), signWebhookPayload("whsec_other", timestamp, body))
+}
+
+// fakeBookings only stores the booking updated
+type fakeBookings struct {
+	repository.Booking
+	locked  *model.Booking
+	updated *model.Booking
+}
+
+// LockOneById returns a copy of the booking as it is locked
+func (f *fakeBookings) LockOneById(ctx context.Context, id uuid.UUID) (*model.Booking, error) {
+	locked := *f.locked
+	return &locked, nil
+}
+
+func (f *fakeBookings) UpdateOne(ctx context.Context, booking *model.Booking) error {
+	f.updated = booking
+	return nil
+}
+
+// fakeWebhookSubscriptions returns the subscriptions given, whatever the event type
+type fakeWebhookSubscriptions struct {
+	repository.WebhookSubscription
+	active []*model.WebhookSubscription
+}
+
+func (f *fakeWebhookSubscriptions) FindActiveByEventType(ctx context.Context, eventType string) ([]*model.WebhookSubscription, error) {
+	return f.active, nil
+}
+
+func (f *fakeWebhookSubscriptions) FindOneById(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	for _, subscription := range f.active {
+		if subscription.Id == id {
+			return subscription, nil
+		}
+	}
+
+	return nil, sql.ErrNoRows
+}
+
+// fakeWebhookDeliveries keeps the deliveries queued in memory, an attempt is saved unless the claim has been lost
+type fakeWebhookDeliveries struct {
+	repository.WebhookDelivery
+	queued    []*model.WebhookDelivery
+	claimLost bool
+	saved     []time.Time
+}
+
+func (f *fakeWebhookDeliveries) UpdateClaimed(ctx context.Context, delivery *model.WebhookDelivery, claimedUntil time.Time) (bool, error) {
+	if f.claimLost {
+		return false, nil
+	}
+
+	f.saved = append(f.saved, claimedUntil)
+	return true, nil
+}
+
+func (f *fakeWebhookDeliveries) CheckExistenceByEventId(ctx context.Context, eventId uuid.UUID) (bool, error) {
+	for _, delivery := range f.queued {
+		if delivery.EventId == eventId {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (f *fakeWebhookDeliveries) AddBatch(ctx context.Context, deliveries []*model.WebhookDelivery) error {
+	f.queued = append(f.queued, deliveries...)
+	return nil
+}
+
+func TestStatusChangePublishesWebhook(t *testing.T) {
+	jobs := &fakeJobs{}
+	bookingUsecase := &BookingUseCase{BookingRepo: &fakeBookings{}, JobRepo: jobs}
+	booking := &model.Booking{Id: uuid.New(), Status: model.BookingPaidStatus}
+	ctx := context.Background()
+
+	// the event is queued along with the change
+	require.NoError(t, bookingUsecase.updateStatus(ctx, booking, model.BookingCompletedStatus))
+	require.Len(t, jobs.queued, 1)
+	assert.Equal(t, model.JobPublishWebhookKind, jobs.queued[0].Kind)
+
+	var event model.WebhookEventJob
+	require.NoError(t, json.Unmarshal(jobs.queued[0].Payload, &event))
+	assert.Equal(t, model.WebhookBookingStatusChangedEvent, event.Type)
+
+	deliveries := &fakeWebhookDeliveries{}
+	webhookUsecase := &WebhookUseCase{
+		WebhookSubscriptionRepo: &fakeWebhookSubscriptions{active: []*model.WebhookSubscription{{Id: uuid.New()}, {Id: uuid.New()}}},
+		WebhookDeliveryRepo:     deliveries,
+	}
+
+	// the worker queues a delivery to every subscriber
+	require.NoError(t, webhookUsecase.PublishQueued(ctx, event))
+	require.Len(t, deliveries.queued, 2)
+	for _, delivery := range deliveries.queued {
+		assert.Equal(t, event.Id, delivery.EventId)
+		assert.Equal(t, model.WebhookDeliveryPendingStatus, delivery.Status)
+
+		var body WebhookEvent
+		require.NoError(t, json.Unmarshal(delivery.Payload, &body))
+		assert.Equal(t, model.BookingCompletedStatus, body.Data.(map[string]any)["status"])
+	}
+
+	// a retried job does not queue the deliveries twice
+	require.NoError(t, webhookUsecase.PublishQueued(ctx, event))
+	assert.Len(t, deliveries.queued, 2)
+}
+
+func TestAttemptKeepsToItsClaim(t *testing.T) {
+	// the subscription is disabled, so that the attempt fails without being sent
+	subscription := &model.WebhookSubscription{Id: uuid.New()}
+	deliveries := &fakeWebhookDeliveries{}
+	webhookUsecase := &WebhookUseCase{
+		WebhookSubscriptionRepo: &fakeWebhookSubscriptions{active: []*model.WebhookSubscription{subscription}},
+		WebhookDeliveryRepo:     deliveries,
+	}
+	ctx := context.Background()
+	newDelivery := func(claimedUntil time.Time) *model.WebhookDelivery {
+		return &model.WebhookDelivery{Id: uuid.New(), SubscriptionId: subscription.Id, Status: model.WebhookDeliveryPendingStatus, NextAttemptAt: claimedUntil}
+	}
+
+	claimedUntil := time.Now().Add(webhookLease)
+	delivery := newDelivery(claimedUntil)
+	require.NoError(t, webhookUsecase.attempt(ctx, delivery))
+	assert.Equal(t, []time.Time{claimedUntil}, deliveries.saved)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.True(t, delivery.NextAttemptAt.Before(claimedUntil))
+
+	// a delivery whose lease has nearly passed is left to be claimed again
+	delivery = newDelivery(time.Now().Add(time.Second))
+	require.NoError(t, webhookUsecase.attempt(ctx, delivery))
+	assert.Len(t, deliveries.saved, 1)
+	assert.Equal(t, 0, delivery.Attempts)
+
+	// the attempt of a delivery claimed again by another server is dropped
+	deliveries.claimLost = true
+	require.NoError(t, webhookUsecase.attempt(ctx, newDelivery(time.Now().Add(webhookLease))))
+	assert.Len(t, deliveries.saved, 1)
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/config"
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/s3utils"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"github.com/uptrace/bun"
+)
+
+const (
+	bookingStatusPeriod   = time.Minute
+	webhookDeliveryPeriod = 30 * time.Second
+	jobPurgePeriod        = time.Hour
+	quoteExpiryPeriod     = time.Minute
+	lifecyclePeriod       = 5 * time.Minute
+)
+
+// RegisterJobs binds every kind of job queued by the api to its handler
+func RegisterJobs(r *Runner, db *bun.DB, lifecycleCfg config.BookingLifecycle) {
+	notificationUsecase := usecase.NewNotificationUseCase(db)
+	galleryUsecase := usecase.NewGalleryUseCase(db)
+	reviewUsecase := usecase.NewReviewUseCase(db)
+	personalDataUsecase := usecase.NewPersonalDataUseCase(db)
+	bookingUsecase := usecase.NewBookingUseCase(db)
+	webhookUsecase := usecase.NewWebhookUseCase(db)
+	conversationUsecase := usecase.NewConversationUseCase(db)
+	bookingRequestUsecase := usecase.NewBookingRequestUseCase(db)
+	documentUsecase := usecase.NewDocumentUseCase(db)
+	roomUsecase := usecase.NewRoomUseCase(db)
+	insightsUsecase := usecase.NewInsightsUseCase(db)
+
+	r.Register(model.JobDeleteS3ObjectKind, Typed(func(ctx context.Context, payload model.S3ObjectJob) error {
+		bucket, err := s3utils.GetInstance()
+		if err != nil {
+			return err
+		}
+
+		return bucket.DeleteFile(ctx, payload.Bucket, payload.Key)
+	}))
+
+	r.Register(model.JobSendNotificationKind, Typed(notificationUsecase.Send))
+
+	r.Register(model.JobPublishChatCardKind, Typed(conversationUsecase.PublishCard))
+
+	r.Register(model.JobPublishWebhookKind, Typed(webhookUsecase.PublishQueued))
+
+	r.Register(model.JobTrackGalleryViewKind, Typed(insightsUsecase.RecordView))
+
+	r.Register(model.JobRecomputeGalleryRatingKind, Typed(func(ctx context.Context, payload model.GalleryRatingJob) error {
+		return galleryUsecase.RecomputeRating(ctx, *reviewUsecase, payload.GalleryId)
+	}))
+
+	r.Register(model.JobBuildDataExportKind, Typed(func(ctx context.Context, payload model.DataExportJob) error {
+		return personalDataUsecase.BuildQueuedExport(ctx, payload.ExportId)
+	}))
+
+	r.Register(model.JobRenderDocumentKind, Typed(func(ctx context.Context, payload model.DocumentJob) error {
+		return documentUsecase.RenderQueued(ctx, payload.DocumentId, *bookingUsecase, *roomUsecase, *galleryUsecase)
+	}))
+
+	r.Every(model.JobUpdateBookingStatusKind, bookingStatusPeriod, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := bookingUsecase.UpdateStatusRoutine(ctx)
+		return err
+	})
+
+	r.Every(model.JobCancelOverdueDepositsKind, bookingStatusPeriod, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := bookingUsecase.CancelOverduePayments(ctx)
+		return err
+	})
+
+	r.Every(model.JobPurgeJobsKind, jobPurgePeriod, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := r.jobUsecase.PurgeSucceeded(ctx)
+		return err
+	})
+
+	r.Every(model.JobDeliverWebhooksKind, webhookDeliveryPeriod, func(ctx context.Context, _ json.RawMessage) error {
+		webhookUsecase.DeliverDue(ctx)
+		return nil
+	})
+
+	r.Every(model.JobExpireBookingQuotesKind, quoteExpiryPeriod, func(ctx context.Context, _ json.RawMessage) error {
+		_, err := bookingRequestUsecase.ExpireQuotes(ctx)
+		return err
+	})
+
+	registerLifecycleJobs(r, lifecycleCfg, bookingUsecase, roomUsecase, galleryUsecase)
+}
+
+// registerLifecycleJobs schedules the jobs of the booking lifecycle that are turned on, the notifications of each one
+// are recorded so that a job run again or by another worker does not send them twice
+func registerLifecycleJobs(r *Runner, cfg config.BookingLifecycle, bookingUsecase *usecase.BookingUseCase, roomUsecase *usecase.RoomUseCase, galleryUsecase *usecase.GalleryUseCase) {
+	period := lifecyclePeriod
+	if cfg.PeriodSeconds > 0 {
+		period = time.Duration(cfg.PeriodSeconds) * time.Second
+	}
+
+	if cfg.ReminderLeadHours > 0 {
+		lead := time.Duration(cfg.ReminderLeadHours) * time.Hour
+		r.Every(model.JobRemindSessionsKind, period, func(ctx context.Context, _ json.RawMessage) error {
+			_, err := bookingUsecase.RemindSessions(ctx, lead, *roomUsecase, *galleryUsecase)
+			return err
+		})
+	}
+
+	if cfg.ReviewRequestDelayHours > 0 {
+		delay := time.Duration(cfg.ReviewRequestDelayHours) * time.Hour
+		r.Every(model.JobRequestReviewsKind, period, func(ctx context.Context, _ json.RawMessage) error {
+			_, err := bookingUsecase.RequestReviews(ctx, delay, *roomUsecase, *galleryUsecase)
+			return err
+		})
+	}
+
+	if cfg.DraftExpiryHours > 0 {
+		expiry := time.Duration(cfg.DraftExpiryHours) * time.Hour
+		r.Every(model.JobExpireDraftsKind, period, func(ctx context.Context, _ json.RawMessage) error {
+			_, err := bookingUsecase.ExpireDrafts(ctx, expiry, *roomUsecase, *galleryUsecase)
+			return err
+		})
+	}
+
+	if cfg.DeliveryAlerts {
+		r.Every(model.JobAlertOverdueDeliveryKind, period, func(ctx context.Context, _ json.RawMessage) error {
+			_, err := bookingUsecase.AlertOverdueDeliveries(ctx, *roomUsecase, *galleryUsecase)
+			return err
+		})
+	}
+}
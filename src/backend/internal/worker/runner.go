@@ -0,0 +1,212 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Roongkun/software-eng-ii/internal/model"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/metrics"
+	"github.com/Roongkun/software-eng-ii/internal/third-party/telemetry"
+	"github.com/Roongkun/software-eng-ii/internal/usecase"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	defaultConcurrency  = 4
+	defaultPollInterval = time.Second
+
+	// a job still running past its lease is assumed to belong to a dead worker and handed to another one
+	jobLease = 5 * time.Minute
+	// the handler of a job is stopped this long before the lease passes, so that its outcome is recorded in time
+	jobFinishTimeout = 30 * time.Second
+)
+
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Typed decodes the payload of the job before handing it to fn
+func Typed[T any](fn func(ctx context.Context, payload T) error) Handler {
+	return func(ctx context.Context, payload json.RawMessage) error {
+		var decoded T
+		if err := json.Unmarshal(payload, &decoded); err != nil {
+			return fmt.Errorf("invalid payload: %w", err)
+		}
+
+		return fn(ctx, decoded)
+	}
+}
+
+type schedule struct {
+	kind       string
+	period     time.Duration
+	lastPeriod time.Time
+}
+
+// Runner claims the due jobs from the queue and runs them on a pool of goroutines,
+// any number of runners can share the queue
+type Runner struct {
+	jobUsecase   *usecase.JobUseCase
+	id           string
+	concurrency  int
+	pollInterval time.Duration
+	handlers     map[string]Handler
+	schedules    []*schedule
+}
+
+func NewRunner(jobUsecase *usecase.JobUseCase, concurrency int, pollInterval time.Duration) *Runner {
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+	if pollInterval <= 0 {
+		pollInterval = defaultPollInterval
+	}
+
+	hostname, _ := os.Hostname()
+
+	return &Runner{
+		jobUsecase:   jobUsecase,
+		id:           fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		concurrency:  concurrency,
+		pollInterval: pollInterval,
+		handlers:     map[string]Handler{},
+	}
+}
+
+func (r *Runner) Register(kind string, handler Handler) {
+	r.handlers[kind] = handler
+}
+
+// Every queues a job of the kind once per period, whichever runner gets there first
+func (r *Runner) Every(kind string, period time.Duration, handler Handler) {
+	r.Register(kind, handler)
+	r.schedules = append(r.schedules, &schedule{kind: kind, period: period})
+}
+
+// Run blocks until the context is cancelled, the jobs already claimed are allowed to finish. Only as many jobs as
+// there are idle goroutines are claimed, so that no claimed job waits for its turn while its lease runs out.
+func (r *Runner) Run(ctx context.Context) error {
+	slog.Info("worker started", "worker", r.id, "concurrency", r.concurrency)
+
+	queue := make(chan *model.Job, r.concurrency)
+	busy := make(chan struct{}, r.concurrency)
+	wg := sync.WaitGroup{}
+	for i := 0; i < r.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				r.run(context.WithoutCancel(ctx), job)
+				<-busy
+			}
+		}()
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+loop:
+	for {
+		r.enqueueScheduled(ctx)
+
+		if idle := r.concurrency - len(busy); idle > 0 {
+			jobs, err := r.jobUsecase.Claim(ctx, r.id, jobLease, idle)
+			if err != nil && ctx.Err() == nil {
+				slog.Error("unable to claim jobs", "error", err)
+			}
+
+			for _, job := range jobs {
+				busy <- struct{}{}
+				queue <- job
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+	}
+
+	close(queue)
+	wg.Wait()
+	slog.Info("worker stopped", "worker", r.id)
+	return nil
+}
+
+func (r *Runner) enqueueScheduled(ctx context.Context) {
+	now := time.Now()
+	for _, schedule := range r.schedules {
+		period := now.Truncate(schedule.period)
+		if period.Equal(schedule.lastPeriod) {
+			continue
+		}
+
+		if _, err := r.jobUsecase.EnqueuePeriodic(ctx, schedule.kind, schedule.period, now); err != nil {
+			slog.Error("unable to schedule a job", "kind", schedule.kind, "error", err)
+			metrics.JobScheduleErrors.WithLabelValues(schedule.kind).Inc()
+			continue
+		}
+		schedule.lastPeriod = period
+	}
+}
+
+func (r *Runner) run(ctx context.Context, job *model.Job) {
+	ctx, span := telemetry.Tracer().Start(ctx, "job "+job.Kind, trace.WithAttributes(
+		attribute.String("job.id", job.Id.String()),
+		attribute.String("job.kind", job.Kind),
+		attribute.Int("job.attempt", job.Attempts),
+	))
+	defer span.End()
+
+	// the run is cut off in time for its outcome to be recorded before the lease passes
+	deadline := time.Now().Add(jobLease)
+	if job.LockedUntil != nil {
+		deadline = *job.LockedUntil
+	}
+	runCtx, cancelRun := context.WithDeadline(ctx, deadline.Add(-jobFinishTimeout))
+	defer cancelRun()
+
+	start := time.Now()
+	err := r.execute(runCtx, job)
+	metrics.JobDuration.WithLabelValues(job.Kind).Observe(time.Since(start).Seconds())
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.WarnContext(ctx, "job failed", "job", job.Id, "kind", job.Kind, "attempt", job.Attempts, "error", err)
+		metrics.JobRuns.WithLabelValues(job.Kind, metrics.JobFailedOutcome).Inc()
+	} else {
+		metrics.JobRuns.WithLabelValues(job.Kind, metrics.JobSucceededOutcome).Inc()
+		metrics.JobLastSuccess.WithLabelValues(job.Kind).SetToCurrentTime()
+	}
+
+	finishCtx, cancelFinish := context.WithTimeout(ctx, jobFinishTimeout)
+	defer cancelFinish()
+	err = r.jobUsecase.Finish(finishCtx, r.id, job, err)
+	if errors.Is(err, usecase.ErrJobClaimLost) {
+		slog.WarnContext(ctx, "the outcome of a job is dropped", "job", job.Id, "kind", job.Kind, "error", err)
+	} else if err != nil {
+		slog.ErrorContext(ctx, "unable to record the outcome of a job", "job", job.Id, "error", err)
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, job *model.Job) (err error) {
+	handler, exist := r.handlers[job.Kind]
+	if !exist {
+		return fmt.Errorf("no handler is registered for %s", job.Kind)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
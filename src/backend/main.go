@@ -1,7 +1,8 @@
 package main
 
 import (
-	"log"
+	"log/slog"
+	"os"
 	"time"
 
 	"github.com/Roongkun/software-eng-ii/internal/cli"
@@ -13,6 +14,7 @@ func init() {
 
 func main() {
 	if err := cli.RootCmd.Execute(); err != nil {
-		log.Fatal(err.Error())
+		slog.Error(err.Error())
+		os.Exit(1)
 	}
 }